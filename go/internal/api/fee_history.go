@@ -0,0 +1,146 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/Q-YZX0/oxy-blockchain/internal/consensus"
+	"github.com/Q-YZX0/oxy-blockchain/internal/execution"
+	"github.com/Q-YZX0/oxy-blockchain/internal/storage"
+)
+
+const (
+	// maxFeeHistoryBlocks es la cantidad máxima de bloques por consulta de historial de fees
+	maxFeeHistoryBlocks = 1024
+	// maxFeeHistoryPercentiles es la cantidad máxima de percentiles de propinas por consulta
+	maxFeeHistoryPercentiles = 100
+	// suggestedPriorityFee es la propina sugerida a wallets (eth_maxPriorityFeePerGas, 1 gwei)
+	suggestedPriorityFee = 1000000000
+)
+
+// feeHistory es el historial de base fees y propinas de un rango de bloques (semántica eth_feeHistory)
+type feeHistory struct {
+	OldestBlock   uint64
+	BaseFeePerGas []*big.Int   // Un valor por bloque más el del bloque siguiente al más nuevo
+	GasUsedRatio  []float64    // Gas usado / gas límite de cada bloque
+	Reward        [][]*big.Int // Propina efectiva por percentil en cada bloque (solo si se piden percentiles)
+}
+
+// txTip es la propina efectiva pagada por una transacción y el gas que usó
+type txTip struct {
+	tip     *big.Int
+	gasUsed uint64
+}
+
+// computeFeeHistory calcula el historial de fees de los blockCount bloques que terminan en newest
+func computeFeeHistory(db *storage.BlockchainDB, executor *execution.EVMExecutor, blockCount, newest uint64, percentiles []float64) (*feeHistory, error) {
+	if blockCount == 0 {
+		return nil, fmt.Errorf("blockCount debe ser mayor que 0")
+	}
+	if blockCount > maxFeeHistoryBlocks {
+		blockCount = maxFeeHistoryBlocks
+	}
+	if len(percentiles) > maxFeeHistoryPercentiles {
+		return nil, fmt.Errorf("máximo %d percentiles", maxFeeHistoryPercentiles)
+	}
+	for i, p := range percentiles {
+		if p < 0 || p > 100 || (i > 0 && p < percentiles[i-1]) {
+			return nil, fmt.Errorf("percentiles inválidos: deben ser crecientes entre 0 y 100")
+		}
+	}
+
+	// La altura 0 no tiene bloque: el rango empieza como mínimo en 1
+	oldest := uint64(1)
+	if newest >= blockCount {
+		oldest = newest - blockCount + 1
+	}
+
+	history := &feeHistory{OldestBlock: oldest}
+	for height := oldest; height <= newest; height++ {
+		header, err := execution.LoadHeader(db, height)
+		if err != nil {
+			return nil, err
+		}
+
+		baseFee := header.BaseFee
+		if baseFee == nil {
+			baseFee = big.NewInt(0)
+		}
+		history.BaseFeePerGas = append(history.BaseFeePerGas, baseFee)
+
+		ratio := 0.0
+		if header.GasLimit > 0 {
+			ratio = float64(header.GasUsed) / float64(header.GasLimit)
+		}
+		history.GasUsedRatio = append(history.GasUsedRatio, ratio)
+
+		if len(percentiles) > 0 {
+			tips, err := blockTips(db, height, baseFee)
+			if err != nil {
+				return nil, err
+			}
+			history.Reward = append(history.Reward, tipPercentiles(tips, header.GasUsed, percentiles))
+		}
+	}
+
+	// Base fee del bloque siguiente al más nuevo (el que pagarían las transacciones nuevas)
+	history.BaseFeePerGas = append(history.BaseFeePerGas, executor.NextBaseFee(newest))
+	if history.GasUsedRatio == nil {
+		history.GasUsedRatio = []float64{}
+	}
+
+	return history, nil
+}
+
+// blockTips retorna las propinas efectivas (precio efectivo - base fee) de las transacciones de un bloque
+func blockTips(db *storage.BlockchainDB, height uint64, baseFee *big.Int) ([]txTip, error) {
+	blockData, err := db.GetBlock(height)
+	if err != nil {
+		return nil, fmt.Errorf("bloque %d no encontrado: %w", height, err)
+	}
+	var block consensus.Block
+	if err := json.Unmarshal(blockData, &block); err != nil {
+		return nil, fmt.Errorf("error decodificando bloque %d: %w", height, err)
+	}
+
+	tips := make([]txTip, 0, len(block.Receipts))
+	for _, receipt := range block.Receipts {
+		price, ok := new(big.Int).SetString(receipt.EffectiveGasPrice, 10)
+		if !ok {
+			continue
+		}
+		tip := new(big.Int).Sub(price, baseFee)
+		if tip.Sign() < 0 {
+			tip.SetInt64(0)
+		}
+		tips = append(tips, txTip{tip: tip, gasUsed: receipt.GasUsed})
+	}
+	return tips, nil
+}
+
+// tipPercentiles calcula las propinas en cada percentil ponderando por gas usado (igual que geth)
+func tipPercentiles(tips []txTip, blockGasUsed uint64, percentiles []float64) []*big.Int {
+	rewards := make([]*big.Int, len(percentiles))
+	if len(tips) == 0 {
+		for i := range rewards {
+			rewards[i] = big.NewInt(0)
+		}
+		return rewards
+	}
+
+	sort.Slice(tips, func(i, j int) bool { return tips[i].tip.Cmp(tips[j].tip) < 0 })
+
+	var txIndex int
+	sumGasUsed := tips[0].gasUsed
+	for i, p := range percentiles {
+		threshold := uint64(float64(blockGasUsed) * p / 100)
+		for sumGasUsed < threshold && txIndex < len(tips)-1 {
+			txIndex++
+			sumGasUsed += tips[txIndex].gasUsed
+		}
+		rewards[i] = tips[txIndex].tip
+	}
+	return rewards
+}
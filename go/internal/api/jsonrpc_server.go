@@ -0,0 +1,1259 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Q-YZX0/oxy-blockchain/internal/consensus"
+	"github.com/Q-YZX0/oxy-blockchain/internal/execution"
+	"github.com/Q-YZX0/oxy-blockchain/internal/storage"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Códigos de error JSON-RPC 2.0 (y extensiones usadas por clientes Ethereum)
+const (
+	rpcErrParse          = -32700
+	rpcErrInvalidRequest = -32600
+	rpcErrMethodNotFound = -32601
+	rpcErrInvalidParams  = -32602
+	rpcErrInternal       = -32603
+	rpcErrServer         = -32000
+	// rpcErrExecutionReverted es el código usado por geth para reverts en eth_call/eth_estimateGas
+	rpcErrExecutionReverted = 3
+)
+
+const (
+	// rpcClientVersion se reporta en web3_clientVersion
+	rpcClientVersion = "oxy-blockchain/v0.1.0"
+	// rpcDefaultGasPrice es el gas price sugerido en eth_gasPrice (1 gwei)
+	rpcDefaultGasPrice = 1000000000
+)
+
+// JSONRPCServer expone una API JSON-RPC 2.0 compatible con Ethereum (eth_, net_, web3_)
+// para que MetaMask, ethers.js, Foundry y Hardhat puedan conectarse al nodo
+type JSONRPCServer struct {
+	host      string
+	port      string
+	storage   *storage.BlockchainDB
+	consensus *consensus.CometBFT
+	executor  *execution.EVMExecutor
+	server    *http.Server
+	methods   map[string]rpcMethod
+}
+
+// rpcMethod es el handler de un método JSON-RPC
+type rpcMethod func(params []json.RawMessage) (interface{}, error)
+
+// rpcRequest representa una request JSON-RPC 2.0
+type rpcRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+// rpcResponse representa una respuesta JSON-RPC 2.0
+type rpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+// rpcError representa un error JSON-RPC (implementa error para poder retornarlo desde los handlers)
+type rpcError struct {
+	Code    int         `json:"code"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+func (e *rpcError) Error() string {
+	return e.Message
+}
+
+// newRPCError crea un error JSON-RPC con código
+func newRPCError(code int, format string, args ...interface{}) *rpcError {
+	return &rpcError{Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+// NewJSONRPCServer crea un nuevo servidor JSON-RPC
+func NewJSONRPCServer(
+	host string,
+	port string,
+	storage *storage.BlockchainDB,
+	consensus *consensus.CometBFT,
+	executor *execution.EVMExecutor,
+) *JSONRPCServer {
+	s := &JSONRPCServer{
+		host:      host,
+		port:      port,
+		storage:   storage,
+		consensus: consensus,
+		executor:  executor,
+	}
+
+	s.methods = map[string]rpcMethod{
+		"web3_clientVersion":        s.web3ClientVersion,
+		"web3_sha3":                 s.web3Sha3,
+		"net_version":               s.netVersion,
+		"net_listening":             s.netListening,
+		"eth_chainId":               s.ethChainID,
+		"eth_blockNumber":           s.ethBlockNumber,
+		"eth_syncing":               s.ethSyncing,
+		"eth_accounts":              s.ethAccounts,
+		"eth_gasPrice":              s.ethGasPrice,
+		"eth_getBalance":            s.ethGetBalance,
+		"eth_getTransactionCount":   s.ethGetTransactionCount,
+		"eth_getCode":               s.ethGetCode,
+		"eth_getStorageAt":          s.ethGetStorageAt,
+		"eth_getBlockByNumber":      s.ethGetBlockByNumber,
+		"eth_getBlockByHash":        s.ethGetBlockByHash,
+		"eth_getTransactionByHash":  s.ethGetTransactionByHash,
+		"eth_getTransactionReceipt": s.ethGetTransactionReceipt,
+		"eth_sendRawTransaction":    s.ethSendRawTransaction,
+		"eth_call":                  s.ethCall,
+		"eth_estimateGas":           s.ethEstimateGas,
+		"eth_getLogs":               s.ethGetLogs,
+		"eth_feeHistory":            s.ethFeeHistory,
+		"eth_maxPriorityFeePerGas":  s.ethMaxPriorityFeePerGas,
+	}
+
+	return s
+}
+
+// Start inicia el servidor JSON-RPC (bloqueante, igual que RestServer.Start)
+func (s *JSONRPCServer) Start() error {
+	handler := s.corsMiddleware(http.HandlerFunc(s.ServeHTTP))
+
+	addr := s.host + ":" + s.port
+	s.server = &http.Server{
+		Addr:         addr,
+		Handler:      handler,
+		ReadTimeout:  getEnvDurationMs("OXY_RPC_READ_TIMEOUT_MS", 15000),
+		WriteTimeout: getEnvDurationMs("OXY_RPC_WRITE_TIMEOUT_MS", 30000),
+		IdleTimeout:  60 * time.Second,
+	}
+
+	fmt.Fprintf(os.Stdout, "[JSON-RPC] Iniciando servidor en %s\n", addr)
+	os.Stdout.Sync()
+
+	err := s.server.ListenAndServe()
+	if err != nil && err != http.ErrServerClosed {
+		fmt.Fprintf(os.Stderr, "[JSON-RPC] ERROR al iniciar: %v\n", err)
+		os.Stderr.Sync()
+		return err
+	}
+
+	fmt.Fprintf(os.Stdout, "[JSON-RPC] Servidor cerrado\n")
+	os.Stdout.Sync()
+	return nil
+}
+
+// Stop detiene el servidor JSON-RPC
+func (s *JSONRPCServer) Stop() error {
+	if s.server != nil {
+		return s.server.Close()
+	}
+	return nil
+}
+
+// corsMiddleware añade headers CORS (los dApps en navegador llaman al RPC directamente)
+func (s *JSONRPCServer) corsMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		allowed := os.Getenv("OXY_RPC_CORS_ORIGINS")
+		origin := r.Header.Get("Origin")
+		if allowed == "*" || allowed == "" {
+			w.Header().Set("Access-Control-Allow-Origin", "*")
+		} else if origin != "" && isOriginAllowed(origin, allowed) {
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+			w.Header().Set("Vary", "Origin")
+		}
+		w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+
+		if r.Method == http.MethodOptions {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// ServeHTTP procesa requests JSON-RPC individuales y batch
+func (s *JSONRPCServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	maxBytes := getEnvInt("OXY_RPC_MAX_BODY_BYTES", 5*1048576)
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, int64(maxBytes)))
+	if err != nil {
+		writeRPCJSON(w, rpcResponse{
+			JSONRPC: "2.0",
+			ID:      json.RawMessage("null"),
+			Error:   newRPCError(rpcErrInvalidRequest, "error leyendo body: %v", err),
+		})
+		return
+	}
+
+	body = bytes.TrimSpace(body)
+	if len(body) > 0 && body[0] == '[' {
+		s.serveBatch(w, body)
+		return
+	}
+
+	if resp := s.handleMessage(body); resp != nil {
+		writeRPCJSON(w, resp)
+	} else {
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// serveBatch procesa un batch de requests JSON-RPC
+func (s *JSONRPCServer) serveBatch(w http.ResponseWriter, body []byte) {
+	var messages []json.RawMessage
+	if err := json.Unmarshal(body, &messages); err != nil {
+		writeRPCJSON(w, rpcResponse{
+			JSONRPC: "2.0",
+			ID:      json.RawMessage("null"),
+			Error:   newRPCError(rpcErrParse, "error parseando batch: %v", err),
+		})
+		return
+	}
+
+	if len(messages) == 0 {
+		writeRPCJSON(w, rpcResponse{
+			JSONRPC: "2.0",
+			ID:      json.RawMessage("null"),
+			Error:   newRPCError(rpcErrInvalidRequest, "batch vacío"),
+		})
+		return
+	}
+
+	maxBatch := getEnvInt("OXY_RPC_MAX_BATCH", 100)
+	if len(messages) > maxBatch {
+		writeRPCJSON(w, rpcResponse{
+			JSONRPC: "2.0",
+			ID:      json.RawMessage("null"),
+			Error:   newRPCError(rpcErrInvalidRequest, "batch demasiado grande: %d (máximo %d)", len(messages), maxBatch),
+		})
+		return
+	}
+
+	responses := make([]*rpcResponse, 0, len(messages))
+	for _, msg := range messages {
+		if resp := s.handleMessage(msg); resp != nil {
+			responses = append(responses, resp)
+		}
+	}
+
+	// Un batch compuesto solo por notificaciones no produce respuesta
+	if len(responses) == 0 {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	writeRPCJSON(w, responses)
+}
+
+// handleMessage procesa una request individual. Retorna nil para notificaciones (sin id).
+func (s *JSONRPCServer) handleMessage(msg []byte) *rpcResponse {
+	var req rpcRequest
+	if err := json.Unmarshal(msg, &req); err != nil {
+		return &rpcResponse{
+			JSONRPC: "2.0",
+			ID:      json.RawMessage("null"),
+			Error:   newRPCError(rpcErrParse, "error parseando request: %v", err),
+		}
+	}
+
+	isNotification := len(req.ID) == 0
+	id := req.ID
+	if isNotification {
+		id = json.RawMessage("null")
+	}
+
+	if req.JSONRPC != "2.0" || req.Method == "" {
+		return &rpcResponse{
+			JSONRPC: "2.0",
+			ID:      id,
+			Error:   newRPCError(rpcErrInvalidRequest, "request JSON-RPC 2.0 inválida"),
+		}
+	}
+
+	result, err := s.call(req.Method, req.Params)
+	if isNotification {
+		return nil
+	}
+
+	resp := &rpcResponse{JSONRPC: "2.0", ID: id}
+	if err != nil {
+		if rpcErr, ok := err.(*rpcError); ok {
+			resp.Error = rpcErr
+		} else {
+			resp.Error = &rpcError{Code: rpcErrServer, Message: err.Error()}
+		}
+		return resp
+	}
+
+	resultData, err := json.Marshal(result)
+	if err != nil {
+		resp.Error = newRPCError(rpcErrInternal, "error serializando resultado: %v", err)
+		return resp
+	}
+	resp.Result = resultData
+	return resp
+}
+
+// call despacha un método JSON-RPC a su handler
+func (s *JSONRPCServer) call(method string, rawParams json.RawMessage) (interface{}, error) {
+	handler, ok := s.methods[method]
+	if !ok {
+		return nil, newRPCError(rpcErrMethodNotFound, "método no soportado: %s", method)
+	}
+
+	var params []json.RawMessage
+	if len(rawParams) > 0 && string(rawParams) != "null" {
+		if err := json.Unmarshal(rawParams, &params); err != nil {
+			return nil, newRPCError(rpcErrInvalidParams, "params debe ser un array: %v", err)
+		}
+	}
+
+	return handler(params)
+}
+
+// writeRPCJSON escribe una respuesta JSON
+func writeRPCJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(v)
+}
+
+// ---- Helpers de parámetros ----
+
+// paramAt decodifica el parámetro i en dst. Si el parámetro no existe y es opcional, no hace nada.
+func paramAt(params []json.RawMessage, i int, dst interface{}, optional bool) error {
+	if i >= len(params) || string(params[i]) == "null" {
+		if optional {
+			return nil
+		}
+		return newRPCError(rpcErrInvalidParams, "falta parámetro %d", i)
+	}
+	if err := json.Unmarshal(params[i], dst); err != nil {
+		return newRPCError(rpcErrInvalidParams, "parámetro %d inválido: %v", i, err)
+	}
+	return nil
+}
+
+// paramAddress decodifica una dirección Ethereum
+func paramAddress(params []json.RawMessage, i int) (common.Address, error) {
+	var addr string
+	if err := paramAt(params, i, &addr, false); err != nil {
+		return common.Address{}, err
+	}
+	if !common.IsHexAddress(addr) {
+		return common.Address{}, newRPCError(rpcErrInvalidParams, "dirección inválida: %s", addr)
+	}
+	return common.HexToAddress(addr), nil
+}
+
+// paramBlockTag decodifica un parámetro de bloque opcional ("latest" por defecto)
+func paramBlockTag(params []json.RawMessage, i int) (string, error) {
+	tag := "latest"
+	if err := paramAt(params, i, &tag, true); err != nil {
+		// Soportar objetos EIP-1898 ({"blockNumber": "0x.."} o {"blockHash": "0x.."})
+		var obj struct {
+			BlockNumber string `json:"blockNumber"`
+			BlockHash   string `json:"blockHash"`
+		}
+		if objErr := paramAt(params, i, &obj, true); objErr != nil {
+			return "", err
+		}
+		if obj.BlockHash != "" {
+			return obj.BlockHash, nil
+		}
+		return obj.BlockNumber, nil
+	}
+	return tag, nil
+}
+
+// latestHeight retorna la altura del último bloque comprometido
+func (s *JSONRPCServer) latestHeight() uint64 {
+	height, err := s.storage.GetLatestHeight()
+	if err != nil {
+		return 0
+	}
+	return height
+}
+
+// resolveBlockNumber convierte un tag de bloque ("latest", "0x10", hash) a altura
+func (s *JSONRPCServer) resolveBlockNumber(tag string) (uint64, error) {
+	switch tag {
+	case "", "latest", "pending", "safe", "finalized":
+		return s.latestHeight(), nil
+	case "earliest":
+		return 0, nil
+	}
+
+	// Hash de bloque (EIP-1898)
+	if len(tag) == 66 && strings.HasPrefix(tag, "0x") {
+		height, err := s.storage.GetBlockHeightByHash(tag)
+		if err != nil {
+			return 0, newRPCError(rpcErrServer, "bloque no encontrado: %s", tag)
+		}
+		return height, nil
+	}
+
+	number, err := hexutil.DecodeUint64(tag)
+	if err != nil {
+		return 0, newRPCError(rpcErrInvalidParams, "número de bloque inválido: %s", tag)
+	}
+	return number, nil
+}
+
+// requireLatestState valida que la consulta de estado sea sobre el último bloque
+// (el ejecutor solo mantiene el estado vigente)
+func (s *JSONRPCServer) requireLatestState(tag string) error {
+	height, err := s.resolveBlockNumber(tag)
+	if err != nil {
+		return err
+	}
+	if height != s.latestHeight() {
+		return newRPCError(rpcErrServer, "estado histórico no disponible para el bloque %d", height)
+	}
+	return nil
+}
+
+// requireExecutor valida que el ejecutor EVM esté disponible
+func (s *JSONRPCServer) requireExecutor() error {
+	if s.executor == nil {
+		return newRPCError(rpcErrServer, "ejecutor EVM no disponible")
+	}
+	return nil
+}
+
+// ---- web3_ / net_ ----
+
+func (s *JSONRPCServer) web3ClientVersion(params []json.RawMessage) (interface{}, error) {
+	return rpcClientVersion, nil
+}
+
+func (s *JSONRPCServer) web3Sha3(params []json.RawMessage) (interface{}, error) {
+	var data hexutil.Bytes
+	if err := paramAt(params, 0, &data, false); err != nil {
+		return nil, err
+	}
+	return hexutil.Bytes(crypto.Keccak256(data)), nil
+}
+
+func (s *JSONRPCServer) netVersion(params []json.RawMessage) (interface{}, error) {
+	if err := s.requireExecutor(); err != nil {
+		return nil, err
+	}
+	return s.executor.ChainID().String(), nil
+}
+
+func (s *JSONRPCServer) netListening(params []json.RawMessage) (interface{}, error) {
+	return true, nil
+}
+
+// ---- eth_ ----
+
+func (s *JSONRPCServer) ethChainID(params []json.RawMessage) (interface{}, error) {
+	if err := s.requireExecutor(); err != nil {
+		return nil, err
+	}
+	return (*hexutil.Big)(s.executor.ChainID()), nil
+}
+
+func (s *JSONRPCServer) ethBlockNumber(params []json.RawMessage) (interface{}, error) {
+	return hexutil.Uint64(s.latestHeight()), nil
+}
+
+func (s *JSONRPCServer) ethSyncing(params []json.RawMessage) (interface{}, error) {
+	return false, nil
+}
+
+func (s *JSONRPCServer) ethAccounts(params []json.RawMessage) (interface{}, error) {
+	// El nodo no custodia claves de usuarios
+	return []string{}, nil
+}
+
+func (s *JSONRPCServer) ethGasPrice(params []json.RawMessage) (interface{}, error) {
+	if s.executor == nil {
+		return (*hexutil.Big)(big.NewInt(rpcDefaultGasPrice)), nil
+	}
+	// Base fee del próximo bloque más la propina sugerida
+	price := new(big.Int).Add(s.executor.NextBaseFee(s.latestHeight()), big.NewInt(suggestedPriorityFee))
+	return (*hexutil.Big)(price), nil
+}
+
+func (s *JSONRPCServer) ethMaxPriorityFeePerGas(params []json.RawMessage) (interface{}, error) {
+	return (*hexutil.Big)(big.NewInt(suggestedPriorityFee)), nil
+}
+
+func (s *JSONRPCServer) ethFeeHistory(params []json.RawMessage) (interface{}, error) {
+	if err := s.requireExecutor(); err != nil {
+		return nil, err
+	}
+
+	// blockCount puede venir como cantidad hex o como número
+	var blockCount hexutil.Uint64
+	if err := paramAt(params, 0, &blockCount, false); err != nil {
+		var count uint64
+		if numErr := paramAt(params, 0, &count, false); numErr != nil {
+			return nil, err
+		}
+		blockCount = hexutil.Uint64(count)
+	}
+	tag, err := paramBlockTag(params, 1)
+	if err != nil {
+		return nil, err
+	}
+	newest, err := s.resolveBlockNumber(tag)
+	if err != nil {
+		return nil, err
+	}
+	if latest := s.latestHeight(); newest > latest {
+		newest = latest
+	}
+	var percentiles []float64
+	if err := paramAt(params, 2, &percentiles, true); err != nil {
+		return nil, err
+	}
+
+	history, err := computeFeeHistory(s.storage, s.executor, uint64(blockCount), newest, percentiles)
+	if err != nil {
+		return nil, newRPCError(rpcErrInvalidParams, "%v", err)
+	}
+
+	baseFees := make([]*hexutil.Big, len(history.BaseFeePerGas))
+	for i, fee := range history.BaseFeePerGas {
+		baseFees[i] = (*hexutil.Big)(fee)
+	}
+	result := map[string]interface{}{
+		"oldestBlock":   hexutil.Uint64(history.OldestBlock),
+		"baseFeePerGas": baseFees,
+		"gasUsedRatio":  history.GasUsedRatio,
+	}
+	if history.Reward != nil {
+		rewards := make([][]*hexutil.Big, len(history.Reward))
+		for i, blockRewards := range history.Reward {
+			rewards[i] = make([]*hexutil.Big, len(blockRewards))
+			for j, reward := range blockRewards {
+				rewards[i][j] = (*hexutil.Big)(reward)
+			}
+		}
+		result["reward"] = rewards
+	}
+	return result, nil
+}
+
+func (s *JSONRPCServer) ethGetBalance(params []json.RawMessage) (interface{}, error) {
+	if err := s.requireExecutor(); err != nil {
+		return nil, err
+	}
+	addr, err := paramAddress(params, 0)
+	if err != nil {
+		return nil, err
+	}
+	tag, err := paramBlockTag(params, 1)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.requireLatestState(tag); err != nil {
+		return nil, err
+	}
+
+	balance, err := s.executor.GetBalance(addr.Hex())
+	if err != nil {
+		return nil, err
+	}
+	return (*hexutil.Big)(balance), nil
+}
+
+func (s *JSONRPCServer) ethGetTransactionCount(params []json.RawMessage) (interface{}, error) {
+	if err := s.requireExecutor(); err != nil {
+		return nil, err
+	}
+	addr, err := paramAddress(params, 0)
+	if err != nil {
+		return nil, err
+	}
+	tag, err := paramBlockTag(params, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce, err := s.executor.GetNonce(addr.Hex())
+	if err != nil {
+		return nil, err
+	}
+
+	// "pending" incluye transacciones del remitente que esperan en el mempool local
+	if tag == "pending" && s.consensus != nil {
+		for _, tx := range s.consensus.GetMempool() {
+			if common.HexToAddress(tx.From) == addr && tx.Nonce >= nonce {
+				nonce = tx.Nonce + 1
+			}
+		}
+		return hexutil.Uint64(nonce), nil
+	}
+
+	if err := s.requireLatestState(tag); err != nil {
+		return nil, err
+	}
+	return hexutil.Uint64(nonce), nil
+}
+
+func (s *JSONRPCServer) ethGetCode(params []json.RawMessage) (interface{}, error) {
+	if err := s.requireExecutor(); err != nil {
+		return nil, err
+	}
+	addr, err := paramAddress(params, 0)
+	if err != nil {
+		return nil, err
+	}
+	tag, err := paramBlockTag(params, 1)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.requireLatestState(tag); err != nil {
+		return nil, err
+	}
+
+	code, err := s.executor.GetCode(addr.Hex())
+	if err != nil {
+		return nil, err
+	}
+	return hexutil.Bytes(code), nil
+}
+
+func (s *JSONRPCServer) ethGetStorageAt(params []json.RawMessage) (interface{}, error) {
+	if err := s.requireExecutor(); err != nil {
+		return nil, err
+	}
+	addr, err := paramAddress(params, 0)
+	if err != nil {
+		return nil, err
+	}
+	var slotHex string
+	if err := paramAt(params, 1, &slotHex, false); err != nil {
+		return nil, err
+	}
+	slot, err := hexutil.DecodeBig(normalizeQuantity(slotHex))
+	if err != nil {
+		return nil, newRPCError(rpcErrInvalidParams, "slot inválido: %s", slotHex)
+	}
+	tag, err := paramBlockTag(params, 2)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.requireLatestState(tag); err != nil {
+		return nil, err
+	}
+
+	value, err := s.executor.GetStorageAt(addr.Hex(), common.BigToHash(slot))
+	if err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+func (s *JSONRPCServer) ethGetBlockByNumber(params []json.RawMessage) (interface{}, error) {
+	var tag string
+	if err := paramAt(params, 0, &tag, false); err != nil {
+		return nil, err
+	}
+	fullTx := false
+	if err := paramAt(params, 1, &fullTx, true); err != nil {
+		return nil, err
+	}
+
+	height, err := s.resolveBlockNumber(tag)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := s.loadBlock(height)
+	if err != nil {
+		return nil, nil // Bloque inexistente: result null
+	}
+	return s.formatBlock(block, fullTx), nil
+}
+
+func (s *JSONRPCServer) ethGetBlockByHash(params []json.RawMessage) (interface{}, error) {
+	var hash common.Hash
+	if err := paramAt(params, 0, &hash, false); err != nil {
+		return nil, err
+	}
+	fullTx := false
+	if err := paramAt(params, 1, &fullTx, true); err != nil {
+		return nil, err
+	}
+
+	height, err := s.storage.GetBlockHeightByHash(hash.Hex())
+	if err != nil {
+		return nil, nil
+	}
+	block, err := s.loadBlock(height)
+	if err != nil {
+		return nil, nil
+	}
+	return s.formatBlock(block, fullTx), nil
+}
+
+func (s *JSONRPCServer) ethGetTransactionByHash(params []json.RawMessage) (interface{}, error) {
+	var hash common.Hash
+	if err := paramAt(params, 0, &hash, false); err != nil {
+		return nil, err
+	}
+
+	block, index, err := s.findTransaction(hash)
+	if err == nil {
+		return s.formatTransaction(block.Transactions[index], block, index), nil
+	}
+
+	// Transacción pendiente en el mempool local
+	if s.consensus != nil {
+		for _, tx := range s.consensus.GetMempool() {
+			if common.HexToHash(tx.Hash) == hash {
+				return s.formatTransaction(tx, nil, 0), nil
+			}
+		}
+	}
+
+	return nil, nil
+}
+
+func (s *JSONRPCServer) ethGetTransactionReceipt(params []json.RawMessage) (interface{}, error) {
+	var hash common.Hash
+	if err := paramAt(params, 0, &hash, false); err != nil {
+		return nil, err
+	}
+
+	block, index, err := s.findTransaction(hash)
+	if err != nil || index >= len(block.Receipts) {
+		return nil, nil
+	}
+	return s.formatReceipt(block, index), nil
+}
+
+func (s *JSONRPCServer) ethSendRawTransaction(params []json.RawMessage) (interface{}, error) {
+	if err := s.requireExecutor(); err != nil {
+		return nil, err
+	}
+	if s.consensus == nil {
+		return nil, newRPCError(rpcErrServer, "consenso no disponible")
+	}
+
+	var raw hexutil.Bytes
+	if err := paramAt(params, 0, &raw, false); err != nil {
+		return nil, err
+	}
+
+	tx, err := consensus.NewTransactionFromRaw(raw, s.executor.ChainID())
+	if err != nil {
+		return nil, newRPCError(rpcErrInvalidParams, "%v", err)
+	}
+
+	if err := s.consensus.SubmitTransaction(tx); err != nil {
+		return nil, newRPCError(rpcErrServer, "%v", err)
+	}
+
+	return common.HexToHash(tx.Hash), nil
+}
+
+// rpcCallArgs representa el objeto de llamada de eth_call
+type rpcCallArgs struct {
+	From  *common.Address `json:"from"`
+	To    *common.Address `json:"to"`
+	Gas   *hexutil.Uint64 `json:"gas"`
+	Value *hexutil.Big    `json:"value"`
+	Data  *hexutil.Bytes  `json:"data"`
+	Input *hexutil.Bytes  `json:"input"`
+}
+
+// callData retorna input (preferido por clientes nuevos) o data
+func (args *rpcCallArgs) callData() []byte {
+	if args.Input != nil {
+		return *args.Input
+	}
+	if args.Data != nil {
+		return *args.Data
+	}
+	return nil
+}
+
+// toCallRequest convierte los argumentos JSON-RPC en una llamada del ejecutor
+func (args *rpcCallArgs) toCallRequest(height uint64) *execution.CallRequest {
+	req := &execution.CallRequest{
+		Data:   args.callData(),
+		Height: height,
+	}
+	if args.From != nil {
+		req.From = args.From.Hex()
+	}
+	if args.To != nil {
+		req.To = args.To.Hex()
+	}
+	if args.Value != nil {
+		req.Value = args.Value.ToInt().String()
+	}
+	if args.Gas != nil {
+		req.GasLimit = uint64(*args.Gas)
+	}
+	return req
+}
+
+// callHeight resuelve el tag de bloque a la altura usada por StaticCall (0 = último estado)
+func (s *JSONRPCServer) callHeight(tag string) (uint64, error) {
+	height, err := s.resolveBlockNumber(tag)
+	if err != nil {
+		return 0, err
+	}
+	if height == s.latestHeight() {
+		return 0, nil
+	}
+	if height == 0 || height > s.latestHeight() {
+		return 0, newRPCError(rpcErrServer, "estado no disponible para el bloque %d", height)
+	}
+	return height, nil
+}
+
+// newRevertError construye el error de revert estándar (código 3 con los datos de revert)
+func newRevertError(result *execution.CallResult) *rpcError {
+	return &rpcError{
+		Code:    rpcErrExecutionReverted,
+		Message: result.Error,
+		Data:    hexutil.Bytes(result.ReturnData),
+	}
+}
+
+func (s *JSONRPCServer) ethCall(params []json.RawMessage) (interface{}, error) {
+	if err := s.requireExecutor(); err != nil {
+		return nil, err
+	}
+
+	var args rpcCallArgs
+	if err := paramAt(params, 0, &args, false); err != nil {
+		return nil, err
+	}
+	if args.To == nil {
+		return nil, newRPCError(rpcErrInvalidParams, "eth_call requiere 'to'")
+	}
+	tag, err := paramBlockTag(params, 1)
+	if err != nil {
+		return nil, err
+	}
+	height, err := s.callHeight(tag)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := s.executor.StaticCall(args.toCallRequest(height))
+	if err != nil {
+		return nil, newRPCError(rpcErrServer, "%v", err)
+	}
+	if result.Reverted {
+		return nil, newRevertError(result)
+	}
+	if !result.Success {
+		return nil, newRPCError(rpcErrServer, "%s", result.Error)
+	}
+	return hexutil.Bytes(result.ReturnData), nil
+}
+
+func (s *JSONRPCServer) ethEstimateGas(params []json.RawMessage) (interface{}, error) {
+	if err := s.requireExecutor(); err != nil {
+		return nil, err
+	}
+
+	var args rpcCallArgs
+	if err := paramAt(params, 0, &args, false); err != nil {
+		return nil, err
+	}
+	tag, err := paramBlockTag(params, 1)
+	if err != nil {
+		return nil, err
+	}
+	height, err := s.callHeight(tag)
+	if err != nil {
+		return nil, err
+	}
+
+	gas, err := s.executor.EstimateGas(args.toCallRequest(height))
+	if err != nil {
+		var revertErr *execution.RevertError
+		if errors.As(err, &revertErr) {
+			return nil, &rpcError{
+				Code:    rpcErrExecutionReverted,
+				Message: revertErr.Error(),
+				Data:    hexutil.Bytes(revertErr.Data),
+			}
+		}
+		return nil, newRPCError(rpcErrServer, "%v", err)
+	}
+	return hexutil.Uint64(gas), nil
+}
+
+// rpcFilterArgs son los parámetros de eth_getLogs
+type rpcFilterArgs struct {
+	FromBlock string            `json:"fromBlock"`
+	ToBlock   string            `json:"toBlock"`
+	BlockHash string            `json:"blockHash"`
+	Address   json.RawMessage   `json:"address"`
+	Topics    []json.RawMessage `json:"topics"`
+}
+
+// singleFilterValue decodifica un criterio de filtro (string, null o array de un elemento).
+// El índice de logs no soporta alternativas (OR) dentro de una misma posición.
+func singleFilterValue(raw json.RawMessage) (string, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return "", nil
+	}
+	var value string
+	if err := json.Unmarshal(raw, &value); err == nil {
+		return value, nil
+	}
+	var values []string
+	if err := json.Unmarshal(raw, &values); err != nil {
+		return "", newRPCError(rpcErrInvalidParams, "criterio de filtro inválido: %s", string(raw))
+	}
+	switch len(values) {
+	case 0:
+		return "", nil
+	case 1:
+		return values[0], nil
+	}
+	return "", newRPCError(rpcErrInvalidParams, "filtros con múltiples valores no soportados")
+}
+
+func (s *JSONRPCServer) ethGetLogs(params []json.RawMessage) (interface{}, error) {
+	var args rpcFilterArgs
+	if err := paramAt(params, 0, &args, false); err != nil {
+		return nil, err
+	}
+
+	filter := &storage.LogFilter{}
+	if args.BlockHash != "" {
+		height, err := s.storage.GetBlockHeightByHash(args.BlockHash)
+		if err != nil {
+			return nil, newRPCError(rpcErrServer, "bloque no encontrado: %s", args.BlockHash)
+		}
+		filter.FromBlock, filter.ToBlock = height, height
+	} else {
+		var err error
+		if filter.FromBlock, err = s.resolveBlockNumber(args.FromBlock); err != nil {
+			return nil, err
+		}
+		if filter.ToBlock, err = s.resolveBlockNumber(args.ToBlock); err != nil {
+			return nil, err
+		}
+		if filter.FromBlock > filter.ToBlock {
+			return nil, newRPCError(rpcErrInvalidParams, "fromBlock mayor que toBlock")
+		}
+	}
+
+	address, err := singleFilterValue(args.Address)
+	if err != nil {
+		return nil, err
+	}
+	if address != "" && !common.IsHexAddress(address) {
+		return nil, newRPCError(rpcErrInvalidParams, "dirección inválida: %s", address)
+	}
+	filter.Address = address
+
+	if len(args.Topics) > 4 {
+		return nil, newRPCError(rpcErrInvalidParams, "máximo 4 topics")
+	}
+	for _, raw := range args.Topics {
+		topic, err := singleFilterValue(raw)
+		if err != nil {
+			return nil, err
+		}
+		filter.Topics = append(filter.Topics, topic)
+	}
+
+	// Un resultado más que el tope para detectar consultas demasiado grandes
+	maxLogs := getEnvInt("OXY_RPC_MAX_LOGS", 10000)
+	filter.Limit = maxLogs + 1
+
+	results, _, err := s.storage.FilterLogs(filter)
+	if err != nil {
+		return nil, newRPCError(rpcErrInternal, "error consultando logs: %v", err)
+	}
+	if len(results) > maxLogs {
+		return nil, newRPCError(rpcErrServer, "la consulta retorna más de %d resultados", maxLogs)
+	}
+
+	logs := make([]map[string]interface{}, 0, len(results))
+	for _, data := range results {
+		var l consensus.Log
+		if err := json.Unmarshal(data, &l); err != nil {
+			return nil, newRPCError(rpcErrInternal, "log corrupto: %v", err)
+		}
+		logs = append(logs, formatLog(&l))
+	}
+	return logs, nil
+}
+
+// ---- Formateo de bloques, transacciones y receipts ----
+
+// loadBlock carga un bloque desde storage
+func (s *JSONRPCServer) loadBlock(height uint64) (*consensus.Block, error) {
+	blockData, err := s.storage.GetBlock(height)
+	if err != nil {
+		return nil, err
+	}
+	var block consensus.Block
+	if err := json.Unmarshal(blockData, &block); err != nil {
+		return nil, err
+	}
+	return &block, nil
+}
+
+// findTransaction localiza una transacción incluida en un bloque
+func (s *JSONRPCServer) findTransaction(hash common.Hash) (*consensus.Block, int, error) {
+	height, index, err := s.storage.GetTxLookup(hash.Hex())
+	if err != nil {
+		return nil, 0, err
+	}
+	block, err := s.loadBlock(height)
+	if err != nil {
+		return nil, 0, err
+	}
+	if index < 0 || index >= len(block.Transactions) {
+		return nil, 0, fmt.Errorf("índice de transacción fuera de rango")
+	}
+	return block, index, nil
+}
+
+// blockHash retorna el hash de un bloque como common.Hash
+func blockHash(block *consensus.Block) common.Hash {
+	return common.HexToHash(block.Header.Hash)
+}
+
+// formatBlock convierte un bloque al formato de Ethereum JSON-RPC
+func (s *JSONRPCServer) formatBlock(block *consensus.Block, fullTx bool) map[string]interface{} {
+	var gasUsed uint64
+	for _, receipt := range block.Receipts {
+		gasUsed += receipt.GasUsed
+	}
+
+	var transactions []interface{}
+	for i, tx := range block.Transactions {
+		if fullTx {
+			transactions = append(transactions, s.formatTransaction(tx, block, i))
+		} else {
+			transactions = append(transactions, common.HexToHash(tx.Hash))
+		}
+	}
+	if transactions == nil {
+		transactions = []interface{}{}
+	}
+
+	result := map[string]interface{}{
+		"number":           hexutil.Uint64(block.Header.Height),
+		"hash":             blockHash(block),
+		"parentHash":       common.HexToHash(block.Header.ParentHash),
+		"nonce":            types.BlockNonce{},
+		"mixHash":          common.Hash{},
+		"sha3Uncles":       types.EmptyUncleHash,
+		"logsBloom":        types.Bloom{},
+		"transactionsRoot": types.EmptyRootHash,
+		"stateRoot":        common.HexToHash(block.Header.StateRoot),
+		"receiptsRoot":     types.EmptyRootHash,
+		"miner":            common.HexToAddress(block.Header.Validator),
+		"difficulty":       (*hexutil.Big)(big.NewInt(0)),
+		"totalDifficulty":  (*hexutil.Big)(big.NewInt(0)),
+		"extraData":        hexutil.Bytes{},
+		"size":             hexutil.Uint64(0),
+		"gasLimit":         hexutil.Uint64(0),
+		"gasUsed":          hexutil.Uint64(gasUsed),
+		"timestamp":        hexutil.Uint64(block.Header.Timestamp.Unix()),
+		"transactions":     transactions,
+		"uncles":           []common.Hash{},
+	}
+
+	// Completar con el header EVM persistido (fuente de verdad del hash del bloque)
+	if header, err := execution.LoadHeader(s.storage, block.Header.Height); err == nil {
+		result["parentHash"] = header.ParentHash
+		result["stateRoot"] = header.Root
+		result["miner"] = header.Coinbase
+		result["logsBloom"] = header.Bloom
+		result["transactionsRoot"] = header.TxHash
+		result["receiptsRoot"] = header.ReceiptHash
+		result["mixHash"] = header.MixDigest
+		result["gasLimit"] = hexutil.Uint64(header.GasLimit)
+		result["size"] = hexutil.Uint64(header.Size())
+		if header.BaseFee != nil {
+			result["baseFeePerGas"] = (*hexutil.Big)(header.BaseFee)
+		}
+	}
+
+	return result
+}
+
+// formatTransaction convierte una transacción al formato de Ethereum JSON-RPC.
+// block es nil para transacciones pendientes.
+func (s *JSONRPCServer) formatTransaction(tx *consensus.Transaction, block *consensus.Block, index int) map[string]interface{} {
+	value, _ := new(big.Int).SetString(tx.Value, 10)
+	if value == nil {
+		value = big.NewInt(0)
+	}
+	gasPrice, _ := new(big.Int).SetString(tx.GasPrice, 10)
+	if gasPrice == nil {
+		gasPrice = big.NewInt(0)
+	}
+
+	result := map[string]interface{}{
+		"hash":             common.HexToHash(tx.Hash),
+		"nonce":            hexutil.Uint64(tx.Nonce),
+		"blockHash":        nil,
+		"blockNumber":      nil,
+		"transactionIndex": nil,
+		"from":             common.HexToAddress(tx.From),
+		"to":               nil,
+		"value":            (*hexutil.Big)(value),
+		"gas":              hexutil.Uint64(tx.GasLimit),
+		"gasPrice":         (*hexutil.Big)(gasPrice),
+		"input":            hexutil.Bytes(tx.Data),
+		"type":             hexutil.Uint64(types.LegacyTxType),
+	}
+	if tx.MaxFeePerGas != "" {
+		maxFee, _ := new(big.Int).SetString(tx.MaxFeePerGas, 10)
+		maxPriorityFee, _ := new(big.Int).SetString(tx.MaxPriorityFeePerGas, 10)
+		if maxPriorityFee == nil {
+			maxPriorityFee = big.NewInt(0)
+		}
+		if maxFee != nil {
+			result["maxFeePerGas"] = (*hexutil.Big)(maxFee)
+			result["maxPriorityFeePerGas"] = (*hexutil.Big)(maxPriorityFee)
+			result["type"] = hexutil.Uint64(types.DynamicFeeTxType)
+		}
+	}
+	if tx.To != "" {
+		result["to"] = common.HexToAddress(tx.To)
+	}
+	if block != nil {
+		result["blockHash"] = blockHash(block)
+		result["blockNumber"] = hexutil.Uint64(block.Header.Height)
+		result["transactionIndex"] = hexutil.Uint64(index)
+	}
+
+	// Firma: desde la transacción Ethereum cruda o desde la firma [R][S][V] interna
+	if len(tx.RawTx) > 0 {
+		var ethTx types.Transaction
+		if err := ethTx.UnmarshalBinary(tx.RawTx); err == nil {
+			v, r, sig := ethTx.RawSignatureValues()
+			result["v"] = (*hexutil.Big)(v)
+			result["r"] = (*hexutil.Big)(r)
+			result["s"] = (*hexutil.Big)(sig)
+			result["type"] = hexutil.Uint64(ethTx.Type())
+			if ethTx.ChainId() != nil {
+				result["chainId"] = (*hexutil.Big)(ethTx.ChainId())
+			}
+		}
+	} else if len(tx.Signature) == 65 {
+		result["r"] = (*hexutil.Big)(new(big.Int).SetBytes(tx.Signature[:32]))
+		result["s"] = (*hexutil.Big)(new(big.Int).SetBytes(tx.Signature[32:64]))
+		result["v"] = (*hexutil.Big)(big.NewInt(int64(tx.Signature[64])))
+	}
+
+	return result
+}
+
+// formatReceipt convierte un receipt al formato de Ethereum JSON-RPC
+func (s *JSONRPCServer) formatReceipt(block *consensus.Block, index int) map[string]interface{} {
+	tx := block.Transactions[index]
+	receipt := block.Receipts[index]
+
+	logs := make([]map[string]interface{}, 0, len(receipt.Logs))
+	for _, l := range receipt.Logs {
+		logs = append(logs, formatLog(&l))
+	}
+
+	status := hexutil.Uint64(types.ReceiptStatusFailed)
+	if receipt.Status == "success" {
+		status = hexutil.Uint64(types.ReceiptStatusSuccessful)
+	}
+
+	var contractAddress interface{}
+	if receipt.ContractAddress != "" {
+		contractAddress = common.HexToAddress(receipt.ContractAddress)
+	}
+
+	formatted := s.formatTransaction(tx, block, index)
+	effectiveGasPrice := formatted["gasPrice"]
+	if price, ok := new(big.Int).SetString(receipt.EffectiveGasPrice, 10); ok {
+		effectiveGasPrice = (*hexutil.Big)(price)
+	}
+
+	var logsBloom types.Bloom
+	if bloom, err := hexutil.Decode(receipt.LogsBloom); err == nil && len(bloom) == types.BloomByteLength {
+		logsBloom = types.BytesToBloom(bloom)
+	}
+
+	result := map[string]interface{}{
+		"transactionHash":   common.HexToHash(tx.Hash),
+		"transactionIndex":  hexutil.Uint64(index),
+		"blockHash":         blockHash(block),
+		"blockNumber":       hexutil.Uint64(block.Header.Height),
+		"from":              formatted["from"],
+		"to":                formatted["to"],
+		"gasUsed":           hexutil.Uint64(receipt.GasUsed),
+		"cumulativeGasUsed": hexutil.Uint64(receipt.CumulativeGasUsed),
+		"effectiveGasPrice": effectiveGasPrice,
+		"contractAddress":   contractAddress,
+		"logs":              logs,
+		"logsBloom":         logsBloom,
+		"status":            status,
+		"type":              formatted["type"],
+	}
+	return result
+}
+
+// formatLog convierte un log al formato de Ethereum JSON-RPC
+func formatLog(l *consensus.Log) map[string]interface{} {
+	topics := make([]common.Hash, len(l.Topics))
+	for i, topic := range l.Topics {
+		topics[i] = common.HexToHash(topic)
+	}
+	return map[string]interface{}{
+		"address":          common.HexToAddress(l.Address),
+		"topics":           topics,
+		"data":             hexutil.Bytes(l.Data),
+		"blockNumber":      hexutil.Uint64(l.BlockNumber),
+		"blockHash":        common.HexToHash(l.BlockHash),
+		"transactionHash":  common.HexToHash(l.TxHash),
+		"transactionIndex": hexutil.Uint64(l.TxIndex),
+		"logIndex":         hexutil.Uint64(l.LogIndex),
+		"removed":          false,
+	}
+}
+
+// normalizeQuantity quita ceros a la izquierda de una cantidad hex (hexutil rechaza "0x00")
+func normalizeQuantity(hex string) string {
+	if !strings.HasPrefix(hex, "0x") && !strings.HasPrefix(hex, "0X") {
+		return hex
+	}
+	trimmed := strings.TrimLeft(hex[2:], "0")
+	if trimmed == "" {
+		trimmed = "0"
+	}
+	return "0x" + trimmed
+}
@@ -0,0 +1,240 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/Q-YZX0/oxy-blockchain/internal/consensus"
+	"github.com/Q-YZX0/oxy-blockchain/internal/execution"
+	"github.com/Q-YZX0/oxy-blockchain/internal/storage"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// crearTestRPCServer crea un servidor JSON-RPC de prueba con ejecutor EVM real
+func crearTestRPCServer(t *testing.T) (*JSONRPCServer, *storage.BlockchainDB, *execution.EVMExecutor) {
+	testDir := "./test_data_api_" + t.Name()
+	db, err := storage.NewBlockchainDB(testDir)
+	if err != nil {
+		t.Fatalf("Error creando storage: %v", err)
+	}
+
+	executor := execution.NewEVMExecutor(db)
+	if err := executor.Start(); err != nil {
+		t.Fatalf("Error iniciando ejecutor: %v", err)
+	}
+
+	server := NewJSONRPCServer("localhost", "8545", db, nil, executor)
+	return server, db, executor
+}
+
+// rpcPost envía un body JSON-RPC al handler y retorna el recorder
+func rpcPost(t *testing.T, server *JSONRPCServer, body string) *httptest.ResponseRecorder {
+	req, err := http.NewRequest("POST", "/", bytes.NewBufferString(body))
+	if err != nil {
+		t.Fatalf("Error creando request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	rr := httptest.NewRecorder()
+	server.ServeHTTP(rr, req)
+	return rr
+}
+
+// decodeRPCResponse parsea una respuesta JSON-RPC individual
+func decodeRPCResponse(t *testing.T, rr *httptest.ResponseRecorder) map[string]interface{} {
+	var resp map[string]interface{}
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Error parseando respuesta JSON-RPC: %v (%s)", err, rr.Body.String())
+	}
+	return resp
+}
+
+// TestJSONRPC_ChainIDAndNetVersion prueba eth_chainId y net_version
+func TestJSONRPC_ChainIDAndNetVersion(t *testing.T) {
+	server, db, executor := crearTestRPCServer(t)
+	defer func() {
+		executor.Stop()
+		db.Close()
+		os.RemoveAll("./test_data_api_" + t.Name())
+	}()
+
+	resp := decodeRPCResponse(t, rpcPost(t, server, `{"jsonrpc":"2.0","id":1,"method":"eth_chainId","params":[]}`))
+	if resp["result"] != "0x3e7" {
+		t.Errorf("eth_chainId incorrecto: esperado 0x3e7, obtenido %v", resp["result"])
+	}
+	if resp["id"] != float64(1) {
+		t.Errorf("id incorrecto: %v", resp["id"])
+	}
+
+	resp = decodeRPCResponse(t, rpcPost(t, server, `{"jsonrpc":"2.0","id":"a","method":"net_version"}`))
+	if resp["result"] != "999" {
+		t.Errorf("net_version incorrecto: esperado 999, obtenido %v", resp["result"])
+	}
+}
+
+// TestJSONRPC_GetBalance prueba eth_getBalance sobre una cuenta con fondos
+func TestJSONRPC_GetBalance(t *testing.T) {
+	server, db, executor := crearTestRPCServer(t)
+	defer func() {
+		executor.Stop()
+		db.Close()
+		os.RemoveAll("./test_data_api_" + t.Name())
+	}()
+
+	address := "0x1234567890123456789012345678901234567890"
+	if err := executor.FundAccount(address, "1000"); err != nil {
+		t.Fatalf("Error fondeando cuenta: %v", err)
+	}
+
+	resp := decodeRPCResponse(t, rpcPost(t, server,
+		`{"jsonrpc":"2.0","id":1,"method":"eth_getBalance","params":["`+address+`","latest"]}`))
+	if resp["result"] != "0x3e8" {
+		t.Errorf("eth_getBalance incorrecto: esperado 0x3e8, obtenido %v (error: %v)", resp["result"], resp["error"])
+	}
+
+	// Dirección inválida
+	resp = decodeRPCResponse(t, rpcPost(t, server,
+		`{"jsonrpc":"2.0","id":2,"method":"eth_getBalance","params":["0xinvalida","latest"]}`))
+	rpcErr, ok := resp["error"].(map[string]interface{})
+	if !ok || rpcErr["code"] != float64(rpcErrInvalidParams) {
+		t.Errorf("Esperado error de parámetros inválidos, obtenido %v", resp["error"])
+	}
+}
+
+// TestJSONRPC_MethodNotFound prueba el error de método inexistente
+func TestJSONRPC_MethodNotFound(t *testing.T) {
+	server, db, executor := crearTestRPCServer(t)
+	defer func() {
+		executor.Stop()
+		db.Close()
+		os.RemoveAll("./test_data_api_" + t.Name())
+	}()
+
+	resp := decodeRPCResponse(t, rpcPost(t, server, `{"jsonrpc":"2.0","id":1,"method":"eth_noExiste","params":[]}`))
+	rpcErr, ok := resp["error"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("Esperado error, obtenido %v", resp)
+	}
+	if rpcErr["code"] != float64(rpcErrMethodNotFound) {
+		t.Errorf("Código de error incorrecto: esperado %d, obtenido %v", rpcErrMethodNotFound, rpcErr["code"])
+	}
+
+	// JSON inválido
+	resp = decodeRPCResponse(t, rpcPost(t, server, `{"jsonrpc":`))
+	rpcErr, ok = resp["error"].(map[string]interface{})
+	if !ok || rpcErr["code"] != float64(rpcErrParse) {
+		t.Errorf("Esperado error de parseo, obtenido %v", resp["error"])
+	}
+}
+
+// TestJSONRPC_Batch prueba requests batch y notificaciones
+func TestJSONRPC_Batch(t *testing.T) {
+	server, db, executor := crearTestRPCServer(t)
+	defer func() {
+		executor.Stop()
+		db.Close()
+		os.RemoveAll("./test_data_api_" + t.Name())
+	}()
+
+	body := `[
+		{"jsonrpc":"2.0","id":1,"method":"eth_blockNumber"},
+		{"jsonrpc":"2.0","method":"eth_chainId"},
+		{"jsonrpc":"2.0","id":2,"method":"eth_getBlockByNumber","params":["0x99",false]}
+	]`
+	rr := rpcPost(t, server, body)
+
+	var responses []map[string]interface{}
+	if err := json.Unmarshal(rr.Body.Bytes(), &responses); err != nil {
+		t.Fatalf("Error parseando batch: %v (%s)", err, rr.Body.String())
+	}
+
+	// La notificación (sin id) no produce respuesta
+	if len(responses) != 2 {
+		t.Fatalf("Esperadas 2 respuestas, obtenidas %d", len(responses))
+	}
+	if responses[0]["result"] != "0x0" {
+		t.Errorf("eth_blockNumber incorrecto: %v", responses[0]["result"])
+	}
+
+	// Bloque inexistente retorna result null (no error)
+	result, exists := responses[1]["result"]
+	if !exists || result != nil {
+		t.Errorf("Esperado result null para bloque inexistente, obtenido %v", responses[1])
+	}
+}
+
+// TestJSONRPC_EstimateGas prueba eth_estimateGas para una transferencia simple
+func TestJSONRPC_EstimateGas(t *testing.T) {
+	server, db, executor := crearTestRPCServer(t)
+	defer func() {
+		executor.Stop()
+		db.Close()
+		os.RemoveAll("./test_data_api_" + t.Name())
+	}()
+
+	resp := decodeRPCResponse(t, rpcPost(t, server, `{"jsonrpc":"2.0","id":1,"method":"eth_estimateGas","params":[{
+		"from":"0x1234567890123456789012345678901234567890",
+		"to":"0x0987654321098765432109876543210987654321",
+		"value":"0x1"
+	}]}`))
+	if resp["result"] != "0x5208" {
+		t.Errorf("eth_estimateGas incorrecto: esperado 0x5208, obtenido %v (error: %v)", resp["result"], resp["error"])
+	}
+}
+
+// TestJSONRPC_FeeHistory prueba eth_feeHistory sobre headers con base fee dinámico
+func TestJSONRPC_FeeHistory(t *testing.T) {
+	server, db, executor := crearTestRPCServer(t)
+	defer func() {
+		executor.Stop()
+		db.Close()
+		os.RemoveAll("./test_data_api_" + t.Name())
+	}()
+
+	// Bloque 1 lleno y bloque 2 vacío; el bloque 2 tiene una transacción que pagó 1.5 gwei de propina
+	for i, gasUsed := range []uint64{execution.DefaultBlockGasLimit, 21000} {
+		height := uint64(i + 1)
+		executor.SetCurrentBlockInfo(height, int64(1700000000+height))
+		if _, err := executor.CommitHeader(common.Hash{}, gasUsed, types.Bloom{}); err != nil {
+			t.Fatalf("Error guardando header: %v", err)
+		}
+	}
+	blockData, _ := json.Marshal(&consensus.Block{
+		Header: consensus.BlockHeader{Height: 2},
+		Receipts: []*consensus.TransactionReceipt{
+			{GasUsed: 21000, EffectiveGasPrice: "2625000000"},
+		},
+	})
+	db.SaveBlock(1, []byte(`{"Receipts":[]}`))
+	db.SaveBlock(2, blockData)
+	db.SaveLatestHeight(2)
+
+	resp := decodeRPCResponse(t, rpcPost(t, server,
+		`{"jsonrpc":"2.0","id":1,"method":"eth_feeHistory","params":["0x2","latest",[50]]}`))
+	result, ok := resp["result"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("Respuesta inválida: %v", resp)
+	}
+
+	if result["oldestBlock"] != "0x1" {
+		t.Errorf("oldestBlock incorrecto: %v", result["oldestBlock"])
+	}
+	baseFees := result["baseFeePerGas"].([]interface{})
+	if len(baseFees) != 3 || baseFees[0] != "0x3b9aca00" || baseFees[1] != "0x430e2340" {
+		t.Errorf("baseFeePerGas incorrecto: %v", baseFees)
+	}
+	rewards := result["reward"].([]interface{})
+	if len(rewards) != 2 || rewards[1].([]interface{})[0] != "0x59682f00" {
+		t.Errorf("reward incorrecto: %v", rewards)
+	}
+
+	resp = decodeRPCResponse(t, rpcPost(t, server, `{"jsonrpc":"2.0","id":2,"method":"eth_maxPriorityFeePerGas"}`))
+	if resp["result"] != "0x3b9aca00" {
+		t.Errorf("eth_maxPriorityFeePerGas incorrecto: %v", resp["result"])
+	}
+}
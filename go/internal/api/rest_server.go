@@ -2,8 +2,10 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"math/big"
 	"net/http"
 	"os"
 	"strconv"
@@ -63,9 +65,17 @@ func (s *RestServer) Start() error {
 	mux.HandleFunc("/metrics/prometheus", s.handlePrometheusMetrics)
 	mux.HandleFunc("/api/v1/blocks/", s.handleBlocks)
 	mux.HandleFunc("/api/v1/transactions/", s.handleTransactions)
+	mux.HandleFunc("/api/v1/receipts/", s.handleReceipts)
 	mux.HandleFunc("/api/v1/accounts/", s.handleAccounts)
 	mux.HandleFunc("/api/v1/submit-tx", s.handleSubmitTx)
+	mux.HandleFunc("/api/v1/call", s.handleCall)
+	mux.HandleFunc("/api/v1/estimate-gas", s.handleEstimateGas)
+	mux.HandleFunc("/api/v1/logs", s.handleLogs)
+	mux.HandleFunc("/api/v1/fee-history", s.handleFeeHistory)
 	mux.HandleFunc("/api/v1/validators", s.handleValidators) // Nuevo endpoint
+	mux.HandleFunc("/api/v1/validators/", s.handleValidatorRewards)
+	mux.HandleFunc("/api/v1/supply", s.handleSupply)
+	mux.HandleFunc("/api/v1/slashing", s.handleSlashing)
 
     // Middlewares: CORS, RateLimit, MaxBody
     handler := s.maxBodyMiddleware(
@@ -346,7 +356,7 @@ func (s *RestServer) handleMetrics(w http.ResponseWriter, r *http.Request) {
 	
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(metricsData)
+	json.NewEncoder(w).Encode(&metricsData)
 }
 
 // handlePrometheusMetrics maneja el endpoint /metrics/prometheus
@@ -512,7 +522,50 @@ func (s *RestServer) handleTransactions(w http.ResponseWriter, r *http.Request)
 	w.Write(txData)
 }
 
-// handleAccounts maneja /api/v1/accounts/{address} y /api/v1/accounts/{address}/fund
+// handleReceipts maneja /api/v1/receipts/{hash} (receipt de una transacción incluida, exitosa o fallida)
+func (s *RestServer) handleReceipts(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	txHash := strings.TrimPrefix(r.URL.Path, "/api/v1/receipts/")
+	if txHash == "" {
+		http.Error(w, "Missing transaction hash", http.StatusBadRequest)
+		return
+	}
+
+	// Ubicar la transacción en su bloque (altura, índice)
+	height, index, err := s.storage.GetTxLookup(txHash)
+	if err != nil {
+		http.Error(w, "Receipt not found", http.StatusNotFound)
+		return
+	}
+
+	blockData, err := s.storage.GetBlock(height)
+	if err != nil {
+		// La transacción se ejecutó pero el bloque aún no fue comprometido
+		http.Error(w, "Receipt not found", http.StatusNotFound)
+		return
+	}
+
+	var block consensus.Block
+	if err := json.Unmarshal(blockData, &block); err != nil {
+		http.Error(w, "Error decoding block", http.StatusInternalServerError)
+		return
+	}
+	if index < 0 || index >= len(block.Receipts) {
+		http.Error(w, "Receipt not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(block.Receipts[index])
+}
+
+// handleAccounts maneja /api/v1/accounts/{address}, /api/v1/accounts/{address}/fund, /api/v1/accounts/{address}/unbonding
+// y /api/v1/accounts/{address}/delegations
 func (s *RestServer) handleAccounts(w http.ResponseWriter, r *http.Request) {
 	// Extraer dirección del path
 	path := strings.TrimPrefix(r.URL.Path, "/api/v1/accounts/")
@@ -530,6 +583,16 @@ func (s *RestServer) handleAccounts(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	
+	if strings.HasSuffix(path, "/unbonding") {
+		s.handleUnbondings(w, r, strings.TrimSuffix(path, "/unbonding"))
+		return
+	}
+
+	if strings.HasSuffix(path, "/delegations") {
+		s.handleDelegations(w, r, strings.TrimSuffix(path, "/delegations"), false)
+		return
+	}
+	
 	// Endpoint GET /api/v1/accounts/{address}
 	if r.Method != http.MethodGet {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
@@ -561,6 +624,59 @@ func (s *RestServer) handleAccounts(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(accountState)
 }
 
+// handleUnbondings maneja GET /api/v1/accounts/{address}/unbonding (retiros de stake pendientes)
+func (s *RestServer) handleUnbondings(w http.ResponseWriter, r *http.Request, address string) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if !common.IsHexAddress(address) {
+		http.Error(w, "Invalid Ethereum address", http.StatusBadRequest)
+		return
+	}
+
+	if s.consensus == nil || s.consensus.GetValidatorSet() == nil {
+		http.Error(w, "Consensus not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	type UnbondingInfo struct {
+		ID               uint64 `json:"id"`
+		Validator        string `json:"validator"`
+		Amount           string `json:"amount"`
+		CreationHeight   uint64 `json:"creationHeight"`
+		CompletionHeight uint64 `json:"completionHeight"`
+		CompletionTime   string `json:"completionTime"`
+	}
+
+	entries := s.consensus.GetValidatorSet().GetUnbondings(address)
+	total := new(big.Int)
+	unbondings := make([]UnbondingInfo, 0, len(entries))
+	for _, entry := range entries {
+		total.Add(total, entry.Amount)
+		unbondings = append(unbondings, UnbondingInfo{
+			ID:               entry.ID,
+			Validator:        entry.Validator,
+			Amount:           entry.Amount.String(),
+			CreationHeight:   entry.CreationHeight,
+			CompletionHeight: entry.CompletionHeight,
+			CompletionTime:   entry.CompletionTime.Format(time.RFC3339),
+		})
+	}
+
+	response := map[string]interface{}{
+		"address":    address,
+		"unbondings": unbondings,
+		"count":      len(unbondings),
+		"total":      total.String(),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
 // handleFundAccount maneja POST /api/v1/accounts/{address}/fund
 func (s *RestServer) handleFundAccount(w http.ResponseWriter, r *http.Request, address string) {
 	log.Printf("💰 handleFundAccount llamado: address=%s, method=%s", address, r.Method)
@@ -675,6 +791,319 @@ func (s *RestServer) handleSubmitTx(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(response)
 }
 
+// handleCall maneja POST /api/v1/call (llamada a contrato sin modificar estado)
+func (s *RestServer) handleCall(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if s.executor == nil {
+		http.Error(w, "EVM executor not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	var req struct {
+		From     string `json:"from"`
+		To       string `json:"to"`
+		Data     string `json:"data"` // Hex con prefijo 0x
+		Value    string `json:"value"`
+		GasLimit uint64 `json:"gasLimit"`
+		Height   uint64 `json:"height"` // 0 = último estado
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("Error parsing request: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	if !common.IsHexAddress(req.To) {
+		http.Error(w, "Invalid contract address", http.StatusBadRequest)
+		return
+	}
+	if req.From != "" && !common.IsHexAddress(req.From) {
+		http.Error(w, "Invalid from address", http.StatusBadRequest)
+		return
+	}
+
+	result, err := s.executor.StaticCall(&execution.CallRequest{
+		From:     req.From,
+		To:       req.To,
+		Value:    req.Value,
+		Data:     common.FromHex(req.Data),
+		GasLimit: req.GasLimit,
+		Height:   req.Height,
+	})
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error executing call: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":      result.Success,
+		"returnData":   fmt.Sprintf("0x%x", result.ReturnData),
+		"gasUsed":      result.GasUsed,
+		"reverted":     result.Reverted,
+		"revertReason": result.RevertReason,
+		"error":        result.Error,
+	})
+}
+
+// handleEstimateGas maneja POST /api/v1/estimate-gas
+func (s *RestServer) handleEstimateGas(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if s.executor == nil {
+		http.Error(w, "EVM executor not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	var req struct {
+		From     string `json:"from"`
+		To       string `json:"to"` // Vacío = creación de contrato
+		Data     string `json:"data"`
+		Value    string `json:"value"`
+		GasLimit uint64 `json:"gasLimit"` // Tope de la búsqueda (0 = tope por defecto)
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("Error parsing request: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	if req.To != "" && !common.IsHexAddress(req.To) {
+		http.Error(w, "Invalid to address", http.StatusBadRequest)
+		return
+	}
+	if !common.IsHexAddress(req.From) {
+		http.Error(w, "Invalid from address", http.StatusBadRequest)
+		return
+	}
+
+	gas, err := s.executor.EstimateGas(&execution.CallRequest{
+		From:     req.From,
+		To:       req.To,
+		Value:    req.Value,
+		Data:     common.FromHex(req.Data),
+		GasLimit: req.GasLimit,
+	})
+	if err != nil {
+		var revertErr *execution.RevertError
+		if errors.As(err, &revertErr) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"success":      false,
+				"reverted":     true,
+				"revertReason": revertErr.Reason,
+				"revertData":   fmt.Sprintf("0x%x", revertErr.Data),
+				"error":        revertErr.Error(),
+			})
+			return
+		}
+		http.Error(w, fmt.Sprintf("Error estimating gas: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":  true,
+		"gasLimit": gas,
+	})
+}
+
+// handleLogs maneja GET /api/v1/logs?fromBlock=&toBlock=&address=&topic0=..topic3=&limit=&cursor=
+func (s *RestServer) handleLogs(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+	maxResults := getEnvInt("OXY_LOGS_MAX_RESULTS", 1000)
+
+	latest, err := s.storage.GetLatestHeight()
+	if err != nil {
+		latest = 0
+	}
+
+	filter := &storage.LogFilter{
+		FromBlock: 0,
+		ToBlock:   latest,
+		Address:   query.Get("address"),
+		Limit:     100,
+	}
+
+	if v := query.Get("fromBlock"); v != "" {
+		if filter.FromBlock, err = strconv.ParseUint(v, 10, 64); err != nil {
+			http.Error(w, "Invalid fromBlock", http.StatusBadRequest)
+			return
+		}
+	}
+	if v := query.Get("toBlock"); v != "" && v != "latest" {
+		if filter.ToBlock, err = strconv.ParseUint(v, 10, 64); err != nil {
+			http.Error(w, "Invalid toBlock", http.StatusBadRequest)
+			return
+		}
+	}
+	if filter.FromBlock > filter.ToBlock {
+		http.Error(w, "fromBlock must be <= toBlock", http.StatusBadRequest)
+		return
+	}
+
+	if filter.Address != "" && !common.IsHexAddress(filter.Address) {
+		http.Error(w, "Invalid address", http.StatusBadRequest)
+		return
+	}
+
+	// Topics por posición; se recortan las posiciones vacías del final
+	topics := make([]string, 4)
+	for i := range topics {
+		topics[i] = query.Get(fmt.Sprintf("topic%d", i))
+		if topics[i] != "" && len(common.FromHex(topics[i])) != common.HashLength {
+			http.Error(w, fmt.Sprintf("Invalid topic%d", i), http.StatusBadRequest)
+			return
+		}
+	}
+	for len(topics) > 0 && topics[len(topics)-1] == "" {
+		topics = topics[:len(topics)-1]
+	}
+	filter.Topics = topics
+
+	if v := query.Get("limit"); v != "" {
+		limit, parseErr := strconv.Atoi(v)
+		if parseErr != nil || limit <= 0 {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+		filter.Limit = limit
+	}
+	if filter.Limit > maxResults {
+		filter.Limit = maxResults
+	}
+
+	// Cursor "altura:índice" retornado como nextCursor en la página anterior
+	if v := query.Get("cursor"); v != "" {
+		var cursor storage.LogPosition
+		if _, err := fmt.Sscanf(v, "%d:%d", &cursor.Height, &cursor.Index); err != nil {
+			http.Error(w, "Invalid cursor", http.StatusBadRequest)
+			return
+		}
+		filter.After = &cursor
+	}
+
+	results, next, err := s.storage.FilterLogs(filter)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error querying logs: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	logs := make([]json.RawMessage, len(results))
+	for i, data := range results {
+		logs[i] = json.RawMessage(data)
+	}
+
+	response := map[string]interface{}{
+		"fromBlock": filter.FromBlock,
+		"toBlock":   filter.ToBlock,
+		"logs":      logs,
+		"count":     len(logs),
+	}
+	if next != nil {
+		response["nextCursor"] = fmt.Sprintf("%d:%d", next.Height, next.Index)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+// handleFeeHistory maneja GET /api/v1/fee-history?blockCount=&newestBlock=&percentiles=10,50,90
+func (s *RestServer) handleFeeHistory(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if s.executor == nil {
+		http.Error(w, "EVM executor not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	query := r.URL.Query()
+
+	latest, err := s.storage.GetLatestHeight()
+	if err != nil {
+		latest = 0
+	}
+
+	blockCount := uint64(10)
+	if v := query.Get("blockCount"); v != "" {
+		if blockCount, err = strconv.ParseUint(v, 10, 64); err != nil {
+			http.Error(w, "Invalid blockCount", http.StatusBadRequest)
+			return
+		}
+	}
+
+	newest := latest
+	if v := query.Get("newestBlock"); v != "" && v != "latest" {
+		if newest, err = strconv.ParseUint(v, 10, 64); err != nil {
+			http.Error(w, "Invalid newestBlock", http.StatusBadRequest)
+			return
+		}
+		if newest > latest {
+			newest = latest
+		}
+	}
+
+	var percentiles []float64
+	if v := query.Get("percentiles"); v != "" {
+		for _, part := range strings.Split(v, ",") {
+			p, parseErr := strconv.ParseFloat(strings.TrimSpace(part), 64)
+			if parseErr != nil {
+				http.Error(w, "Invalid percentiles", http.StatusBadRequest)
+				return
+			}
+			percentiles = append(percentiles, p)
+		}
+	}
+
+	history, err := computeFeeHistory(s.storage, s.executor, blockCount, newest, percentiles)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error computing fee history: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	baseFees := make([]string, len(history.BaseFeePerGas))
+	for i, fee := range history.BaseFeePerGas {
+		baseFees[i] = fee.String()
+	}
+	response := map[string]interface{}{
+		"oldestBlock":   history.OldestBlock,
+		"baseFeePerGas": baseFees,
+		"gasUsedRatio":  history.GasUsedRatio,
+		"nextBaseFee":   baseFees[len(baseFees)-1],
+	}
+	if history.Reward != nil {
+		rewards := make([][]string, len(history.Reward))
+		for i, blockRewards := range history.Reward {
+			rewards[i] = make([]string, len(blockRewards))
+			for j, reward := range blockRewards {
+				rewards[i][j] = reward.String()
+			}
+		}
+		response["reward"] = rewards
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
 // handleValidators maneja /api/v1/validators
 func (s *RestServer) handleValidators(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
@@ -699,11 +1128,12 @@ func (s *RestServer) handleValidators(w http.ResponseWriter, r *http.Request) {
 		Jailed       bool     `json:"jailed"`
 		CreatedAt    string   `json:"createdAt"`
 		LastActiveAt string   `json:"lastActiveAt"`
+		Uptime       *consensus.UptimeStats `json:"uptime,omitempty"`
 	}
 
 	validatorInfos := make([]ValidatorInfo, 0, len(validators))
 	for _, v := range validators {
-		validatorInfos = append(validatorInfos, ValidatorInfo{
+		info := ValidatorInfo{
 			Address:      v.Address,
 			PubKey:       fmt.Sprintf("0x%x", v.PubKey),
 			Stake:        v.Stake.String(),
@@ -711,7 +1141,14 @@ func (s *RestServer) handleValidators(w http.ResponseWriter, r *http.Request) {
 			Jailed:       v.Jailed,
 			CreatedAt:    v.CreatedAt.Format(time.RFC3339),
 			LastActiveAt: v.LastActiveAt.Format(time.RFC3339),
-		})
+		}
+		// Estadísticas de firma en la ventana de liveness
+		if vs := s.consensus.GetValidatorSet(); vs != nil {
+			if uptime, err := vs.GetUptime(v.Address); err == nil {
+				info.Uptime = uptime
+			}
+		}
+		validatorInfos = append(validatorInfos, info)
 	}
 
 	response := map[string]interface{}{
@@ -724,3 +1161,187 @@ func (s *RestServer) handleValidators(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(response)
 }
 
+
+// handleValidatorRewards maneja GET /api/v1/validators/{address}/rewards?limit= y
+// GET /api/v1/validators/{address}/delegations
+func (s *RestServer) handleValidatorRewards(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	path := strings.TrimPrefix(r.URL.Path, "/api/v1/validators/")
+	if address, ok := strings.CutSuffix(path, "/delegations"); ok {
+		s.handleDelegations(w, r, address, true)
+		return
+	}
+	address, ok := strings.CutSuffix(path, "/rewards")
+	if !ok || address == "" {
+		http.Error(w, "Not found", http.StatusNotFound)
+		return
+	}
+	if !common.IsHexAddress(address) {
+		http.Error(w, "Invalid address", http.StatusBadRequest)
+		return
+	}
+
+	limit := 100
+	if v := r.URL.Query().Get("limit"); v != "" {
+		parsed, err := strconv.Atoi(v)
+		if err != nil || parsed <= 0 || parsed > 1000 {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+		limit = parsed
+	}
+
+	results, err := s.storage.GetRewards(address, limit)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error querying rewards: %v", err), http.StatusInternalServerError)
+		return
+	}
+	total, err := s.storage.GetRewardTotal(address)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error querying rewards: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	rewards := make([]json.RawMessage, len(results))
+	for i, data := range results {
+		rewards[i] = json.RawMessage(data)
+	}
+
+	response := map[string]interface{}{
+		"validator": address,
+		"total":     total.String(),
+		"rewards":   rewards,
+		"count":     len(rewards),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+// handleDelegations maneja GET /api/v1/accounts/{address}/delegations (delegaciones de un delegador) y
+// GET /api/v1/validators/{address}/delegations (delegaciones a un validador)
+func (s *RestServer) handleDelegations(w http.ResponseWriter, r *http.Request, address string, byValidator bool) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if !common.IsHexAddress(address) {
+		http.Error(w, "Invalid Ethereum address", http.StatusBadRequest)
+		return
+	}
+
+	if s.consensus == nil || s.consensus.GetValidatorSet() == nil {
+		http.Error(w, "Consensus not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	type DelegationInfo struct {
+		Delegator      string `json:"delegator"`
+		Validator      string `json:"validator"`
+		Shares         string `json:"shares"`
+		Tokens         string `json:"tokens"`
+		PendingRewards string `json:"pendingRewards"`
+	}
+
+	vs := s.consensus.GetValidatorSet()
+	entries := vs.GetDelegationsByDelegator(address)
+	if byValidator {
+		entries = vs.GetDelegationsByValidator(address)
+	}
+
+	total := new(big.Int)
+	delegations := make([]DelegationInfo, 0, len(entries))
+	for _, entry := range entries {
+		total.Add(total, entry.Tokens)
+		delegations = append(delegations, DelegationInfo{
+			Delegator:      entry.Delegator,
+			Validator:      entry.Validator,
+			Shares:         entry.Shares.String(),
+			Tokens:         entry.Tokens.String(),
+			PendingRewards: entry.PendingRewards.String(),
+		})
+	}
+
+	response := map[string]interface{}{
+		"address":     address,
+		"delegations": delegations,
+		"count":       len(delegations),
+		"total":       total.String(),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+// handleSlashing maneja GET /api/v1/slashing?validator=&limit= (historial de slashing de la cadena o de un validador)
+func (s *RestServer) handleSlashing(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	validator := r.URL.Query().Get("validator")
+	if validator != "" && !common.IsHexAddress(validator) {
+		http.Error(w, "Invalid address", http.StatusBadRequest)
+		return
+	}
+
+	limit := 100
+	if v := r.URL.Query().Get("limit"); v != "" {
+		parsed, err := strconv.Atoi(v)
+		if err != nil || parsed <= 0 || parsed > 1000 {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+		limit = parsed
+	}
+
+	results, err := s.storage.GetSlashingRecords(validator, limit)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error querying slashing history: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	slashes := make([]json.RawMessage, len(results))
+	for i, data := range results {
+		slashes[i] = json.RawMessage(data)
+	}
+
+	response := map[string]interface{}{
+		"validator": validator,
+		"slashes":   slashes,
+		"count":     len(slashes),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+// handleSupply maneja GET /api/v1/supply (total supply del token nativo)
+func (s *RestServer) handleSupply(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if s.executor == nil {
+		http.Error(w, "EVM executor not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	response := map[string]interface{}{
+		"totalSupply": s.executor.TotalSupply().String(),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
@@ -8,9 +8,13 @@ import (
 	"os"
 	"testing"
 
+	"github.com/Q-YZX0/oxy-blockchain/internal/consensus"
+	"github.com/Q-YZX0/oxy-blockchain/internal/execution"
 	"github.com/Q-YZX0/oxy-blockchain/internal/health"
 	"github.com/Q-YZX0/oxy-blockchain/internal/metrics"
 	"github.com/Q-YZX0/oxy-blockchain/internal/storage"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
 )
 
 // crearTestServer crea un servidor REST de prueba
@@ -323,3 +327,153 @@ func TestRestServer_MethodNotAllowed(t *testing.T) {
 	}
 }
 
+
+// TestRestServer_Call prueba el endpoint /api/v1/call
+func TestRestServer_Call(t *testing.T) {
+	server, db := crearTestServer(t)
+	defer func() {
+		db.Close()
+		os.RemoveAll("./test_data_api_" + t.Name())
+	}()
+
+	body := `{"to":"0x1111111111111111111111111111111111111111","data":"0x"}`
+
+	// Sin executor el endpoint no está disponible
+	req, _ := http.NewRequest("POST", "/api/v1/call", bytes.NewBufferString(body))
+	rr := httptest.NewRecorder()
+	server.handleCall(rr, req)
+	if rr.Code != http.StatusServiceUnavailable {
+		t.Errorf("Status code incorrecto: esperado 503, obtenido %d", rr.Code)
+	}
+
+	// Con executor: llamada a una cuenta sin código retorna éxito vacío
+	server.executor = execution.NewEVMExecutor(db)
+	if err := server.executor.Start(); err != nil {
+		t.Fatalf("Error iniciando ejecutor: %v", err)
+	}
+	defer server.executor.Stop()
+
+	req, _ = http.NewRequest("POST", "/api/v1/call", bytes.NewBufferString(body))
+	rr = httptest.NewRecorder()
+	server.handleCall(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Status code incorrecto: esperado 200, obtenido %d (%s)", rr.Code, rr.Body.String())
+	}
+
+	var response map[string]interface{}
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Error parseando respuesta: %v", err)
+	}
+	if response["success"] != true {
+		t.Errorf("Se esperaba success=true, obtenido %v", response)
+	}
+
+	// Dirección inválida
+	req, _ = http.NewRequest("POST", "/api/v1/call", bytes.NewBufferString(`{"to":"0xinvalida"}`))
+	rr = httptest.NewRecorder()
+	server.handleCall(rr, req)
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("Status code incorrecto: esperado 400, obtenido %d", rr.Code)
+	}
+}
+
+// TestRestServer_Logs prueba GET /api/v1/logs con filtro por dirección y paginación
+func TestRestServer_Logs(t *testing.T) {
+	server, db := crearTestServer(t)
+	defer func() {
+		db.Close()
+		os.RemoveAll("./test_data_api_" + t.Name())
+	}()
+
+	contract := "0x1111111111111111111111111111111111111111"
+	var bloom types.Bloom
+	bloom.Add(common.HexToAddress(contract).Bytes())
+	for height := uint64(1); height <= 3; height++ {
+		logs := []storage.IndexedLog{
+			{Index: 0, Address: contract, Data: []byte(`{"Address":"` + contract + `"}`)},
+			{Index: 1, Address: "0x2222222222222222222222222222222222222222", Data: []byte(`{}`)},
+		}
+		if err := db.SaveLogs(height, logs, bloom.Bytes()); err != nil {
+			t.Fatalf("Error guardando logs: %v", err)
+		}
+	}
+	db.SaveLatestHeight(3)
+
+	get := func(url string) map[string]interface{} {
+		req, _ := http.NewRequest("GET", url, nil)
+		rr := httptest.NewRecorder()
+		server.handleLogs(rr, req)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Status code incorrecto para %s: %d (%s)", url, rr.Code, rr.Body.String())
+		}
+		var resp map[string]interface{}
+		if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("Error parseando respuesta: %v", err)
+		}
+		return resp
+	}
+
+	resp := get("/api/v1/logs?fromBlock=1&address=" + contract + "&limit=2")
+	if resp["count"] != float64(2) || resp["nextCursor"] != "2:0" {
+		t.Fatalf("Primera página incorrecta: %v", resp)
+	}
+
+	resp = get("/api/v1/logs?fromBlock=1&address=" + contract + "&limit=2&cursor=2:0")
+	if resp["count"] != float64(1) || resp["nextCursor"] != nil {
+		t.Errorf("Segunda página incorrecta: %v", resp)
+	}
+
+	req, _ := http.NewRequest("GET", "/api/v1/logs?fromBlock=5&toBlock=1", nil)
+	rr := httptest.NewRecorder()
+	server.handleLogs(rr, req)
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("Esperado 400 para rango inválido, obtenido %d", rr.Code)
+	}
+}
+
+// TestRestServer_GetReceipt prueba GET /api/v1/receipts/{hash} a través del lookup hash -> bloque
+func TestRestServer_GetReceipt(t *testing.T) {
+	server, db := crearTestServer(t)
+	defer func() {
+		db.Close()
+		os.RemoveAll("./test_data_api_" + t.Name())
+	}()
+
+	txHash := "0x00000000000000000000000000000000000000000000000000000000000000aa"
+	block := consensus.Block{
+		Header:       consensus.BlockHeader{Height: 1, Hash: "0x01"},
+		Transactions: []*consensus.Transaction{{Hash: txHash}},
+		Receipts: []*consensus.TransactionReceipt{{
+			TransactionHash: txHash,
+			BlockNumber:     1,
+			GasUsed:         21500,
+			Status:          "failed",
+			Error:           "execution reverted",
+		}},
+	}
+	blockData, _ := json.Marshal(&block)
+	db.SaveBlock(1, blockData)
+	db.SaveTxLookup(txHash, 1, 0)
+
+	req, _ := http.NewRequest("GET", "/api/v1/receipts/"+txHash, nil)
+	rr := httptest.NewRecorder()
+	server.handleReceipts(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Status code incorrecto: %d (%s)", rr.Code, rr.Body.String())
+	}
+
+	var receipt consensus.TransactionReceipt
+	if err := json.Unmarshal(rr.Body.Bytes(), &receipt); err != nil {
+		t.Fatalf("Error parseando receipt: %v", err)
+	}
+	if receipt.Status != "failed" || receipt.GasUsed != 21500 || receipt.BlockNumber != 1 {
+		t.Errorf("Receipt incorrecto: %+v", receipt)
+	}
+
+	req, _ = http.NewRequest("GET", "/api/v1/receipts/0xdeadbeef", nil)
+	rr = httptest.NewRecorder()
+	server.handleReceipts(rr, req)
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("Esperado 404 para receipt inexistente, obtenido %d", rr.Code)
+	}
+}
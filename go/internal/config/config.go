@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"strconv"
 )
 
 // Config contiene toda la configuración del nodo blockchain
@@ -13,6 +14,9 @@ type Config struct {
 	// Chain ID
 	ChainID string
 
+	// Archivo de configuración de cadena EVM (chain ID y calendario de hardforks). Vacío = configuración por defecto.
+	ChainConfigFile string
+
 	// Configuración de validador
 	ValidatorAddr string
 	ValidatorKey  string
@@ -37,6 +41,20 @@ type Config struct {
 	APIEnabled bool
 	APIPort    string
 	APIHost    string
+
+	// Configuración del servidor JSON-RPC (compatible con Ethereum: eth_, net_, web3_)
+	RPCEnabled bool
+	RPCPort    string
+	RPCHost    string
+
+	// Snapshots de state sync: cada cuántos bloques se crea uno (0 = no se crean) y cuántos se conservan
+	SnapshotInterval   uint64
+	SnapshotKeepRecent int
+
+	// Fuentes del oráculo que el validador informa en sus votos: archivo JSON local y/o endpoint HTTP
+	// con el formato {"OXG/USD": "0.25"}
+	OracleFile string
+	OracleURL  string
 }
 
 // LoadConfig carga la configuración desde variables de entorno
@@ -46,6 +64,7 @@ func LoadConfig() *Config {
 	return &Config{
 		DataDir:        dataDir,
 		ChainID:        getEnv("OXY_CHAIN_ID", "oxy-gen-chain"),
+		ChainConfigFile: getEnv("OXY_CHAIN_CONFIG", ""),
 		ValidatorAddr:  getEnv("OXY_VALIDATOR_ADDR", ""),
 		ValidatorKey:   getEnv("OXY_VALIDATOR_KEY", ""),
 		MeshEndpoint:    getEnv("OXY_MESH_ENDPOINT", "ws://localhost:3001"),
@@ -57,6 +76,13 @@ func LoadConfig() *Config {
 		APIEnabled:     getEnvBool("BLOCKCHAIN_API_ENABLED", true),
 		APIPort:         getEnv("BLOCKCHAIN_API_PORT", "8080"),
 		APIHost:         getEnv("BLOCKCHAIN_API_HOST", "localhost"),
+		RPCEnabled:      getEnvBool("BLOCKCHAIN_RPC_ENABLED", true),
+		RPCPort:         getEnv("BLOCKCHAIN_RPC_PORT", "8545"),
+		RPCHost:         getEnv("BLOCKCHAIN_RPC_HOST", "localhost"),
+		SnapshotInterval:   getEnvUint("OXY_SNAPSHOT_INTERVAL", 1000),
+		SnapshotKeepRecent: int(getEnvUint("OXY_SNAPSHOT_KEEP_RECENT", 2)),
+		OracleFile:         getEnv("OXY_ORACLE_FILE", ""),
+		OracleURL:          getEnv("OXY_ORACLE_URL", ""),
 	}
 }
 
@@ -68,6 +94,16 @@ func getEnv(key, defaultValue string) string {
 	return defaultValue
 }
 
+// getEnvUint obtiene una variable de entorno numérica (valor por defecto si no es un entero válido)
+func getEnvUint(key string, defaultValue uint64) uint64 {
+	if value := os.Getenv(key); value != "" {
+		if parsed, err := strconv.ParseUint(value, 10, 64); err == nil {
+			return parsed
+		}
+	}
+	return defaultValue
+}
+
 // getEnvBool obtiene una variable de entorno booleana
 func getEnvBool(key string, defaultValue bool) bool {
 	if value := os.Getenv(key); value != "" {
@@ -6,15 +6,21 @@ import (
 	"fmt"
 	"math/big"
 	"os"
+	"sort"
 	"time"
 
 	cryptosigner "github.com/Q-YZX0/oxy-blockchain/internal/crypto"
 	execution "github.com/Q-YZX0/oxy-blockchain/internal/execution"
 	"github.com/Q-YZX0/oxy-blockchain/internal/logger"
 	"github.com/Q-YZX0/oxy-blockchain/internal/metrics"
+	"github.com/Q-YZX0/oxy-blockchain/internal/oracle"
 	"github.com/Q-YZX0/oxy-blockchain/internal/storage"
 	abcitypes "github.com/cometbft/cometbft/abci/types"
+	cmtproto "github.com/cometbft/cometbft/api/cometbft/types/v1"
+	cmttypes "github.com/cometbft/cometbft/types"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
 )
 
@@ -30,9 +36,17 @@ type ABCIApp struct {
 	currentBlockTxs      []*Transaction
 	currentBlockReceipts []*TransactionReceipt
 	chainID              string
+	blockGasLimit        uint64                // Gas límite por bloque (parámetro de consenso block.max_gas)
+	blockMaxBytes        int64                 // Tamaño máximo de bloque (parámetro de consenso block.max_bytes)
+	genesis              *GenesisState         // app_state del genesis (reparto de fees)
 	getMempool           func() []*Transaction // Función para obtener el mempool local
 	clearMempoolTx       func(string)          // Función para limpiar una transacción del mempool
 	metrics              *metrics.Metrics      // Referencia a las métricas (opcional)
+	snapshots            *SnapshotManager      // Snapshots de state sync (opcional)
+	oracleSources        []oracle.Source       // Fuentes que este validador informa en sus votos (opcional)
+	appVersion           uint64                // Versión de la aplicación (sube con cada actualización aplicada)
+	upgradeHandlers      map[string]UpgradeHandler
+	upgradesChecked      bool // Ya se verificó que el binario tiene el handler de la última actualización
 }
 
 // AppState mantiene el estado de la aplicación
@@ -44,11 +58,29 @@ type AppState struct {
 
 // NewABCIApp crea una nueva aplicación ABCI
 func NewABCIApp(storage *storage.BlockchainDB, executor *execution.EVMExecutor, validators *ValidatorSet, chainID string) *ABCIApp {
-	return &ABCIApp{
-		storage:    storage,
-		executor:   executor,
-		validators: validators,
-		chainID:    chainID,
+	// Gas límite por bloque fijado en InitChain (persistido); por defecto el del ejecutor
+	blockGasLimit, err := storage.GetBlockGasLimit()
+	if err != nil || blockGasLimit == 0 {
+		blockGasLimit = execution.DefaultBlockGasLimit
+	}
+	executor.SetBlockGasLimit(blockGasLimit)
+	blockMaxBytes, err := storage.GetBlockMaxBytes()
+	if err != nil || blockMaxBytes <= 0 {
+		blockMaxBytes = cmttypes.MaxBlockSizeBytes
+	}
+	appVersion, err := storage.GetAppVersion()
+	if err != nil || appVersion == 0 {
+		appVersion = 1
+	}
+
+	app := &ABCIApp{
+		storage:       storage,
+		executor:      executor,
+		validators:    validators,
+		chainID:       chainID,
+		blockGasLimit: blockGasLimit,
+		blockMaxBytes: blockMaxBytes,
+		appVersion:    appVersion,
 		state: &AppState{
 			Height:     0,
 			AppHash:    make([]byte, 32),
@@ -58,7 +90,33 @@ func NewABCIApp(storage *storage.BlockchainDB, executor *execution.EVMExecutor,
 		currentBlockReceipts: make([]*TransactionReceipt, 0),
 		getMempool:           nil, // Se establecerá después
 		clearMempoolTx:       nil, // Se establecerá después
+		genesis:              &GenesisState{},
+		upgradeHandlers:      make(map[string]UpgradeHandler),
+	}
+	for name, handler := range upgradeHandlers {
+		app.upgradeHandlers[name] = handler
+	}
+
+	// Retomar desde el último bloque confirmado (handshake de CometBFT tras un reinicio)
+	if err := app.loadCommittedState(); err != nil {
+		logger.Warn("Error cargando estado confirmado: " + err.Error())
+	}
+
+	// Reaplicar el app_state del genesis guardado en InitChain (reinicio del nodo)
+	if genesisData, err := storage.GetGenesisState(); err == nil {
+		genesis, err := ParseGenesisState(genesisData)
+		if err == nil {
+			err = app.applyGenesisState(genesis)
+		}
+		if err != nil {
+			logger.Warn("Error cargando app_state del genesis: " + err.Error())
+		}
+	}
+	if err := app.loadFeeConfig(); err != nil {
+		logger.Warn("Error cargando mercado de fees: " + err.Error())
 	}
+
+	return app
 }
 
 // SetGetMempool establece la función para obtener el mempool local
@@ -79,9 +137,9 @@ func (app *ABCIApp) SetMetrics(m *metrics.Metrics) {
 // Info retorna información sobre el estado de la aplicación (nueva API v1.0.1)
 func (app *ABCIApp) Info(ctx context.Context, req *abcitypes.InfoRequest) (*abcitypes.InfoResponse, error) {
 	return &abcitypes.InfoResponse{
-		Data:             fmt.Sprintf("oxy-blockchain-v0.1.0"),
-		Version:          "0.1.0",
-		AppVersion:       1,
+		Data:             fmt.Sprintf("oxy-blockchain-v%s", AppSoftwareVersion),
+		Version:          AppSoftwareVersion,
+		AppVersion:       app.appVersion,
 		LastBlockHeight:  app.state.Height,
 		LastBlockAppHash: app.state.AppHash,
 	}, nil
@@ -93,6 +151,33 @@ func (app *ABCIApp) InitChain(ctx context.Context, req *abcitypes.InitChainReque
 	os.Stdout.Sync()
 	logger.Info("Inicializando blockchain")
 
+	// Parámetros de la aplicación desde el app_state del genesis (GreenPool)
+	genesis, err := ParseGenesisState(req.AppStateBytes)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "[ABCI] ERROR en app_state del genesis: %v\n", err)
+		os.Stderr.Sync()
+		return nil, err
+	}
+	if err := app.applyGenesisState(genesis); err != nil {
+		return nil, err
+	}
+	if err := app.storage.SaveGenesisState(req.AppStateBytes); err != nil {
+		return nil, fmt.Errorf("error guardando app_state del genesis: %w", err)
+	}
+	fmt.Fprintf(os.Stdout, "[ABCI] GreenPool: address=%s, percent=%d\n", genesis.Fees.GreenPoolAddress, genesis.Fees.GreenPoolPercent)
+	os.Stdout.Sync()
+
+	// Mercado de fees EIP-1559 del genesis: igual en todos los nodos
+	feeConfig, err := genesis.Fees.feeConfig()
+	if err != nil {
+		return nil, err
+	}
+	if err := app.setFeeConfig(feeConfig); err != nil {
+		return nil, err
+	}
+	fmt.Fprintf(os.Stdout, "[ABCI] Mercado de fees: base fee inicial=%s, mínimo=%s\n", feeConfig.InitialBaseFee, feeConfig.MinBaseFee)
+	os.Stdout.Sync()
+
 	// Cargar validadores guardados
 	fmt.Fprintf(os.Stdout, "[ABCI] Verificando validadores...\n")
 	os.Stdout.Sync()
@@ -102,6 +187,8 @@ func (app *ABCIApp) InitChain(ctx context.Context, req *abcitypes.InitChainReque
 		if err := app.validators.LoadValidators(); err != nil {
 			logger.Warn("Error cargando validadores: " + err.Error())
 		}
+		// Los validadores del genesis se registran con la hora del genesis
+		app.validators.SetBlockInfo(uint64(req.InitialHeight), req.Time.UTC())
 
 		fmt.Fprintf(os.Stdout, "[ABCI] Verificando validadores activos...\n")
 		os.Stdout.Sync()
@@ -114,23 +201,13 @@ func (app *ABCIApp) InitChain(ctx context.Context, req *abcitypes.InitChainReque
 		if len(activeValidators) == 0 {
 			fmt.Fprintf(os.Stdout, "[ABCI] No hay validadores activos, inicializando desde genesis...\n")
 			os.Stdout.Sync()
-			// Convertir validadores del genesis al formato interno
-			genesisValidators := make([]GenesisValidator, 0, len(req.Validators))
-			for _, v := range req.Validators {
-				// Extraer dirección desde la clave pública (simplificado)
-				// Nueva API v1.0.1: usar PubKeyBytes en lugar de PubKey.Data
-				address := common.BytesToAddress(v.PubKeyBytes).Hex()
-
-				// Convertir power a stake usando big.Int para evitar overflow
-				powerBig := big.NewInt(int64(v.Power))
-				multiplier := big.NewInt(1e18)
-				stake := new(big.Int).Mul(powerBig, multiplier)
-
-				genesisValidators = append(genesisValidators, GenesisValidator{
-					Address: address,
-					PubKey:  v.PubKeyBytes,
-					Stake:   stake,
-				})
+			// Convertir validadores del genesis al formato interno: cada clave de consenso se vincula
+			// con la cuenta de su operador
+			genesisValidators, err := genesis.genesisValidators(req.ChainId, req.Validators)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "[ABCI] ERROR en validadores del genesis: %v\n", err)
+				os.Stderr.Sync()
+				return nil, err
 			}
 
 			fmt.Fprintf(os.Stdout, "[ABCI] Validadores genesis convertidos: %d\n", len(genesisValidators))
@@ -143,6 +220,13 @@ func (app *ABCIApp) InitChain(ctx context.Context, req *abcitypes.InitChainReque
 			} else {
 				fmt.Fprintf(os.Stdout, "[ABCI] Validadores genesis inicializados exitosamente\n")
 				os.Stdout.Sync()
+
+				// Comisión de los validadores del genesis
+				for _, gv := range genesisValidators {
+					if err := app.validators.SetCommission(gv.Address, genesis.Rewards.CommissionPercent); err != nil {
+						logger.Warn("Error fijando comisión de validador genesis: " + err.Error())
+					}
+				}
 			}
 		}
 
@@ -179,11 +263,49 @@ func (app *ABCIApp) InitChain(ctx context.Context, req *abcitypes.InitChainReque
 		app.state.Validators = req.Validators
 	}
 
+	// Gas límite por bloque desde los parámetros de consenso del genesis.
+	// Sin límite (max_gas = -1) se fija el límite por defecto y se devuelve a CometBFT
+	// para que el mempool y la propuesta de bloques también lo respeten.
+	var consensusParams *cmtproto.ConsensusParams
+	if req.ConsensusParams != nil && req.ConsensusParams.Block != nil {
+		// max_bytes = -1 equivale al máximo de CometBFT
+		if req.ConsensusParams.Block.MaxBytes > 0 {
+			app.setBlockMaxBytes(req.ConsensusParams.Block.MaxBytes)
+		} else {
+			app.setBlockMaxBytes(cmttypes.MaxBlockSizeBytes)
+		}
+		if req.ConsensusParams.Block.MaxGas > 0 {
+			app.setBlockGasLimit(uint64(req.ConsensusParams.Block.MaxGas))
+		} else {
+			app.setBlockGasLimit(execution.DefaultBlockGasLimit)
+			consensusParams = &cmtproto.ConsensusParams{
+				Block: &cmtproto.BlockParams{
+					MaxBytes: req.ConsensusParams.Block.MaxBytes,
+					MaxGas:   int64(execution.DefaultBlockGasLimit),
+				},
+			}
+		}
+	}
+	fmt.Fprintf(os.Stdout, "[ABCI] Gas límite por bloque: %d\n", app.blockGasLimit)
+	os.Stdout.Sync()
+
+	// CometBFT usa el set de la respuesta en la altura inicial y en la siguiente
+	initialHeight := req.InitialHeight
+	if initialHeight == 0 {
+		initialHeight = 1
+	}
+	for _, height := range []int64{initialHeight, initialHeight + 1} {
+		if err := app.saveConsensusValidators(height, toConsensusValidators(app.state.Validators)); err != nil {
+			return nil, err
+		}
+	}
+
 	fmt.Fprintf(os.Stdout, "[ABCI] Preparando respuesta InitChain...\n")
 	os.Stdout.Sync()
 	response := &abcitypes.InitChainResponse{
-		Validators: app.state.Validators,
-		AppHash:    app.state.AppHash,
+		ConsensusParams: consensusParams,
+		Validators:      app.state.Validators,
+		AppHash:         app.state.AppHash,
 	}
 	fmt.Fprintf(os.Stdout, "[ABCI] InitChain completado, retornando respuesta\n")
 	os.Stdout.Sync()
@@ -207,6 +329,14 @@ func (app *ABCIApp) FinalizeBlock(ctx context.Context, req *abcitypes.FinalizeBl
 		os.Stdout.Sync()
 	}
 
+	// Un plan de actualización para esta altura sin su handler detiene el nodo antes de escribir nada
+	if err := app.checkUpgradeHalt(uint64(req.Height)); err != nil {
+		return nil, err
+	}
+
+	// Las escrituras del bloque se acumulan hasta Commit, que las confirma juntas
+	app.storage.BeginBlock()
+
 	// Guardar altura y timestamp actuales para uso en ejecución EVM
 	app.state.Height = req.Height
 	app.currentBlockHeight = uint64(req.Height)
@@ -221,12 +351,50 @@ func (app *ABCIApp) FinalizeBlock(ctx context.Context, req *abcitypes.FinalizeBl
 
 	// Establecer información del bloque actual en el ejecutor
 	app.executor.SetCurrentBlockInfo(uint64(req.Height), app.currentBlockTime)
+	if app.validators != nil {
+		app.validators.SetBlockInfo(app.currentBlockHeight, app.blockTime())
+	}
+	proposer := app.proposerAddress(req.ProposerAddress)
+	app.executor.SetCoinbase(proposer)
+
+	// Fees acreditadas en el bloque al proponente y al GreenPool
+	proposerFees := new(big.Int)
+	greenPoolFees := new(big.Int)
+
+	// Todas las transacciones del bloque comparten un único gas pool del tamaño del gas límite por bloque
+	app.executor.ResetGasPool()
+
+	// Set de validadores antes de aplicar la evidencia y las transacciones de staking del bloque
+	var validatorsBefore []abcitypes.ValidatorUpdate
+	if app.validators != nil {
+		validatorsBefore = app.validators.ToCometBFTValidators()
+	}
+
+	// Migraciones de la actualización programada para esta altura, antes que el resto del bloque
+	upgradeEvents, consensusParamUpdates, err := app.applyScheduledUpgrade(app.currentBlockHeight)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "[ABCI] ERROR aplicando actualización: %v\n", err)
+		os.Stderr.Sync()
+		return nil, err
+	}
+
+	// Evidencia de mala conducta (doble firma) reportada por CometBFT y firmas del último commit
+	slashEvents, validatorSetChanged, err := app.processSlashing(req)
+	if err != nil {
+		return nil, err
+	}
 
 	// Procesar cada transacción
 	for i, txBytes := range req.Txs {
 		fmt.Fprintf(os.Stdout, "[ABCI] Procesando transacción %d de %d (bytes: %d)\n", i+1, len(req.Txs), len(txBytes))
 		os.Stdout.Sync()
 
+		// La transacción del oráculo publica los valores agregados de las extensiones de voto
+		if i == 0 && isOracleTx(txBytes) {
+			txResults = append(txResults, app.applyOracleTx(txBytes, req.Height))
+			continue
+		}
+
 		// Decodificar transacción
 		var tx Transaction
 		if err := json.Unmarshal(txBytes, &tx); err != nil {
@@ -257,6 +425,38 @@ func (app *ABCIApp) FinalizeBlock(ctx context.Context, req *abcitypes.FinalizeBl
 		fmt.Fprintf(os.Stdout, "[ABCI] Validación exitosa: hash=%s\n", tx.Hash)
 		os.Stdout.Sync()
 
+		// Las transacciones de staking se validan contra el set de validadores antes de ejecutarse
+		var stakingMsg *StakingMsg
+		if IsStakingTransaction(&tx) {
+			msg, err := app.checkStakingTx(&tx)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "[ABCI] ERROR transacción de staking inválida: %v\n", err)
+				os.Stderr.Sync()
+				txResults = append(txResults, &abcitypes.ExecTxResult{
+					Code: 5,
+					Log:  fmt.Sprintf("Transacción de staking inválida: %v", err),
+				})
+				continue
+			}
+			stakingMsg = msg
+		}
+
+		// Las aprobaciones de actualizaciones se validan igual, contra el set de validadores
+		var upgradeMsg *UpgradeMsg
+		if IsUpgradeTransaction(&tx) {
+			msg, err := app.checkUpgradeTx(&tx)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "[ABCI] ERROR transacción de actualización inválida: %v\n", err)
+				os.Stderr.Sync()
+				txResults = append(txResults, &abcitypes.ExecTxResult{
+					Code: 5,
+					Log:  fmt.Sprintf("Transacción de actualización inválida: %v", err),
+				})
+				continue
+			}
+			upgradeMsg = msg
+		}
+
 		// Convertir a formato execution.Transaction
 		fmt.Fprintf(os.Stdout, "[ABCI] Convirtiendo a formato execution: hash=%s\n", tx.Hash)
 		os.Stdout.Sync()
@@ -269,6 +469,11 @@ func (app *ABCIApp) FinalizeBlock(ctx context.Context, req *abcitypes.FinalizeBl
 			GasLimit: tx.GasLimit,
 			GasPrice: tx.GasPrice,
 			Nonce:    tx.Nonce,
+			Index:    len(app.currentBlockTxs),
+
+			MaxFeePerGas:         tx.MaxFeePerGas,
+			MaxPriorityFeePerGas: tx.MaxPriorityFeePerGas,
+			AccessList:           tx.AccessList,
 		}
 
 		// Ejecutar transacción con EVM
@@ -284,6 +489,16 @@ func (app *ABCIApp) FinalizeBlock(ctx context.Context, req *abcitypes.FinalizeBl
 			})
 			continue
 		}
+		if result.Rejected {
+			// Sin receipt ni lookup: la transacción no cambió el estado (ni gas ni nonce)
+			fmt.Fprintf(os.Stderr, "[ABCI] ERROR transacción rechazada por la EVM: hash=%s, error=%s\n", tx.Hash, result.Error)
+			os.Stderr.Sync()
+			txResults = append(txResults, &abcitypes.ExecTxResult{
+				Code: 3,
+				Log:  fmt.Sprintf("Error ejecutando transacción: %s", result.Error),
+			})
+			continue
+		}
 		fmt.Fprintf(os.Stdout, "[ABCI] Ejecución completada: hash=%s, success=%v\n", tx.Hash, result.Success)
 		os.Stdout.Sync()
 
@@ -295,75 +510,120 @@ func (app *ABCIApp) FinalizeBlock(ctx context.Context, req *abcitypes.FinalizeBl
 			Events:  app.buildEvents(result),
 		}
 
+		// La transferencia al módulo de staking ya se ejecutó: aplicar la operación al set de validadores
+		if stakingMsg != nil && result.Success {
+			stakingEvents, err := app.applyStakingTx(&tx, stakingMsg)
+			if err != nil {
+				// Devolver el valor transferido al módulo para no dejar tokens sin dueño
+				if value, ok := new(big.Int).SetString(tx.Value, 10); ok && value.Sign() > 0 {
+					if refundErr := app.executor.Transfer(StakingModuleAddress, common.HexToAddress(tx.From), value); refundErr != nil {
+						logger.Error("Error devolviendo valor de staking: " + refundErr.Error())
+					}
+				}
+				result.Success = false
+				result.Error = fmt.Sprintf("error aplicando staking: %v", err)
+			} else {
+				execTxResult.Events = append(execTxResult.Events, stakingEvents...)
+				validatorSetChanged = true
+			}
+		}
+		if upgradeMsg != nil && result.Success {
+			approvalEvents, err := app.applyUpgradeTx(&tx, upgradeMsg)
+			if err != nil {
+				result.Success = false
+				result.Error = fmt.Sprintf("error aplicando aprobación de actualización: %v", err)
+			} else {
+				execTxResult.Events = append(execTxResult.Events, approvalEvents...)
+			}
+		}
+
+		status := "success"
 		if !result.Success {
 			fmt.Fprintf(os.Stderr, "[ABCI] Transacción falló en ejecución: hash=%s, error=%s\n", tx.Hash, result.Error)
 			os.Stderr.Sync()
 			execTxResult.Code = 4
 			execTxResult.Log = result.Error
+			status = "failed"
 
 			// Actualizar métricas para transacción rechazada
 			if app.metrics != nil {
 				app.metrics.IncrementRejectedTransactions()
 			}
+		} else if app.metrics != nil {
+			// Actualizar métricas para transacción exitosa
+			app.metrics.IncrementTransactions()
+			app.metrics.AddGasUsed(result.GasUsed)
+		}
 
-			// IMPORTANTE: Remover transacción del mempool incluso si falla
-			// Esto evita que se reintente infinitamente
-			if app.clearMempoolTx != nil {
-				app.clearMempoolTx(tx.Hash)
-				fmt.Fprintf(os.Stdout, "[ABCI] Transacción fallida removida del mempool: hash=%s\n", tx.Hash)
-				os.Stdout.Sync()
-			}
+		// Las fees se cobran también si la ejecución revierte
+		addFee(proposerFees, result.ProposerFee)
+		addFee(greenPoolFees, result.GreenPoolFee)
+
+		// Una transacción ejecutada queda incluida en el bloque aunque revierta: cobró gas e incrementó
+		// el nonce, así que se guarda con su receipt. Las que la EVM rechaza antes de ejecutar
+		// (ExecuteTransaction retorna error) no cambian el estado y no se incluyen.
+		fmt.Fprintf(os.Stdout, "[ABCI] Guardando transacción en storage: hash=%s, status=%s\n", tx.Hash, status)
+		os.Stdout.Sync()
 
-			// Guardar transacción fallida en storage para referencia
-			// (opcional: solo si quieres trackear transacciones fallidas)
-			// txData, _ := json.Marshal(tx)
-			// app.storage.SaveTransaction(tx.Hash, txData)
+		txData, _ := json.Marshal(tx)
+		if err := app.storage.SaveTransaction(tx.Hash, txData); err != nil {
+			fmt.Fprintf(os.Stderr, "[ABCI] ERROR guardando transacción en storage: %v\n", err)
+			os.Stderr.Sync()
 		} else {
-			// Guardar transacción solo si fue exitosa
-			fmt.Fprintf(os.Stdout, "[ABCI] Transacción exitosa, guardando en storage: hash=%s\n", tx.Hash)
+			fmt.Fprintf(os.Stdout, "[ABCI] Transacción guardada exitosamente en storage: hash=%s\n", tx.Hash)
 			os.Stdout.Sync()
+		}
 
-			txData, _ := json.Marshal(tx)
-			if err := app.storage.SaveTransaction(tx.Hash, txData); err != nil {
-				fmt.Fprintf(os.Stderr, "[ABCI] ERROR guardando transacción en storage: %v\n", err)
-				os.Stderr.Sync()
-			} else {
-				fmt.Fprintf(os.Stdout, "[ABCI] Transacción guardada exitosamente en storage: hash=%s\n", tx.Hash)
-				os.Stdout.Sync()
-			}
-
-			// Actualizar métricas para transacción exitosa
-			if app.metrics != nil {
-				app.metrics.IncrementTransactions()
-				app.metrics.AddGasUsed(result.GasUsed)
-			}
-
-			// Agregar transacción al bloque actual
-			app.currentBlockTxs = append(app.currentBlockTxs, &tx)
+		// Agregar transacción al bloque actual
+		app.currentBlockTxs = append(app.currentBlockTxs, &tx)
 
-			// Limpiar transacción del mempool local después de procesarla exitosamente
-			if app.clearMempoolTx != nil {
-				app.clearMempoolTx(tx.Hash)
-				fmt.Fprintf(os.Stdout, "[ABCI] Transacción exitosa removida del mempool: hash=%s\n", tx.Hash)
-				os.Stdout.Sync()
-			}
+		// Indexar ubicación de la transacción (altura, índice) para consultas por hash
+		if err := app.storage.SaveTxLookup(tx.Hash, app.currentBlockHeight, len(app.currentBlockTxs)-1); err != nil {
+			logger.Warn("Error guardando lookup de transacción: " + err.Error())
+		}
 
-			// Crear receipt de la transacción
-			receipt := &TransactionReceipt{
-				TransactionHash: tx.Hash,
-				BlockNumber:     app.currentBlockHeight,
-				GasUsed:         result.GasUsed,
-				Status:          "success",
-				Logs:            convertLogs(result.Logs),
-				Error:           result.Error,
-			}
+		// Limpiar transacción del mempool local (también si falla, para no reintentarla infinitamente)
+		if app.clearMempoolTx != nil {
+			app.clearMempoolTx(tx.Hash)
+			fmt.Fprintf(os.Stdout, "[ABCI] Transacción removida del mempool: hash=%s\n", tx.Hash)
+			os.Stdout.Sync()
+		}
 
-			app.currentBlockReceipts = append(app.currentBlockReceipts, receipt)
+		// Crear receipt de la transacción (BlockHash se completa en Commit)
+		txIndex := uint(len(app.currentBlockTxs) - 1)
+		cumulativeGasUsed, firstLogIndex := app.blockReceiptTotals()
+		receipt := &TransactionReceipt{
+			TransactionHash:   tx.Hash,
+			TransactionIndex:  txIndex,
+			BlockNumber:       app.currentBlockHeight,
+			GasUsed:           result.GasUsed,
+			CumulativeGasUsed: cumulativeGasUsed + result.GasUsed,
+			EffectiveGasPrice: result.EffectiveGasPrice,
+			Status:            status,
+			Logs:              convertLogs(result.Logs, tx.Hash, app.currentBlockHeight, txIndex, firstLogIndex),
+			LogsBloom:         hexutil.Encode(result.Bloom.Bytes()),
+			Error:             result.Error,
+			RevertReason:      result.RevertReason,
+			ContractAddress:   result.ContractAddress,
 		}
 
+		app.currentBlockReceipts = append(app.currentBlockReceipts, receipt)
+
 		txResults = append(txResults, execTxResult)
 	}
 
+	// Retiros de stake maduros y rewards de protocolo (emisión según la curva de inflación del genesis)
+	events := append(upgradeEvents, slashEvents...)
+	events = append(events, app.feeDistributionEvents(proposer, proposerFees, greenPoolFees)...)
+	events = append(events, app.releaseMaturedUnbondings()...)
+	rewardEvents, err := app.distributeBlockRewards(app.currentBlockHeight)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "[ABCI] ERROR distribuyendo rewards: %v\n", err)
+		os.Stderr.Sync()
+		logger.Error("Error distribuyendo rewards: " + err.Error())
+	}
+	events = append(events, rewardEvents...)
+
 	// Rotar validadores periódicamente (cada 100 bloques)
 	// IMPORTANTE: Solo retornar ValidatorUpdates si hay cambios REALES
 	// CometBFT puede detenerse si recibe validadores sin cambios
@@ -417,52 +677,144 @@ func (app *ABCIApp) FinalizeBlock(ctx context.Context, req *abcitypes.FinalizeBl
 		}
 	}
 
+	// La evidencia y las transacciones de staking cambian el set: informar a CometBFT solo las diferencias
+	if validatorSetChanged {
+		validatorUpdates = diffValidatorUpdates(validatorsBefore, app.validators.ToCometBFTValidators())
+	}
+
+	// Set de CometBFT de cada altura: el oráculo verifica los votos contra el set que firmó el bloque anterior
+	if err := app.trackConsensusValidators(req.Height, validatorUpdates, validatorsBefore); err != nil {
+		return nil, err
+	}
+
 	dur := time.Since(startFinalize)
 	fmt.Fprintf(os.Stdout, "[ABCI] FinalizeBlock completado: height=%d, txs=%d, duración=%s\n", req.Height, len(req.Txs), dur)
 	os.Stdout.Sync()
 
+	// El total supply (base de la inflación) forma parte del estado comprometido en el AppHash
+	if err := app.executor.SaveTotalSupply(); err != nil {
+		return nil, err
+	}
+
+	// AppHash del bloque: CometBFT lo incluye en el header siguiente y lo usa el light client (state sync)
+	appHash, err := app.computeAppHash()
+	if err != nil {
+		return nil, fmt.Errorf("error calculando AppHash: %w", err)
+	}
+
 	return &abcitypes.FinalizeBlockResponse{
-		TxResults:        txResults,
-		ValidatorUpdates: validatorUpdates,
+		TxResults:             txResults,
+		ValidatorUpdates:      validatorUpdates,
+		ConsensusParamUpdates: consensusParamUpdates,
+		Events:                events,
+		AppHash:               appHash,
 	}, nil
 }
 
+// computeAppHash calcula el AppHash: keccak256 del root del estado EVM y del hash del estado de la
+// aplicación fuera del EVM (validadores, delegaciones, unbondings y parámetros)
+func (app *ABCIApp) computeAppHash() ([]byte, error) {
+	stateRoot := app.executor.GetStateManager().GetRootHash()
+	appStateHash, err := app.storage.AppStateHash()
+	if err != nil {
+		return nil, err
+	}
+	return crypto.Keccak256(stateRoot[:], appStateHash), nil
+}
+
+// addFee suma a total un monto de fees en wei (decimal); ignora montos vacíos o inválidos
+func addFee(total *big.Int, amount string) {
+	if value, ok := new(big.Int).SetString(amount, 10); ok {
+		total.Add(total, value)
+	}
+}
+
+// feeDistributionEvents construye los eventos de bloque con las fees acreditadas al proponente y al GreenPool
+func (app *ABCIApp) feeDistributionEvents(proposer string, proposerFees, greenPoolFees *big.Int) []abcitypes.Event {
+	events := []abcitypes.Event{}
+
+	// Sin proponente resoluble la propina queda en el coinbase zero
+	if proposer == "" {
+		proposer = common.Address{}.Hex()
+	}
+
+	if proposerFees.Sign() > 0 {
+		events = append(events, abcitypes.Event{
+			Type: "fee_distribution",
+			Attributes: []abcitypes.EventAttribute{
+				{Key: "recipient", Value: proposer, Index: true},
+				{Key: "role", Value: "proposer"},
+				{Key: "amount", Value: proposerFees.String()},
+			},
+		})
+	}
+
+	if greenPoolFees.Sign() > 0 {
+		events = append(events, abcitypes.Event{
+			Type: "fee_distribution",
+			Attributes: []abcitypes.EventAttribute{
+				{Key: "recipient", Value: app.genesis.Fees.GreenPoolAddress, Index: true},
+				{Key: "role", Value: "green_pool"},
+				{Key: "amount", Value: greenPoolFees.String()},
+				{Key: "percent", Value: fmt.Sprintf("%d", app.genesis.Fees.GreenPoolPercent)},
+			},
+		})
+	}
+
+	return events
+}
+
 // Commit confirma el bloque y retorna el AppHash (nueva API v1.0.1)
 func (app *ABCIApp) Commit(ctx context.Context, req *abcitypes.CommitRequest) (*abcitypes.CommitResponse, error) {
 	fmt.Fprintf(os.Stdout, "[ABCI] Commit llamado: currentBlockHeight=%d\n", app.currentBlockHeight)
 	os.Stdout.Sync()
 
-	// Guardar estado EVM completo (esto persiste el StateDB)
+	// Guardar estado EVM completo: los nodos del trie se escriben en disco antes que los metadatos del
+	// bloque, así que tras una caída el root confirmado siempre está disponible
 	if err := app.executor.SaveState(); err != nil {
 		fmt.Fprintf(os.Stderr, "[ABCI] ERROR guardando estado EVM: %v\n", err)
 		os.Stderr.Sync()
-		logger.Warn("Error guardando estado EVM: " + err.Error())
+		return nil, fmt.Errorf("error guardando estado EVM: %w", err)
 	}
 
-	// Obtener root hash del StateDB
-	stateRoot := app.executor.GetStateManager().GetRootHash()
+	// Invariante de total supply: suma de balances == emitido - quemado. Si se rompe el nodo se detiene
+	// sin confirmar el bloque: seguir comprometería un total supply incorrecto en el AppHash
+	if app.currentBlockHeight%supplyInvariantInterval == 0 {
+		if err := app.executor.CheckSupplyInvariant(); err != nil {
+			fmt.Fprintf(os.Stderr, "[ABCI] ERROR invariante de supply en altura %d: %v\n", app.currentBlockHeight, err)
+			os.Stderr.Sync()
+			logger.Error("Invariante de supply roto: " + err.Error())
+			return nil, fmt.Errorf("altura %d: %w", app.currentBlockHeight, err)
+		}
+	}
 
-	// Si no hay root, usar hash del estado de la aplicación
-	var appHash []byte
-	if stateRoot != (common.Hash{}) {
-		appHash = stateRoot[:]
-	} else {
-		stateData, _ := json.Marshal(app.state)
-		hash := crypto.Keccak256(stateData)
-		appHash = hash[:32]
+	// Obtener root hash del StateDB y el AppHash (el mismo que retornó FinalizeBlock)
+	stateRoot := app.executor.GetStateManager().GetRootHash()
+	appHash, err := app.computeAppHash()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "[ABCI] ERROR calculando AppHash: %v\n", err)
+		os.Stderr.Sync()
+		return nil, fmt.Errorf("error calculando AppHash: %w", err)
 	}
 
 	// Guardar metadata del estado
-	stateData, _ := json.Marshal(map[string]interface{}{
-		"root":     stateRoot.Hex(),
-		"height":   app.state.Height,
-		"app_hash": common.BytesToHash(appHash).Hex(),
-	})
-	app.storage.SaveState(stateData)
+	if err := app.saveCommittedState(stateRoot, app.currentBlockHeight, appHash); err != nil {
+		return nil, err
+	}
 
-	// Guardar bloque completo
+	// Guardar header EVM y bloque completo
 	if app.currentBlockHeight > 0 {
-		if err := app.saveBlock(appHash); err != nil {
+		var gasUsed uint64
+		for _, receipt := range app.currentBlockReceipts {
+			gasUsed += receipt.GasUsed
+		}
+
+		header, err := app.executor.CommitHeader(stateRoot, gasUsed, app.blockBloom())
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "[ABCI] ERROR guardando header EVM: %v\n", err)
+			os.Stderr.Sync()
+			logger.Warn("Error guardando header EVM: " + err.Error())
+		} else if err := app.saveBlock(header); err != nil {
 			logger.Warn("Error guardando bloque: " + err.Error())
 		}
 
@@ -478,9 +830,21 @@ func (app *ABCIApp) Commit(ctx context.Context, req *abcitypes.CommitRequest) (*
 		}
 	}
 
-	// Actualizar AppHash con root del StateDB
+	// Confirmar en un solo batch el bloque, el root, la altura y el estado de la aplicación
+	if err := app.storage.CommitBlock(); err != nil {
+		fmt.Fprintf(os.Stderr, "[ABCI] ERROR confirmando bloque %d: %v\n", app.currentBlockHeight, err)
+		os.Stderr.Sync()
+		return nil, err
+	}
+
+	// Actualizar AppHash
 	copy(app.state.AppHash, appHash)
 
+	// Snapshot de state sync cada intervalo configurado
+	if app.snapshots != nil && app.snapshots.shouldSnapshot(app.currentBlockHeight) {
+		app.createSnapshot(app.currentBlockHeight, appHash, stateRoot)
+	}
+
 	fmt.Fprintf(os.Stdout, "[ABCI] Commit completado: height=%d, appHash=%s\n", app.currentBlockHeight, common.BytesToHash(appHash).Hex()[:16])
 	os.Stdout.Sync()
 
@@ -490,20 +854,69 @@ func (app *ABCIApp) Commit(ctx context.Context, req *abcitypes.CommitRequest) (*
 	}, nil
 }
 
-// saveBlock guarda el bloque completo en storage
-func (app *ABCIApp) saveBlock(blockHash []byte) error {
-	// Calcular hash del bloque
-	blockHashStr := common.BytesToHash(blockHash).Hex()
+// committedState es el estado del último bloque confirmado (state:latest). Con él la aplicación
+// retoma su altura y AppHash tras un reinicio y CometBFT solo reproduce los bloques que faltan.
+type committedState struct {
+	Root    string `json:"root"`
+	Height  uint64 `json:"height"`
+	AppHash string `json:"app_hash"`
+}
+
+// saveCommittedState guarda el root EVM, la altura y el AppHash del bloque confirmado
+func (app *ABCIApp) saveCommittedState(stateRoot common.Hash, height uint64, appHash []byte) error {
+	stateData, err := json.Marshal(committedState{
+		Root:    stateRoot.Hex(),
+		Height:  height,
+		AppHash: common.BytesToHash(appHash).Hex(),
+	})
+	if err != nil {
+		return fmt.Errorf("error serializando estado: %w", err)
+	}
+	if err := app.storage.SaveState(stateData); err != nil {
+		return fmt.Errorf("error guardando estado: %w", err)
+	}
+	return nil
+}
+
+// loadCommittedState restaura el AppState (altura, AppHash y validadores) del último bloque confirmado
+func (app *ABCIApp) loadCommittedState() error {
+	stateData, err := app.storage.GetState()
+	if err != nil {
+		return nil // Cadena nueva: InitChain la inicializa
+	}
+	var committed committedState
+	if err := json.Unmarshal(stateData, &committed); err != nil {
+		return fmt.Errorf("estado confirmado corrupto: %w", err)
+	}
+	if committed.AppHash == "" {
+		return nil // Estado guardado antes del primer Commit
+	}
 
-	// Obtener hash del bloque padre
+	app.currentBlockHeight = committed.Height
+	app.state.Height = int64(committed.Height)
+	app.state.AppHash = common.HexToHash(committed.AppHash).Bytes()
+	if app.validators != nil {
+		app.state.Validators = app.validators.ToCometBFTValidators()
+	}
+	return nil
+}
+
+// saveBlock guarda el bloque completo en storage a partir del header EVM comprometido
+func (app *ABCIApp) saveBlock(header *types.Header) error {
+	// El hash del bloque es el hash del header EVM (el mismo que ve BLOCKHASH)
+	blockHashStr := header.Hash().Hex()
+
+	// Hash del bloque padre (zero hash en el primer bloque)
 	parentHash := ""
-	if app.currentBlockHeight > 0 {
-		parentBlockData, err := app.storage.GetBlock(app.currentBlockHeight - 1)
-		if err == nil && parentBlockData != nil {
-			var parentBlock Block
-			if err := json.Unmarshal(parentBlockData, &parentBlock); err == nil {
-				parentHash = parentBlock.Header.Hash
-			}
+	if header.ParentHash != (common.Hash{}) {
+		parentHash = header.ParentHash.Hex()
+	}
+
+	// Completar el hash del bloque en receipts y logs (no se conoce hasta tener el root)
+	for _, receipt := range app.currentBlockReceipts {
+		receipt.BlockHash = blockHashStr
+		for i := range receipt.Logs {
+			receipt.Logs[i].BlockHash = blockHashStr
 		}
 	}
 
@@ -514,7 +927,11 @@ func (app *ABCIApp) saveBlock(blockHash []byte) error {
 			Hash:       blockHashStr,
 			ParentHash: parentHash,
 			Timestamp:  time.Unix(app.currentBlockTime, 0),
+			Validator:  header.Coinbase.Hex(),
 			ChainID:    app.chainID,
+			StateRoot:  header.Root.Hex(),
+			BaseFee:    header.BaseFee.String(),
+			GasLimit:   header.GasLimit,
 		},
 		Transactions: app.currentBlockTxs,
 		Receipts:     app.currentBlockReceipts,
@@ -530,6 +947,16 @@ func (app *ABCIApp) saveBlock(blockHash []byte) error {
 		return fmt.Errorf("error guardando bloque: %w", err)
 	}
 
+	// Indexar hash -> altura para búsquedas por hash (eth_getBlockByHash)
+	if err := app.storage.SaveBlockHashIndex(blockHashStr, app.currentBlockHeight); err != nil {
+		logger.Warn("Error guardando índice de hash de bloque: " + err.Error())
+	}
+
+	// Indexar logs por dirección y topics para consultas por rango
+	if err := app.indexLogs(header); err != nil {
+		logger.Warn("Error indexando logs: " + err.Error())
+	}
+
 	// Guardar altura del último bloque
 	if err := app.storage.SaveLatestHeight(app.currentBlockHeight); err != nil {
 		logger.Warn("Error guardando altura: " + err.Error())
@@ -541,21 +968,84 @@ func (app *ABCIApp) saveBlock(blockHash []byte) error {
 	return nil
 }
 
-// convertLogs convierte logs de execution a consensus
-func convertLogs(execLogs []execution.Log) []Log {
+// indexLogs guarda los logs del bloque actual en el índice de logs junto con el bloom del bloque
+func (app *ABCIApp) indexLogs(header *types.Header) error {
+	var logs []storage.IndexedLog
+	for _, receipt := range app.currentBlockReceipts {
+		for _, log := range receipt.Logs {
+			logData, err := json.Marshal(log)
+			if err != nil {
+				return fmt.Errorf("error serializando log: %w", err)
+			}
+			logs = append(logs, storage.IndexedLog{
+				Index:   log.LogIndex,
+				Address: log.Address,
+				Topics:  log.Topics,
+				Data:    logData,
+			})
+		}
+	}
+
+	return app.storage.SaveLogs(app.currentBlockHeight, logs, header.Bloom.Bytes())
+}
+
+// proposerAddress mapea la dirección de consenso del proponente a su dirección EVM.
+// Retorna "" (coinbase zero) si el proponente no está en el ValidatorSet.
+func (app *ABCIApp) proposerAddress(consensusAddress []byte) string {
+	if app.validators == nil || len(consensusAddress) == 0 {
+		return ""
+	}
+
+	validator, err := app.validators.GetValidatorByConsensusAddress(consensusAddress)
+	if err != nil {
+		return ""
+	}
+	return validator.Address
+}
+
+// convertLogs convierte logs de execution a consensus con su posición en el bloque
+func convertLogs(execLogs []execution.Log, txHash string, blockNumber uint64, txIndex uint, firstLogIndex uint) []Log {
 	logs := make([]Log, len(execLogs))
 	for i, log := range execLogs {
 		logs[i] = Log{
 			Address:     log.Address,
 			Topics:      log.Topics,
 			Data:        log.Data,
-			BlockNumber: 0, // Se actualizará al guardar el bloque
-			TxHash:      "",
+			BlockNumber: blockNumber,
+			TxHash:      txHash,
+			TxIndex:     txIndex,
+			LogIndex:    firstLogIndex + uint(i),
 		}
 	}
 	return logs
 }
 
+// blockReceiptTotals retorna el gas acumulado y la cantidad de logs de los receipts del bloque actual
+func (app *ABCIApp) blockReceiptTotals() (uint64, uint) {
+	var gasUsed uint64
+	var logCount uint
+	for _, receipt := range app.currentBlockReceipts {
+		gasUsed += receipt.GasUsed
+		logCount += uint(len(receipt.Logs))
+	}
+	return gasUsed, logCount
+}
+
+// blockBloom combina los blooms de los receipts del bloque actual
+func (app *ABCIApp) blockBloom() types.Bloom {
+	var bloom types.Bloom
+	for _, receipt := range app.currentBlockReceipts {
+		receiptBloom, err := hexutil.Decode(receipt.LogsBloom)
+		if err != nil || len(receiptBloom) != types.BloomByteLength {
+			continue
+		}
+		for i := range bloom {
+			bloom[i] |= receiptBloom[i]
+		}
+	}
+	return bloom
+}
+
 // Query permite consultar el estado de la aplicación (nueva API v1.0.1)
 func (app *ABCIApp) Query(ctx context.Context, req *abcitypes.QueryRequest) (*abcitypes.QueryResponse, error) {
 	// Parsear path del query
@@ -565,6 +1055,10 @@ func (app *ABCIApp) Query(ctx context.Context, req *abcitypes.QueryRequest) (*ab
 	// - "tx/{hash}" - Obtener transacción por hash
 	// - "block/{height}" - Obtener bloque por altura
 	// - "height" - Obtener altura actual
+	// - "rewards/{address}" - Obtener rewards de un validador
+	// - "supply" - Obtener total supply del token nativo
+	// - "slashing" / "slashing/{address}" - Historial de slashing de la cadena o de un validador
+	// - "upgrade" - Plan de actualización programado, aprobaciones pendientes y versión de la aplicación
 
 	path := string(req.Path)
 
@@ -628,6 +1122,75 @@ func (app *ABCIApp) Query(ctx context.Context, req *abcitypes.QueryRequest) (*ab
 			Value: txData,
 		}, nil
 
+	case len(path) > 8 && path[:8] == "rewards/":
+		address := path[8:]
+		entries, total, err := app.GetValidatorRewards(address, 100)
+		if err != nil {
+			return &abcitypes.QueryResponse{
+				Code: 1,
+				Log:  fmt.Sprintf("Error obteniendo rewards: %v", err),
+			}, nil
+		}
+
+		resultData, _ := json.Marshal(map[string]interface{}{
+			"validator": address,
+			"total":     total.String(),
+			"rewards":   entries,
+		})
+		return &abcitypes.QueryResponse{
+			Code:  0,
+			Value: resultData,
+		}, nil
+
+	case path == "slashing" || len(path) > 9 && path[:9] == "slashing/":
+		address := ""
+		if len(path) > 9 {
+			address = path[9:]
+		}
+		records, err := app.GetSlashingHistory(address, 100)
+		if err != nil {
+			return &abcitypes.QueryResponse{
+				Code: 1,
+				Log:  fmt.Sprintf("Error obteniendo historial de slashing: %v", err),
+			}, nil
+		}
+
+		resultData, _ := json.Marshal(map[string]interface{}{
+			"validator": address,
+			"slashes":   records,
+		})
+		return &abcitypes.QueryResponse{
+			Code:  0,
+			Value: resultData,
+		}, nil
+
+	case path == "upgrade":
+		plan, err := app.getUpgradePlan()
+		if err == nil {
+			var approvals map[string]UpgradePlan
+			if approvals, err = app.getUpgradeApprovals(); err == nil {
+				resultData, _ := json.Marshal(map[string]interface{}{
+					"plan":       plan,
+					"approvals":  approvals,
+					"appVersion": app.appVersion,
+				})
+				return &abcitypes.QueryResponse{
+					Code:  0,
+					Value: resultData,
+				}, nil
+			}
+		}
+		return &abcitypes.QueryResponse{
+			Code: 1,
+			Log:  fmt.Sprintf("Error obteniendo plan de actualización: %v", err),
+		}, nil
+
+	case path == "supply":
+		return &abcitypes.QueryResponse{
+			Code:  0,
+			Value: []byte(app.executor.TotalSupply().String()),
+		}, nil
+
 	case len(path) > 6 && path[:6] == "block/":
 		height := uint64(0)
 		fmt.Sscanf(path[6:], "%d", &height)
@@ -671,9 +1234,28 @@ func (app *ABCIApp) CheckTx(ctx context.Context, req *abcitypes.CheckTxRequest)
 		}, nil
 	}
 
+	if IsStakingTransaction(&tx) {
+		if _, err := app.checkStakingTx(&tx); err != nil {
+			return &abcitypes.CheckTxResponse{
+				Code: 5,
+				Log:  fmt.Sprintf("Transacción de staking inválida: %v", err),
+			}, nil
+		}
+	}
+	if IsUpgradeTransaction(&tx) {
+		if _, err := app.checkUpgradeTx(&tx); err != nil {
+			return &abcitypes.CheckTxResponse{
+				Code: 5,
+				Log:  fmt.Sprintf("Transacción de actualización inválida: %v", err),
+			}, nil
+		}
+	}
+
+	// GasWanted permite a CometBFT respetar block.max_gas al armar bloques desde su mempool
 	return &abcitypes.CheckTxResponse{
-		Code: 0,
-		Log:  "OK",
+		Code:      0,
+		Log:       "OK",
+		GasWanted: int64(tx.GasLimit),
 	}, nil
 }
 
@@ -733,8 +1315,11 @@ func (app *ABCIApp) validateTransactionComplete(tx *Transaction) error {
 			return fmt.Errorf("balance inválido: %s", accountState.Balance)
 		}
 
-		// Calcular gas cost
+		// Calcular gas cost (con fee cap EIP-1559 si la transacción lo define)
 		gasPrice, ok := new(big.Int).SetString(tx.GasPrice, 10)
+		if tx.MaxFeePerGas != "" {
+			gasPrice, ok = new(big.Int).SetString(tx.MaxFeePerGas, 10)
+		}
 		if !ok {
 			return fmt.Errorf("gas price inválido: %s", tx.GasPrice)
 		}
@@ -748,23 +1333,18 @@ func (app *ABCIApp) validateTransactionComplete(tx *Transaction) error {
 		}
 	}
 
+	// Las transacciones Ethereum crudas (eth_sendRawTransaction) traen su propia firma EIP-155/EIP-2718
+	if len(tx.RawTx) > 0 {
+		return verifyRawTransaction(tx, app.executor.ChainID())
+	}
+
 	// Validar firma criptográfica
 	if len(tx.Signature) == 0 {
 		return fmt.Errorf("transacción sin firma")
 	}
 
 	// Convertir transacción a mapa para validación de firma
-	txMap := map[string]interface{}{
-		"hash":      tx.Hash,
-		"from":      tx.From,
-		"to":        tx.To,
-		"value":     tx.Value,
-		"data":      tx.Data,
-		"gasLimit":  tx.GasLimit,
-		"gasPrice":  tx.GasPrice,
-		"nonce":     tx.Nonce,
-		"signature": tx.Signature,
-	}
+	txMap := signingPayload(tx)
 
 	// Verificar firma
 	_, err = cryptosigner.VerifyTransactionSignature(txMap)
@@ -787,6 +1367,27 @@ func (app *ABCIApp) validateTransactionComplete(tx *Transaction) error {
 	return nil
 }
 
+// signingPayload retorna los campos firmados de una transacción nativa, en el formato de cryptosigner
+func signingPayload(tx *Transaction) map[string]interface{} {
+	txMap := map[string]interface{}{
+		"hash":      tx.Hash,
+		"from":      tx.From,
+		"to":        tx.To,
+		"value":     tx.Value,
+		"data":      tx.Data,
+		"gasLimit":  tx.GasLimit,
+		"gasPrice":  tx.GasPrice,
+		"nonce":     tx.Nonce,
+		"signature": tx.Signature,
+	}
+	// Los campos EIP-1559 solo forman parte del payload firmado si están presentes
+	if tx.MaxFeePerGas != "" {
+		txMap["maxFeePerGas"] = tx.MaxFeePerGas
+		txMap["maxPriorityFeePerGas"] = tx.MaxPriorityFeePerGas
+	}
+	return txMap
+}
+
 // buildEvents construye eventos a partir del resultado de ejecución
 func (app *ABCIApp) buildEvents(result *execution.ExecutionResult) []abcitypes.Event {
 	events := []abcitypes.Event{}
@@ -800,6 +1401,30 @@ func (app *ABCIApp) buildEvents(result *execution.ExecutionResult) []abcitypes.E
 		},
 	})
 
+	// Evento de fees EIP-1559 (base fee quemado o enviado al destinatario configurado)
+	if result.BaseFee != "" {
+		events = append(events, abcitypes.Event{
+			Type: "fee",
+			Attributes: []abcitypes.EventAttribute{
+				{Key: "base_fee", Value: result.BaseFee},
+				{Key: "base_fee_amount", Value: result.BaseFeeAmount},
+				{Key: "effective_gas_price", Value: result.EffectiveGasPrice},
+				{Key: "proposer_fee", Value: result.ProposerFee},
+				{Key: "green_pool_fee", Value: result.GreenPoolFee},
+			},
+		})
+	}
+
+	// Evento de creación de contrato
+	if result.ContractAddress != "" {
+		events = append(events, abcitypes.Event{
+			Type: "contract_created",
+			Attributes: []abcitypes.EventAttribute{
+				{Key: "address", Value: result.ContractAddress},
+			},
+		})
+	}
+
 	// Eventos de logs de contratos
 	for _, log := range result.Logs {
 		events = append(events, abcitypes.Event{
@@ -813,13 +1438,22 @@ func (app *ABCIApp) buildEvents(result *execution.ExecutionResult) []abcitypes.E
 	return events
 }
 
-// PrepareProposal prepara una propuesta de bloque (nueva API v1.0.1)
+// PrepareProposal prepara una propuesta de bloque (nueva API v1.0.1).
+// Solo incluye transacciones que pasan las mismas verificaciones que ProcessProposal, para que los
+// demás validadores no rechacen la propuesta.
 func (app *ABCIApp) PrepareProposal(ctx context.Context, req *abcitypes.PrepareProposalRequest) (*abcitypes.PrepareProposalResponse, error) {
 	fmt.Fprintf(os.Stdout, "[ABCI] PrepareProposal llamado: height=%d, maxTxBytes=%d\n", req.Height, req.MaxTxBytes)
 	os.Stdout.Sync()
 
 	txs := make([][]byte, 0)
-	var totalBytes int64
+	checker := app.newProposalChecker(req.Height, req.Time, req.MaxTxBytes)
+
+	// La transacción del oráculo va primero, con las extensiones de voto del último commit
+	if oracleTx := app.buildOracleTx(req.Height, req.LocalLastCommit); oracleTx != nil {
+		if err := checker.checkOracle(oracleTx, req.Height); err == nil {
+			txs = append(txs, oracleTx)
+		}
+	}
 
 	// Primero, agregar transacciones del mempool local si está disponible
 	if app.getMempool != nil {
@@ -827,6 +1461,11 @@ func (app *ABCIApp) PrepareProposal(ctx context.Context, req *abcitypes.PrepareP
 		fmt.Fprintf(os.Stdout, "[ABCI] Mempool local tiene %d transacciones\n", len(localMempool))
 		os.Stdout.Sync()
 
+		// Las transacciones de un remitente deben ir en orden de nonce
+		sort.SliceStable(localMempool, func(i, j int) bool {
+			return localMempool[i].Nonce < localMempool[j].Nonce
+		})
+
 		for i, tx := range localMempool {
 			// Serializar transacción a JSON
 			txBytes, err := json.Marshal(tx)
@@ -836,16 +1475,15 @@ func (app *ABCIApp) PrepareProposal(ctx context.Context, req *abcitypes.PrepareP
 				continue // Saltar si no se puede serializar
 			}
 
-			// Verificar límite de bytes
-			if totalBytes+int64(len(txBytes)) > req.MaxTxBytes {
-				fmt.Fprintf(os.Stdout, "[ABCI] Límite de bytes alcanzado: %d + %d > %d\n", totalBytes, len(txBytes), req.MaxTxBytes)
+			// Verificar firma, duplicados, nonce y límites de bytes y gas (una transacción más chica puede entrar todavía)
+			if err := checker.check(txBytes); err != nil {
+				fmt.Fprintf(os.Stdout, "[ABCI] Transacción %s excluida de la propuesta: %v\n", tx.Hash, err)
 				os.Stdout.Sync()
-				break
+				continue
 			}
 
 			txs = append(txs, txBytes)
-			totalBytes += int64(len(txBytes))
-			fmt.Fprintf(os.Stdout, "[ABCI] Transacción %s agregada a propuesta (total: %d bytes)\n", tx.Hash, totalBytes)
+			fmt.Fprintf(os.Stdout, "[ABCI] Transacción %s agregada a propuesta (total: %d bytes)\n", tx.Hash, checker.totalBytes)
 			os.Stdout.Sync()
 		}
 	} else {
@@ -853,39 +1491,44 @@ func (app *ABCIApp) PrepareProposal(ctx context.Context, req *abcitypes.PrepareP
 		os.Stderr.Sync()
 	}
 
-	// Luego, agregar transacciones que vienen de CometBFT (si hay espacio)
+	// Luego, agregar transacciones que vienen de CometBFT (si hay espacio y no están ya incluidas)
 	for _, tx := range req.Txs {
-		if totalBytes+int64(len(tx)) > req.MaxTxBytes {
-			break
-		}
-
-		// Evitar duplicados: verificar si la transacción ya está en la lista
-		isDuplicate := false
-		for _, existingTx := range txs {
-			if string(existingTx) == string(tx) {
-				isDuplicate = true
-				break
-			}
-		}
-
-		if !isDuplicate {
-			txs = append(txs, tx)
-			totalBytes += int64(len(tx))
+		if err := checker.check(tx); err != nil {
+			continue
 		}
+		txs = append(txs, tx)
 	}
 
-	fmt.Fprintf(os.Stdout, "[ABCI] PrepareProposal retornando %d transacciones (total bytes: %d/%d)\n", len(txs), totalBytes, req.MaxTxBytes)
+	fmt.Fprintf(os.Stdout, "[ABCI] PrepareProposal retornando %d transacciones (total bytes: %d/%d, gas: %d/%d)\n", len(txs), checker.totalBytes, req.MaxTxBytes, checker.totalGas, app.blockGasLimit)
 	os.Stdout.Sync()
 
 	return &abcitypes.PrepareProposalResponse{Txs: txs}, nil
 }
 
-// ProcessProposal procesa una propuesta de bloque (nueva API v1.0.1)
+// ProcessProposal valida una propuesta de bloque (nueva API v1.0.1). Rechaza la propuesta completa si
+// alguna transacción no se puede decodificar, no tiene firma o hash válidos, está duplicada, no cubre su
+// gas intrínseco, rompe el orden de nonce de su remitente o excede el tamaño o el gas límite del bloque.
+// La transacción del oráculo, si la hay, va primero y todas sus extensiones de voto deben estar firmadas.
 func (app *ABCIApp) ProcessProposal(ctx context.Context, req *abcitypes.ProcessProposalRequest) (*abcitypes.ProcessProposalResponse, error) {
 	fmt.Fprintf(os.Stdout, "[ABCI] ProcessProposal llamado: height=%d, txs=%d\n", req.Height, len(req.Txs))
 	os.Stdout.Sync()
 
-	// Por ahora, aceptamos todas las propuestas
+	checker := app.newProposalChecker(req.Height, req.Time, app.blockMaxBytes)
+	for i, tx := range req.Txs {
+		check := checker.check
+		if i == 0 && isOracleTx(tx) {
+			check = func(tx []byte) error { return checker.checkOracle(tx, req.Height) }
+		}
+		if err := check(tx); err != nil {
+			fmt.Fprintf(os.Stderr, "[ABCI] ProcessProposal rechazando bloque %d: transacción %d: %v\n", req.Height, i, err)
+			os.Stderr.Sync()
+			logger.Warn(fmt.Sprintf("Propuesta de bloque %d rechazada: transacción %d: %v", req.Height, i, err))
+			return &abcitypes.ProcessProposalResponse{
+				Status: abcitypes.PROCESS_PROPOSAL_STATUS_REJECT,
+			}, nil
+		}
+	}
+
 	response := &abcitypes.ProcessProposalResponse{
 		Status: abcitypes.PROCESS_PROPOSAL_STATUS_ACCEPT,
 	}
@@ -896,42 +1539,68 @@ func (app *ABCIApp) ProcessProposal(ctx context.Context, req *abcitypes.ProcessP
 	return response, nil
 }
 
-// ExtendVote extiende un voto (nueva API v1.0.1)
-func (app *ABCIApp) ExtendVote(ctx context.Context, req *abcitypes.ExtendVoteRequest) (*abcitypes.ExtendVoteResponse, error) {
-	// Por ahora, no extendemos votos
-	return &abcitypes.ExtendVoteResponse{}, nil
+// setBlockGasLimit actualiza y persiste el gas límite por bloque
+func (app *ABCIApp) setBlockGasLimit(limit uint64) {
+	app.blockGasLimit = limit
+	app.executor.SetBlockGasLimit(limit)
+	if err := app.storage.SaveBlockGasLimit(limit); err != nil {
+		logger.Warn("Error guardando gas límite por bloque: " + err.Error())
+	}
 }
 
-// VerifyVoteExtension verifica una extensión de voto (nueva API v1.0.1)
-func (app *ABCIApp) VerifyVoteExtension(ctx context.Context, req *abcitypes.VerifyVoteExtensionRequest) (*abcitypes.VerifyVoteExtensionResponse, error) {
-	// Por ahora, aceptamos todas las extensiones de voto
-	return &abcitypes.VerifyVoteExtensionResponse{
-		Status: abcitypes.VERIFY_VOTE_EXTENSION_STATUS_ACCEPT,
-	}, nil
+// setBlockMaxBytes actualiza y persiste el tamaño máximo de bloque
+func (app *ABCIApp) setBlockMaxBytes(limit int64) {
+	app.blockMaxBytes = limit
+	if err := app.storage.SaveBlockMaxBytes(limit); err != nil {
+		logger.Warn("Error guardando tamaño máximo de bloque: " + err.Error())
+	}
+}
+
+// BlockGasLimit retorna el gas límite por bloque
+func (app *ABCIApp) BlockGasLimit() uint64 {
+	return app.blockGasLimit
 }
 
-// ListSnapshots retorna snapshots disponibles (nueva API v1.0.1)
+// ListSnapshots retorna los snapshots locales disponibles para state sync (nueva API v1.0.1)
 func (app *ABCIApp) ListSnapshots(ctx context.Context, req *abcitypes.ListSnapshotsRequest) (*abcitypes.ListSnapshotsResponse, error) {
-	return &abcitypes.ListSnapshotsResponse{}, nil
+	if app.snapshots == nil {
+		return &abcitypes.ListSnapshotsResponse{}, nil
+	}
+	snapshots, err := app.snapshots.List()
+	if err != nil {
+		logger.Warn("Error listando snapshots: " + err.Error())
+		return &abcitypes.ListSnapshotsResponse{}, nil
+	}
+	return &abcitypes.ListSnapshotsResponse{Snapshots: snapshots}, nil
 }
 
-// OfferSnapshot ofrece un snapshot (nueva API v1.0.1)
+// OfferSnapshot acepta un snapshot de un peer si su metadata es válida y su AppHash es el de confianza
+// del light client (nueva API v1.0.1)
 func (app *ABCIApp) OfferSnapshot(ctx context.Context, req *abcitypes.OfferSnapshotRequest) (*abcitypes.OfferSnapshotResponse, error) {
-	return &abcitypes.OfferSnapshotResponse{
-		Result: abcitypes.OFFER_SNAPSHOT_RESULT_REJECT,
-	}, nil
+	if app.snapshots == nil {
+		return &abcitypes.OfferSnapshotResponse{Result: abcitypes.OFFER_SNAPSHOT_RESULT_REJECT}, nil
+	}
+	return &abcitypes.OfferSnapshotResponse{Result: app.offerSnapshot(req.Snapshot, req.AppHash)}, nil
 }
 
-// LoadSnapshotChunk carga un chunk de snapshot (nueva API v1.0.1)
+// LoadSnapshotChunk carga un chunk de un snapshot local para enviarlo a un peer (nueva API v1.0.1)
 func (app *ABCIApp) LoadSnapshotChunk(ctx context.Context, req *abcitypes.LoadSnapshotChunkRequest) (*abcitypes.LoadSnapshotChunkResponse, error) {
-	return &abcitypes.LoadSnapshotChunkResponse{}, nil
+	if app.snapshots == nil {
+		return &abcitypes.LoadSnapshotChunkResponse{}, nil
+	}
+	chunk, err := app.snapshots.LoadChunk(req.Height, req.Format, req.Chunk)
+	if err != nil {
+		logger.Warn(fmt.Sprintf("Error cargando chunk %d del snapshot de altura %d: %v", req.Chunk, req.Height, err))
+	}
+	return &abcitypes.LoadSnapshotChunkResponse{Chunk: chunk}, nil
 }
 
-// ApplySnapshotChunk aplica un chunk de snapshot (nueva API v1.0.1)
+// ApplySnapshotChunk aplica un chunk del snapshot en restauración (nueva API v1.0.1)
 func (app *ABCIApp) ApplySnapshotChunk(ctx context.Context, req *abcitypes.ApplySnapshotChunkRequest) (*abcitypes.ApplySnapshotChunkResponse, error) {
-	return &abcitypes.ApplySnapshotChunkResponse{
-		Result: abcitypes.APPLY_SNAPSHOT_CHUNK_RESULT_REJECT_SNAPSHOT,
-	}, nil
+	if app.snapshots == nil {
+		return &abcitypes.ApplySnapshotChunkResponse{Result: abcitypes.APPLY_SNAPSHOT_CHUNK_RESULT_ABORT}, nil
+	}
+	return app.applySnapshotChunk(req), nil
 }
 
 // hasValidatorChanges compara dos sets de validadores para detectar cambios reales
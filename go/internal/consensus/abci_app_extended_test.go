@@ -4,45 +4,20 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"math/big"
 	"os"
-	"path/filepath"
 	"testing"
 	"time"
 
 	abcitypes "github.com/cometbft/cometbft/abci/types"
+	cmtproto "github.com/cometbft/cometbft/api/cometbft/types/v1"
+	"github.com/cometbft/cometbft/crypto/ed25519"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/crypto"
 	execution "github.com/Q-YZX0/oxy-blockchain/internal/execution"
 	"github.com/Q-YZX0/oxy-blockchain/internal/storage"
 )
 
-// cleanupTestDir limpia completamente un directorio de test incluyendo subdirectorios de Pebble DB
-func cleanupTestDir(dir string) error {
-	if dir == "" {
-		return nil
-	}
-	
-	// Limpiar subdirectorio de Pebble DB si existe
-	pebbleDir := filepath.Join(dir, "evm_state")
-	if err := os.RemoveAll(pebbleDir); err != nil {
-		return err
-	}
-	
-	// Limpiar directorio principal
-	if err := os.RemoveAll(dir); err != nil {
-		return err
-	}
-	
-	return nil
-}
-
-// createTestDir crea un directorio único de test con timestamp en un directorio temporal del sistema
-func createTestDir(testName string) string {
-	timestamp := time.Now().UnixNano()
-	tmpDir := os.TempDir()
-	return filepath.Join(tmpDir, "oxy_blockchain_test", fmt.Sprintf("test_data_%s_%d", testName, timestamp))
-}
-
 // TestABCIApp_MultipleBlocks prueba el procesamiento de múltiples bloques en secuencia
 func TestABCIApp_MultipleBlocks(t *testing.T) {
 	ctx := context.Background()
@@ -571,3 +546,488 @@ func TestABCIApp_CheckTx_RateLimit(t *testing.T) {
 	}
 }
 
+
+// TestABCIApp_Commit_HeaderChain verifica que los bloques guardados forman una cadena de headers EVM
+// y que el coinbase es la dirección EVM del proponente
+func TestABCIApp_Commit_HeaderChain(t *testing.T) {
+	ctx := context.Background()
+
+	testDir := createTestDir("header_chain")
+	defer func() {
+		if err := cleanupTestDir(testDir); err != nil {
+			t.Logf("Advertencia: error limpiando directorio: %v", err)
+		}
+	}()
+
+	db, err := storage.NewBlockchainDB(testDir)
+	if err != nil {
+		t.Fatalf("Error creando storage: %v", err)
+	}
+	defer db.Close()
+
+	evm := execution.NewEVMExecutor(db)
+	if err := evm.Start(); err != nil {
+		t.Fatalf("Error iniciando EVM: %v", err)
+	}
+	defer evm.Stop()
+
+	// Validador con clave CometBFT conocida
+	privKey := ed25519.GenPrivKey()
+	validatorAddr := "0xAbCdEf0123456789aBcDeF0123456789AbCdEf01"
+	validators := NewValidatorSet(db, evm, big.NewInt(1000), 10)
+	if _, err := validators.RegisterValidator(validatorAddr, privKey.PubKey().Bytes(), big.NewInt(5000)); err != nil {
+		t.Fatalf("Error registrando validador: %v", err)
+	}
+
+	app := NewABCIApp(db, evm, validators, "test-chain")
+
+	for i := int64(1); i <= 3; i++ {
+		_, err := app.FinalizeBlock(ctx, &abcitypes.FinalizeBlockRequest{
+			Height:          i,
+			Time:            time.Unix(1700000000+i, 0),
+			ProposerAddress: privKey.PubKey().Address(),
+		})
+		if err != nil {
+			t.Fatalf("Error en FinalizeBlock altura %d: %v", i, err)
+		}
+		if _, err := app.Commit(ctx, &abcitypes.CommitRequest{}); err != nil {
+			t.Fatalf("Error en Commit altura %d: %v", i, err)
+		}
+	}
+
+	var previousHash string
+	for i := uint64(1); i <= 3; i++ {
+		blockData, err := db.GetBlock(i)
+		if err != nil {
+			t.Fatalf("Bloque %d no encontrado: %v", i, err)
+		}
+		var block Block
+		if err := json.Unmarshal(blockData, &block); err != nil {
+			t.Fatalf("Error parseando bloque %d: %v", i, err)
+		}
+
+		header, err := execution.LoadHeader(db, i)
+		if err != nil {
+			t.Fatalf("Header %d no encontrado: %v", i, err)
+		}
+
+		if block.Header.Hash != header.Hash().Hex() {
+			t.Errorf("Hash de bloque %d no coincide con el header EVM", i)
+		}
+		if block.Header.ParentHash != previousHash {
+			t.Errorf("ParentHash de bloque %d incorrecto: esperado %s, obtenido %s", i, previousHash, block.Header.ParentHash)
+		}
+		if header.Coinbase != common.HexToAddress(validatorAddr) {
+			t.Errorf("Coinbase de bloque %d incorrecto: %s", i, header.Coinbase.Hex())
+		}
+		if header.Time != uint64(1700000000+i) {
+			t.Errorf("Timestamp de header %d incorrecto: %d", i, header.Time)
+		}
+
+		height, err := db.GetBlockHeightByHash(block.Header.Hash)
+		if err != nil || height != i {
+			t.Errorf("Índice hash -> altura incorrecto para bloque %d", i)
+		}
+
+		previousHash = block.Header.Hash
+	}
+}
+
+// TestABCIApp_FinalizeBlock_FailedTxReceipt verifica que una transacción revertida queda incluida
+// en el bloque con su receipt de fallo, su lookup y la transacción guardada
+func TestABCIApp_FinalizeBlock_FailedTxReceipt(t *testing.T) {
+	ctx := context.Background()
+
+	testDir := createTestDir("failed_tx_receipt")
+	defer func() {
+		if err := cleanupTestDir(testDir); err != nil {
+			t.Logf("Advertencia: error limpiando directorio: %v", err)
+		}
+	}()
+
+	db, err := storage.NewBlockchainDB(testDir)
+	if err != nil {
+		t.Fatalf("Error creando storage: %v", err)
+	}
+	defer db.Close()
+
+	evm := execution.NewEVMExecutor(db)
+	if err := evm.Start(); err != nil {
+		t.Fatalf("Error iniciando EVM: %v", err)
+	}
+	defer evm.Stop()
+
+	// Contrato cuyo runtime siempre hace REVERT (PUSH1 0 PUSH1 0 REVERT)
+	deployer := "0x1000000000000000000000000000000000000001"
+	if err := evm.FundAccount(deployer, "1000000000000000000"); err != nil {
+		t.Fatalf("Error fondeando cuenta: %v", err)
+	}
+	contract, _, err := evm.DeployContract(deployer, common.FromHex("0x6460006000fd6000526005601bf3"), nil, 200000, "1000000000")
+	if err != nil {
+		t.Fatalf("Error desplegando contrato: %v", err)
+	}
+
+	sender := "0x2000000000000000000000000000000000000002"
+	if err := evm.FundAccount(sender, "1000000000000000000"); err != nil {
+		t.Fatalf("Error fondeando cuenta: %v", err)
+	}
+
+	app := NewABCIApp(db, evm, nil, "test-chain")
+
+	txHash := "0x" + fmt.Sprintf("%064x", 1)
+	txData, _ := json.Marshal(Transaction{
+		Hash:     txHash,
+		From:     sender,
+		To:       contract,
+		Value:    "0",
+		GasLimit: 50000,
+		GasPrice: "1000000000",
+		Nonce:    0,
+	})
+
+	resp, err := app.FinalizeBlock(ctx, &abcitypes.FinalizeBlockRequest{
+		Height: 1,
+		Time:   time.Unix(1700000001, 0),
+		Txs:    [][]byte{txData},
+	})
+	if err != nil {
+		t.Fatalf("Error en FinalizeBlock: %v", err)
+	}
+	if resp.TxResults[0].Code != 4 {
+		t.Fatalf("Esperado código 4 (fallo de ejecución), obtenido %d: %s", resp.TxResults[0].Code, resp.TxResults[0].Log)
+	}
+	if _, err := app.Commit(ctx, &abcitypes.CommitRequest{}); err != nil {
+		t.Fatalf("Error en Commit: %v", err)
+	}
+
+	if _, err := db.GetTransaction(txHash); err != nil {
+		t.Errorf("La transacción fallida no se guardó: %v", err)
+	}
+
+	height, index, err := db.GetTxLookup(txHash)
+	if err != nil || height != 1 || index != 0 {
+		t.Fatalf("Lookup incorrecto: height=%d index=%d err=%v", height, index, err)
+	}
+
+	blockData, err := db.GetBlock(1)
+	if err != nil {
+		t.Fatalf("Bloque no encontrado: %v", err)
+	}
+	var block Block
+	if err := json.Unmarshal(blockData, &block); err != nil {
+		t.Fatalf("Error parseando bloque: %v", err)
+	}
+	if len(block.Transactions) != 1 || len(block.Receipts) != 1 {
+		t.Fatalf("El bloque debería incluir la transacción fallida: txs=%d receipts=%d", len(block.Transactions), len(block.Receipts))
+	}
+
+	receipt := block.Receipts[0]
+	if receipt.Status != "failed" || receipt.Error == "" {
+		t.Errorf("Receipt debería indicar fallo: status=%s error=%q", receipt.Status, receipt.Error)
+	}
+	if receipt.GasUsed == 0 || receipt.BlockHash != block.Header.Hash {
+		t.Errorf("Receipt incompleto: gasUsed=%d blockHash=%s", receipt.GasUsed, receipt.BlockHash)
+	}
+
+	// El nonce se incrementa aunque la ejecución revierta
+	if nonce, _ := evm.GetNonce(sender); nonce != 1 {
+		t.Errorf("Nonce incorrecto tras transacción fallida: %d", nonce)
+	}
+}
+
+// TestABCIApp_BlockGasLimit verifica el gas límite por bloque de los parámetros de consenso:
+// empaquetado en PrepareProposal, rechazo en ProcessProposal y gas pool compartido en FinalizeBlock
+func TestABCIApp_BlockGasLimit(t *testing.T) {
+	ctx := context.Background()
+
+	testDir := createTestDir("block_gas_limit")
+	defer func() {
+		if err := cleanupTestDir(testDir); err != nil {
+			t.Logf("Advertencia: error limpiando directorio: %v", err)
+		}
+	}()
+
+	db, err := storage.NewBlockchainDB(testDir)
+	if err != nil {
+		t.Fatalf("Error creando storage: %v", err)
+	}
+	defer db.Close()
+
+	evm := execution.NewEVMExecutor(db)
+	if err := evm.Start(); err != nil {
+		t.Fatalf("Error iniciando EVM: %v", err)
+	}
+	defer evm.Stop()
+
+	senderKey, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatalf("Error generando clave: %v", err)
+	}
+	if err := evm.FundAccount(crypto.PubkeyToAddress(senderKey.PublicKey).Hex(), "1000000000000000000"); err != nil {
+		t.Fatalf("Error fondeando cuenta: %v", err)
+	}
+
+	app := NewABCIApp(db, evm, nil, "test-chain")
+
+	// Genesis sin límite (max_gas = -1): se fija el límite por defecto y se informa a CometBFT
+	initResp, err := app.InitChain(ctx, &abcitypes.InitChainRequest{
+		ChainId:         "test-chain",
+		ConsensusParams: &cmtproto.ConsensusParams{Block: &cmtproto.BlockParams{MaxBytes: 1048576, MaxGas: -1}},
+	})
+	if err != nil {
+		t.Fatalf("Error en InitChain: %v", err)
+	}
+	if initResp.ConsensusParams == nil || initResp.ConsensusParams.Block.MaxGas != int64(execution.DefaultBlockGasLimit) {
+		t.Fatalf("InitChain debería devolver el gas límite por defecto: %+v", initResp.ConsensusParams)
+	}
+
+	// Genesis con límite explícito
+	initResp, err = app.InitChain(ctx, &abcitypes.InitChainRequest{
+		ChainId:         "test-chain",
+		ConsensusParams: &cmtproto.ConsensusParams{Block: &cmtproto.BlockParams{MaxBytes: 1048576, MaxGas: 100000}},
+	})
+	if err != nil {
+		t.Fatalf("Error en InitChain: %v", err)
+	}
+	if initResp.ConsensusParams != nil || app.BlockGasLimit() != 100000 {
+		t.Fatalf("Gas límite por bloque incorrecto: %d", app.BlockGasLimit())
+	}
+
+	// Transferencias de 60000 de gas límite (usan 21000 cada una)
+	txs := make([][]byte, 0, 3)
+	for i := 0; i < 3; i++ {
+		txs = append(txs, signTestTransaction(t, senderKey, Transaction{
+			To:       "0x4000000000000000000000000000000000000004",
+			Value:    "1",
+			GasLimit: 60000,
+			GasPrice: "1000000000",
+			Nonce:    uint64(i),
+		}))
+	}
+
+	prepareResp, err := app.PrepareProposal(ctx, &abcitypes.PrepareProposalRequest{Height: 1, MaxTxBytes: 1048576, Txs: txs})
+	if err != nil {
+		t.Fatalf("Error en PrepareProposal: %v", err)
+	}
+	if len(prepareResp.Txs) != 1 {
+		t.Errorf("PrepareProposal debería empaquetar 1 transacción por gas, obtenido %d", len(prepareResp.Txs))
+	}
+
+	processResp, err := app.ProcessProposal(ctx, &abcitypes.ProcessProposalRequest{Height: 1, Txs: txs[:2]})
+	if err != nil {
+		t.Fatalf("Error en ProcessProposal: %v", err)
+	}
+	if processResp.Status != abcitypes.PROCESS_PROPOSAL_STATUS_REJECT {
+		t.Errorf("ProcessProposal debería rechazar un bloque que excede el gas límite")
+	}
+	processResp, _ = app.ProcessProposal(ctx, &abcitypes.ProcessProposalRequest{Height: 1, Txs: txs[:1]})
+	if processResp.Status != abcitypes.PROCESS_PROPOSAL_STATUS_ACCEPT {
+		t.Errorf("ProcessProposal debería aceptar un bloque dentro del gas límite")
+	}
+
+	// Gas pool compartido: 100000 - 21000 - 21000 = 58000 < 60000, la tercera no entra
+	resp, err := app.FinalizeBlock(ctx, &abcitypes.FinalizeBlockRequest{
+		Height: 1,
+		Time:   time.Unix(1700000001, 0),
+		Txs:    txs,
+	})
+	if err != nil {
+		t.Fatalf("Error en FinalizeBlock: %v", err)
+	}
+	if resp.TxResults[0].Code != 0 || resp.TxResults[1].Code != 0 || resp.TxResults[2].Code != 3 {
+		t.Fatalf("Códigos incorrectos: %d %d %d", resp.TxResults[0].Code, resp.TxResults[1].Code, resp.TxResults[2].Code)
+	}
+	if _, err := app.Commit(ctx, &abcitypes.CommitRequest{}); err != nil {
+		t.Fatalf("Error en Commit: %v", err)
+	}
+
+	blockData, err := db.GetBlock(1)
+	if err != nil {
+		t.Fatalf("Bloque no encontrado: %v", err)
+	}
+	var block Block
+	if err := json.Unmarshal(blockData, &block); err != nil {
+		t.Fatalf("Error parseando bloque: %v", err)
+	}
+	if len(block.Transactions) != 2 || block.Header.GasLimit != 100000 {
+		t.Errorf("Bloque incorrecto: txs=%d gasLimit=%d", len(block.Transactions), block.Header.GasLimit)
+	}
+	if header, err := execution.LoadHeader(db, 1); err != nil || header.GasLimit != 100000 {
+		t.Errorf("Header EVM sin el gas límite por bloque: %v", err)
+	}
+
+	// Gas límite por debajo del gas intrínseco: la EVM rechaza la transacción después de cobrar el gas
+	// por adelantado; el cobro y el gas del bloque se devuelven y la transacción no se incluye.
+	// La siguiente solo entra si el gas pool se restauró (100000 - 20000 < 90000).
+	sender := crypto.PubkeyToAddress(senderKey.PublicKey).Hex()
+	before, _ := evm.GetBalance(sender)
+	underpriced := signTestTransaction(t, senderKey, Transaction{
+		To: "0x4000000000000000000000000000000000000004", Value: "1", GasLimit: 20000, GasPrice: "1000000000", Nonce: 2,
+	})
+	valid := signTestTransaction(t, senderKey, Transaction{
+		To: "0x4000000000000000000000000000000000000004", Value: "1", GasLimit: 90000, GasPrice: "1000000000", Nonce: 2,
+	})
+	resp, err = app.FinalizeBlock(ctx, &abcitypes.FinalizeBlockRequest{Height: 2, Time: time.Unix(1700000002, 0), Txs: [][]byte{underpriced, valid}})
+	if err != nil {
+		t.Fatalf("Error en FinalizeBlock 2: %v", err)
+	}
+	if resp.TxResults[0].Code != 3 || resp.TxResults[1].Code != 0 {
+		t.Fatalf("Códigos incorrectos: %d %d (%s)", resp.TxResults[0].Code, resp.TxResults[1].Code, resp.TxResults[1].Log)
+	}
+	if _, err := app.Commit(ctx, &abcitypes.CommitRequest{}); err != nil {
+		t.Fatalf("Error en Commit 2: %v", err)
+	}
+	after, _ := evm.GetBalance(sender)
+	if cost := new(big.Int).Sub(before, after); cost.Cmp(big.NewInt(21000*1000000000+1)) != 0 {
+		t.Errorf("La transacción rechazada no debería cobrar gas: costo del bloque %s", cost)
+	}
+	if nonce, _ := evm.GetNonce(sender); nonce != 3 {
+		t.Errorf("Nonce incorrecto tras el bloque 2: %d", nonce)
+	}
+	var rejected Transaction
+	json.Unmarshal(underpriced, &rejected)
+	if _, _, err := db.GetTxLookup(rejected.Hash); err == nil {
+		t.Error("La transacción rechazada no debería incluirse en el bloque")
+	}
+	if err := evm.CheckSupplyInvariant(); err != nil {
+		t.Errorf("Invariante de supply roto: %v", err)
+	}
+
+	// El límite persiste para una nueva instancia de la aplicación
+	if restarted := NewABCIApp(db, evm, nil, "test-chain"); restarted.BlockGasLimit() != 100000 {
+		t.Errorf("Gas límite no persistido: %d", restarted.BlockGasLimit())
+	}
+}
+
+// TestABCIApp_FeeDistribution verifica que la propina se acredita al proponente y el porcentaje
+// del genesis al GreenPool, con eventos de bloque para ambos créditos
+func TestABCIApp_FeeDistribution(t *testing.T) {
+	ctx := context.Background()
+
+	testDir := createTestDir("fee_distribution")
+	defer func() {
+		if err := cleanupTestDir(testDir); err != nil {
+			t.Logf("Advertencia: error limpiando directorio: %v", err)
+		}
+	}()
+
+	db, err := storage.NewBlockchainDB(testDir)
+	if err != nil {
+		t.Fatalf("Error creando storage: %v", err)
+	}
+	defer db.Close()
+
+	evm := execution.NewEVMExecutor(db)
+	if err := evm.Start(); err != nil {
+		t.Fatalf("Error iniciando EVM: %v", err)
+	}
+	defer evm.Stop()
+
+	privKey := ed25519.GenPrivKey()
+	proposer := "0xAbCdEf0123456789aBcDeF0123456789AbCdEf01"
+	validators := NewValidatorSet(db, evm, big.NewInt(1000), 10)
+	if _, err := validators.RegisterValidator(proposer, privKey.PubKey().Bytes(), big.NewInt(5000)); err != nil {
+		t.Fatalf("Error registrando validador: %v", err)
+	}
+
+	app := NewABCIApp(db, evm, validators, "test-chain")
+
+	// Un app_state inválido impide iniciar la cadena
+	if _, err := app.InitChain(ctx, &abcitypes.InitChainRequest{
+		ChainId:       "test-chain",
+		AppStateBytes: []byte(`{"fees":{"green_pool_address":"0x1","green_pool_percent":10}}`),
+	}); err == nil {
+		t.Fatal("InitChain debería rechazar una dirección de GreenPool inválida")
+	}
+	if _, err := app.InitChain(ctx, &abcitypes.InitChainRequest{
+		ChainId:       "test-chain",
+		AppStateBytes: []byte(`{"fees":{"initial_base_fee":"1gwei"}}`),
+	}); err == nil {
+		t.Fatal("InitChain debería rechazar un base fee inicial inválido")
+	}
+
+	// El mercado de fees EIP-1559 también sale del genesis: el base fee va a baseFeeRecipient
+	greenPool := "0x6000000000000000000000000000000000000006"
+	baseFeeRecipient := "0x8000000000000000000000000000000000000008"
+	if _, err := app.InitChain(ctx, &abcitypes.InitChainRequest{
+		ChainId: "test-chain",
+		AppStateBytes: []byte(`{"fees":{"green_pool_address":"` + greenPool + `","green_pool_percent":10,` +
+			`"initial_base_fee":"1000000000","min_base_fee":"500000000","base_fee_recipient":"` + baseFeeRecipient + `"}}`),
+	}); err != nil {
+		t.Fatalf("Error en InitChain: %v", err)
+	}
+
+	sender := "0x5000000000000000000000000000000000000005"
+	if err := evm.FundAccount(sender, "1000000000000000000"); err != nil {
+		t.Fatalf("Error fondeando cuenta: %v", err)
+	}
+	proposerBefore, _ := evm.GetBalance(proposer)
+
+	// Base fee inicial 1 gwei, gas price 2 gwei: propina de 1 gwei * 21000
+	txData, _ := json.Marshal(Transaction{
+		Hash:     "0x" + fmt.Sprintf("%064x", 1),
+		From:     sender,
+		To:       "0x7000000000000000000000000000000000000007",
+		Value:    "1",
+		GasLimit: 21000,
+		GasPrice: "2000000000",
+	})
+	resp, err := app.FinalizeBlock(ctx, &abcitypes.FinalizeBlockRequest{
+		Height:          1,
+		Time:            time.Unix(1700000001, 0),
+		Txs:             [][]byte{txData},
+		ProposerAddress: privKey.PubKey().Address(),
+	})
+	if err != nil {
+		t.Fatalf("Error en FinalizeBlock: %v", err)
+	}
+	if resp.TxResults[0].Code != 0 {
+		t.Fatalf("Transacción falló: %s", resp.TxResults[0].Log)
+	}
+
+	tip := big.NewInt(21000 * 1000000000)
+	greenPoolFee := new(big.Int).Div(tip, big.NewInt(10))
+	proposerFee := new(big.Int).Sub(tip, greenPoolFee)
+
+	if balance, _ := evm.GetBalance(greenPool); balance.Cmp(greenPoolFee) != 0 {
+		t.Errorf("Balance del GreenPool incorrecto: %s, esperado %s", balance, greenPoolFee)
+	}
+	proposerAfter, _ := evm.GetBalance(proposer)
+	if gained := new(big.Int).Sub(proposerAfter, proposerBefore); gained.Cmp(proposerFee) != 0 {
+		t.Errorf("Fees del proponente incorrectas: %s, esperado %s", gained, proposerFee)
+	}
+
+	credits := map[string]string{}
+	for _, event := range resp.Events {
+		if event.Type != "fee_distribution" {
+			continue
+		}
+		var role, amount string
+		for _, attr := range event.Attributes {
+			switch attr.Key {
+			case "role":
+				role = attr.Value
+			case "amount":
+				amount = attr.Value
+			}
+		}
+		credits[role] = amount
+	}
+	if credits["proposer"] != proposerFee.String() || credits["green_pool"] != greenPoolFee.String() {
+		t.Errorf("Eventos de reparto de fees incorrectos: %v", credits)
+	}
+	if balance, _ := evm.GetBalance(baseFeeRecipient); balance.Cmp(big.NewInt(21000*1000000000)) != 0 {
+		t.Errorf("Balance del receptor del base fee incorrecto: %s", balance)
+	}
+
+	// El reparto y el mercado de fees del genesis se reaplican tras un reinicio
+	evm.SetFeeDistribution(execution.FeeDistribution{})
+	evm.SetFeeConfig(execution.DefaultFeeConfig())
+	NewABCIApp(db, evm, validators, "test-chain")
+	if dist := evm.GetFeeDistribution(); dist.GreenPool != common.HexToAddress(greenPool) || dist.GreenPoolPercent != 10 {
+		t.Errorf("Reparto de fees no restaurado: %+v", dist)
+	}
+	if cfg := evm.GetFeeConfig(); cfg.BaseFeeRecipient != common.HexToAddress(baseFeeRecipient) || cfg.MinBaseFee.Int64() != 500000000 {
+		t.Errorf("Mercado de fees no restaurado: %+v", cfg)
+	}
+}
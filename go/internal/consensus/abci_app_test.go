@@ -2,6 +2,7 @@ package consensus
 
 import (
 	"context"
+	"crypto/ecdsa"
 	"encoding/json"
 	"fmt"
 	"os"
@@ -10,8 +11,10 @@ import (
 	"time"
 
 	abcitypes "github.com/cometbft/cometbft/abci/types"
+	cmtproto "github.com/cometbft/cometbft/api/cometbft/types/v1"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/crypto"
+	cryptosigner "github.com/Q-YZX0/oxy-blockchain/internal/crypto"
 	execution "github.com/Q-YZX0/oxy-blockchain/internal/execution"
 	"github.com/Q-YZX0/oxy-blockchain/internal/storage"
 )
@@ -39,6 +42,26 @@ func createTestDir(testName string) string {
 	return filepath.Join(tmpDir, "oxy_blockchain_test", fmt.Sprintf("test_data_%s_%d", testName, timestamp))
 }
 
+// signTestTransaction firma una transacción nativa con la clave dada (remitente, hash y firma) y la serializa
+func signTestTransaction(t *testing.T, key *ecdsa.PrivateKey, tx Transaction) []byte {
+	t.Helper()
+	tx.From = crypto.PubkeyToAddress(key.PublicKey).Hex()
+	tx.Signature = nil
+	hash, err := cryptosigner.CalculateTransactionHash(signingPayload(&tx))
+	if err != nil {
+		t.Fatalf("Error calculando hash: %v", err)
+	}
+	tx.Hash = hash.Hex()
+	if tx.Signature, err = crypto.Sign(hash.Bytes(), key); err != nil {
+		t.Fatalf("Error firmando transacción: %v", err)
+	}
+	txData, err := json.Marshal(tx)
+	if err != nil {
+		t.Fatalf("Error serializando transacción: %v", err)
+	}
+	return txData
+}
+
 // TestABCIApp_BasicFlow prueba el flujo básico de ABCI
 func TestABCIApp_BasicFlow(t *testing.T) {
 	ctx := context.Background()
@@ -313,3 +336,134 @@ func TestABCIApp_Query(t *testing.T) {
 	}
 }
 
+
+// TestABCIApp_ProcessProposal verifica que se rechazan propuestas maliciosas (transacciones sin decodificar,
+// sin firma, con firma o hash alterados, duplicadas, con nonces fuera de orden o que exceden los límites
+// del bloque) y que PrepareProposal solo arma propuestas válidas
+func TestABCIApp_ProcessProposal(t *testing.T) {
+	ctx := context.Background()
+
+	testDir := createTestDir("process_proposal")
+	defer func() {
+		if err := cleanupTestDir(testDir); err != nil {
+			t.Logf("Advertencia: error limpiando directorio: %v", err)
+		}
+	}()
+
+	db, err := storage.NewBlockchainDB(testDir)
+	if err != nil {
+		t.Fatalf("Error creando storage: %v", err)
+	}
+	defer db.Close()
+
+	evm := execution.NewEVMExecutor(db)
+	if err := evm.Start(); err != nil {
+		t.Fatalf("Error iniciando EVM: %v", err)
+	}
+	defer evm.Stop()
+
+	app := NewABCIApp(db, evm, nil, "test-chain")
+	if _, err := app.InitChain(ctx, &abcitypes.InitChainRequest{
+		ChainId:         "test-chain",
+		ConsensusParams: &cmtproto.ConsensusParams{Block: &cmtproto.BlockParams{MaxBytes: 4096, MaxGas: 100000}},
+	}); err != nil {
+		t.Fatalf("Error en InitChain: %v", err)
+	}
+
+	senderKey, _ := crypto.GenerateKey()
+	otherKey, _ := crypto.GenerateKey()
+	for _, key := range []*ecdsa.PrivateKey{senderKey, otherKey} {
+		if err := evm.FundAccount(crypto.PubkeyToAddress(key.PublicKey).Hex(), "1000000000000000000"); err != nil {
+			t.Fatalf("Error fondeando cuenta: %v", err)
+		}
+	}
+
+	transfer := func(key *ecdsa.PrivateKey, nonce uint64, gasLimit uint64) []byte {
+		return signTestTransaction(t, key, Transaction{
+			To:       "0x4000000000000000000000000000000000000004",
+			Value:    "1",
+			GasLimit: gasLimit,
+			GasPrice: "1000000000",
+			Nonce:    nonce,
+		})
+	}
+	tamper := func(txData []byte, alter func(tx *Transaction)) []byte {
+		var tx Transaction
+		if err := json.Unmarshal(txData, &tx); err != nil {
+			t.Fatalf("Error decodificando transacción: %v", err)
+		}
+		alter(&tx)
+		altered, _ := json.Marshal(tx)
+		return altered
+	}
+
+	tx0 := transfer(senderKey, 0, 21000)
+	tx1 := transfer(senderKey, 1, 21000)
+	otherTx := transfer(otherKey, 0, 21000)
+	bigTx := signTestTransaction(t, senderKey, Transaction{
+		To:       "0x4000000000000000000000000000000000000004",
+		Value:    "1",
+		Data:     make([]byte, 4096),
+		GasLimit: 60000,
+		GasPrice: "1000000000",
+		Nonce:    1,
+	})
+	signedByOther := tamper(tx0, func(tx *Transaction) {
+		tx.From = crypto.PubkeyToAddress(otherKey.PublicKey).Hex()
+	})
+
+	cases := []struct {
+		name   string
+		txs    [][]byte
+		accept bool
+	}{
+		{"vacía", nil, true},
+		{"válida", [][]byte{tx0, otherTx, tx1}, true},
+		{"no decodificable", [][]byte{tx0, []byte("no es json")}, false},
+		{"sin firma", [][]byte{tamper(tx0, func(tx *Transaction) { tx.Signature = nil })}, false},
+		{"firma de otra cuenta", [][]byte{signedByOther}, false},
+		{"hash alterado", [][]byte{tamper(tx0, func(tx *Transaction) { tx.Hash = common.HexToHash("0x01").Hex() })}, false},
+		{"campo alterado tras firmar", [][]byte{tamper(tx0, func(tx *Transaction) { tx.Value = "2" })}, false},
+		{"duplicada", [][]byte{tx0, otherTx, tx0}, false},
+		{"nonce fuera de orden", [][]byte{tx1, tx0}, false},
+		{"nonce con hueco", [][]byte{tx0, transfer(senderKey, 2, 21000)}, false},
+		{"nonce ya usado", [][]byte{tx0, transfer(senderKey, 0, 22000)}, false},
+		{"gas menor al intrínseco", [][]byte{tx0, transfer(senderKey, 1, 20000)}, false},
+		{"excede gas límite", [][]byte{transfer(senderKey, 0, 60000), transfer(senderKey, 1, 60000)}, false},
+		{"excede tamaño de bloque", [][]byte{tx0, bigTx}, false},
+	}
+	for _, tc := range cases {
+		resp, err := app.ProcessProposal(ctx, &abcitypes.ProcessProposalRequest{Height: 1, Txs: tc.txs})
+		if err != nil {
+			t.Fatalf("%s: error en ProcessProposal: %v", tc.name, err)
+		}
+		if accepted := resp.Status == abcitypes.PROCESS_PROPOSAL_STATUS_ACCEPT; accepted != tc.accept {
+			t.Errorf("%s: aceptada=%v, esperado %v", tc.name, accepted, tc.accept)
+		}
+	}
+
+	// PrepareProposal descarta las transacciones inválidas (también las de gas menor al intrínseco)
+	// y ordena el mempool local por nonce
+	var local []*Transaction
+	for _, txData := range [][]byte{tx1, tx0, signedByOther} {
+		var tx Transaction
+		json.Unmarshal(txData, &tx)
+		local = append(local, &tx)
+	}
+	app.SetGetMempool(func() []*Transaction { return local })
+	prepareResp, err := app.PrepareProposal(ctx, &abcitypes.PrepareProposalRequest{
+		Height:     1,
+		MaxTxBytes: 4096,
+		Txs:        [][]byte{tx0, otherTx, []byte("no es json"), transfer(otherKey, 1, 20000)},
+	})
+	if err != nil {
+		t.Fatalf("Error en PrepareProposal: %v", err)
+	}
+	if len(prepareResp.Txs) != 3 {
+		t.Fatalf("PrepareProposal debería incluir 3 transacciones válidas, incluyó %d", len(prepareResp.Txs))
+	}
+	processResp, _ := app.ProcessProposal(ctx, &abcitypes.ProcessProposalRequest{Height: 1, Txs: prepareResp.Txs})
+	if processResp.Status != abcitypes.PROCESS_PROPOSAL_STATUS_ACCEPT {
+		t.Error("ProcessProposal debería aceptar la propuesta armada por PrepareProposal")
+	}
+}
@@ -32,6 +32,14 @@ type Config struct {
 	ChainID       string
 	ValidatorAddr string
 	ValidatorKey  string
+
+	// Snapshots de state sync (0 = no se crean snapshots)
+	SnapshotInterval   uint64
+	SnapshotKeepRecent int
+
+	// Fuentes del oráculo (archivo JSON local y endpoint HTTP; vacías = el validador no informa datos)
+	OracleFile string
+	OracleURL  string
 }
 
 // NewCometBFT crea una nueva instancia del motor de consenso
@@ -118,6 +126,11 @@ func (c *CometBFT) Stop() error {
 		return fmt.Errorf("error deteniendo nodo CometBFT: %w", err)
 	}
 
+	// Terminar el snapshot en creación antes de que se cierre el estado EVM
+	if c.node.abciApp != nil && c.node.abciApp.snapshots != nil {
+		c.node.abciApp.snapshots.Wait()
+	}
+
 	c.running = false
 	log.Println("⏹️  Consenso CometBFT detenido")
 	return nil
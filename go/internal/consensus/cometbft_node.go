@@ -6,6 +6,8 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/Q-YZX0/oxy-blockchain/internal/execution"
@@ -41,6 +43,10 @@ func NewCometBFTNode(
 	// Crear aplicación ABCI con validators
 	// Nota: El mempool se establecerá después de crear CometBFT completo
 	abciApp := NewABCIApp(storage, executor, validators, cfg.ChainID)
+	abciApp.SetSnapshotManager(NewSnapshotManager(filepath.Join(cfg.DataDir, "snapshots"), cfg.SnapshotInterval, cfg.SnapshotKeepRecent))
+
+	// Fuentes del oráculo que este validador informa en sus extensiones de voto
+	abciApp.SetOracleSources(cfg.OracleSources()...)
 
 	// Crear configuración de CometBFT
 	cometConfig := cometcfg.DefaultConfig()
@@ -64,6 +70,24 @@ func NewCometBFTNode(
 		os.Stdout.Sync()
 	}
 
+	// State sync: un nodo nuevo restaura un snapshot de sus peers en lugar de reproducir toda la cadena.
+	// Requiere al menos dos servidores RPC y una altura y hash de confianza para el light client.
+	if rpcServers := os.Getenv("OXY_STATESYNC_RPC_SERVERS"); rpcServers != "" {
+		trustHeight, err := strconv.ParseInt(os.Getenv("OXY_STATESYNC_TRUST_HEIGHT"), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("OXY_STATESYNC_TRUST_HEIGHT inválido: %w", err)
+		}
+		cometConfig.StateSync.Enable = true
+		cometConfig.StateSync.RPCServers = strings.Split(rpcServers, ",")
+		cometConfig.StateSync.TrustHeight = trustHeight
+		cometConfig.StateSync.TrustHash = os.Getenv("OXY_STATESYNC_TRUST_HASH")
+		if err := cometConfig.StateSync.ValidateBasic(); err != nil {
+			return nil, fmt.Errorf("configuración de state sync inválida: %w", err)
+		}
+		fmt.Fprintf(os.Stdout, "[CometBFT] State sync habilitado: rpc=%s, trust_height=%d\n", rpcServers, trustHeight)
+		os.Stdout.Sync()
+	}
+
 	// Asegurar que el directorio existe
 	if err := os.MkdirAll(cometConfig.RootDir, 0755); err != nil {
 		return nil, fmt.Errorf("error creando directorio CometBFT: %w", err)
@@ -255,6 +279,19 @@ func NewCometBFTNode(
 	// Verificar hash mismatch: eliminar bases de datos si es necesario
 	// Esto se hace mediante el marcador .genesis_changed que se verifica antes de crear el nodo
 
+	// Verificar que el chain_id del genesis coincida con la configuración de cadena EVM
+	genesisDoc, err := types.GenesisDocFromFile(genesisFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "[CometBFT] ERROR cargando genesis: %v\n", err)
+		os.Stderr.Sync()
+		return nil, fmt.Errorf("error cargando genesis: %w", err)
+	}
+	if err := executor.ValidateCometChainID(genesisDoc.ChainID); err != nil {
+		fmt.Fprintf(os.Stderr, "[CometBFT] ERROR chain_id del genesis: %v\n", err)
+		os.Stderr.Sync()
+		return nil, fmt.Errorf("error validando chain_id del genesis: %w", err)
+	}
+
 	// Cargar configuración
 	fmt.Fprintf(os.Stdout, "[CometBFT] Validando configuración...\n")
 	os.Stdout.Sync()
@@ -594,10 +631,13 @@ func createCometBFTConfig(cfg *cometcfg.Config, appConfig *Config) error {
 	// Crear genesis básico
 	fmt.Fprintf(os.Stdout, "[CometBFT] Creando genesis...\n")
 	os.Stdout.Sync()
+	// Extensiones de voto desde el primer bloque: llevan las observaciones del oráculo
+	consensusParams := types.DefaultConsensusParams()
+	consensusParams.Feature.VoteExtensionsEnableHeight = 1
 	genesis := &types.GenesisDoc{
 		ChainID:         appConfig.ChainID,
 		GenesisTime:     time.Now(),
-		ConsensusParams: types.DefaultConsensusParams(),
+		ConsensusParams: consensusParams,
 	}
 
 	genesisFile := filepath.Join(cfg.RootDir, "config", "genesis.json")
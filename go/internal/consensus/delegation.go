@@ -0,0 +1,438 @@
+package consensus
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math/big"
+	"sort"
+	"strings"
+)
+
+// Delegation es el stake que un delegador tiene en un validador, expresado en shares del validador.
+// Los tokens de una delegación son Shares * Validator.Stake / Validator.DelegatorShares, así un slash
+// al validador reduce proporcionalmente a todos sus delegadores. El self-stake es la delegación del
+// operador a sí mismo.
+type Delegation struct {
+	Delegator      string   `json:"delegator"`
+	Validator      string   `json:"validator"`
+	Shares         *big.Int `json:"shares"`
+	PendingRewards *big.Int `json:"pendingRewards"` // Rewards acumulados sin retirar (wei)
+}
+
+// DelegationInfo es una copia de una delegación con su valor actual en tokens
+type DelegationInfo struct {
+	Delegation
+	Tokens *big.Int `json:"tokens"`
+}
+
+// delegationKey identifica la delegación de un delegador a un validador
+func delegationKey(delegator, validator string) string {
+	return strings.ToLower(delegator) + "/" + strings.ToLower(validator)
+}
+
+// Delegate delega tokens a un validador
+func (vs *ValidatorSet) Delegate(delegator, validatorAddress string, amount *big.Int) error {
+	vs.mutex.Lock()
+	defer vs.mutex.Unlock()
+
+	validator, exists := vs.validators[validatorAddress]
+	if !exists {
+		return fmt.Errorf("validador no encontrado: %s", validatorAddress)
+	}
+	if validator.Jailed {
+		return fmt.Errorf("validador está en jail: %s", validatorAddress)
+	}
+	if amount.Sign() <= 0 {
+		return fmt.Errorf("monto a delegar inválido: %s", amount.String())
+	}
+
+	vs.delegateLocked(delegator, validator, amount)
+	log.Printf("✅ %s delegó %s a %s (stake total: %s)", delegator, amount.String(), validatorAddress, validator.Stake.String())
+
+	if err := vs.saveValidatorsLocked(); err != nil {
+		log.Printf("Advertencia: error guardando validadores: %v", err)
+	}
+	return nil
+}
+
+// Undelegate retira tokens delegados a un validador. No aplica al self-stake del operador (ver Unstake).
+// Los tokens retirados deben pasar por el unbonding.
+func (vs *ValidatorSet) Undelegate(delegator, validatorAddress string, amount *big.Int) error {
+	vs.mutex.Lock()
+	defer vs.mutex.Unlock()
+
+	validator, exists := vs.validators[validatorAddress]
+	if !exists {
+		return fmt.Errorf("validador no encontrado: %s", validatorAddress)
+	}
+	if strings.EqualFold(delegator, validator.Address) {
+		return fmt.Errorf("el operador retira su self-stake con unstake")
+	}
+	if err := vs.undelegateLocked(delegator, validator, amount); err != nil {
+		return err
+	}
+
+	log.Printf("✅ %s retiró %s delegados a %s", delegator, amount.String(), validatorAddress)
+
+	if err := vs.saveValidatorsLocked(); err != nil {
+		log.Printf("Advertencia: error guardando validadores: %v", err)
+	}
+	return nil
+}
+
+// Redelegate mueve tokens delegados de un validador a otro sin pasar por el unbonding
+func (vs *ValidatorSet) Redelegate(delegator, srcAddress, dstAddress string, amount *big.Int) error {
+	vs.mutex.Lock()
+	defer vs.mutex.Unlock()
+
+	src, exists := vs.validators[srcAddress]
+	if !exists {
+		return fmt.Errorf("validador origen no encontrado: %s", srcAddress)
+	}
+	dst, exists := vs.validators[dstAddress]
+	if !exists {
+		return fmt.Errorf("validador destino no encontrado: %s", dstAddress)
+	}
+	if src == dst {
+		return fmt.Errorf("validador origen y destino son el mismo")
+	}
+	if dst.Jailed {
+		return fmt.Errorf("validador destino está en jail: %s", dstAddress)
+	}
+	if strings.EqualFold(delegator, src.Address) {
+		return fmt.Errorf("el operador no puede redelegar su self-stake")
+	}
+
+	if err := vs.undelegateLocked(delegator, src, amount); err != nil {
+		return err
+	}
+	vs.delegateLocked(delegator, dst, amount)
+
+	log.Printf("✅ %s redelegó %s de %s a %s", delegator, amount.String(), srcAddress, dstAddress)
+
+	if err := vs.saveValidatorsLocked(); err != nil {
+		log.Printf("Advertencia: error guardando validadores: %v", err)
+	}
+	return nil
+}
+
+// AllocateRewards reparte el reward de un validador: la comisión queda acumulada para el operador y el
+// resto se acredita a los delegadores (incluido el self-stake) en proporción a sus shares.
+// El resto de la división por shares se suma a la comisión para que todo el monto emitido tenga dueño.
+// Retorna la comisión.
+func (vs *ValidatorSet) AllocateRewards(validatorAddress string, amount *big.Int) (*big.Int, error) {
+	vs.mutex.Lock()
+	defer vs.mutex.Unlock()
+
+	validator, exists := vs.validators[validatorAddress]
+	if !exists {
+		return nil, fmt.Errorf("validador no encontrado: %s", validatorAddress)
+	}
+
+	commission := new(big.Int).Mul(amount, new(big.Int).SetUint64(validator.Commission))
+	commission.Div(commission, big.NewInt(100))
+
+	remaining := new(big.Int).Sub(amount, commission)
+	undistributed := new(big.Int).Set(remaining)
+	if validator.DelegatorShares.Sign() > 0 {
+		for _, d := range vs.delegationsOfLocked(validator.Address) {
+			share := new(big.Int).Mul(remaining, d.Shares)
+			share.Div(share, validator.DelegatorShares)
+			d.PendingRewards.Add(d.PendingRewards, share)
+			undistributed.Sub(undistributed, share)
+		}
+	}
+	commission.Add(commission, undistributed)
+	validator.AccumulatedCommission = new(big.Int).Add(bigOrZero(validator.AccumulatedCommission), commission)
+
+	if err := vs.saveValidatorsLocked(); err != nil {
+		log.Printf("Advertencia: error guardando validadores: %v", err)
+	}
+	return commission, nil
+}
+
+// WithdrawRewards retira los rewards acumulados de una delegación; si el delegador es el operador
+// incluye la comisión acumulada del validador. Retorna el monto a pagar.
+func (vs *ValidatorSet) WithdrawRewards(delegator, validatorAddress string) (*big.Int, error) {
+	vs.mutex.Lock()
+	defer vs.mutex.Unlock()
+
+	total := new(big.Int)
+	if d, exists := vs.delegations[delegationKey(delegator, validatorAddress)]; exists {
+		total.Add(total, d.PendingRewards)
+		d.PendingRewards = new(big.Int)
+		// Delegación ya retirada que solo conservaba rewards
+		if d.Shares.Sign() == 0 {
+			delete(vs.delegations, delegationKey(delegator, validatorAddress))
+		}
+	}
+	if validator, exists := vs.validators[validatorAddress]; exists && strings.EqualFold(delegator, validator.Address) {
+		total.Add(total, bigOrZero(validator.AccumulatedCommission))
+		validator.AccumulatedCommission = new(big.Int)
+	}
+
+	if total.Sign() == 0 {
+		return nil, fmt.Errorf("sin rewards para retirar de %s", validatorAddress)
+	}
+
+	if err := vs.saveValidatorsLocked(); err != nil {
+		log.Printf("Advertencia: error guardando validadores: %v", err)
+	}
+	return total, nil
+}
+
+// PendingRewards retorna lo que WithdrawRewards pagaría al delegador sin modificar el estado
+func (vs *ValidatorSet) PendingRewards(delegator, validatorAddress string) *big.Int {
+	vs.mutex.RLock()
+	defer vs.mutex.RUnlock()
+
+	total := new(big.Int)
+	if d, exists := vs.delegations[delegationKey(delegator, validatorAddress)]; exists {
+		total.Add(total, d.PendingRewards)
+	}
+	if validator, exists := vs.validators[validatorAddress]; exists && strings.EqualFold(delegator, validator.Address) {
+		total.Add(total, bigOrZero(validator.AccumulatedCommission))
+	}
+	return total
+}
+
+// SelfStake retorna los tokens que el operador de un validador tiene en su propio validador
+func (vs *ValidatorSet) SelfStake(validatorAddress string) *big.Int {
+	vs.mutex.RLock()
+	defer vs.mutex.RUnlock()
+
+	validator, exists := vs.validators[validatorAddress]
+	if !exists {
+		return new(big.Int)
+	}
+	return vs.selfStakeLocked(validator)
+}
+
+// GetDelegation retorna la delegación de un delegador a un validador con su valor en tokens
+func (vs *ValidatorSet) GetDelegation(delegator, validatorAddress string) (*DelegationInfo, error) {
+	vs.mutex.RLock()
+	defer vs.mutex.RUnlock()
+
+	d, exists := vs.delegations[delegationKey(delegator, validatorAddress)]
+	if !exists {
+		return nil, fmt.Errorf("delegación no encontrada: %s -> %s", delegator, validatorAddress)
+	}
+	return vs.delegationInfoLocked(d), nil
+}
+
+// GetDelegationsByDelegator retorna las delegaciones de un delegador
+func (vs *ValidatorSet) GetDelegationsByDelegator(delegator string) []*DelegationInfo {
+	vs.mutex.RLock()
+	defer vs.mutex.RUnlock()
+
+	infos := make([]*DelegationInfo, 0)
+	for _, d := range vs.sortedDelegationsLocked() {
+		if strings.EqualFold(d.Delegator, delegator) {
+			infos = append(infos, vs.delegationInfoLocked(d))
+		}
+	}
+	return infos
+}
+
+// GetDelegationsByValidator retorna las delegaciones a un validador
+func (vs *ValidatorSet) GetDelegationsByValidator(validatorAddress string) []*DelegationInfo {
+	vs.mutex.RLock()
+	defer vs.mutex.RUnlock()
+
+	infos := make([]*DelegationInfo, 0)
+	for _, d := range vs.delegationsOfLocked(validatorAddress) {
+		infos = append(infos, vs.delegationInfoLocked(d))
+	}
+	return infos
+}
+
+// delegateLocked agrega tokens a la delegación al precio actual de las shares del validador
+func (vs *ValidatorSet) delegateLocked(delegator string, validator *Validator, amount *big.Int) {
+	shares := tokensToShares(validator, amount, false)
+
+	key := delegationKey(delegator, validator.Address)
+	d, exists := vs.delegations[key]
+	if !exists {
+		d = &Delegation{Delegator: delegator, Validator: validator.Address, Shares: new(big.Int), PendingRewards: new(big.Int)}
+		vs.delegations[key] = d
+	}
+	d.Shares.Add(d.Shares, shares)
+
+	validator.DelegatorShares.Add(validator.DelegatorShares, shares)
+	validator.Stake.Add(validator.Stake, amount)
+	validator.Power = vs.calculatePower(validator.Stake)
+}
+
+// undelegateLocked quita tokens de una delegación (redondeando las shares a favor del validador)
+func (vs *ValidatorSet) undelegateLocked(delegator string, validator *Validator, amount *big.Int) error {
+	if amount.Sign() <= 0 {
+		return fmt.Errorf("monto a retirar inválido: %s", amount.String())
+	}
+
+	d, exists := vs.delegations[delegationKey(delegator, validator.Address)]
+	if !exists || d.Shares.Sign() == 0 {
+		return fmt.Errorf("delegación no encontrada: %s -> %s", delegator, validator.Address)
+	}
+
+	shares := tokensToShares(validator, amount, true)
+	if shares.Cmp(d.Shares) > 0 {
+		return fmt.Errorf("delegación insuficiente: tiene %s, retira %s", sharesToTokens(validator, d.Shares).String(), amount.String())
+	}
+
+	d.Shares.Sub(d.Shares, shares)
+	validator.DelegatorShares.Sub(validator.DelegatorShares, shares)
+	validator.Stake.Sub(validator.Stake, amount)
+	validator.Power = vs.calculatePower(validator.Stake)
+
+	vs.pruneDelegationLocked(d)
+	return nil
+}
+
+// removeValidatorLocked saca a un validador del set. Las delegaciones restantes pasan al unbonding
+// por su valor en tokens (siguen siendo slasheables) y la comisión acumulada queda para el operador.
+func (vs *ValidatorSet) removeValidatorLocked(validator *Validator) {
+	for _, d := range vs.delegationsOfLocked(validator.Address) {
+		if tokens := sharesToTokens(validator, d.Shares); tokens.Sign() > 0 {
+			vs.beginUnbondingLocked(d.Delegator, validator.Address, tokens, vs.blockHeight, vs.blockTime)
+		}
+		d.Shares = new(big.Int)
+		if strings.EqualFold(d.Delegator, validator.Address) {
+			d.PendingRewards.Add(d.PendingRewards, bigOrZero(validator.AccumulatedCommission))
+		}
+		vs.pruneDelegationLocked(d)
+	}
+	delete(vs.validators, validator.Address)
+}
+
+// pruneDelegationLocked borra una delegación sin shares ni rewards pendientes
+func (vs *ValidatorSet) pruneDelegationLocked(d *Delegation) {
+	if d.Shares.Sign() == 0 && d.PendingRewards.Sign() == 0 {
+		delete(vs.delegations, delegationKey(d.Delegator, d.Validator))
+	}
+}
+
+// ensureSelfDelegationLocked inicializa las shares de un validador sin delegaciones (validadores
+// nuevos o guardados antes de existir la delegación): todo su stake es self-stake 1:1
+func (vs *ValidatorSet) ensureSelfDelegationLocked(validator *Validator) {
+	if validator.DelegatorShares != nil {
+		return
+	}
+	validator.DelegatorShares = new(big.Int).Set(validator.Stake)
+	vs.delegations[delegationKey(validator.Address, validator.Address)] = &Delegation{
+		Delegator:      validator.Address,
+		Validator:      validator.Address,
+		Shares:         new(big.Int).Set(validator.Stake),
+		PendingRewards: new(big.Int),
+	}
+}
+
+// selfStakeLocked retorna los tokens que el operador tiene delegados a su propio validador
+func (vs *ValidatorSet) selfStakeLocked(validator *Validator) *big.Int {
+	d, exists := vs.delegations[delegationKey(validator.Address, validator.Address)]
+	if !exists {
+		return new(big.Int)
+	}
+	return sharesToTokens(validator, d.Shares)
+}
+
+// delegationsOfLocked retorna las delegaciones a un validador ordenadas por delegador
+func (vs *ValidatorSet) delegationsOfLocked(validatorAddress string) []*Delegation {
+	delegations := make([]*Delegation, 0)
+	for _, d := range vs.sortedDelegationsLocked() {
+		if strings.EqualFold(d.Validator, validatorAddress) {
+			delegations = append(delegations, d)
+		}
+	}
+	return delegations
+}
+
+// sortedDelegationsLocked retorna todas las delegaciones en orden determinístico
+func (vs *ValidatorSet) sortedDelegationsLocked() []*Delegation {
+	keys := make([]string, 0, len(vs.delegations))
+	for key := range vs.delegations {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	delegations := make([]*Delegation, len(keys))
+	for i, key := range keys {
+		delegations[i] = vs.delegations[key]
+	}
+	return delegations
+}
+
+// delegationInfoLocked copia una delegación y calcula su valor en tokens
+func (vs *ValidatorSet) delegationInfoLocked(d *Delegation) *DelegationInfo {
+	tokens := new(big.Int)
+	if validator, exists := vs.validators[d.Validator]; exists {
+		tokens = sharesToTokens(validator, d.Shares)
+	}
+	return &DelegationInfo{
+		Delegation: Delegation{
+			Delegator:      d.Delegator,
+			Validator:      d.Validator,
+			Shares:         new(big.Int).Set(d.Shares),
+			PendingRewards: new(big.Int).Set(d.PendingRewards),
+		},
+		Tokens: tokens,
+	}
+}
+
+// loadDelegationsLocked carga las delegaciones desde storage. Asume que el llamador tiene el mutex.
+func (vs *ValidatorSet) loadDelegationsLocked() error {
+	data, err := vs.storage.GetAccount("validators:delegations")
+	if err != nil {
+		// Sin delegaciones guardadas
+		return nil
+	}
+
+	var delegations []*Delegation
+	if err := json.Unmarshal(data, &delegations); err != nil {
+		return fmt.Errorf("error parseando delegaciones: %w", err)
+	}
+	vs.delegations = make(map[string]*Delegation, len(delegations))
+	for _, d := range delegations {
+		vs.delegations[delegationKey(d.Delegator, d.Validator)] = d
+	}
+	return nil
+}
+
+// saveDelegationsLocked guarda las delegaciones. Asume que el llamador tiene el mutex.
+func (vs *ValidatorSet) saveDelegationsLocked() error {
+	data, err := json.Marshal(vs.sortedDelegationsLocked())
+	if err != nil {
+		return fmt.Errorf("error serializando delegaciones: %w", err)
+	}
+	return vs.storage.SaveAccount("validators:delegations", data)
+}
+
+// tokensToShares convierte tokens a shares del validador (roundUp al retirar, para no regalar tokens)
+func tokensToShares(validator *Validator, tokens *big.Int, roundUp bool) *big.Int {
+	if validator.DelegatorShares.Sign() == 0 || validator.Stake.Sign() == 0 {
+		return new(big.Int).Set(tokens)
+	}
+
+	shares := new(big.Int).Mul(tokens, validator.DelegatorShares)
+	if roundUp {
+		shares.Add(shares, new(big.Int).Sub(validator.Stake, big.NewInt(1)))
+	}
+	return shares.Div(shares, validator.Stake)
+}
+
+// sharesToTokens convierte shares del validador a tokens
+func sharesToTokens(validator *Validator, shares *big.Int) *big.Int {
+	if validator.DelegatorShares.Sign() == 0 {
+		return new(big.Int)
+	}
+	tokens := new(big.Int).Mul(shares, validator.Stake)
+	return tokens.Div(tokens, validator.DelegatorShares)
+}
+
+// bigOrZero retorna n o cero si es nil (campos agregados a validadores ya guardados)
+func bigOrZero(n *big.Int) *big.Int {
+	if n == nil {
+		return new(big.Int)
+	}
+	return n
+}
@@ -0,0 +1,288 @@
+package consensus
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/Q-YZX0/oxy-blockchain/internal/execution"
+	"github.com/Q-YZX0/oxy-blockchain/internal/storage"
+	abcitypes "github.com/cometbft/cometbft/abci/types"
+	"github.com/cometbft/cometbft/crypto/ed25519"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// TestValidatorSet_Delegation verifica shares, reparto de rewards con comisión, slash proporcional,
+// redelegación, retiro, persistencia y la salida del validador con delegaciones
+func TestValidatorSet_Delegation(t *testing.T) {
+	testDir := createTestDir("delegation")
+	defer func() {
+		if err := cleanupTestDir(testDir); err != nil {
+			t.Logf("Advertencia: error limpiando directorio: %v", err)
+		}
+	}()
+
+	db, err := storage.NewBlockchainDB(testDir)
+	if err != nil {
+		t.Fatalf("Error creando storage: %v", err)
+	}
+	defer db.Close()
+
+	// Los tests de validators no necesitan el EVM corriendo
+	evm := execution.NewEVMExecutor(db)
+	validatorSet := NewValidatorSet(db, evm, big.NewInt(1000), 10)
+	validatorSet.SetUnbondingPeriod(10, 0)
+	validatorSet.SetBlockInfo(7, time.Unix(1700000000, 0))
+
+	validatorA := "0xA000000000000000000000000000000000000001"
+	validatorB := "0xB000000000000000000000000000000000000002"
+	delegator := "0xD000000000000000000000000000000000000004"
+	if _, err := validatorSet.RegisterValidator(validatorA, ed25519.GenPrivKey().PubKey().Bytes(), big.NewInt(5000)); err != nil {
+		t.Fatalf("Error registrando validador: %v", err)
+	}
+	if _, err := validatorSet.RegisterValidator(validatorB, ed25519.GenPrivKey().PubKey().Bytes(), big.NewInt(2000)); err != nil {
+		t.Fatalf("Error registrando validador: %v", err)
+	}
+	if err := validatorSet.SetCommission(validatorA, 10); err != nil {
+		t.Fatalf("Error fijando comisión: %v", err)
+	}
+
+	tokensOf := func(delegator, validator string) int64 {
+		d, err := validatorSet.GetDelegation(delegator, validator)
+		if err != nil {
+			return 0
+		}
+		return d.Tokens.Int64()
+	}
+
+	// La delegación suma al stake y al power del validador
+	if err := validatorSet.Delegate(delegator, validatorA, big.NewInt(5000)); err != nil {
+		t.Fatalf("Error delegando: %v", err)
+	}
+	validator, _ := validatorSet.GetValidator(validatorA)
+	if validator.Stake.Int64() != 10000 || validator.DelegatorShares.Int64() != 10000 {
+		t.Fatalf("Stake tras delegar incorrecto: stake=%s, shares=%s", validator.Stake, validator.DelegatorShares)
+	}
+	if self := validatorSet.SelfStake(validatorA); self.Int64() != 5000 {
+		t.Errorf("Self-stake incorrecto: %s", self)
+	}
+
+	// Reward de 1000: 10% de comisión y el resto por shares
+	commission, err := validatorSet.AllocateRewards(validatorA, big.NewInt(1000))
+	if err != nil || commission.Int64() != 100 {
+		t.Fatalf("Comisión incorrecta: %v %v", commission, err)
+	}
+	if pending := validatorSet.PendingRewards(delegator, validatorA); pending.Int64() != 450 {
+		t.Errorf("Rewards del delegador incorrectos: %s", pending)
+	}
+	if pending := validatorSet.PendingRewards(validatorA, validatorA); pending.Int64() != 550 {
+		t.Errorf("Rewards del operador (con comisión) incorrectos: %s", pending)
+	}
+
+	// El slash reduce a todos los delegadores en proporción
+	if err := validatorSet.Slash(validatorA, 10, 0); err != nil {
+		t.Fatalf("Error en slash: %v", err)
+	}
+	if tokens := tokensOf(delegator, validatorA); tokens != 4500 {
+		t.Errorf("Delegación tras slash incorrecta: %d", tokens)
+	}
+	if err := validatorSet.Unjail(validatorA); err != nil {
+		t.Fatalf("Error en unjail: %v", err)
+	}
+
+	// El operador no retira su self-stake como delegación ni puede retirar más de lo delegado
+	if err := validatorSet.Undelegate(validatorA, validatorA, big.NewInt(100)); err == nil {
+		t.Error("El operador no debería poder undelegate su self-stake")
+	}
+	if err := validatorSet.Undelegate(delegator, validatorA, big.NewInt(4600)); err == nil {
+		t.Error("No debería poder retirar más de lo delegado")
+	}
+
+	// Redelegación inmediata y retiro
+	if err := validatorSet.Redelegate(delegator, validatorA, validatorB, big.NewInt(1800)); err != nil {
+		t.Fatalf("Error redelegando: %v", err)
+	}
+	if err := validatorSet.Undelegate(delegator, validatorA, big.NewInt(900)); err != nil {
+		t.Fatalf("Error en undelegate: %v", err)
+	}
+	if a, b := tokensOf(delegator, validatorA), tokensOf(delegator, validatorB); a != 1800 || b != 1800 {
+		t.Errorf("Delegaciones incorrectas: A=%d, B=%d", a, b)
+	}
+	validator, _ = validatorSet.GetValidator(validatorB)
+	if validator.Stake.Int64() != 3800 {
+		t.Errorf("Stake del validador B incorrecto: %s", validator.Stake)
+	}
+	if delegations := validatorSet.GetDelegationsByDelegator(delegator); len(delegations) != 2 {
+		t.Errorf("Delegaciones del delegador incorrectas: %d", len(delegations))
+	}
+
+	// Retiro de rewards: el operador cobra también la comisión
+	if rewards, err := validatorSet.WithdrawRewards(validatorA, validatorA); err != nil || rewards.Int64() != 550 {
+		t.Errorf("Retiro del operador incorrecto: %v %v", rewards, err)
+	}
+	if rewards, err := validatorSet.WithdrawRewards(delegator, validatorA); err != nil || rewards.Int64() != 450 {
+		t.Errorf("Retiro del delegador incorrecto: %v %v", rewards, err)
+	}
+	if _, err := validatorSet.WithdrawRewards(delegator, validatorA); err == nil {
+		t.Error("Un segundo retiro sin rewards debería fallar")
+	}
+
+	// Con un reward no divisible por shares el resto de la división va a la comisión: todo el monto
+	// emitido queda asignado y el módulo de distribución no retiene nada
+	if _, err := validatorSet.AllocateRewards(validatorA, big.NewInt(1001)); err != nil {
+		t.Fatalf("Error asignando rewards: %v", err)
+	}
+	operatorRewards, _ := validatorSet.WithdrawRewards(validatorA, validatorA)
+	delegatorRewards, _ := validatorSet.WithdrawRewards(delegator, validatorA)
+	if operatorRewards == nil || delegatorRewards == nil || new(big.Int).Add(operatorRewards, delegatorRewards).Int64() != 1001 {
+		t.Errorf("Rewards asignados incorrectos: operador %v, delegador %v", operatorRewards, delegatorRewards)
+	}
+
+	// Las delegaciones se persisten con el set
+	reloaded := NewValidatorSet(db, evm, big.NewInt(1000), 10)
+	if err := reloaded.LoadValidators(); err != nil {
+		t.Fatalf("Error cargando validadores: %v", err)
+	}
+	if delegations := reloaded.GetDelegationsByValidator(validatorA); len(delegations) != 2 || delegations[1].Tokens.Int64() != 1800 {
+		t.Errorf("Delegaciones recargadas incorrectas: %+v", delegations)
+	}
+
+	// Si el operador retira todo su self-stake, las delegaciones restantes pasan al unbonding
+	if err := validatorSet.Unstake(validatorA, big.NewInt(4500)); err != nil {
+		t.Fatalf("Error en unstake: %v", err)
+	}
+	if _, err := validatorSet.GetValidator(validatorA); err == nil {
+		t.Error("El validador debería haber salido del set")
+	}
+	entries := validatorSet.GetUnbondings(delegator)
+	if len(entries) != 1 || entries[0].Amount.Int64() != 1800 || entries[0].Validator != validatorA || entries[0].CompletionHeight != 17 {
+		t.Errorf("Unbonding de la delegación incorrecto: %+v", entries)
+	}
+	if delegations := validatorSet.GetDelegationsByValidator(validatorA); len(delegations) != 0 {
+		t.Errorf("No deberían quedar delegaciones al validador removido: %+v", delegations)
+	}
+}
+
+// TestABCIApp_DelegationTransactions verifica delegate, withdraw_rewards y undelegate como transacciones
+// al módulo de staking
+func TestABCIApp_DelegationTransactions(t *testing.T) {
+	ctx := context.Background()
+
+	testDir := createTestDir("delegation_txs")
+	defer func() {
+		if err := cleanupTestDir(testDir); err != nil {
+			t.Logf("Advertencia: error limpiando directorio: %v", err)
+		}
+	}()
+
+	db, err := storage.NewBlockchainDB(testDir)
+	if err != nil {
+		t.Fatalf("Error creando storage: %v", err)
+	}
+	defer db.Close()
+
+	evm := execution.NewEVMExecutor(db)
+	if err := evm.Start(); err != nil {
+		t.Fatalf("Error iniciando EVM: %v", err)
+	}
+	defer evm.Stop()
+
+	oneOXG := big.NewInt(1e18)
+	operator := common.HexToAddress("0xA000000000000000000000000000000000000001").Hex()
+	delegator := common.HexToAddress("0xD000000000000000000000000000000000000004").Hex()
+
+	validators := NewValidatorSet(db, evm, oneOXG, 10)
+	if _, err := validators.RegisterValidator(operator, ed25519.GenPrivKey().PubKey().Bytes(), new(big.Int).Mul(oneOXG, big.NewInt(3))); err != nil {
+		t.Fatalf("Error registrando validador: %v", err)
+	}
+
+	app := NewABCIApp(db, evm, validators, "test-chain")
+	if _, err := app.InitChain(ctx, &abcitypes.InitChainRequest{
+		ChainId:       "test-chain",
+		AppStateBytes: []byte(`{"rewards":{"inflation_bps":1000,"blocks_per_year":100},"staking":{"unbonding_blocks":2}}`),
+	}); err != nil {
+		t.Fatalf("Error en InitChain: %v", err)
+	}
+	if err := evm.FundAccount(delegator, "10000000000000000000"); err != nil {
+		t.Fatalf("Error fondeando cuenta: %v", err)
+	}
+
+	txCount := 0
+	delegationTx := func(nonce uint64, value *big.Int, msg string) []byte {
+		txCount++
+		txData, _ := json.Marshal(Transaction{
+			Hash:     "0x" + fmt.Sprintf("%064x", txCount),
+			From:     delegator,
+			To:       StakingModuleAddress.Hex(),
+			Value:    value.String(),
+			Data:     []byte(msg),
+			GasLimit: 100000,
+			GasPrice: "1000000000",
+			Nonce:    nonce,
+		})
+		return txData
+	}
+	height := int64(0)
+	finalize := func(txs ...[]byte) *abcitypes.FinalizeBlockResponse {
+		height++
+		resp, err := app.FinalizeBlock(ctx, &abcitypes.FinalizeBlockRequest{
+			Height: height,
+			Time:   time.Unix(1700000000+height, 0),
+			Txs:    txs,
+		})
+		if err != nil {
+			t.Fatalf("Error en FinalizeBlock: %v", err)
+		}
+		if _, err := app.Commit(ctx, &abcitypes.CommitRequest{}); err != nil {
+			t.Fatalf("Error en Commit: %v", err)
+		}
+		return resp
+	}
+
+	// delegate de 1 OXG: el power del validador pasa de 3 a 4
+	msg := fmt.Sprintf(`{"type":"delegate","validator":"%s"}`, operator)
+	resp := finalize(delegationTx(0, oneOXG, msg))
+	if resp.TxResults[0].Code != 0 {
+		t.Fatalf("delegate falló: %s", resp.TxResults[0].Log)
+	}
+	if len(resp.ValidatorUpdates) != 1 || resp.ValidatorUpdates[0].Power != 4 {
+		t.Errorf("ValidatorUpdates incorrectos: %+v", resp.ValidatorUpdates)
+	}
+
+	// Delegar a un validador inexistente se rechaza sin ejecutarse
+	if resp := finalize(delegationTx(1, oneOXG, `{"type":"delegate","validator":"0xB000000000000000000000000000000000000002"}`)); resp.TxResults[0].Code != 5 {
+		t.Errorf("delegate a validador inexistente debería ser rechazado: code=%d", resp.TxResults[0].Code)
+	}
+
+	// El delegador retira los rewards acumulados desde el módulo de distribución
+	pending := validators.PendingRewards(delegator, operator)
+	if pending.Sign() == 0 {
+		t.Fatal("El delegador debería tener rewards pendientes")
+	}
+	before, _ := evm.GetBalance(delegator)
+	resp = finalize(delegationTx(1, new(big.Int), fmt.Sprintf(`{"type":"withdraw_rewards","validator":"%s"}`, operator)))
+	if resp.TxResults[0].Code != 0 {
+		t.Fatalf("withdraw_rewards falló: %s", resp.TxResults[0].Log)
+	}
+	after, _ := evm.GetBalance(delegator)
+	fee := new(big.Int).Sub(new(big.Int).Add(before, pending), after)
+	if fee.Sign() < 0 || fee.Cmp(big.NewInt(1e15)) > 0 {
+		t.Errorf("Balance tras retirar rewards incorrecto: antes=%s, pendiente=%s, después=%s", before, pending, after)
+	}
+
+	// undelegate: los tokens pasan al unbonding del delegador
+	resp = finalize(delegationTx(2, new(big.Int), fmt.Sprintf(`{"type":"undelegate","validator":"%s","amount":"%s"}`, operator, oneOXG)))
+	if resp.TxResults[0].Code != 0 {
+		t.Fatalf("undelegate falló: %s", resp.TxResults[0].Log)
+	}
+	entries := validators.GetUnbondings(delegator)
+	if len(entries) != 1 || entries[0].Amount.Cmp(oneOXG) != 0 || entries[0].Validator != operator {
+		t.Errorf("Unbonding del delegador incorrecto: %+v", entries)
+	}
+	if err := evm.CheckSupplyInvariant(); err != nil {
+		t.Errorf("Invariante de supply: %v", err)
+	}
+}
@@ -0,0 +1,245 @@
+package consensus
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"reflect"
+	"testing"
+	"time"
+
+	cryptosigner "github.com/Q-YZX0/oxy-blockchain/internal/crypto"
+	"github.com/Q-YZX0/oxy-blockchain/internal/execution"
+	"github.com/Q-YZX0/oxy-blockchain/internal/storage"
+	abcitypes "github.com/cometbft/cometbft/abci/types"
+	cmtproto "github.com/cometbft/cometbft/api/cometbft/types/v1"
+	"github.com/cometbft/cometbft/crypto/ed25519"
+	"github.com/ethereum/go-ethereum/common"
+	ethcrypto "github.com/ethereum/go-ethereum/crypto"
+)
+
+// TestABCIApp_DeterministicValidatorUpdates ejecuta los mismos bloques en dos instancias independientes
+// (altas con empates de stake, reemplazo del validador con menor stake, doble firma, downtime y unjail)
+// y verifica que producen los mismos ValidatorUpdates y el mismo set de validadores
+func TestABCIApp_DeterministicValidatorUpdates(t *testing.T) {
+	ctx := context.Background()
+	oneOXG := big.NewInt(1e18)
+	genesisTime := time.Unix(1700000000, 0)
+
+	// Claves deterministas: operador secp256k1 y clave de consenso ed25519 por validador
+	operatorKey := func(i int) (*ecdsa.PrivateKey, string) {
+		key, _ := ethcrypto.ToECDSA(ethcrypto.Keccak256([]byte(fmt.Sprintf("operator-%d", i))))
+		return key, ethcrypto.PubkeyToAddress(key.PublicKey).Hex()
+	}
+	keyProof := func(i int, pubKey []byte) []byte {
+		key, _ := operatorKey(i)
+		proof, err := cryptosigner.SignValidatorKeyProof("test-chain", pubKey, key)
+		if err != nil {
+			t.Fatalf("Error firmando prueba de posesión: %v", err)
+		}
+		return proof
+	}
+	consensusProof := func(i int, consensusKey ed25519.PrivKey) []byte {
+		_, operator := operatorKey(i)
+		return cryptosigner.SignConsensusKeyProof("test-chain", common.HexToAddress(operator), consensusKey.Bytes())
+	}
+
+	genesisKeys := make([]ed25519.PubKey, 3)
+	genesisOperators := make([]string, 3)
+	genesisValidators := make([]abcitypes.ValidatorUpdate, 3)
+	genesisState := GenesisState{Slashing: GenesisSlashing{DoubleSignJailSeconds: 3600, SignedBlocksWindow: 2, MinSignedPercent: 50, DowntimeJailSeconds: 5}}
+	for i := range genesisKeys {
+		genesisPrivKey := ed25519.GenPrivKeyFromSecret([]byte(fmt.Sprintf("genesis-%d", i)))
+		genesisKeys[i] = genesisPrivKey.PubKey().(ed25519.PubKey)
+		_, genesisOperators[i] = operatorKey(i)
+		genesisValidators[i] = abcitypes.ValidatorUpdate{PubKeyBytes: genesisKeys[i].Bytes(), PubKeyType: "ed25519", Power: 5}
+		genesisState.Validators = append(genesisState.Validators, GenesisValidatorIdentity{
+			OperatorAddress: genesisOperators[i],
+			PubKey:          genesisKeys[i].Bytes(),
+			KeyProof:        keyProof(i, genesisKeys[i].Bytes()),
+			ConsensusProof:  consensusProof(i, genesisPrivKey),
+		})
+	}
+	appState, _ := json.Marshal(genesisState)
+	_, operatorX := operatorKey(3)
+	_, operatorY := operatorKey(4)
+	privKeyX := ed25519.GenPrivKeyFromSecret([]byte("operator-x"))
+	privKeyY := ed25519.GenPrivKeyFromSecret([]byte("operator-y"))
+	pubKeyX, pubKeyY := privKeyX.PubKey().Bytes(), privKeyY.PubKey().Bytes()
+
+	stakingTx := func(hash int, from string, nonce uint64, value *big.Int, msg string) []byte {
+		txData, _ := json.Marshal(Transaction{
+			Hash:     "0x" + fmt.Sprintf("%064x", hash),
+			From:     from,
+			To:       StakingModuleAddress.Hex(),
+			Value:    value.String(),
+			Data:     []byte(msg),
+			GasLimit: 100000,
+			GasPrice: "1000000000",
+			Nonce:    nonce,
+		})
+		return txData
+	}
+	votes := func(absent ed25519.PubKey) abcitypes.CommitInfo {
+		commit := abcitypes.CommitInfo{}
+		for _, key := range genesisKeys {
+			flag := cmtproto.BlockIDFlagCommit
+			if string(key) == string(absent) {
+				flag = cmtproto.BlockIDFlagAbsent
+			}
+			commit.Votes = append(commit.Votes, abcitypes.VoteInfo{Validator: abcitypes.Validator{Address: key.Address(), Power: 5}, BlockIdFlag: flag})
+		}
+		return commit
+	}
+
+	// Con el set lleno Y reemplaza al de menor stake: empate a 5 OXG resuelto por la menor dirección
+	evicted := operatorX
+	for _, operator := range genesisOperators {
+		if operator < evicted {
+			evicted = operator
+		}
+	}
+	var remaining []ed25519.PubKey
+	for i, key := range genesisKeys {
+		if genesisOperators[i] != evicted {
+			remaining = append(remaining, key)
+		}
+	}
+	doubleSigner, downtimeSigner := remaining[0], remaining[1]
+
+	// Misma secuencia de bloques para todas las instancias
+	stake := new(big.Int).Mul(oneOXG, big.NewInt(5))
+	blocks := []*abcitypes.FinalizeBlockRequest{
+		// X empata en stake con los validadores del genesis
+		{Txs: [][]byte{stakingTx(1, operatorX, 0, stake, fmt.Sprintf(`{"type":"create_validator","pubKey":"0x%x","keyProof":"0x%x","consensusProof":"0x%x"}`, pubKeyX, keyProof(3, pubKeyX), consensusProof(3, privKeyX)))}},
+		{Txs: [][]byte{stakingTx(2, operatorY, 0, new(big.Int).Mul(oneOXG, big.NewInt(6)), fmt.Sprintf(`{"type":"create_validator","pubKey":"0x%x","keyProof":"0x%x","consensusProof":"0x%x"}`, pubKeyY, keyProof(4, pubKeyY), consensusProof(4, privKeyY)))}},
+		{Misbehavior: []abcitypes.Misbehavior{{
+			Type:      abcitypes.MISBEHAVIOR_TYPE_DUPLICATE_VOTE,
+			Validator: abcitypes.Validator{Address: doubleSigner.Address(), Power: 5},
+			Height:    2,
+		}}},
+		// downtimeSigner no firma: con ventana de 2 bloques va a jail en el bloque 5
+		{DecidedLastCommit: votes(downtimeSigner)},
+		{DecidedLastCommit: votes(downtimeSigner)},
+		{},
+	}
+	if evicted != operatorX {
+		// X sale del set retirando todo su stake
+		blocks = append(blocks, &abcitypes.FinalizeBlockRequest{
+			Txs: [][]byte{stakingTx(3, operatorX, 1, new(big.Int), fmt.Sprintf(`{"type":"unstake","amount":"%s"}`, stake))},
+		})
+	}
+
+	type nodeResult struct {
+		Updates    [][]abcitypes.ValidatorUpdate
+		Validators []string
+		Jails      []time.Time
+	}
+	runNode := func(name string) nodeResult {
+		testDir := createTestDir("determinism_" + name)
+		defer func() {
+			if err := cleanupTestDir(testDir); err != nil {
+				t.Logf("Advertencia: error limpiando directorio: %v", err)
+			}
+		}()
+
+		db, err := storage.NewBlockchainDB(testDir)
+		if err != nil {
+			t.Fatalf("Error creando storage: %v", err)
+		}
+		defer db.Close()
+
+		evm := execution.NewEVMExecutor(db)
+		if err := evm.Start(); err != nil {
+			t.Fatalf("Error iniciando EVM: %v", err)
+		}
+		defer evm.Stop()
+
+		validators := NewValidatorSet(db, evm, oneOXG, 4)
+		app := NewABCIApp(db, evm, validators, "test-chain")
+		if _, err := app.InitChain(ctx, &abcitypes.InitChainRequest{
+			ChainId:       "test-chain",
+			Time:          genesisTime,
+			InitialHeight: 1,
+			Validators:    genesisValidators,
+			AppStateBytes: appState,
+		}); err != nil {
+			t.Fatalf("Error en InitChain: %v", err)
+		}
+		if err := evm.FundAccount(StakingModuleAddress.Hex(), new(big.Int).Mul(stake, big.NewInt(3)).String()); err != nil {
+			t.Fatalf("Error fondeando módulo de staking: %v", err)
+		}
+		for _, operator := range []string{operatorX, operatorY} {
+			if err := evm.FundAccount(operator, new(big.Int).Mul(oneOXG, big.NewInt(10)).String()); err != nil {
+				t.Fatalf("Error fondeando cuenta: %v", err)
+			}
+		}
+
+		var result nodeResult
+		for i, block := range blocks {
+			req := *block
+			req.Height = int64(i + 1)
+			req.Time = genesisTime.Add(time.Duration(i+1) * time.Second)
+			resp, err := app.FinalizeBlock(ctx, &req)
+			if err != nil {
+				t.Fatalf("Error en FinalizeBlock: %v", err)
+			}
+			for j, txResult := range resp.TxResults {
+				if txResult.Code != 0 {
+					t.Fatalf("Transacción %d del bloque %d falló: %s", j, req.Height, txResult.Log)
+				}
+			}
+			if _, err := app.Commit(ctx, &abcitypes.CommitRequest{}); err != nil {
+				t.Fatalf("Error en Commit: %v", err)
+			}
+			result.Updates = append(result.Updates, resp.ValidatorUpdates)
+		}
+
+		// Estado final del set, incluidos los validadores en jail
+		for _, v := range validators.GetActiveValidators() {
+			result.Validators = append(result.Validators, fmt.Sprintf("activo %s stake=%s", v.Address, v.Stake))
+		}
+		for _, key := range []ed25519.PubKey{doubleSigner, downtimeSigner} {
+			v, err := validators.GetValidatorByConsensusAddress(key.Address())
+			if err != nil {
+				t.Fatalf("Validador no encontrado: %v", err)
+			}
+			if v.Address == common.BytesToAddress(key.Bytes()).Hex() {
+				t.Errorf("El validador del genesis debería usar la dirección de su operador: %s", v.Address)
+			}
+			result.Validators = append(result.Validators, fmt.Sprintf("%s stake=%s jailed=%v tombstoned=%v missed=%d",
+				v.Address, v.Stake, v.Jailed, v.Tombstoned, v.TotalMissed))
+			result.Jails = append(result.Jails, v.JailedUntil)
+		}
+		return result
+	}
+
+	first := runNode("a")
+	second := runNode("b")
+
+	if !reflect.DeepEqual(first.Updates, second.Updates) {
+		t.Errorf("ValidatorUpdates distintos entre instancias:\n%v\n%v", first.Updates, second.Updates)
+	}
+	if !reflect.DeepEqual(first.Validators, second.Validators) || !reflect.DeepEqual(first.Jails, second.Jails) {
+		t.Errorf("Sets de validadores distintos entre instancias:\n%v\n%v", first.Validators, second.Validators)
+	}
+
+	// Los jails se miden en tiempo de bloque: doble firma en el bloque 3, downtime en el bloque 5
+	if len(first.Jails) != 2 ||
+		!first.Jails[0].Equal(genesisTime.Add(3*time.Second+time.Hour)) ||
+		!first.Jails[1].Equal(genesisTime.Add(5*time.Second+5*time.Second)) {
+		t.Errorf("Jails incorrectos: %v", first.Jails)
+	}
+	if len(first.Updates[1]) != 2 || len(first.Updates[2]) != 1 || len(first.Updates[4]) != 1 {
+		t.Errorf("Cambios de validadores por bloque inesperados: %v", first.Updates)
+	}
+	for _, update := range first.Updates {
+		for _, u := range update {
+			if len(u.PubKeyBytes) != ed25519.PubKeySize {
+				t.Errorf("ValidatorUpdate sin clave pública: %+v", u)
+			}
+		}
+	}
+}
@@ -0,0 +1,245 @@
+package consensus
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"time"
+
+	cryptosigner "github.com/Q-YZX0/oxy-blockchain/internal/crypto"
+	execution "github.com/Q-YZX0/oxy-blockchain/internal/execution"
+	"github.com/Q-YZX0/oxy-blockchain/internal/logger"
+	abcitypes "github.com/cometbft/cometbft/abci/types"
+	"github.com/cometbft/cometbft/crypto/ed25519"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// GenesisState es el app_state del genesis de CometBFT: parámetros de la aplicación fijados al crear la cadena
+type GenesisState struct {
+	Fees     GenesisFees     `json:"fees"`
+	Rewards  GenesisRewards  `json:"rewards"`
+	Staking  GenesisStaking  `json:"staking"`
+	Slashing GenesisSlashing `json:"slashing"`
+
+	// Operadores de los validadores del genesis de CometBFT (vacío = direcciones derivadas de la clave de consenso)
+	Validators []GenesisValidatorIdentity `json:"validators"`
+}
+
+// GenesisFees define el mercado de fees EIP-1559 y el reparto de las fees de transacción entre el
+// proponente y el GreenPool (equivalente a greenPoolWallet y feePercent de OXGToken)
+type GenesisFees struct {
+	GreenPoolAddress string `json:"green_pool_address"` // Vacío = todas las fees al proponente
+	GreenPoolPercent uint64 `json:"green_pool_percent"` // Porcentaje (0-100) de las fees para el GreenPool
+
+	InitialBaseFee   string `json:"initial_base_fee"`   // Base fee del primer bloque en wei (vacío = 1 gwei)
+	MinBaseFee       string `json:"min_base_fee"`       // Piso del base fee en wei (vacío = sin piso)
+	BaseFeeRecipient string `json:"base_fee_recipient"` // Recibe el base fee cobrado (vacío = se quema)
+}
+
+// GenesisRewards define la curva de inflación con la que el protocolo emite rewards a los validadores.
+// La inflación anual arranca en InflationBps y cada año baja InflationDecayBps (relativo) hasta MinInflationBps.
+type GenesisRewards struct {
+	InflationBps      uint64 `json:"inflation_bps"`       // Inflación anual inicial en puntos básicos (0 = sin rewards)
+	InflationDecayBps uint64 `json:"inflation_decay_bps"` // Reducción anual de la inflación en puntos básicos de la tasa vigente
+	MinInflationBps   uint64 `json:"min_inflation_bps"`   // Piso de la inflación anual
+	BlocksPerYear     uint64 `json:"blocks_per_year"`     // 0 = DefaultBlocksPerYear
+	EpochBlocks       uint64 `json:"epoch_blocks"`        // Cada cuántos bloques se emiten rewards (0 = cada bloque)
+	CommissionPercent uint64 `json:"commission_percent"`  // Comisión (0-100) de los validadores del genesis
+}
+
+// GenesisStaking define los parámetros del módulo de staking
+type GenesisStaking struct {
+	UnbondingBlocks  uint64 `json:"unbonding_blocks"`  // Bloques hasta liberar un retiro de stake
+	UnbondingSeconds uint64 `json:"unbonding_seconds"` // Segundos de tiempo de bloque hasta liberar un retiro (ambos 0 = DefaultUnbondingBlocks)
+}
+
+// GenesisSlashing define las penalizaciones por evidencia de mala conducta reportada por CometBFT y por
+// downtime (bloques sin firmar en la ventana de liveness)
+type GenesisSlashing struct {
+	DoubleSignSlashPercent uint64 `json:"double_sign_slash_percent"` // Porcentaje (0-100) del stake slasheado por doble firma (0 = DefaultDoubleSignSlashPercent)
+	DoubleSignJailSeconds  uint64 `json:"double_sign_jail_seconds"`  // Jail por doble firma (0 = tombstone: jail permanente)
+	SignedBlocksWindow     uint64 `json:"signed_blocks_window"`      // Ventana de liveness en bloques (0 = DefaultSignedBlocksWindow)
+	MinSignedPercent       uint64 `json:"min_signed_percent"`        // Porcentaje mínimo firmado en la ventana (0 = DefaultMinSignedPercent)
+	DowntimeSlashPercent   uint64 `json:"downtime_slash_percent"`    // Porcentaje del stake slasheado por downtime (0 = DefaultDowntimeSlashPercent)
+	DowntimeJailSeconds    uint64 `json:"downtime_jail_seconds"`     // Jail por downtime (0 = DefaultDowntimeJailSeconds)
+}
+
+// GenesisValidatorIdentity vincula un validador del genesis de CometBFT (clave ed25519 de consenso) con la
+// cuenta EVM de su operador, que es la que bondea el stake y cobra los rewards
+type GenesisValidatorIdentity struct {
+	OperatorAddress string        `json:"operator_address"`
+	PubKey          hexutil.Bytes `json:"pub_key"`         // Clave ed25519 de consenso (la misma que en validators del genesis)
+	KeyProof        hexutil.Bytes `json:"key_proof"`       // Firma del operador sobre la clave de consenso (crypto.SignValidatorKeyProof)
+	ConsensusProof  hexutil.Bytes `json:"consensus_proof"` // Firma de la clave de consenso sobre chain y operador (crypto.SignConsensusKeyProof)
+}
+
+// ParseGenesisState decodifica y valida el app_state del genesis (vacío = valores por defecto)
+func ParseGenesisState(data []byte) (*GenesisState, error) {
+	state := &GenesisState{}
+	if len(data) == 0 {
+		return state, nil
+	}
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("error decodificando app_state del genesis: %w", err)
+	}
+	if err := state.Validate(); err != nil {
+		return nil, fmt.Errorf("app_state del genesis inválido: %w", err)
+	}
+	return state, nil
+}
+
+// Validate verifica los parámetros del genesis
+func (g *GenesisState) Validate() error {
+	if g.Fees.GreenPoolAddress != "" && !common.IsHexAddress(g.Fees.GreenPoolAddress) {
+		return fmt.Errorf("dirección del GreenPool inválida: %s", g.Fees.GreenPoolAddress)
+	}
+	if g.Fees.GreenPoolPercent > 100 {
+		return fmt.Errorf("porcentaje del GreenPool inválido: %d", g.Fees.GreenPoolPercent)
+	}
+	if _, err := g.Fees.feeConfig(); err != nil {
+		return err
+	}
+	if g.Rewards.InflationBps > bpsDenominator || g.Rewards.MinInflationBps > bpsDenominator {
+		return fmt.Errorf("inflación inválida: inicial %d, mínima %d (máximo %d bps)", g.Rewards.InflationBps, g.Rewards.MinInflationBps, bpsDenominator)
+	}
+	if g.Rewards.InflationDecayBps > bpsDenominator {
+		return fmt.Errorf("reducción de inflación inválida: %d", g.Rewards.InflationDecayBps)
+	}
+	if g.Rewards.CommissionPercent > 100 {
+		return fmt.Errorf("comisión inválida: %d", g.Rewards.CommissionPercent)
+	}
+	if g.Slashing.DoubleSignSlashPercent > 100 {
+		return fmt.Errorf("porcentaje de slash por doble firma inválido: %d", g.Slashing.DoubleSignSlashPercent)
+	}
+	if g.Slashing.MinSignedPercent > 100 || g.Slashing.DowntimeSlashPercent > 100 {
+		return fmt.Errorf("parámetros de liveness inválidos: mínimo firmado %d, slash %d", g.Slashing.MinSignedPercent, g.Slashing.DowntimeSlashPercent)
+	}
+	operators := make(map[common.Address]bool, len(g.Validators))
+	pubKeys := make(map[string]bool, len(g.Validators))
+	for _, v := range g.Validators {
+		if !common.IsHexAddress(v.OperatorAddress) {
+			return fmt.Errorf("dirección de operador inválida: %s", v.OperatorAddress)
+		}
+		if len(v.PubKey) != ed25519.PubKeySize {
+			return fmt.Errorf("clave de consenso inválida para %s: se esperan %d bytes, tiene %d", v.OperatorAddress, ed25519.PubKeySize, len(v.PubKey))
+		}
+		operator := common.HexToAddress(v.OperatorAddress)
+		if operators[operator] || pubKeys[string(v.PubKey)] {
+			return fmt.Errorf("validador duplicado en el genesis: %s", v.OperatorAddress)
+		}
+		operators[operator] = true
+		pubKeys[string(v.PubKey)] = true
+	}
+	return nil
+}
+
+// feeConfig convierte los parámetros del mercado de fees del genesis a la configuración del ejecutor
+func (f GenesisFees) feeConfig() (execution.FeeConfig, error) {
+	cfg := execution.DefaultFeeConfig()
+	if f.InitialBaseFee != "" {
+		initialBaseFee, ok := new(big.Int).SetString(f.InitialBaseFee, 10)
+		if !ok || initialBaseFee.Sign() < 0 {
+			return cfg, fmt.Errorf("base fee inicial inválido: %s", f.InitialBaseFee)
+		}
+		cfg.InitialBaseFee = initialBaseFee
+	}
+	if f.MinBaseFee != "" {
+		minBaseFee, ok := new(big.Int).SetString(f.MinBaseFee, 10)
+		if !ok || minBaseFee.Sign() < 0 {
+			return cfg, fmt.Errorf("base fee mínimo inválido: %s", f.MinBaseFee)
+		}
+		cfg.MinBaseFee = minBaseFee
+	}
+	if f.BaseFeeRecipient != "" {
+		if !common.IsHexAddress(f.BaseFeeRecipient) {
+			return cfg, fmt.Errorf("receptor del base fee inválido: %s", f.BaseFeeRecipient)
+		}
+		cfg.BaseFeeRecipient = common.HexToAddress(f.BaseFeeRecipient)
+	}
+	return cfg, nil
+}
+
+// genesisValidators convierte los validadores del genesis de CometBFT al formato interno con el stake
+// equivalente a su power. Con operadores en app_state cada validador debe tener el suyo con una prueba de
+// posesión válida; sin operadores (genesis de desarrollo de un solo nodo) la dirección se deriva de la
+// clave de consenso y no corresponde a ninguna cuenta.
+func (g *GenesisState) genesisValidators(chainID string, validators []abcitypes.ValidatorUpdate) ([]GenesisValidator, error) {
+	identities := make(map[string]GenesisValidatorIdentity, len(g.Validators))
+	for _, identity := range g.Validators {
+		identities[string(identity.PubKey)] = identity
+	}
+	if len(identities) > 0 && len(identities) != len(validators) {
+		return nil, fmt.Errorf("app_state lista %d operadores para %d validadores", len(identities), len(validators))
+	}
+
+	result := make([]GenesisValidator, 0, len(validators))
+	for _, v := range validators {
+		// Convertir power a stake usando big.Int para evitar overflow
+		stake := new(big.Int).Mul(big.NewInt(v.Power), big.NewInt(1e18))
+
+		if len(identities) == 0 {
+			address := common.BytesToAddress(v.PubKeyBytes).Hex()
+			logger.Warn(fmt.Sprintf("Validador del genesis sin operador en app_state: se usa la dirección %s derivada de la clave de consenso", address))
+			result = append(result, GenesisValidator{Address: address, PubKey: v.PubKeyBytes, Stake: stake})
+			continue
+		}
+
+		identity, ok := identities[string(v.PubKeyBytes)]
+		if !ok {
+			return nil, fmt.Errorf("validador del genesis sin operador: 0x%x", v.PubKeyBytes)
+		}
+		operator := common.HexToAddress(identity.OperatorAddress)
+		if err := cryptosigner.VerifyValidatorKeyProof(chainID, operator, identity.PubKey, identity.KeyProof); err != nil {
+			return nil, fmt.Errorf("operador %s: %w", operator.Hex(), err)
+		}
+		if err := cryptosigner.VerifyConsensusKeyProof(chainID, operator, identity.PubKey, identity.ConsensusProof); err != nil {
+			return nil, fmt.Errorf("operador %s: %w", operator.Hex(), err)
+		}
+		result = append(result, GenesisValidator{Address: operator.Hex(), PubKey: v.PubKeyBytes, Stake: stake})
+	}
+	return result, nil
+}
+
+// setFeeConfig aplica y persiste (params:feemarket) la configuración del mercado de fees. Al ser un
+// parámetro de consenso forma parte del AppHash y de los snapshots.
+func (app *ABCIApp) setFeeConfig(cfg execution.FeeConfig) error {
+	if err := app.executor.SetFeeConfig(cfg); err != nil {
+		return fmt.Errorf("error configurando mercado de fees: %w", err)
+	}
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return fmt.Errorf("error serializando mercado de fees: %w", err)
+	}
+	return app.storage.SaveFeeMarketParams(data)
+}
+
+// loadFeeConfig aplica al ejecutor la configuración del mercado de fees persistida (si existe)
+func (app *ABCIApp) loadFeeConfig() error {
+	data, err := app.storage.GetFeeMarketParams()
+	if err != nil {
+		return nil
+	}
+	var cfg execution.FeeConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return fmt.Errorf("mercado de fees corrupto: %w", err)
+	}
+	return app.executor.SetFeeConfig(cfg)
+}
+
+// applyGenesisState aplica los parámetros del genesis al ejecutor
+func (app *ABCIApp) applyGenesisState(state *GenesisState) error {
+	dist := execution.FeeDistribution{GreenPoolPercent: state.Fees.GreenPoolPercent}
+	if state.Fees.GreenPoolAddress != "" {
+		dist.GreenPool = common.HexToAddress(state.Fees.GreenPoolAddress)
+	}
+	if err := app.executor.SetFeeDistribution(dist); err != nil {
+		return fmt.Errorf("error configurando reparto de fees: %w", err)
+	}
+	if app.validators != nil {
+		app.validators.SetUnbondingPeriod(state.Staking.UnbondingBlocks, time.Duration(state.Staking.UnbondingSeconds)*time.Second)
+		app.validators.SetLivenessParams(state.Slashing.SignedBlocksWindow, state.Slashing.MinSignedPercent)
+	}
+	app.genesis = state
+	return nil
+}
@@ -0,0 +1,178 @@
+package consensus
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Q-YZX0/oxy-blockchain/internal/logger"
+	"github.com/Q-YZX0/oxy-blockchain/internal/storage"
+	abcitypes "github.com/cometbft/cometbft/abci/types"
+	cmtproto "github.com/cometbft/cometbft/api/cometbft/types/v1"
+)
+
+// Parámetros de liveness por defecto
+const (
+	DefaultSignedBlocksWindow   uint64 = 100 // Bloques de la ventana deslizante de firmas
+	DefaultMinSignedPercent     uint64 = 50  // Porcentaje mínimo de bloques firmados en la ventana
+	DefaultDowntimeSlashPercent uint64 = 1   // Porcentaje del stake slasheado por downtime
+	DefaultDowntimeJailSeconds  uint64 = 600 // Jail por downtime
+)
+
+// UptimeStats son las estadísticas de firma de un validador en la ventana de liveness
+type UptimeStats struct {
+	Window        uint64  `json:"window"`        // Tamaño de la ventana
+	BlocksCounted uint64  `json:"blocksCounted"` // Bloques de la ventana ya registrados (hasta Window)
+	Signed        uint64  `json:"signed"`
+	Missed        uint64  `json:"missed"`
+	Uptime        float64 `json:"uptime"` // Porcentaje firmado de los bloques registrados
+	TotalMissed   int     `json:"totalMissed"`
+}
+
+// SetLivenessParams establece la ventana de firmas y el porcentaje mínimo firmado (0 = valores por defecto)
+func (vs *ValidatorSet) SetLivenessParams(window, minSignedPercent uint64) {
+	vs.mutex.Lock()
+	defer vs.mutex.Unlock()
+
+	if window == 0 {
+		window = DefaultSignedBlocksWindow
+	}
+	if minSignedPercent == 0 {
+		minSignedPercent = DefaultMinSignedPercent
+	}
+	vs.signedBlocksWindow = window
+	vs.minSignedPercent = minSignedPercent
+}
+
+// UpdateValidatorActivity registra si un validador firmó un bloque en su ventana deslizante.
+// Retorna true si con la ventana completa los bloques perdidos superan el máximo permitido.
+// No guarda el set: el llamador lo hace una vez por bloque.
+func (vs *ValidatorSet) UpdateValidatorActivity(address string, missedBlock bool) bool {
+	vs.mutex.Lock()
+	defer vs.mutex.Unlock()
+
+	validator, exists := vs.validators[address]
+	if !exists || validator.Jailed {
+		return false
+	}
+
+	window := vs.signedBlocksWindow
+	if len(validator.MissedBitmap) != int((window+7)/8) {
+		// Ventana nueva o cambio de tamaño
+		vs.resetLivenessLocked(validator)
+	}
+
+	// El bit del índice corresponde al bloque que sale de la ventana
+	index := validator.WindowBlocks % window
+	mask := byte(1) << (index % 8)
+	previouslyMissed := validator.MissedBitmap[index/8]&mask != 0
+	switch {
+	case missedBlock && !previouslyMissed:
+		validator.MissedBitmap[index/8] |= mask
+		validator.MissedBlocks++
+	case !missedBlock && previouslyMissed:
+		validator.MissedBitmap[index/8] &^= mask
+		validator.MissedBlocks--
+	}
+	validator.WindowBlocks++
+
+	if missedBlock {
+		validator.TotalMissed++
+	} else {
+		validator.LastActiveAt = vs.blockTime
+	}
+
+	maxMissed := window - window*vs.minSignedPercent/100
+	return validator.WindowBlocks >= window && uint64(validator.MissedBlocks) > maxMissed
+}
+
+// GetUptime retorna las estadísticas de firma de un validador
+func (vs *ValidatorSet) GetUptime(address string) (*UptimeStats, error) {
+	vs.mutex.RLock()
+	defer vs.mutex.RUnlock()
+
+	validator, exists := vs.validators[address]
+	if !exists {
+		return nil, fmt.Errorf("validador no encontrado: %s", address)
+	}
+
+	counted := validator.WindowBlocks
+	if counted > vs.signedBlocksWindow {
+		counted = vs.signedBlocksWindow
+	}
+	stats := &UptimeStats{
+		Window:        vs.signedBlocksWindow,
+		BlocksCounted: counted,
+		Missed:        uint64(validator.MissedBlocks),
+		Uptime:        100,
+		TotalMissed:   validator.TotalMissed,
+	}
+	stats.Signed = counted - stats.Missed
+	if counted > 0 {
+		stats.Uptime = float64(stats.Signed) * 100 / float64(counted)
+	}
+	return stats, nil
+}
+
+// resetLivenessLocked empieza una ventana de liveness vacía. Asume que el llamador tiene el mutex.
+func (vs *ValidatorSet) resetLivenessLocked(validator *Validator) {
+	validator.MissedBlocks = 0
+	validator.WindowBlocks = 0
+	validator.MissedBitmap = make([]byte, (vs.signedBlocksWindow+7)/8)
+}
+
+// processLiveness registra las firmas del último commit decidido y slashea y envía a jail a los
+// validadores que quedaron por debajo del mínimo firmado en la ventana
+func (app *ABCIApp) processLiveness(commit abcitypes.CommitInfo) ([]abcitypes.Event, []storage.SlashingRecord, error) {
+	if len(commit.Votes) == 0 {
+		return nil, nil, nil
+	}
+
+	params := app.genesis.Slashing
+	percent := params.DowntimeSlashPercent
+	if percent == 0 {
+		percent = DefaultDowntimeSlashPercent
+	}
+	jailSeconds := params.DowntimeJailSeconds
+	if jailSeconds == 0 {
+		jailSeconds = DefaultDowntimeJailSeconds
+	}
+
+	// El commit decidido es el del bloque anterior
+	infractionHeight := uint64(0)
+	if app.currentBlockHeight > 0 {
+		infractionHeight = app.currentBlockHeight - 1
+	}
+
+	var events []abcitypes.Event
+	var records []storage.SlashingRecord
+	for _, vote := range commit.Votes {
+		validator, err := app.validators.GetValidatorByConsensusAddress(vote.Validator.Address)
+		if err != nil {
+			continue
+		}
+
+		missed := vote.BlockIdFlag == cmtproto.BlockIDFlagAbsent
+		if !app.validators.UpdateValidatorActivity(validator.Address, missed) {
+			continue
+		}
+
+		logger.Warn(fmt.Sprintf("Validador %s por debajo del mínimo firmado en la ventana de liveness", validator.Address))
+		event, record, err := app.slashValidator(validator.Address, vote.Validator.Address, InfractionDowntime, infractionHeight, percent, time.Duration(jailSeconds)*time.Second, false)
+		if errors.Is(err, ErrSlashBurn) {
+			return nil, nil, err
+		}
+		if err != nil {
+			logger.Warn(fmt.Sprintf("Error slasheando por downtime a %s: %v", validator.Address, err))
+			continue
+		}
+		events = append(events, event)
+		records = append(records, record)
+	}
+
+	// Un solo guardado por bloque con el estado de las ventanas
+	if err := app.validators.SaveValidators(); err != nil {
+		logger.Warn("Error guardando liveness de validadores: " + err.Error())
+	}
+	return events, records, nil
+}
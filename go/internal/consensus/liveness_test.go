@@ -0,0 +1,128 @@
+package consensus
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/Q-YZX0/oxy-blockchain/internal/execution"
+	"github.com/Q-YZX0/oxy-blockchain/internal/storage"
+	abcitypes "github.com/cometbft/cometbft/abci/types"
+	cmtproto "github.com/cometbft/cometbft/api/cometbft/types/v1"
+	"github.com/cometbft/cometbft/crypto/ed25519"
+)
+
+// TestABCIApp_DowntimeJailing verifica que las firmas del último commit se registran en la ventana de
+// liveness y que un validador por debajo del mínimo firmado es slasheado y sale del set
+func TestABCIApp_DowntimeJailing(t *testing.T) {
+	ctx := context.Background()
+
+	testDir := createTestDir("downtime")
+	defer func() {
+		if err := cleanupTestDir(testDir); err != nil {
+			t.Logf("Advertencia: error limpiando directorio: %v", err)
+		}
+	}()
+
+	db, err := storage.NewBlockchainDB(testDir)
+	if err != nil {
+		t.Fatalf("Error creando storage: %v", err)
+	}
+	defer db.Close()
+
+	evm := execution.NewEVMExecutor(db)
+	if err := evm.Start(); err != nil {
+		t.Fatalf("Error iniciando EVM: %v", err)
+	}
+	defer evm.Stop()
+
+	oneOXG := big.NewInt(1e18)
+	validatorA := "0xA000000000000000000000000000000000000001"
+	validatorB := "0xB000000000000000000000000000000000000002"
+	pubKeyA := ed25519.GenPrivKey().PubKey()
+	pubKeyB := ed25519.GenPrivKey().PubKey()
+	stake := new(big.Int).Mul(oneOXG, big.NewInt(10))
+
+	validators := NewValidatorSet(db, evm, oneOXG, 10)
+	if _, err := validators.RegisterValidator(validatorA, pubKeyA.Bytes(), stake); err != nil {
+		t.Fatalf("Error registrando validador: %v", err)
+	}
+	if _, err := validators.RegisterValidator(validatorB, pubKeyB.Bytes(), stake); err != nil {
+		t.Fatalf("Error registrando validador: %v", err)
+	}
+
+	// Ventana de 4 bloques con mínimo 50% firmado: se permiten 2 bloques perdidos
+	app := NewABCIApp(db, evm, validators, "test-chain")
+	if _, err := app.InitChain(ctx, &abcitypes.InitChainRequest{
+		ChainId:       "test-chain",
+		AppStateBytes: []byte(`{"slashing":{"signed_blocks_window":4,"min_signed_percent":50,"downtime_slash_percent":10,"downtime_jail_seconds":60}}`),
+	}); err != nil {
+		t.Fatalf("Error en InitChain: %v", err)
+	}
+	if err := evm.FundAccount(StakingModuleAddress.Hex(), new(big.Int).Mul(stake, big.NewInt(2)).String()); err != nil {
+		t.Fatalf("Error fondeando módulo de staking: %v", err)
+	}
+
+	// A nunca firma, B siempre
+	lastCommit := abcitypes.CommitInfo{Votes: []abcitypes.VoteInfo{
+		{Validator: abcitypes.Validator{Address: pubKeyA.Address(), Power: 10}, BlockIdFlag: cmtproto.BlockIDFlagAbsent},
+		{Validator: abcitypes.Validator{Address: pubKeyB.Address(), Power: 10}, BlockIdFlag: cmtproto.BlockIDFlagCommit},
+	}}
+	finalize := func(height int64) *abcitypes.FinalizeBlockResponse {
+		resp, err := app.FinalizeBlock(ctx, &abcitypes.FinalizeBlockRequest{
+			Height:            height,
+			Time:              time.Unix(1700000000+height, 0),
+			DecidedLastCommit: lastCommit,
+		})
+		if err != nil {
+			t.Fatalf("Error en FinalizeBlock: %v", err)
+		}
+		if _, err := app.Commit(ctx, &abcitypes.CommitRequest{}); err != nil {
+			t.Fatalf("Error en Commit: %v", err)
+		}
+		return resp
+	}
+
+	// Hasta completar la ventana no hay penalización
+	for height := int64(2); height <= 4; height++ {
+		if resp := finalize(height); len(resp.ValidatorUpdates) != 0 {
+			t.Fatalf("No debería haber cambios de validadores en altura %d: %+v", height, resp.ValidatorUpdates)
+		}
+	}
+	uptime, err := validators.GetUptime(validatorA)
+	if err != nil || uptime.BlocksCounted != 3 || uptime.Missed != 3 || uptime.Uptime != 0 {
+		t.Errorf("Uptime de A incorrecto: %+v %v", uptime, err)
+	}
+
+	// Con la ventana completa A supera los bloques perdidos permitidos
+	resp := finalize(5)
+	if len(resp.ValidatorUpdates) != 1 || string(resp.ValidatorUpdates[0].PubKeyBytes) != string(pubKeyA.Bytes()) || resp.ValidatorUpdates[0].Power != 0 {
+		t.Errorf("ValidatorUpdates incorrectos: %+v", resp.ValidatorUpdates)
+	}
+	validator, _ := validators.GetValidator(validatorA)
+	expectedStake := new(big.Int).Sub(stake, new(big.Int).Div(stake, big.NewInt(10)))
+	if !validator.Jailed || validator.Tombstoned || validator.Stake.Cmp(expectedStake) != 0 {
+		t.Errorf("Validador A tras downtime incorrecto: jailed=%v, tombstoned=%v, stake=%s", validator.Jailed, validator.Tombstoned, validator.Stake)
+	}
+	if validator.WindowBlocks != 0 || validator.MissedBlocks != 0 || validator.TotalMissed != 4 {
+		t.Errorf("La ventana de liveness debería reiniciarse al ir a jail: %+v", validator)
+	}
+
+	records, err := app.GetSlashingHistory(validatorA, 10)
+	if err != nil || len(records) != 1 || records[0].Infraction != InfractionDowntime || records[0].InfractionHeight != 4 || records[0].JailedUntil == "" {
+		t.Errorf("Historial de slashing incorrecto: %+v %v", records, err)
+	}
+
+	// B firmó todos los bloques
+	uptime, err = validators.GetUptime(validatorB)
+	if err != nil || uptime.BlocksCounted != 4 || uptime.Signed != 4 || uptime.Uptime != 100 {
+		t.Errorf("Uptime de B incorrecto: %+v %v", uptime, err)
+	}
+
+	// Un validador en jail no acumula bloques perdidos
+	finalize(6)
+	if validator, _ := validators.GetValidator(validatorA); validator.TotalMissed != 4 {
+		t.Errorf("Bloques perdidos en jail no deberían contarse: %d", validator.TotalMissed)
+	}
+}
@@ -0,0 +1,268 @@
+package consensus
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"sort"
+	"time"
+
+	"github.com/Q-YZX0/oxy-blockchain/internal/logger"
+	"github.com/Q-YZX0/oxy-blockchain/internal/oracle"
+	abcitypes "github.com/cometbft/cometbft/abci/types"
+	cmtproto "github.com/cometbft/cometbft/api/cometbft/types/v1"
+	"github.com/cometbft/cometbft/crypto/ed25519"
+	cmttypes "github.com/cometbft/cometbft/types"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// El oráculo usa las extensiones de voto de CometBFT: cada validador adjunta a su precommit las
+// observaciones de sus fuentes (ExtendVote), el proponente del bloque siguiente las reúne con sus firmas
+// en una transacción del oráculo al inicio del bloque (PrepareProposal) y FinalizeBlock publica la
+// mediana ponderada por poder de cada feed en el contrato de sistema OracleModuleAddress.
+
+// OracleModuleAddress es el contrato de sistema que expone los valores del oráculo a los contratos.
+// latest(bytes32 feedId) retorna (uint256 value, uint256 height) con feedId = keccak256(nombre del feed).
+var OracleModuleAddress = common.HexToAddress("0x0000000000000000000000000000000000000802")
+
+// oracleContractCode es el bytecode del contrato del oráculo: lee el feedId de calldata[4:36] y retorna
+// los slots feedId (valor) y feedId+1 (altura en que se publicó)
+var oracleContractCode = common.FromHex("0x60043580546000526001015460205260406000f3")
+
+// oracleTxPrefix identifica la transacción del oráculo (las transacciones normales son JSON)
+var oracleTxPrefix = []byte("oxy-oracle:")
+
+// oracleObserveTimeout acota cuánto espera ExtendVote a las fuentes de datos
+const oracleObserveTimeout = 2 * time.Second
+
+// OracleTx es la transacción del oráculo: las extensiones de voto del último commit con sus firmas,
+// para que cada nodo pueda verificarlas y recalcular el mismo agregado
+type OracleTx struct {
+	Height int64        `json:"height"` // Altura de los votos (la del bloque anterior)
+	Round  int32        `json:"round"`
+	Votes  []OracleVote `json:"votes"`
+}
+
+// OracleVote es la extensión de voto de un validador
+type OracleVote struct {
+	Validator []byte `json:"validator"` // Dirección de consenso
+	Extension []byte `json:"extension"`
+	Signature []byte `json:"signature"` // Firma de CometBFT sobre la extensión
+}
+
+// OracleFeedID retorna el identificador de un feed en el contrato del oráculo
+func OracleFeedID(feed string) common.Hash {
+	return crypto.Keccak256Hash([]byte(feed))
+}
+
+// OracleSources retorna las fuentes del oráculo configuradas en el nodo (archivo local y/o endpoint HTTP)
+func (cfg *Config) OracleSources() []oracle.Source {
+	var sources []oracle.Source
+	if cfg.OracleFile != "" {
+		sources = append(sources, &oracle.FileSource{Path: cfg.OracleFile})
+	}
+	if cfg.OracleURL != "" {
+		sources = append(sources, &oracle.HTTPSource{URL: cfg.OracleURL})
+	}
+	return sources
+}
+
+// SetOracleSources establece las fuentes de datos que este validador informa en sus votos
+func (app *ABCIApp) SetOracleSources(sources ...oracle.Source) {
+	app.oracleSources = sources
+}
+
+// ExtendVote adjunta al precommit las observaciones de las fuentes del oráculo (nueva API v1.0.1).
+// Sin fuentes o si ninguna responde la extensión queda vacía.
+func (app *ABCIApp) ExtendVote(ctx context.Context, req *abcitypes.ExtendVoteRequest) (*abcitypes.ExtendVoteResponse, error) {
+	if len(app.oracleSources) == 0 {
+		return &abcitypes.ExtendVoteResponse{}, nil
+	}
+
+	observeCtx, cancel := context.WithTimeout(ctx, oracleObserveTimeout)
+	defer cancel()
+	values, err := oracle.Observe(observeCtx, app.oracleSources)
+	if err != nil {
+		logger.Warn(fmt.Sprintf("Error consultando fuentes del oráculo en altura %d: %v", req.Height, err))
+	}
+	if len(values) == 0 {
+		return &abcitypes.ExtendVoteResponse{}, nil
+	}
+
+	extension, err := oracle.EncodeExtension(values)
+	if err != nil {
+		logger.Warn(fmt.Sprintf("Observaciones del oráculo inválidas en altura %d: %v", req.Height, err))
+		return &abcitypes.ExtendVoteResponse{}, nil
+	}
+	return &abcitypes.ExtendVoteResponse{VoteExtension: extension}, nil
+}
+
+// VerifyVoteExtension verifica la extensión de voto de otro validador (nueva API v1.0.1): vacía o con
+// observaciones bien formadas
+func (app *ABCIApp) VerifyVoteExtension(ctx context.Context, req *abcitypes.VerifyVoteExtensionRequest) (*abcitypes.VerifyVoteExtensionResponse, error) {
+	if len(req.VoteExtension) > 0 {
+		if _, err := oracle.DecodeExtension(req.VoteExtension); err != nil {
+			logger.Warn(fmt.Sprintf("Extensión de voto de %X rechazada en altura %d: %v", req.ValidatorAddress, req.Height, err))
+			return &abcitypes.VerifyVoteExtensionResponse{
+				Status: abcitypes.VERIFY_VOTE_EXTENSION_STATUS_REJECT,
+			}, nil
+		}
+	}
+	return &abcitypes.VerifyVoteExtensionResponse{
+		Status: abcitypes.VERIFY_VOTE_EXTENSION_STATUS_ACCEPT,
+	}, nil
+}
+
+// buildOracleTx arma la transacción del oráculo del bloque height con las extensiones del último commit.
+// Retorna nil si no hay extensiones o si ningún feed alcanza el quorum.
+func (app *ABCIApp) buildOracleTx(height int64, commit abcitypes.ExtendedCommitInfo) []byte {
+	tx := OracleTx{Height: height - 1, Round: commit.Round}
+	for _, vote := range commit.Votes {
+		if vote.BlockIdFlag != cmtproto.BlockIDFlagCommit || len(vote.VoteExtension) == 0 {
+			continue
+		}
+		tx.Votes = append(tx.Votes, OracleVote{
+			Validator: vote.Validator.Address,
+			Extension: vote.VoteExtension,
+			Signature: vote.ExtensionSignature,
+		})
+	}
+	if len(tx.Votes) == 0 {
+		return nil
+	}
+
+	if _, err := app.aggregateOracleTx(&tx, height); err != nil {
+		logger.Warn(fmt.Sprintf("Transacción del oráculo omitida en altura %d: %v", height, err))
+		return nil
+	}
+	data, err := json.Marshal(tx)
+	if err != nil {
+		return nil
+	}
+	return append(append([]byte{}, oracleTxPrefix...), data...)
+}
+
+// isOracleTx indica si una transacción del bloque es la del oráculo
+func isOracleTx(txBytes []byte) bool {
+	return bytes.HasPrefix(txBytes, oracleTxPrefix)
+}
+
+// decodeOracleTx decodifica la transacción del oráculo
+func decodeOracleTx(txBytes []byte) (*OracleTx, error) {
+	var tx OracleTx
+	if err := json.Unmarshal(txBytes[len(oracleTxPrefix):], &tx); err != nil {
+		return nil, fmt.Errorf("error decodificando transacción del oráculo: %w", err)
+	}
+	return &tx, nil
+}
+
+// aggregateOracleTx verifica las extensiones de la transacción del oráculo del bloque height y retorna
+// la mediana ponderada de cada feed informado por validadores con más de 2/3 del poder.
+// Los pesos salen del set de CometBFT que firmó el bloque anterior, no de la transacción ni del set actual.
+func (app *ABCIApp) aggregateOracleTx(tx *OracleTx, height int64) (map[string]*big.Int, error) {
+	if tx.Height != height-1 {
+		return nil, fmt.Errorf("votos de altura %d en el bloque %d", tx.Height, height)
+	}
+
+	// Set de CometBFT que firmó los votos (el de la altura tx.Height), por dirección de consenso
+	set, err := app.loadConsensusValidators(tx.Height)
+	if err != nil {
+		return nil, fmt.Errorf("sin set de validadores de la altura %d: %w", tx.Height, err)
+	}
+	signers := make(map[string]consensusValidator, len(set))
+	var totalPower int64
+	for _, v := range set {
+		if len(v.PubKey) != ed25519.PubKeySize {
+			continue
+		}
+		signers[string(ed25519.PubKey(v.PubKey).Address())] = v
+		totalPower += v.Power
+	}
+
+	reports := make(map[string][]oracle.Report)
+	reportedPower := make(map[string]int64)
+	seen := make(map[string]bool)
+	for i, vote := range tx.Votes {
+		validator, ok := signers[string(vote.Validator)]
+		if !ok {
+			return nil, fmt.Errorf("voto %d: %X no es un validador del set de la altura %d", i, vote.Validator, tx.Height)
+		}
+		if seen[string(vote.Validator)] {
+			return nil, fmt.Errorf("voto %d: validador %X repetido", i, vote.Validator)
+		}
+		seen[string(vote.Validator)] = true
+
+		signBytes := cmttypes.VoteExtensionSignBytes(app.chainID, &cmtproto.Vote{
+			Height:    tx.Height,
+			Round:     tx.Round,
+			Extension: vote.Extension,
+		})
+		if !ed25519.PubKey(validator.PubKey).VerifySignature(signBytes, vote.Signature) {
+			return nil, fmt.Errorf("voto %d: firma de extensión inválida de %X", i, vote.Validator)
+		}
+
+		values, err := oracle.DecodeExtension(vote.Extension)
+		if err != nil {
+			return nil, fmt.Errorf("voto %d: %w", i, err)
+		}
+		for feed, value := range values {
+			reports[feed] = append(reports[feed], oracle.Report{Value: value, Power: validator.Power})
+			reportedPower[feed] += validator.Power
+		}
+	}
+
+	medians := make(map[string]*big.Int)
+	for feed, feedReports := range reports {
+		if 3*reportedPower[feed] > 2*totalPower {
+			medians[feed] = oracle.WeightedMedian(feedReports)
+		}
+	}
+	if len(medians) == 0 {
+		return nil, fmt.Errorf("ningún feed alcanza más de 2/3 del poder")
+	}
+	return medians, nil
+}
+
+// applyOracleTx publica en el contrato del oráculo los valores agregados de la transacción del oráculo
+func (app *ABCIApp) applyOracleTx(txBytes []byte, height int64) *abcitypes.ExecTxResult {
+	tx, err := decodeOracleTx(txBytes)
+	var values map[string]*big.Int
+	if err == nil {
+		values, err = app.aggregateOracleTx(tx, height)
+	}
+	if err != nil {
+		return &abcitypes.ExecTxResult{Code: 6, Log: fmt.Sprintf("Transacción del oráculo inválida: %v", err)}
+	}
+
+	feeds := make([]string, 0, len(values))
+	for feed := range values {
+		feeds = append(feeds, feed)
+	}
+	sort.Strings(feeds)
+
+	slots := make(map[common.Hash]common.Hash, 2*len(values))
+	events := make([]abcitypes.Event, 0, len(values))
+	for _, feed := range feeds {
+		id := OracleFeedID(feed)
+		slots[id] = common.BigToHash(values[feed])
+		slots[common.BigToHash(new(big.Int).Add(id.Big(), big.NewInt(1)))] = common.BigToHash(big.NewInt(height))
+		events = append(events, abcitypes.Event{
+			Type: "oracle_update",
+			Attributes: []abcitypes.EventAttribute{
+				{Key: "feed", Value: feed, Index: true},
+				{Key: "feed_id", Value: id.Hex()},
+				{Key: "value", Value: values[feed].String()},
+				{Key: "height", Value: fmt.Sprintf("%d", height)},
+			},
+		})
+	}
+	if err := app.executor.SetSystemStorage(OracleModuleAddress, oracleContractCode, slots); err != nil {
+		return &abcitypes.ExecTxResult{Code: 6, Log: fmt.Sprintf("Error publicando valores del oráculo: %v", err)}
+	}
+
+	logger.Info(fmt.Sprintf("Oráculo actualizado en bloque %d: %d feeds de %d votos", height, len(feeds), len(tx.Votes)))
+	return &abcitypes.ExecTxResult{Code: 0, Log: "OK", Events: events}
+}
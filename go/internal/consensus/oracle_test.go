@@ -0,0 +1,197 @@
+package consensus
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	execution "github.com/Q-YZX0/oxy-blockchain/internal/execution"
+	"github.com/Q-YZX0/oxy-blockchain/internal/oracle"
+	"github.com/Q-YZX0/oxy-blockchain/internal/storage"
+	abcitypes "github.com/cometbft/cometbft/abci/types"
+	cmtproto "github.com/cometbft/cometbft/api/cometbft/types/v1"
+	"github.com/cometbft/cometbft/crypto/ed25519"
+	cmttypes "github.com/cometbft/cometbft/types"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// TestABCIApp_OracleVoteExtensions verifica el flujo del oráculo: cada validador extiende su voto con
+// sus observaciones, el proponente las agrega en la transacción del oráculo, ProcessProposal rechaza
+// firmas falsas y FinalizeBlock publica la mediana ponderada por el poder del set que firmó los votos
+// en el contrato del oráculo
+func TestABCIApp_OracleVoteExtensions(t *testing.T) {
+	ctx := context.Background()
+
+	testDir := createTestDir("oracle_vote_extensions")
+	defer func() {
+		if err := cleanupTestDir(testDir); err != nil {
+			t.Logf("Advertencia: error limpiando directorio: %v", err)
+		}
+	}()
+
+	db, err := storage.NewBlockchainDB(testDir)
+	if err != nil {
+		t.Fatalf("Error creando storage: %v", err)
+	}
+	defer db.Close()
+	evm := execution.NewEVMExecutor(db)
+	if err := evm.Start(); err != nil {
+		t.Fatalf("Error iniciando EVM: %v", err)
+	}
+	defer evm.Stop()
+
+	oneOXG := big.NewInt(1e18)
+	validators := NewValidatorSet(db, evm, oneOXG, 10)
+	app := NewABCIApp(db, evm, validators, "test-chain")
+
+	// Tres validadores con poder 1, 1 y 3; el tercero no informa GREENPOOL/KWH
+	type member struct {
+		key   ed25519.PrivKey
+		feeds string
+	}
+	members := []member{
+		{ed25519.GenPrivKeyFromSecret([]byte("oracle-1")), `{"OXG/USD": "0.20", "GREENPOOL/KWH": "100"}`},
+		{ed25519.GenPrivKeyFromSecret([]byte("oracle-2")), `{"OXG/USD": "0.21", "GREENPOOL/KWH": "110"}`},
+		{ed25519.GenPrivKeyFromSecret([]byte("oracle-3")), `{"OXG/USD": "0.25"}`},
+	}
+	stakes := []int64{1, 1, 3}
+	for i, m := range members {
+		address := common.BigToAddress(big.NewInt(int64(0xA1 + i))).Hex()
+		if _, err := validators.RegisterValidator(address, m.key.PubKey().Bytes(), new(big.Int).Mul(oneOXG, big.NewInt(stakes[i]))); err != nil {
+			t.Fatalf("Error registrando validador: %v", err)
+		}
+	}
+	if _, err := app.InitChain(ctx, &abcitypes.InitChainRequest{ChainId: "test-chain"}); err != nil {
+		t.Fatalf("Error en InitChain: %v", err)
+	}
+	if _, err := app.FinalizeBlock(ctx, &abcitypes.FinalizeBlockRequest{Height: 1, Time: time.Unix(1700000001, 0)}); err != nil {
+		t.Fatalf("Error en FinalizeBlock 1: %v", err)
+	}
+	if _, err := app.Commit(ctx, &abcitypes.CommitRequest{}); err != nil {
+		t.Fatalf("Error en Commit 1: %v", err)
+	}
+
+	// Cada validador extiende su precommit del bloque 1 y CometBFT firma la extensión
+	var commit abcitypes.ExtendedCommitInfo
+	for i, m := range members {
+		path := filepath.Join(testDir, "feeds.json")
+		if err := os.WriteFile(path, []byte(m.feeds), 0644); err != nil {
+			t.Fatalf("Error escribiendo feeds: %v", err)
+		}
+		app.SetOracleSources(&oracle.FileSource{Path: path})
+		ext, err := app.ExtendVote(ctx, &abcitypes.ExtendVoteRequest{Height: 1})
+		if err != nil || len(ext.VoteExtension) == 0 {
+			t.Fatalf("ExtendVote del validador %d sin extensión: %v", i, err)
+		}
+		verify, _ := app.VerifyVoteExtension(ctx, &abcitypes.VerifyVoteExtensionRequest{Height: 1, VoteExtension: ext.VoteExtension})
+		if verify.Status != abcitypes.VERIFY_VOTE_EXTENSION_STATUS_ACCEPT {
+			t.Fatalf("Extensión del validador %d rechazada", i)
+		}
+		signature, err := m.key.Sign(cmttypes.VoteExtensionSignBytes("test-chain", &cmtproto.Vote{Height: 1, Extension: ext.VoteExtension}))
+		if err != nil {
+			t.Fatalf("Error firmando extensión: %v", err)
+		}
+		commit.Votes = append(commit.Votes, abcitypes.ExtendedVoteInfo{
+			Validator:          abcitypes.Validator{Address: m.key.PubKey().Address(), Power: stakes[i]},
+			VoteExtension:      ext.VoteExtension,
+			ExtensionSignature: signature,
+			BlockIdFlag:        cmtproto.BlockIDFlagCommit,
+		})
+	}
+
+	verify, _ := app.VerifyVoteExtension(ctx, &abcitypes.VerifyVoteExtensionRequest{Height: 1, VoteExtension: []byte(`{"values":{"OXG/USD":"-1"}}`)})
+	if verify.Status != abcitypes.VERIFY_VOTE_EXTENSION_STATUS_REJECT {
+		t.Fatal("Extensión con valor negativo debería rechazarse")
+	}
+
+	// Un validador que entra después de la altura 1 no firmó esos votos: no cuenta en el quorum
+	latecomer := ed25519.GenPrivKeyFromSecret([]byte("oracle-4")).PubKey().Bytes()
+	if _, err := validators.RegisterValidator(common.BigToAddress(big.NewInt(0xA4)).Hex(), latecomer, new(big.Int).Mul(oneOXG, big.NewInt(100))); err != nil {
+		t.Fatalf("Error registrando validador: %v", err)
+	}
+
+	// El proponente del bloque 2 agrega las extensiones como primera transacción
+	proposal, err := app.PrepareProposal(ctx, &abcitypes.PrepareProposalRequest{Height: 2, MaxTxBytes: 1 << 20, LocalLastCommit: commit})
+	if err != nil {
+		t.Fatalf("Error en PrepareProposal: %v", err)
+	}
+	if len(proposal.Txs) != 1 || !isOracleTx(proposal.Txs[0]) {
+		t.Fatalf("La propuesta debería tener solo la transacción del oráculo, tiene %d", len(proposal.Txs))
+	}
+	oracleTx := proposal.Txs[0]
+
+	process := func(txs [][]byte) abcitypes.ProcessProposalStatus {
+		resp, err := app.ProcessProposal(ctx, &abcitypes.ProcessProposalRequest{Height: 2, Txs: txs})
+		if err != nil {
+			t.Fatalf("Error en ProcessProposal: %v", err)
+		}
+		return resp.Status
+	}
+	if status := process(proposal.Txs); status != abcitypes.PROCESS_PROPOSAL_STATUS_ACCEPT {
+		t.Fatalf("Propuesta con transacción del oráculo válida rechazada: %v", status)
+	}
+
+	// Una firma alterada, un validador repetido o la transacción fuera del primer lugar rechazan el bloque
+	tx, _ := decodeOracleTx(oracleTx)
+	forged := *tx
+	forged.Votes = append([]OracleVote{}, tx.Votes...)
+	forged.Votes[2].Extension = []byte(`{"values":{"OXG/USD":"9000000000000000000"}}`)
+	repeated := *tx
+	repeated.Votes = append([]OracleVote{}, tx.Votes[0], tx.Votes[0], tx.Votes[2])
+	for name, bad := range map[string]*OracleTx{"firma falsa": &forged, "validador repetido": &repeated} {
+		if _, err := app.aggregateOracleTx(bad, 2); err == nil {
+			t.Errorf("Transacción del oráculo con %s debería ser inválida", name)
+		}
+	}
+	forgedData, _ := json.Marshal(forged)
+	if status := process([][]byte{append(append([]byte{}, oracleTxPrefix...), forgedData...)}); status != abcitypes.PROCESS_PROPOSAL_STATUS_REJECT {
+		t.Error("Propuesta con extensión de firma falsa debería rechazarse")
+	}
+	if status := process([][]byte{oracleTx, oracleTx}); status != abcitypes.PROCESS_PROPOSAL_STATUS_REJECT {
+		t.Error("Transacción del oráculo fuera del primer lugar debería rechazarse")
+	}
+
+	// FinalizeBlock publica las medianas en el contrato del oráculo
+	resp, err := app.FinalizeBlock(ctx, &abcitypes.FinalizeBlockRequest{Height: 2, Time: time.Unix(1700000002, 0), Txs: proposal.Txs})
+	if err != nil {
+		t.Fatalf("Error en FinalizeBlock 2: %v", err)
+	}
+	if resp.TxResults[0].Code != 0 || len(resp.TxResults[0].Events) != 1 {
+		t.Fatalf("Transacción del oráculo: código %d, %d eventos (%s)", resp.TxResults[0].Code, len(resp.TxResults[0].Events), resp.TxResults[0].Log)
+	}
+	if _, err := app.Commit(ctx, &abcitypes.CommitRequest{}); err != nil {
+		t.Fatalf("Error en Commit 2: %v", err)
+	}
+
+	latest := func(feed string) (*big.Int, *big.Int) {
+		t.Helper()
+		id := OracleFeedID(feed)
+		result, err := evm.StaticCall(&execution.CallRequest{
+			To:   OracleModuleAddress.Hex(),
+			Data: append([]byte{0xde, 0xad, 0xbe, 0xef}, id.Bytes()...),
+		})
+		if err != nil || !result.Success || len(result.ReturnData) != 64 {
+			t.Fatalf("Error leyendo %s del contrato del oráculo: %v", feed, err)
+		}
+		return new(big.Int).SetBytes(result.ReturnData[:32]), new(big.Int).SetBytes(result.ReturnData[32:])
+	}
+
+	// OXG/USD: el validador con poder 3 tiene la mayoría, la mediana ponderada es su valor
+	value, height := latest("OXG/USD")
+	if want, _ := oracle.ParseValue("0.25"); value.Cmp(want) != 0 || height.Int64() != 2 {
+		t.Errorf("OXG/USD = %s en altura %s, esperado %s en altura 2", value, height, want)
+	}
+	// GREENPOOL/KWH: informado solo por 2 de 5 de poder, no alcanza el quorum
+	value, height = latest("GREENPOOL/KWH")
+	if value.Sign() != 0 || height.Sign() != 0 {
+		t.Errorf("GREENPOOL/KWH sin quorum no debería publicarse: %s en altura %s", value, height)
+	}
+	if code, _ := evm.GetCode(OracleModuleAddress.Hex()); !bytes.Equal(code, oracleContractCode) {
+		t.Error("Bytecode del contrato del oráculo no instalado")
+	}
+}
@@ -0,0 +1,109 @@
+package consensus
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	execution "github.com/Q-YZX0/oxy-blockchain/internal/execution"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// proposalChecker verifica las transacciones de una propuesta de bloque en orden. Además de las reglas
+// de cada transacción (validateTransactionComplete) y del gas intrínseco con las reglas EVM del bloque,
+// aplica las del bloque completo: sin hashes duplicados, nonces consecutivos por remitente y límites de
+// bytes y gas.
+type proposalChecker struct {
+	app        *ABCIApp
+	height     int64
+	blockTime  int64
+	maxBytes   int64
+	totalBytes int64
+	totalGas   uint64
+	seen       map[string]bool
+	nonces     map[common.Address]uint64 // Próximo nonce esperado por remitente
+}
+
+// newProposalChecker crea un verificador para la propuesta del bloque height con el límite de bytes dado
+func (app *ABCIApp) newProposalChecker(height int64, blockTime time.Time, maxBytes int64) *proposalChecker {
+	return &proposalChecker{
+		app:       app,
+		height:    height,
+		blockTime: blockTime.Unix(),
+		maxBytes:  maxBytes,
+		seen:      make(map[string]bool),
+		nonces:    make(map[common.Address]uint64),
+	}
+}
+
+// check verifica la siguiente transacción de la propuesta. Si es válida la suma a los totales del bloque.
+func (c *proposalChecker) check(txBytes []byte) error {
+	if c.totalBytes+int64(len(txBytes)) > c.maxBytes {
+		return fmt.Errorf("bytes del bloque %d + %d > límite %d", c.totalBytes, len(txBytes), c.maxBytes)
+	}
+
+	var tx Transaction
+	if err := json.Unmarshal(txBytes, &tx); err != nil {
+		return fmt.Errorf("error decodificando transacción: %w", err)
+	}
+	if err := c.app.validateTransactionComplete(&tx); err != nil {
+		return fmt.Errorf("transacción %s inválida: %w", tx.Hash, err)
+	}
+
+	hash := strings.ToLower(tx.Hash)
+	if c.seen[hash] {
+		return fmt.Errorf("transacción duplicada: %s", tx.Hash)
+	}
+
+	// Con menos gas que el intrínseco la EVM la rechazaría sin ejecutarla
+	if err := c.app.executor.CheckIntrinsicGas(&execution.Transaction{
+		To:         tx.To,
+		Data:       tx.Data,
+		GasLimit:   tx.GasLimit,
+		AccessList: tx.AccessList,
+	}, uint64(c.height), c.blockTime); err != nil {
+		return fmt.Errorf("transacción %s inválida: %w", tx.Hash, err)
+	}
+
+	if tx.GasLimit > c.app.blockGasLimit-c.totalGas {
+		return fmt.Errorf("gas %d + %d > límite %d", c.totalGas, tx.GasLimit, c.app.blockGasLimit)
+	}
+
+	// La primera transacción de cada remitente usa su nonce actual y las siguientes van en orden
+	from := common.HexToAddress(tx.From)
+	expected, ok := c.nonces[from]
+	if !ok {
+		nonce, err := c.app.executor.GetNonce(tx.From)
+		if err != nil {
+			return fmt.Errorf("error obteniendo nonce de %s: %w", tx.From, err)
+		}
+		expected = nonce
+	}
+	if tx.Nonce != expected {
+		return fmt.Errorf("nonce fuera de orden para %s: esperado %d, tiene %d", tx.From, expected, tx.Nonce)
+	}
+
+	c.seen[hash] = true
+	c.nonces[from] = expected + 1
+	c.totalBytes += int64(len(txBytes))
+	c.totalGas += tx.GasLimit
+	return nil
+}
+
+// checkOracle verifica la transacción del oráculo (solo puede ser la primera de la propuesta) y la suma
+// a los bytes del bloque
+func (c *proposalChecker) checkOracle(txBytes []byte, height int64) error {
+	if c.totalBytes+int64(len(txBytes)) > c.maxBytes {
+		return fmt.Errorf("bytes del bloque %d + %d > límite %d", c.totalBytes, len(txBytes), c.maxBytes)
+	}
+	tx, err := decodeOracleTx(txBytes)
+	if err != nil {
+		return err
+	}
+	if _, err := c.app.aggregateOracleTx(tx, height); err != nil {
+		return fmt.Errorf("transacción del oráculo inválida: %w", err)
+	}
+	c.totalBytes += int64(len(txBytes))
+	return nil
+}
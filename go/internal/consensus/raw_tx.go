@@ -0,0 +1,119 @@
+package consensus
+
+import (
+	"fmt"
+	"math/big"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// NewTransactionFromRaw decodifica una transacción Ethereum firmada (legacy RLP con EIP-155, EIP-2930
+// o EIP-1559) y la convierte al formato interno. El remitente se recupera de la firma usando el chain ID dado.
+func NewTransactionFromRaw(raw []byte, chainID *big.Int) (*Transaction, error) {
+	ethTx, from, err := decodeRawTransaction(raw, chainID)
+	if err != nil {
+		return nil, err
+	}
+
+	to := ""
+	if ethTx.To() != nil {
+		to = ethTx.To().Hex()
+	}
+
+	tx := &Transaction{
+		Hash:      ethTx.Hash().Hex(),
+		From:      from.Hex(),
+		To:        to,
+		Value:     ethTx.Value().String(),
+		Data:      ethTx.Data(),
+		GasLimit:  ethTx.Gas(),
+		GasPrice:  ethTx.GasPrice().String(),
+		Nonce:     ethTx.Nonce(),
+		Timestamp: time.Now().Unix(),
+		RawTx:     raw,
+	}
+
+	// Transacciones EIP-1559 (y posteriores): fee cap y propina máxima
+	if ethTx.Type() >= types.DynamicFeeTxType {
+		tx.MaxFeePerGas = ethTx.GasFeeCap().String()
+		tx.MaxPriorityFeePerGas = ethTx.GasTipCap().String()
+	}
+
+	// Access list (EIP-2930 y posteriores): direcciones y slots precalentados
+	if len(ethTx.AccessList()) > 0 {
+		tx.AccessList = ethTx.AccessList()
+	}
+
+	return tx, nil
+}
+
+// decodeRawTransaction decodifica la transacción y recupera su remitente
+func decodeRawTransaction(raw []byte, chainID *big.Int) (*types.Transaction, common.Address, error) {
+	var ethTx types.Transaction
+	if err := ethTx.UnmarshalBinary(raw); err != nil {
+		return nil, common.Address{}, fmt.Errorf("transacción cruda inválida: %w", err)
+	}
+
+	// Solo tipos que el ejecutor sabe procesar: blob (EIP-4844) y set-code (EIP-7702) quedan fuera
+	switch ethTx.Type() {
+	case types.LegacyTxType, types.AccessListTxType, types.DynamicFeeTxType:
+	default:
+		return nil, common.Address{}, fmt.Errorf("tipo de transacción no soportado: %d", ethTx.Type())
+	}
+
+	// Legacy sin EIP-155 no está ligada a la cadena y podría repetirse en otras redes
+	if !ethTx.Protected() {
+		return nil, common.Address{}, fmt.Errorf("transacción sin protección contra replay (EIP-155)")
+	}
+
+	signer := types.LatestSignerForChainID(chainID)
+	from, err := types.Sender(signer, &ethTx)
+	if err != nil {
+		return nil, common.Address{}, fmt.Errorf("firma de transacción cruda inválida: %w", err)
+	}
+
+	return &ethTx, from, nil
+}
+
+// verifyRawTransaction verifica que los campos de la transacción coincidan con la transacción
+// Ethereum firmada que transporta en RawTx. Así un proponente no puede alterar los campos
+// decodificados sin invalidar la firma original.
+func verifyRawTransaction(tx *Transaction, chainID *big.Int) error {
+	decoded, err := NewTransactionFromRaw(tx.RawTx, chainID)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case !strings.EqualFold(tx.Hash, decoded.Hash):
+		return fmt.Errorf("hash de transacción inválido: esperado %s, tiene %s", decoded.Hash, tx.Hash)
+	case common.HexToAddress(tx.From) != common.HexToAddress(decoded.From):
+		return fmt.Errorf("remitente no coincide con la firma: esperado %s, tiene %s", decoded.From, tx.From)
+	case !sameRecipient(tx.To, decoded.To):
+		return fmt.Errorf("destinatario no coincide con la transacción firmada")
+	case tx.Value != decoded.Value || tx.GasPrice != decoded.GasPrice:
+		return fmt.Errorf("valor o gas price no coinciden con la transacción firmada")
+	case tx.MaxFeePerGas != decoded.MaxFeePerGas || tx.MaxPriorityFeePerGas != decoded.MaxPriorityFeePerGas:
+		return fmt.Errorf("fees EIP-1559 no coinciden con la transacción firmada")
+	case tx.GasLimit != decoded.GasLimit || tx.Nonce != decoded.Nonce:
+		return fmt.Errorf("gas limit o nonce no coinciden con la transacción firmada")
+	case string(tx.Data) != string(decoded.Data):
+		return fmt.Errorf("data no coincide con la transacción firmada")
+	case !reflect.DeepEqual(tx.AccessList, decoded.AccessList):
+		return fmt.Errorf("access list no coincide con la transacción firmada")
+	}
+
+	return nil
+}
+
+// sameRecipient compara destinatarios tratando "" como creación de contrato
+func sameRecipient(a, b string) bool {
+	if a == "" || b == "" {
+		return a == b
+	}
+	return common.HexToAddress(a) == common.HexToAddress(b)
+}
@@ -0,0 +1,80 @@
+package consensus
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/holiman/uint256"
+)
+
+// TestRawTransaction_Validation verifica que solo se aceptan transacciones crudas con protección
+// contra replay y de tipos soportados, y que la access list firmada se conserva
+func TestRawTransaction_Validation(t *testing.T) {
+	chainID := big.NewInt(1337)
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatalf("Error generando clave: %v", err)
+	}
+	to := common.HexToAddress("0x1000000000000000000000000000000000000001")
+
+	encode := func(tx *types.Transaction) []byte {
+		raw, err := tx.MarshalBinary()
+		if err != nil {
+			t.Fatalf("Error codificando transacción: %v", err)
+		}
+		return raw
+	}
+
+	// Legacy sin EIP-155: firma válida pero sin chain ID
+	unprotected, err := types.SignTx(types.NewTx(&types.LegacyTx{
+		To: &to, Gas: 21000, GasPrice: big.NewInt(1e9), Value: big.NewInt(1),
+	}), types.HomesteadSigner{}, key)
+	if err != nil {
+		t.Fatalf("Error firmando transacción legacy: %v", err)
+	}
+	if _, err := NewTransactionFromRaw(encode(unprotected), chainID); err == nil {
+		t.Error("Una transacción legacy sin EIP-155 debería rechazarse")
+	}
+
+	// Tipos que el ejecutor no procesa
+	signer := types.LatestSignerForChainID(chainID)
+	unsupported := []types.TxData{
+		&types.BlobTx{ChainID: uint256.MustFromBig(chainID), To: to, Gas: 21000, GasFeeCap: uint256.NewInt(1e9), BlobFeeCap: uint256.NewInt(1)},
+		&types.SetCodeTx{ChainID: uint256.MustFromBig(chainID), To: to, Gas: 21000, GasFeeCap: uint256.NewInt(1e9)},
+	}
+	for _, data := range unsupported {
+		tx, err := types.SignTx(types.NewTx(data), signer, key)
+		if err != nil {
+			t.Fatalf("Error firmando transacción: %v", err)
+		}
+		if _, err := NewTransactionFromRaw(encode(tx), chainID); err == nil {
+			t.Errorf("Una transacción de tipo %d debería rechazarse", tx.Type())
+		}
+	}
+
+	// EIP-1559 con access list: se conserva y no se puede alterar
+	accessList := types.AccessList{{Address: to, StorageKeys: []common.Hash{common.HexToHash("0x01")}}}
+	signed, err := types.SignTx(types.NewTx(&types.DynamicFeeTx{
+		ChainID: chainID, To: &to, Gas: 30000, GasFeeCap: big.NewInt(2e9), GasTipCap: big.NewInt(1e9), AccessList: accessList,
+	}), signer, key)
+	if err != nil {
+		t.Fatalf("Error firmando transacción EIP-1559: %v", err)
+	}
+	tx, err := NewTransactionFromRaw(encode(signed), chainID)
+	if err != nil {
+		t.Fatalf("Transacción EIP-1559 rechazada: %v", err)
+	}
+	if len(tx.AccessList) != 1 || tx.AccessList[0].Address != to || len(tx.AccessList[0].StorageKeys) != 1 {
+		t.Fatalf("Access list no conservada: %+v", tx.AccessList)
+	}
+	if err := verifyRawTransaction(tx, chainID); err != nil {
+		t.Fatalf("Verificación de la transacción original: %v", err)
+	}
+	tx.AccessList = nil
+	if err := verifyRawTransaction(tx, chainID); err == nil {
+		t.Error("Quitar la access list debería invalidar la transacción")
+	}
+}
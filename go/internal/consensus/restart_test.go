@@ -0,0 +1,197 @@
+package consensus
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	execution "github.com/Q-YZX0/oxy-blockchain/internal/execution"
+	"github.com/Q-YZX0/oxy-blockchain/internal/storage"
+	abcitypes "github.com/cometbft/cometbft/abci/types"
+	"github.com/cometbft/cometbft/crypto/ed25519"
+)
+
+// copyDataDir copia el directorio de datos de un nodo en ejecución tal como está en disco, sin detenerlo:
+// es lo que encontraría el nodo al reiniciar después de una caída
+func copyDataDir(t *testing.T, src, dst string) {
+	t.Helper()
+	err := filepath.WalkDir(src, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		if entry.IsDir() {
+			return os.MkdirAll(target, 0755)
+		}
+		if entry.Name() == "LOCK" {
+			return nil
+		}
+		in, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer in.Close()
+		out, err := os.Create(target)
+		if err != nil {
+			return err
+		}
+		defer out.Close()
+		_, err = io.Copy(out, in)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("Error copiando directorio de datos: %v", err)
+	}
+}
+
+// TestABCIApp_RestartHandshake verifica que la aplicación retoma altura, AppHash y validadores tras un
+// reinicio, y que si se cae entre FinalizeBlock y Commit el handshake de CometBFT (Info y reproducción
+// del bloque faltante) llega al mismo AppHash sin aplicar el bloque dos veces
+func TestABCIApp_RestartHandshake(t *testing.T) {
+	ctx := context.Background()
+
+	testDir := createTestDir("restart_handshake")
+	crashDir := testDir + "_crash"
+	defer func() {
+		for _, dir := range []string{testDir, crashDir} {
+			if err := cleanupTestDir(dir); err != nil {
+				t.Logf("Advertencia: error limpiando directorio: %v", err)
+			}
+		}
+	}()
+
+	oneOXG := big.NewInt(1e18)
+	validatorAddr := "0xA000000000000000000000000000000000000001"
+	sender := "0x5000000000000000000000000000000000000005"
+	recipient := "0x6000000000000000000000000000000000000006"
+
+	type node struct {
+		db         *storage.BlockchainDB
+		evm        *execution.EVMExecutor
+		validators *ValidatorSet
+		app        *ABCIApp
+	}
+	open := func(dir string) *node {
+		db, err := storage.NewBlockchainDB(dir)
+		if err != nil {
+			t.Fatalf("Error abriendo storage: %v", err)
+		}
+		evm := execution.NewEVMExecutor(db)
+		if err := evm.Start(); err != nil {
+			t.Fatalf("Error iniciando EVM: %v", err)
+		}
+		validators := NewValidatorSet(db, evm, oneOXG, 10)
+		if err := validators.LoadValidators(); err != nil {
+			t.Fatalf("Error cargando validadores: %v", err)
+		}
+		return &node{db: db, evm: evm, validators: validators, app: NewABCIApp(db, evm, validators, "test-chain")}
+	}
+	shutdown := func(n *node) {
+		n.evm.Stop()
+		n.db.Close()
+	}
+
+	// Transferencia de 1 wei por bloque; los rewards se emiten cada 2 bloques (cambian el estado de la aplicación)
+	block := func(height int64) *abcitypes.FinalizeBlockRequest {
+		txData, _ := json.Marshal(Transaction{
+			Hash:     "0x" + fmt.Sprintf("%064x", height),
+			From:     sender,
+			To:       recipient,
+			Value:    "1",
+			GasLimit: 21000,
+			GasPrice: "1000000000",
+			Nonce:    uint64(height - 1),
+		})
+		return &abcitypes.FinalizeBlockRequest{Height: height, Time: time.Unix(1700000000+height, 0), Txs: [][]byte{txData}}
+	}
+	commitBlock := func(n *node, height int64) []byte {
+		resp, err := n.app.FinalizeBlock(ctx, block(height))
+		if err != nil {
+			t.Fatalf("Error en FinalizeBlock %d: %v", height, err)
+		}
+		if resp.TxResults[0].Code != 0 {
+			t.Fatalf("Transacción del bloque %d falló: %s", height, resp.TxResults[0].Log)
+		}
+		if _, err := n.app.Commit(ctx, &abcitypes.CommitRequest{}); err != nil {
+			t.Fatalf("Error en Commit %d: %v", height, err)
+		}
+		return resp.AppHash
+	}
+	checkInfo := func(n *node, height int64, appHash []byte) {
+		t.Helper()
+		info, err := n.app.Info(ctx, &abcitypes.InfoRequest{})
+		if err != nil {
+			t.Fatalf("Error en Info: %v", err)
+		}
+		if info.LastBlockHeight != height || !bytes.Equal(info.LastBlockAppHash, appHash) {
+			t.Fatalf("Info tras reinicio: altura %d AppHash %X, esperado %d %X", info.LastBlockHeight, info.LastBlockAppHash, height, appHash)
+		}
+		if balance, _ := n.evm.GetBalance(recipient); balance.Int64() != height {
+			t.Fatalf("Balance tras reinicio: %s, esperado %d", balance, height)
+		}
+	}
+
+	n := open(testDir)
+	if _, err := n.validators.RegisterValidator(validatorAddr, ed25519.GenPrivKeyFromSecret([]byte("restart")).PubKey().Bytes(), new(big.Int).Mul(oneOXG, big.NewInt(2))); err != nil {
+		t.Fatalf("Error registrando validador: %v", err)
+	}
+	if _, err := n.app.InitChain(ctx, &abcitypes.InitChainRequest{
+		ChainId:       "test-chain",
+		AppStateBytes: []byte(`{"rewards":{"inflation_bps":1000,"blocks_per_year":100,"epoch_blocks":2}}`),
+	}); err != nil {
+		t.Fatalf("Error en InitChain: %v", err)
+	}
+	if err := n.evm.FundAccount(sender, "1000000000000000000000"); err != nil {
+		t.Fatalf("Error fondeando cuenta: %v", err)
+	}
+
+	var appHash3 []byte
+	for height := int64(1); height <= 3; height++ {
+		appHash3 = commitBlock(n, height)
+	}
+	root3 := n.evm.GetStateManager().GetRootHash()
+
+	// Caída después de FinalizeBlock del bloque 4 y antes de su Commit: se copia el disco del nodo en
+	// ejecución, sin Stop ni Close, y se reinicia desde la copia
+	resp4, err := n.app.FinalizeBlock(ctx, block(4))
+	if err != nil {
+		t.Fatalf("Error en FinalizeBlock 4: %v", err)
+	}
+	copyDataDir(t, testDir, crashDir)
+	shutdown(n)
+
+	// Handshake: la aplicación informa el último bloque confirmado y CometBFT reproduce el 4
+	n = open(crashDir)
+	checkInfo(n, 3, appHash3)
+	if root := n.evm.GetStateManager().GetRootHash(); root != root3 {
+		t.Fatalf("Root EVM tras la caída %s, esperado %s", root.Hex(), root3.Hex())
+	}
+	if len(n.app.state.Validators) != 1 {
+		t.Fatalf("Validadores no restaurados: %d", len(n.app.state.Validators))
+	}
+	if appHash4 := commitBlock(n, 4); !bytes.Equal(appHash4, resp4.AppHash) {
+		t.Fatalf("AppHash del bloque reproducido %X, esperado %X", appHash4, resp4.AppHash)
+	}
+	appHash5 := commitBlock(n, 5)
+	shutdown(n)
+
+	// Reinicio ordenado después de un Commit
+	n = open(crashDir)
+	defer shutdown(n)
+	checkInfo(n, 5, appHash5)
+	if appHash, err := n.app.computeAppHash(); err != nil || !bytes.Equal(appHash, appHash5) {
+		t.Fatalf("Estado tras reinicio no corresponde al AppHash confirmado: %X", appHash)
+	}
+}
@@ -0,0 +1,186 @@
+package consensus
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/Q-YZX0/oxy-blockchain/internal/storage"
+	abcitypes "github.com/cometbft/cometbft/abci/types"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// DefaultBlocksPerYear asume bloques de ~5 segundos
+const DefaultBlocksPerYear uint64 = 6307200
+
+// bpsDenominator es la base de los puntos básicos (10000 bps = 100%)
+const bpsDenominator uint64 = 10000
+
+// DistributionModuleAddress custodia los rewards emitidos hasta que los delegadores los retiran
+var DistributionModuleAddress = common.HexToAddress("0x0000000000000000000000000000000000000801")
+
+// supplyInvariantInterval es cada cuántos bloques se verifica el invariante de total supply en Commit
+const supplyInvariantInterval uint64 = 100
+
+// RewardEntry es el reward emitido a un validador en un bloque (consultable por validador)
+type RewardEntry struct {
+	Validator         string `json:"validator"`
+	Height            uint64 `json:"height"`
+	Amount            string `json:"amount"`     // Total emitido al validador (wei, decimal)
+	Commission        string `json:"commission"` // Parte del total correspondiente a la comisión
+	CommissionPercent uint64 `json:"commissionPercent"`
+	Power             int64  `json:"power"`
+	InflationBps      uint64 `json:"inflationBps"` // Inflación anual vigente al emitir
+}
+
+// blocksPerYear retorna los bloques por año de la curva de inflación
+func (r GenesisRewards) blocksPerYear() uint64 {
+	if r.BlocksPerYear == 0 {
+		return DefaultBlocksPerYear
+	}
+	return r.BlocksPerYear
+}
+
+// epochBlocks retorna cada cuántos bloques se emiten rewards
+func (r GenesisRewards) epochBlocks() uint64 {
+	if r.EpochBlocks == 0 {
+		return 1
+	}
+	return r.EpochBlocks
+}
+
+// InflationAt retorna la inflación anual (bps) vigente en una altura según la curva del genesis
+func (r GenesisRewards) InflationAt(height uint64) uint64 {
+	rate := r.InflationBps
+	if height == 0 {
+		return rate
+	}
+
+	years := (height - 1) / r.blocksPerYear()
+	for i := uint64(0); i < years && rate > r.MinInflationBps; i++ {
+		rate = rate * (bpsDenominator - r.InflationDecayBps) / bpsDenominator
+	}
+	if rate < r.MinInflationBps {
+		rate = r.MinInflationBps
+	}
+	return rate
+}
+
+// EpochProvision retorna los tokens a emitir en una altura dado el total supply: la inflación anual
+// prorrateada a los bloques de la época. Cero si la altura no cierra una época.
+func (r GenesisRewards) EpochProvision(height uint64, totalSupply *big.Int) *big.Int {
+	epoch := r.epochBlocks()
+	if height == 0 || height%epoch != 0 {
+		return new(big.Int)
+	}
+
+	provision := new(big.Int).Mul(totalSupply, new(big.Int).SetUint64(r.InflationAt(height)))
+	provision.Mul(provision, new(big.Int).SetUint64(epoch))
+	provision.Div(provision, new(big.Int).SetUint64(bpsDenominator*r.blocksPerYear()))
+	return provision
+}
+
+// distributeBlockRewards emite los rewards de la época y los reparte entre los validadores activos
+// en proporción a su Power. Los tokens se emiten al módulo de distribución y se acreditan a la comisión
+// del validador y a sus delegadores. El resto de la división no se emite. Retorna los eventos del bloque.
+func (app *ABCIApp) distributeBlockRewards(height uint64) ([]abcitypes.Event, error) {
+	rewards := app.genesis.Rewards
+	if app.validators == nil || rewards.InflationBps == 0 && rewards.MinInflationBps == 0 {
+		return nil, nil
+	}
+
+	provision := rewards.EpochProvision(height, app.executor.TotalSupply())
+	if provision.Sign() <= 0 {
+		return nil, nil
+	}
+
+	// Orden por dirección para que el reparto sea idéntico en todos los nodos
+	validators := app.validators.GetActiveValidators()
+	sort.Slice(validators, func(i, j int) bool {
+		return validators[i].Address < validators[j].Address
+	})
+
+	totalPower := new(big.Int)
+	for _, v := range validators {
+		if v.Power > 0 && common.IsHexAddress(v.Address) {
+			totalPower.Add(totalPower, big.NewInt(v.Power))
+		}
+	}
+	if totalPower.Sign() == 0 {
+		return nil, nil
+	}
+
+	inflation := rewards.InflationAt(height)
+	entries := make([]storage.ValidatorReward, 0, len(validators))
+	events := make([]abcitypes.Event, 0, len(validators))
+	for _, v := range validators {
+		if v.Power <= 0 || !common.IsHexAddress(v.Address) {
+			continue
+		}
+
+		amount := new(big.Int).Mul(provision, big.NewInt(v.Power))
+		amount.Div(amount, totalPower)
+		if amount.Sign() == 0 {
+			continue
+		}
+
+		if err := app.executor.Mint(DistributionModuleAddress, amount); err != nil {
+			return nil, fmt.Errorf("error emitiendo reward para %s: %w", v.Address, err)
+		}
+		commission, err := app.validators.AllocateRewards(v.Address, amount)
+		if err != nil {
+			return nil, fmt.Errorf("error asignando reward para %s: %w", v.Address, err)
+		}
+
+		entry := RewardEntry{
+			Validator:         v.Address,
+			Height:            height,
+			Amount:            amount.String(),
+			Commission:        commission.String(),
+			CommissionPercent: v.Commission,
+			Power:             v.Power,
+			InflationBps:      inflation,
+		}
+		data, _ := json.Marshal(entry)
+		entries = append(entries, storage.ValidatorReward{Validator: v.Address, Amount: amount, Data: data})
+
+		events = append(events, abcitypes.Event{
+			Type: "block_reward",
+			Attributes: []abcitypes.EventAttribute{
+				{Key: "validator", Value: v.Address, Index: true},
+				{Key: "amount", Value: entry.Amount},
+				{Key: "commission", Value: entry.Commission},
+				{Key: "inflation_bps", Value: fmt.Sprintf("%d", inflation)},
+			},
+		})
+	}
+
+	if err := app.storage.SaveRewards(height, entries); err != nil {
+		return nil, fmt.Errorf("error guardando rewards: %w", err)
+	}
+	return events, nil
+}
+
+// GetValidatorRewards retorna las últimas entradas de reward de un validador y su total acumulado
+func (app *ABCIApp) GetValidatorRewards(address string, limit int) ([]RewardEntry, *big.Int, error) {
+	results, err := app.storage.GetRewards(address, limit)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	entries := make([]RewardEntry, 0, len(results))
+	for _, data := range results {
+		var entry RewardEntry
+		if err := json.Unmarshal(data, &entry); err != nil {
+			return nil, nil, fmt.Errorf("error decodificando reward: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+
+	total, err := app.storage.GetRewardTotal(address)
+	if err != nil {
+		return nil, nil, err
+	}
+	return entries, total, nil
+}
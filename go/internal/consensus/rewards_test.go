@@ -0,0 +1,243 @@
+package consensus
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	execution "github.com/Q-YZX0/oxy-blockchain/internal/execution"
+	"github.com/Q-YZX0/oxy-blockchain/internal/storage"
+	abcitypes "github.com/cometbft/cometbft/abci/types"
+	"github.com/cometbft/cometbft/crypto/ed25519"
+)
+
+// TestGenesisRewards_InflationCurve verifica la reducción anual de la inflación y su piso
+func TestGenesisRewards_InflationCurve(t *testing.T) {
+	rewards := GenesisRewards{
+		InflationBps:      1000,
+		InflationDecayBps: 5000,
+		MinInflationBps:   200,
+		BlocksPerYear:     100,
+		EpochBlocks:       10,
+	}
+
+	cases := map[uint64]uint64{1: 1000, 100: 1000, 101: 500, 201: 250, 301: 200, 10000: 200}
+	for height, expected := range cases {
+		if rate := rewards.InflationAt(height); rate != expected {
+			t.Errorf("Inflación en altura %d: %d, esperado %d", height, rate, expected)
+		}
+	}
+
+	// Solo se emite al cerrar una época: 10% anual * 10/100 bloques = 1% del supply
+	supply := big.NewInt(1000000)
+	if provision := rewards.EpochProvision(15, supply); provision.Sign() != 0 {
+		t.Errorf("No debería emitirse fuera del cierre de época: %s", provision)
+	}
+	if provision := rewards.EpochProvision(20, supply); provision.Cmp(big.NewInt(10000)) != 0 {
+		t.Errorf("Emisión de época incorrecta: %s", provision)
+	}
+}
+
+// TestABCIApp_BlockRewards verifica que los rewards se emiten al cerrar cada época, se reparten por
+// Power con la comisión del genesis, quedan registrados por validador y mantienen el invariante de supply
+func TestABCIApp_BlockRewards(t *testing.T) {
+	ctx := context.Background()
+
+	testDir := createTestDir("block_rewards")
+	defer func() {
+		if err := cleanupTestDir(testDir); err != nil {
+			t.Logf("Advertencia: error limpiando directorio: %v", err)
+		}
+	}()
+
+	db, err := storage.NewBlockchainDB(testDir)
+	if err != nil {
+		t.Fatalf("Error creando storage: %v", err)
+	}
+	defer db.Close()
+
+	evm := execution.NewEVMExecutor(db)
+	if err := evm.Start(); err != nil {
+		t.Fatalf("Error iniciando EVM: %v", err)
+	}
+	defer evm.Stop()
+
+	// Validadores con power 3 y 1
+	oneOXG := big.NewInt(1e18)
+	validatorA := "0xA000000000000000000000000000000000000001"
+	validatorB := "0xB000000000000000000000000000000000000002"
+	validators := NewValidatorSet(db, evm, oneOXG, 10)
+	if _, err := validators.RegisterValidator(validatorA, ed25519.GenPrivKey().PubKey().Bytes(), new(big.Int).Mul(oneOXG, big.NewInt(3))); err != nil {
+		t.Fatalf("Error registrando validador: %v", err)
+	}
+	if _, err := validators.RegisterValidator(validatorB, ed25519.GenPrivKey().PubKey().Bytes(), oneOXG); err != nil {
+		t.Fatalf("Error registrando validador: %v", err)
+	}
+	if err := validators.SetCommission(validatorA, 10); err != nil {
+		t.Fatalf("Error fijando comisión: %v", err)
+	}
+
+	app := NewABCIApp(db, evm, validators, "test-chain")
+	if _, err := app.InitChain(ctx, &abcitypes.InitChainRequest{
+		ChainId:       "test-chain",
+		AppStateBytes: []byte(`{"rewards":{"inflation_bps":1000,"blocks_per_year":100,"epoch_blocks":2}}`),
+	}); err != nil {
+		t.Fatalf("Error en InitChain: %v", err)
+	}
+
+	supply, _ := new(big.Int).SetString("1000000000000000000000000", 10)
+	if err := evm.FundAccount("0x5000000000000000000000000000000000000005", supply.String()); err != nil {
+		t.Fatalf("Error fondeando cuenta: %v", err)
+	}
+
+	rewardEvents := func(resp *abcitypes.FinalizeBlockResponse) map[string]string {
+		amounts := map[string]string{}
+		for _, event := range resp.Events {
+			if event.Type != "block_reward" {
+				continue
+			}
+			var validator, amount string
+			for _, attr := range event.Attributes {
+				switch attr.Key {
+				case "validator":
+					validator = attr.Value
+				case "amount":
+					amount = attr.Value
+				}
+			}
+			amounts[validator] = amount
+		}
+		return amounts
+	}
+
+	// Altura 1 no cierra época: sin emisión
+	resp, err := app.FinalizeBlock(ctx, &abcitypes.FinalizeBlockRequest{Height: 1, Time: time.Unix(1700000001, 0)})
+	if err != nil {
+		t.Fatalf("Error en FinalizeBlock: %v", err)
+	}
+	if events := rewardEvents(resp); len(events) != 0 {
+		t.Fatalf("No debería haber rewards en altura 1: %v", events)
+	}
+	if _, err := app.Commit(ctx, &abcitypes.CommitRequest{}); err != nil {
+		t.Fatalf("Error en Commit: %v", err)
+	}
+
+	// Altura 2: 10% anual * 2/100 bloques = supply/500, repartido 3:1
+	resp, err = app.FinalizeBlock(ctx, &abcitypes.FinalizeBlockRequest{Height: 2, Time: time.Unix(1700000002, 0)})
+	if err != nil {
+		t.Fatalf("Error en FinalizeBlock: %v", err)
+	}
+	if _, err := app.Commit(ctx, &abcitypes.CommitRequest{}); err != nil {
+		t.Fatalf("Error en Commit: %v", err)
+	}
+
+	provision := new(big.Int).Div(supply, big.NewInt(500))
+	rewardA := new(big.Int).Div(new(big.Int).Mul(provision, big.NewInt(3)), big.NewInt(4))
+	rewardB := new(big.Int).Div(provision, big.NewInt(4))
+
+	events := rewardEvents(resp)
+	if events[validatorA] != rewardA.String() || events[validatorB] != rewardB.String() {
+		t.Errorf("Eventos de rewards incorrectos: %v", events)
+	}
+	// Los rewards quedan en el módulo de distribución hasta que se retiran
+	if balance, _ := evm.GetBalance(DistributionModuleAddress.Hex()); balance.Cmp(provision) != 0 {
+		t.Errorf("Balance del módulo de distribución incorrecto: %s, esperado %s", balance, provision)
+	}
+	if pending := validators.PendingRewards(validatorA, validatorA); pending.Cmp(rewardA) != 0 {
+		t.Errorf("Rewards pendientes del validador A incorrectos: %s, esperado %s", pending, rewardA)
+	}
+	if pending := validators.PendingRewards(validatorB, validatorB); pending.Cmp(rewardB) != 0 {
+		t.Errorf("Rewards pendientes del validador B incorrectos: %s, esperado %s", pending, rewardB)
+	}
+
+	expectedSupply := new(big.Int).Add(supply, provision)
+	if total := evm.TotalSupply(); total.Cmp(expectedSupply) != 0 {
+		t.Errorf("Total supply incorrecto: %s, esperado %s", total, expectedSupply)
+	}
+	if err := evm.CheckSupplyInvariant(); err != nil {
+		t.Errorf("Invariante de supply: %v", err)
+	}
+
+	// Entrada consultable por validador con la comisión
+	entries, total, err := app.GetValidatorRewards(validatorA, 10)
+	if err != nil {
+		t.Fatalf("Error obteniendo rewards: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Height != 2 || entries[0].Amount != rewardA.String() || total.Cmp(rewardA) != 0 {
+		t.Fatalf("Rewards registrados incorrectos: %+v (total %s)", entries, total)
+	}
+	commission := new(big.Int).Div(rewardA, big.NewInt(10))
+	if entries[0].Commission != commission.String() || entries[0].CommissionPercent != 10 || entries[0].Power != 3 {
+		t.Errorf("Comisión registrada incorrecta: %+v", entries[0])
+	}
+
+	queryResp, err := app.Query(ctx, &abcitypes.QueryRequest{Path: "rewards/" + validatorB})
+	if err != nil || queryResp.Code != 0 {
+		t.Fatalf("Error en query de rewards: %v %s", err, queryResp.Log)
+	}
+	var result struct {
+		Total   string        `json:"total"`
+		Rewards []RewardEntry `json:"rewards"`
+	}
+	if err := json.Unmarshal(queryResp.Value, &result); err != nil {
+		t.Fatalf("Error decodificando query: %v", err)
+	}
+	if result.Total != rewardB.String() || len(result.Rewards) != 1 {
+		t.Errorf("Query de rewards incorrecta: %+v", result)
+	}
+}
+
+// TestABCIApp_SupplyInvariantHalt verifica que Commit falla sin confirmar el bloque cuando el total
+// supply no coincide con la suma de los balances
+func TestABCIApp_SupplyInvariantHalt(t *testing.T) {
+	ctx := context.Background()
+
+	testDir := createTestDir("supply_invariant_halt")
+	defer func() {
+		if err := cleanupTestDir(testDir); err != nil {
+			t.Logf("Advertencia: error limpiando directorio: %v", err)
+		}
+	}()
+
+	db, err := storage.NewBlockchainDB(testDir)
+	if err != nil {
+		t.Fatalf("Error creando storage: %v", err)
+	}
+	defer db.Close()
+
+	evm := execution.NewEVMExecutor(db)
+	if err := evm.Start(); err != nil {
+		t.Fatalf("Error iniciando EVM: %v", err)
+	}
+	if err := evm.FundAccount("0x5000000000000000000000000000000000000005", "1000000000000000000"); err != nil {
+		t.Fatalf("Error fondeando cuenta: %v", err)
+	}
+	evm.Stop()
+
+	// Total supply guardado que no coincide con los balances
+	if err := db.SaveTotalSupply(big.NewInt(1)); err != nil {
+		t.Fatalf("Error guardando total supply: %v", err)
+	}
+	evm = execution.NewEVMExecutor(db)
+	if err := evm.Start(); err != nil {
+		t.Fatalf("Error iniciando EVM: %v", err)
+	}
+	defer evm.Stop()
+
+	app := NewABCIApp(db, evm, nil, "test-chain")
+	height := int64(supplyInvariantInterval)
+	if _, err := app.FinalizeBlock(ctx, &abcitypes.FinalizeBlockRequest{Height: height, Time: time.Unix(1700000000, 0)}); err != nil {
+		t.Fatalf("Error en FinalizeBlock: %v", err)
+	}
+	if _, err := app.Commit(ctx, &abcitypes.CommitRequest{}); !errors.Is(err, execution.ErrSupplyInvariant) {
+		t.Fatalf("Commit debería fallar con el invariante de supply roto: %v", err)
+	}
+
+	// El bloque no queda confirmado
+	if info, _ := NewABCIApp(db, evm, nil, "test-chain").Info(ctx, &abcitypes.InfoRequest{}); info.LastBlockHeight == height {
+		t.Errorf("El bloque %d no debería confirmarse", height)
+	}
+}
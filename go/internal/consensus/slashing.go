@@ -0,0 +1,186 @@
+package consensus
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/Q-YZX0/oxy-blockchain/internal/logger"
+	"github.com/Q-YZX0/oxy-blockchain/internal/storage"
+	abcitypes "github.com/cometbft/cometbft/abci/types"
+)
+
+// DefaultDoubleSignSlashPercent es el porcentaje del stake slasheado por doble firma si el genesis no lo fija
+const DefaultDoubleSignSlashPercent uint64 = 5
+
+// Infracciones penalizadas en FinalizeBlock: evidencia reportada por CometBFT y liveness del último commit
+const (
+	InfractionDuplicateVote     = "duplicate_vote"
+	InfractionLightClientAttack = "light_client_attack"
+	InfractionDowntime          = "downtime"
+)
+
+// ErrSlashBurn indica que no se pudieron quemar del módulo de staking los tokens slasheados. El stake ya
+// se redujo, así que el bloque no puede confirmarse: el módulo no respalda el stake bondeado.
+var ErrSlashBurn = errors.New("error quemando stake slasheado")
+
+// SlashingRecord es un slash aplicado a un validador (consultable en el historial de slashing)
+type SlashingRecord struct {
+	Validator        string `json:"validator"`
+	ConsensusAddress string `json:"consensusAddress"` // Dirección de consenso CometBFT (hex)
+	Infraction       string `json:"infraction"`
+	InfractionHeight uint64 `json:"infractionHeight"`
+	Height           uint64 `json:"height"` // Bloque en el que se aplicó el slash
+	Percent          uint64 `json:"percent"`
+	Amount           string `json:"amount"` // Total slasheado (stake y unbondings, wei)
+	Tombstoned       bool   `json:"tombstoned"`
+	JailedUntil      string `json:"jailedUntil,omitempty"`
+}
+
+// infractionName retorna el nombre de la infracción de una evidencia de CometBFT
+func infractionName(t abcitypes.MisbehaviorType) string {
+	switch t {
+	case abcitypes.MISBEHAVIOR_TYPE_DUPLICATE_VOTE:
+		return InfractionDuplicateVote
+	case abcitypes.MISBEHAVIOR_TYPE_LIGHT_CLIENT_ATTACK:
+		return InfractionLightClientAttack
+	}
+	return ""
+}
+
+// processSlashing aplica la evidencia de mala conducta y el control de liveness del bloque y guarda
+// los slashes en el historial. Retorna los eventos y si cambió el set de validadores, o ErrSlashBurn si
+// no se pudo quemar el stake slasheado.
+func (app *ABCIApp) processSlashing(req *abcitypes.FinalizeBlockRequest) ([]abcitypes.Event, bool, error) {
+	if app.validators == nil {
+		return nil, false, nil
+	}
+
+	events, records, err := app.processMisbehavior(req.Misbehavior)
+	if err != nil {
+		return nil, false, err
+	}
+	livenessEvents, livenessRecords, err := app.processLiveness(req.DecidedLastCommit)
+	if err != nil {
+		return nil, false, err
+	}
+	events = append(events, livenessEvents...)
+	records = append(records, livenessRecords...)
+
+	if err := app.storage.SaveSlashingRecords(app.currentBlockHeight, records); err != nil {
+		logger.Error("Error guardando historial de slashing: " + err.Error())
+	}
+	return events, len(records) > 0, nil
+}
+
+// processMisbehavior slashea y envía a jail (o tombstone) a los validadores de la evidencia de mala
+// conducta del bloque
+func (app *ABCIApp) processMisbehavior(misbehavior []abcitypes.Misbehavior) ([]abcitypes.Event, []storage.SlashingRecord, error) {
+	params := app.genesis.Slashing
+	percent := params.DoubleSignSlashPercent
+	if percent == 0 {
+		percent = DefaultDoubleSignSlashPercent
+	}
+	tombstone := params.DoubleSignJailSeconds == 0
+	jailDuration := time.Duration(params.DoubleSignJailSeconds) * time.Second
+
+	var events []abcitypes.Event
+	var records []storage.SlashingRecord
+	for _, m := range misbehavior {
+		infraction := infractionName(m.Type)
+		if infraction == "" {
+			logger.Warn(fmt.Sprintf("Evidencia de tipo desconocido ignorada: %v", m.Type))
+			continue
+		}
+
+		validator, err := app.validators.GetValidatorByConsensusAddress(m.Validator.Address)
+		if err != nil {
+			logger.Warn(fmt.Sprintf("Evidencia de %s para validador desconocido: %X", infraction, m.Validator.Address))
+			continue
+		}
+
+		infractionHeight := uint64(0)
+		if m.Height > 0 {
+			infractionHeight = uint64(m.Height)
+		}
+		event, record, err := app.slashValidator(validator.Address, m.Validator.Address, infraction, infractionHeight, percent, jailDuration, tombstone)
+		if errors.Is(err, ErrSlashBurn) {
+			return nil, nil, err
+		}
+		if err != nil {
+			// Ya tombstoned por otra evidencia: no se penaliza dos veces
+			logger.Warn(fmt.Sprintf("Evidencia de %s ignorada: %v", infraction, err))
+			continue
+		}
+		events = append(events, event)
+		records = append(records, record)
+	}
+	return events, records, nil
+}
+
+// slashValidator slashea y envía a jail a un validador, quema del módulo de staking los tokens slasheados
+// y retorna el evento y la entrada del historial. Si la quema falla retorna ErrSlashBurn.
+func (app *ABCIApp) slashValidator(address string, consensusAddress []byte, infraction string, infractionHeight, percent uint64, jailDuration time.Duration, tombstone bool) (abcitypes.Event, storage.SlashingRecord, error) {
+	amount, err := app.validators.SlashForInfraction(address, int(percent), infractionHeight, jailDuration, tombstone)
+	if err != nil {
+		return abcitypes.Event{}, storage.SlashingRecord{}, err
+	}
+
+	// Los tokens slasheados están custodiados por el módulo de staking
+	if err := app.executor.Burn(StakingModuleAddress, amount); err != nil {
+		fmt.Fprintf(os.Stderr, "[ABCI] ERROR quemando stake slasheado de %s: %v\n", address, err)
+		os.Stderr.Sync()
+		logger.Error(fmt.Sprintf("Error quemando stake slasheado de %s: %v", address, err))
+		return abcitypes.Event{}, storage.SlashingRecord{}, fmt.Errorf("%w de %s: %v", ErrSlashBurn, address, err)
+	}
+
+	record := SlashingRecord{
+		Validator:        address,
+		ConsensusAddress: fmt.Sprintf("%X", consensusAddress),
+		Infraction:       infraction,
+		InfractionHeight: infractionHeight,
+		Height:           app.currentBlockHeight,
+		Percent:          percent,
+		Amount:           amount.String(),
+		Tombstoned:       tombstone,
+	}
+	if !tombstone {
+		if v, err := app.validators.GetValidator(address); err == nil {
+			record.JailedUntil = v.JailedUntil.UTC().Format(time.RFC3339)
+		}
+	}
+	data, _ := json.Marshal(record)
+
+	event := abcitypes.Event{
+		Type: "slash",
+		Attributes: []abcitypes.EventAttribute{
+			{Key: "validator", Value: address, Index: true},
+			{Key: "reason", Value: infraction},
+			{Key: "infraction_height", Value: fmt.Sprintf("%d", infractionHeight)},
+			{Key: "amount", Value: record.Amount},
+			{Key: "tombstoned", Value: fmt.Sprintf("%t", tombstone)},
+		},
+	}
+	logger.Warn(fmt.Sprintf("Validador %s slasheado por %s en altura %d: %s", address, infraction, infractionHeight, record.Amount))
+	return event, storage.SlashingRecord{Validator: address, Data: data}, nil
+}
+
+// GetSlashingHistory retorna los últimos slashes de la cadena (address vacío) o de un validador
+func (app *ABCIApp) GetSlashingHistory(address string, limit int) ([]SlashingRecord, error) {
+	results, err := app.storage.GetSlashingRecords(address, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	records := make([]SlashingRecord, 0, len(results))
+	for _, data := range results {
+		var record SlashingRecord
+		if err := json.Unmarshal(data, &record); err != nil {
+			return nil, fmt.Errorf("error decodificando slash: %w", err)
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
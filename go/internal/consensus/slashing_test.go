@@ -0,0 +1,224 @@
+package consensus
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/Q-YZX0/oxy-blockchain/internal/execution"
+	"github.com/Q-YZX0/oxy-blockchain/internal/storage"
+	abcitypes "github.com/cometbft/cometbft/abci/types"
+	"github.com/cometbft/cometbft/crypto/ed25519"
+)
+
+// TestABCIApp_DoubleSignSlashing verifica que la evidencia de doble firma slashea, quema y tombstonea al
+// validador, lo saca del set en el mismo bloque y queda en el historial de slashing
+func TestABCIApp_DoubleSignSlashing(t *testing.T) {
+	ctx := context.Background()
+
+	testDir := createTestDir("double_sign")
+	defer func() {
+		if err := cleanupTestDir(testDir); err != nil {
+			t.Logf("Advertencia: error limpiando directorio: %v", err)
+		}
+	}()
+
+	db, err := storage.NewBlockchainDB(testDir)
+	if err != nil {
+		t.Fatalf("Error creando storage: %v", err)
+	}
+	defer db.Close()
+
+	evm := execution.NewEVMExecutor(db)
+	if err := evm.Start(); err != nil {
+		t.Fatalf("Error iniciando EVM: %v", err)
+	}
+	defer evm.Stop()
+
+	oneOXG := big.NewInt(1e18)
+	validatorA := "0xA000000000000000000000000000000000000001"
+	validatorB := "0xB000000000000000000000000000000000000002"
+	pubKeyA := ed25519.GenPrivKey().PubKey()
+	stakeA := new(big.Int).Mul(oneOXG, big.NewInt(4))
+	stakeB := new(big.Int).Mul(oneOXG, big.NewInt(2))
+
+	validators := NewValidatorSet(db, evm, oneOXG, 10)
+	if _, err := validators.RegisterValidator(validatorA, pubKeyA.Bytes(), stakeA); err != nil {
+		t.Fatalf("Error registrando validador: %v", err)
+	}
+	if _, err := validators.RegisterValidator(validatorB, ed25519.GenPrivKey().PubKey().Bytes(), stakeB); err != nil {
+		t.Fatalf("Error registrando validador: %v", err)
+	}
+
+	app := NewABCIApp(db, evm, validators, "test-chain")
+	if _, err := app.InitChain(ctx, &abcitypes.InitChainRequest{
+		ChainId:       "test-chain",
+		AppStateBytes: []byte(`{"slashing":{"double_sign_slash_percent":25}}`),
+	}); err != nil {
+		t.Fatalf("Error en InitChain: %v", err)
+	}
+
+	// El stake registrado directamente se respalda en el módulo de staking
+	if err := evm.FundAccount(StakingModuleAddress.Hex(), new(big.Int).Add(stakeA, stakeB).String()); err != nil {
+		t.Fatalf("Error fondeando módulo de staking: %v", err)
+	}
+	supplyBefore := evm.TotalSupply()
+
+	evidence := abcitypes.Misbehavior{
+		Type:      abcitypes.MISBEHAVIOR_TYPE_DUPLICATE_VOTE,
+		Validator: abcitypes.Validator{Address: pubKeyA.Address(), Power: 4},
+		Height:    1,
+		Time:      time.Unix(1700000001, 0),
+	}
+	resp, err := app.FinalizeBlock(ctx, &abcitypes.FinalizeBlockRequest{
+		Height:      2,
+		Time:        time.Unix(1700000002, 0),
+		Misbehavior: []abcitypes.Misbehavior{evidence, evidence},
+	})
+	if err != nil {
+		t.Fatalf("Error en FinalizeBlock: %v", err)
+	}
+	if _, err := app.Commit(ctx, &abcitypes.CommitRequest{}); err != nil {
+		t.Fatalf("Error en Commit: %v", err)
+	}
+
+	// El validador queda en jail permanente y sale del set de CometBFT en el mismo bloque
+	if len(resp.ValidatorUpdates) != 1 || string(resp.ValidatorUpdates[0].PubKeyBytes) != string(pubKeyA.Bytes()) || resp.ValidatorUpdates[0].Power != 0 {
+		t.Errorf("ValidatorUpdates incorrectos: %+v", resp.ValidatorUpdates)
+	}
+	validator, err := validators.GetValidator(validatorA)
+	if err != nil {
+		t.Fatalf("Validador no encontrado: %v", err)
+	}
+	slashed := new(big.Int).Div(stakeA, big.NewInt(4))
+	if !validator.Jailed || !validator.Tombstoned || validator.Stake.Cmp(new(big.Int).Sub(stakeA, slashed)) != 0 {
+		t.Errorf("Validador tras slash incorrecto: jailed=%v, tombstoned=%v, stake=%s", validator.Jailed, validator.Tombstoned, validator.Stake)
+	}
+	if err := validators.Unjail(validatorA); err == nil {
+		t.Error("Un validador tombstoned no debería poder hacer unjail")
+	}
+
+	// La evidencia repetida no vuelve a penalizar; el stake slasheado se quema
+	slashEvents := 0
+	for _, event := range resp.Events {
+		if event.Type == "slash" {
+			slashEvents++
+		}
+	}
+	if slashEvents != 1 {
+		t.Errorf("Eventos de slash incorrectos: %d", slashEvents)
+	}
+	if supply := evm.TotalSupply(); supply.Cmp(new(big.Int).Sub(supplyBefore, slashed)) != 0 {
+		t.Errorf("Total supply tras slash incorrecto: %s", supply)
+	}
+	if err := evm.CheckSupplyInvariant(); err != nil {
+		t.Errorf("Invariante de supply: %v", err)
+	}
+
+	// Historial consultable por validador
+	records, err := app.GetSlashingHistory(validatorA, 10)
+	if err != nil {
+		t.Fatalf("Error obteniendo historial: %v", err)
+	}
+	if len(records) != 1 || records[0].Infraction != InfractionDuplicateVote || records[0].InfractionHeight != 1 ||
+		records[0].Height != 2 || records[0].Amount != slashed.String() || !records[0].Tombstoned {
+		t.Errorf("Historial de slashing incorrecto: %+v", records)
+	}
+
+	queryResp, err := app.Query(ctx, &abcitypes.QueryRequest{Path: "slashing"})
+	if err != nil || queryResp.Code != 0 {
+		t.Fatalf("Error en query de slashing: %v %s", err, queryResp.Log)
+	}
+	var result struct {
+		Slashes []SlashingRecord `json:"slashes"`
+	}
+	if err := json.Unmarshal(queryResp.Value, &result); err != nil || len(result.Slashes) != 1 {
+		t.Errorf("Query de slashing incorrecta: %s", queryResp.Value)
+	}
+}
+
+// TestABCIApp_SlashBurnFailure verifica que si el módulo de staking no puede quemar el stake slasheado el
+// bloque falla y nada de lo que hizo el slash llega a disco
+func TestABCIApp_SlashBurnFailure(t *testing.T) {
+	ctx := context.Background()
+
+	testDir := createTestDir("slash_burn_failure")
+	defer func() {
+		if err := cleanupTestDir(testDir); err != nil {
+			t.Logf("Advertencia: error limpiando directorio: %v", err)
+		}
+	}()
+
+	db, err := storage.NewBlockchainDB(testDir)
+	if err != nil {
+		t.Fatalf("Error creando storage: %v", err)
+	}
+	evm := execution.NewEVMExecutor(db)
+	if err := evm.Start(); err != nil {
+		t.Fatalf("Error iniciando EVM: %v", err)
+	}
+
+	oneOXG := big.NewInt(1e18)
+	validatorA := "0xA000000000000000000000000000000000000001"
+	pubKeyA := ed25519.GenPrivKey().PubKey()
+	stake := new(big.Int).Mul(oneOXG, big.NewInt(4))
+
+	validators := NewValidatorSet(db, evm, oneOXG, 10)
+	if _, err := validators.RegisterValidator(validatorA, pubKeyA.Bytes(), stake); err != nil {
+		t.Fatalf("Error registrando validador: %v", err)
+	}
+	if _, err := validators.RegisterValidator("0xB000000000000000000000000000000000000002", ed25519.GenPrivKey().PubKey().Bytes(), stake); err != nil {
+		t.Fatalf("Error registrando validador: %v", err)
+	}
+
+	app := NewABCIApp(db, evm, validators, "test-chain")
+	if _, err := app.InitChain(ctx, &abcitypes.InitChainRequest{ChainId: "test-chain"}); err != nil {
+		t.Fatalf("Error en InitChain: %v", err)
+	}
+
+	// Sin fondear el módulo de staking la quema falla: el bloque no puede confirmarse
+	_, err = app.FinalizeBlock(ctx, &abcitypes.FinalizeBlockRequest{
+		Height: 1,
+		Time:   time.Unix(1700000001, 0),
+		Misbehavior: []abcitypes.Misbehavior{{
+			Type:      abcitypes.MISBEHAVIOR_TYPE_DUPLICATE_VOTE,
+			Validator: abcitypes.Validator{Address: pubKeyA.Address(), Power: 4},
+			Height:    1,
+			Time:      time.Unix(1700000001, 0),
+		}},
+	})
+	if !errors.Is(err, ErrSlashBurn) {
+		t.Fatalf("Se esperaba ErrSlashBurn: %v", err)
+	}
+	evm.Stop()
+	db.Close()
+
+	// Tras reiniciar el stake y el jail siguen como antes del bloque
+	db, err = storage.NewBlockchainDB(testDir)
+	if err != nil {
+		t.Fatalf("Error reabriendo storage: %v", err)
+	}
+	defer db.Close()
+	evm = execution.NewEVMExecutor(db)
+	if err := evm.Start(); err != nil {
+		t.Fatalf("Error reiniciando EVM: %v", err)
+	}
+	defer evm.Stop()
+	validators = NewValidatorSet(db, evm, oneOXG, 10)
+	if err := validators.LoadValidators(); err != nil {
+		t.Fatalf("Error cargando validadores: %v", err)
+	}
+	validator, err := validators.GetValidator(validatorA)
+	if err != nil {
+		t.Fatalf("Validador no encontrado: %v", err)
+	}
+	if validator.Jailed || validator.Tombstoned || validator.Stake.Cmp(stake) != 0 {
+		t.Errorf("El slash fallido no debería persistir: jailed=%v, tombstoned=%v, stake=%s", validator.Jailed, validator.Tombstoned, validator.Stake)
+	}
+	if records, _ := NewABCIApp(db, evm, validators, "test-chain").GetSlashingHistory(validatorA, 10); len(records) != 0 {
+		t.Errorf("Historial de slashing no debería tener entradas: %+v", records)
+	}
+}
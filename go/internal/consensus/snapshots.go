@@ -0,0 +1,576 @@
+package consensus
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/Q-YZX0/oxy-blockchain/internal/execution"
+	"github.com/Q-YZX0/oxy-blockchain/internal/logger"
+	"github.com/Q-YZX0/oxy-blockchain/internal/storage"
+	abcitypes "github.com/cometbft/cometbft/abci/types"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Parámetros de los snapshots de state sync
+const (
+	SnapshotFormat            uint32 = 1       // Versión del formato de los chunks
+	DefaultSnapshotKeepRecent        = 2       // Snapshots conservados si la configuración no fija otro valor
+	defaultSnapshotChunkSize         = 4 << 20 // Bytes por chunk (CometBFT admite hasta 16 MB)
+)
+
+// snapshotEntryApp es el tipo de entrada de las claves del estado de la aplicación fuera del EVM
+// (los tipos del estado EVM son execution.StateEntryTrieNode y execution.StateEntryCode)
+const snapshotEntryApp byte = 3
+
+// SnapshotMetadata describe un snapshot: se envía a los peers en el campo Metadata de CometBFT
+type SnapshotMetadata struct {
+	Height      uint64   `json:"height"`
+	AppHash     string   `json:"appHash"`     // AppHash del bloque (hex)
+	StateRoot   string   `json:"stateRoot"`   // Root del estado EVM (hex)
+	ChunkHashes []string `json:"chunkHashes"` // sha256 de cada chunk (hex)
+}
+
+// SnapshotManager exporta periódicamente el estado (trie EVM, set de validadores y metadata de la
+// aplicación) en chunks con hash bajo <dataDir>/snapshots/<altura>/ y conserva los últimos keepRecent.
+// También guarda el progreso de una restauración recibida por state sync.
+type SnapshotManager struct {
+	dir        string
+	interval   uint64 // Cada cuántos bloques se crea un snapshot (0 = no se crean)
+	keepRecent int
+	chunkSize  int
+
+	mu       sync.Mutex
+	creating bool
+	wg       sync.WaitGroup
+	restore  *snapshotRestore
+}
+
+// snapshotRestore es una restauración en curso: los chunks recibidos se guardan en disco hasta tenerlos todos
+type snapshotRestore struct {
+	snapshot *abcitypes.Snapshot
+	metadata SnapshotMetadata
+	dir      string
+	received []bool
+	pending  int
+}
+
+// NewSnapshotManager crea el gestor de snapshots (interval 0 = no se crean snapshots, solo se restauran)
+func NewSnapshotManager(dir string, interval uint64, keepRecent int) *SnapshotManager {
+	if keepRecent <= 0 {
+		keepRecent = DefaultSnapshotKeepRecent
+	}
+	return &SnapshotManager{
+		dir:        dir,
+		interval:   interval,
+		keepRecent: keepRecent,
+		chunkSize:  defaultSnapshotChunkSize,
+	}
+}
+
+// SetSnapshotManager establece el gestor de snapshots de state sync
+func (app *ABCIApp) SetSnapshotManager(m *SnapshotManager) {
+	app.snapshots = m
+}
+
+// Wait espera a que termine el snapshot en creación
+func (m *SnapshotManager) Wait() {
+	m.wg.Wait()
+}
+
+// shouldSnapshot indica si hay que crear un snapshot en la altura dada
+func (m *SnapshotManager) shouldSnapshot(height uint64) bool {
+	return m.interval > 0 && height > 0 && height%m.interval == 0
+}
+
+// snapshotDir retorna el directorio de un snapshot
+func (m *SnapshotManager) snapshotDir(height uint64) string {
+	return filepath.Join(m.dir, strconv.FormatUint(height, 10))
+}
+
+// createSnapshot captura el estado de la aplicación del bloque recién confirmado y exporta el estado EVM
+// en segundo plano. Si el snapshot anterior sigue en creación se omite este.
+func (app *ABCIApp) createSnapshot(height uint64, appHash []byte, stateRoot common.Hash) {
+	m := app.snapshots
+	m.mu.Lock()
+	if m.creating {
+		m.mu.Unlock()
+		logger.Warn(fmt.Sprintf("Snapshot de altura %d omitido: el anterior sigue en creación", height))
+		return
+	}
+	m.creating = true
+	m.mu.Unlock()
+
+	// El estado de la aplicación cambia en el bloque siguiente: se lee ahora. Los nodos del trie EVM no
+	// se borran, así que se pueden exportar después.
+	entries, err := app.storage.SnapshotEntries(height)
+	if err != nil {
+		logger.Error(fmt.Sprintf("Error leyendo estado para snapshot de altura %d: %v", height, err))
+		m.mu.Lock()
+		m.creating = false
+		m.mu.Unlock()
+		return
+	}
+
+	metadata := SnapshotMetadata{
+		Height:    height,
+		AppHash:   common.Bytes2Hex(appHash),
+		StateRoot: stateRoot.Hex(),
+	}
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		defer func() {
+			m.mu.Lock()
+			m.creating = false
+			m.mu.Unlock()
+		}()
+
+		err := m.write(metadata, func(w *snapshotWriter) error {
+			if err := app.executor.ExportState(stateRoot, w.writeEntry); err != nil {
+				return err
+			}
+			for _, entry := range entries {
+				if err := w.writeEntry(snapshotEntryApp, entry.Key, entry.Value); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			logger.Error(fmt.Sprintf("Error creando snapshot de altura %d: %v", height, err))
+			return
+		}
+		logger.Info(fmt.Sprintf("Snapshot creado en altura %d", height))
+		m.prune()
+	}()
+}
+
+// write escribe un snapshot en un directorio temporal y lo publica al terminar (renombrándolo), de modo
+// que ListSnapshots nunca ve un snapshot a medias
+func (m *SnapshotManager) write(metadata SnapshotMetadata, export func(w *snapshotWriter) error) error {
+	final := m.snapshotDir(metadata.Height)
+	tmp := final + ".tmp"
+	if err := os.RemoveAll(tmp); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(tmp, 0755); err != nil {
+		return fmt.Errorf("error creando directorio de snapshot: %w", err)
+	}
+
+	chunks := &chunkWriter{dir: tmp, size: m.chunkSize}
+	gz := gzip.NewWriter(chunks)
+	w := &snapshotWriter{w: bufio.NewWriter(gz)}
+	err := export(w)
+	if err == nil {
+		err = w.w.Flush()
+	}
+	if err == nil {
+		err = gz.Close()
+	}
+	if err == nil {
+		err = chunks.Close()
+	}
+	if err != nil {
+		os.RemoveAll(tmp)
+		return err
+	}
+
+	metadata.ChunkHashes = chunks.hashes
+	data, err := json.Marshal(metadata)
+	if err != nil {
+		os.RemoveAll(tmp)
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(tmp, "metadata.json"), data, 0644); err != nil {
+		os.RemoveAll(tmp)
+		return fmt.Errorf("error guardando metadata de snapshot: %w", err)
+	}
+	if err := os.RemoveAll(final); err != nil {
+		return err
+	}
+	return os.Rename(tmp, final)
+}
+
+// prune borra los snapshots más antiguos que los últimos keepRecent
+func (m *SnapshotManager) prune() {
+	heights := m.heights()
+	for i := m.keepRecent; i < len(heights); i++ {
+		if err := os.RemoveAll(m.snapshotDir(heights[i])); err != nil {
+			logger.Warn(fmt.Sprintf("Error borrando snapshot de altura %d: %v", heights[i], err))
+		}
+	}
+}
+
+// heights retorna las alturas de los snapshots completos, de la más reciente a la más antigua
+func (m *SnapshotManager) heights() []uint64 {
+	dirEntries, err := os.ReadDir(m.dir)
+	if err != nil {
+		return nil
+	}
+	var heights []uint64
+	for _, entry := range dirEntries {
+		height, err := strconv.ParseUint(entry.Name(), 10, 64)
+		if err != nil || !entry.IsDir() {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(m.dir, entry.Name(), "metadata.json")); err == nil {
+			heights = append(heights, height)
+		}
+	}
+	sort.Slice(heights, func(i, j int) bool { return heights[i] > heights[j] })
+	return heights
+}
+
+// List retorna los snapshots disponibles en el formato de CometBFT, del más reciente al más antiguo
+func (m *SnapshotManager) List() ([]*abcitypes.Snapshot, error) {
+	var snapshots []*abcitypes.Snapshot
+	for _, height := range m.heights() {
+		data, err := os.ReadFile(filepath.Join(m.snapshotDir(height), "metadata.json"))
+		if err != nil {
+			return nil, fmt.Errorf("error leyendo snapshot de altura %d: %w", height, err)
+		}
+		var metadata SnapshotMetadata
+		if err := json.Unmarshal(data, &metadata); err != nil {
+			return nil, fmt.Errorf("metadata de snapshot corrupta en altura %d: %w", height, err)
+		}
+		hash, err := snapshotHash(metadata.ChunkHashes)
+		if err != nil {
+			return nil, err
+		}
+		snapshots = append(snapshots, &abcitypes.Snapshot{
+			Height:   height,
+			Format:   SnapshotFormat,
+			Chunks:   uint32(len(metadata.ChunkHashes)),
+			Hash:     hash,
+			Metadata: data,
+		})
+	}
+	return snapshots, nil
+}
+
+// LoadChunk retorna un chunk de un snapshot (nil si no existe)
+func (m *SnapshotManager) LoadChunk(height uint64, format, index uint32) ([]byte, error) {
+	if format != SnapshotFormat {
+		return nil, nil
+	}
+	data, err := os.ReadFile(filepath.Join(m.snapshotDir(height), chunkFileName(index)))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	return data, err
+}
+
+// snapshotHash es el hash de un snapshot: sha256 de los hashes de sus chunks concatenados
+func snapshotHash(chunkHashes []string) ([]byte, error) {
+	hasher := sha256.New()
+	for _, h := range chunkHashes {
+		hash := common.FromHex(h)
+		if len(hash) != sha256.Size {
+			return nil, fmt.Errorf("hash de chunk inválido: %s", h)
+		}
+		hasher.Write(hash)
+	}
+	return hasher.Sum(nil), nil
+}
+
+// chunkFileName retorna el nombre del archivo de un chunk
+func chunkFileName(index uint32) string {
+	return fmt.Sprintf("chunk-%05d", index)
+}
+
+// snapshotWriter serializa las entradas de un snapshot: tipo (1 byte), clave y valor con su longitud
+// (uvarint)
+type snapshotWriter struct {
+	w *bufio.Writer
+}
+
+// writeEntry escribe una entrada del snapshot
+func (w *snapshotWriter) writeEntry(kind byte, key, value []byte) error {
+	var length [binary.MaxVarintLen64]byte
+	if err := w.w.WriteByte(kind); err != nil {
+		return err
+	}
+	for _, field := range [][]byte{key, value} {
+		n := binary.PutUvarint(length[:], uint64(len(field)))
+		if _, err := w.w.Write(length[:n]); err != nil {
+			return err
+		}
+		if _, err := w.w.Write(field); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readSnapshotEntry lee la siguiente entrada del snapshot (io.EOF al terminar)
+func readSnapshotEntry(r *bufio.Reader) (byte, []byte, []byte, error) {
+	kind, err := r.ReadByte()
+	if err != nil {
+		return 0, nil, nil, err
+	}
+	fields := make([][]byte, 2)
+	for i := range fields {
+		length, err := binary.ReadUvarint(r)
+		if err != nil {
+			return 0, nil, nil, io.ErrUnexpectedEOF
+		}
+		if length > defaultSnapshotChunkSize*4 {
+			return 0, nil, nil, fmt.Errorf("entrada de snapshot demasiado grande: %d bytes", length)
+		}
+		fields[i] = make([]byte, length)
+		if _, err := io.ReadFull(r, fields[i]); err != nil {
+			return 0, nil, nil, io.ErrUnexpectedEOF
+		}
+	}
+	return kind, fields[0], fields[1], nil
+}
+
+// chunkWriter parte el flujo comprimido en archivos de tamaño fijo y calcula el hash de cada uno
+type chunkWriter struct {
+	dir    string
+	size   int
+	buf    bytes.Buffer
+	hashes []string
+}
+
+func (c *chunkWriter) Write(p []byte) (int, error) {
+	c.buf.Write(p)
+	for c.buf.Len() >= c.size {
+		if err := c.flush(c.buf.Next(c.size)); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// Close escribe el último chunk
+func (c *chunkWriter) Close() error {
+	if c.buf.Len() == 0 && len(c.hashes) > 0 {
+		return nil
+	}
+	return c.flush(c.buf.Next(c.buf.Len()))
+}
+
+func (c *chunkWriter) flush(chunk []byte) error {
+	hash := sha256.Sum256(chunk)
+	if err := os.WriteFile(filepath.Join(c.dir, chunkFileName(uint32(len(c.hashes)))), chunk, 0644); err != nil {
+		return fmt.Errorf("error guardando chunk de snapshot: %w", err)
+	}
+	c.hashes = append(c.hashes, common.Bytes2Hex(hash[:]))
+	return nil
+}
+
+// offerSnapshot valida un snapshot ofrecido por un peer y prepara su restauración
+func (app *ABCIApp) offerSnapshot(snapshot *abcitypes.Snapshot, trustedAppHash []byte) abcitypes.OfferSnapshotResult {
+	m := app.snapshots
+	if snapshot == nil {
+		return abcitypes.OFFER_SNAPSHOT_RESULT_REJECT
+	}
+	if snapshot.Format != SnapshotFormat {
+		return abcitypes.OFFER_SNAPSHOT_RESULT_REJECT_FORMAT
+	}
+
+	var metadata SnapshotMetadata
+	if err := json.Unmarshal(snapshot.Metadata, &metadata); err != nil {
+		logger.Warn(fmt.Sprintf("Snapshot de altura %d rechazado: metadata inválida: %v", snapshot.Height, err))
+		return abcitypes.OFFER_SNAPSHOT_RESULT_REJECT
+	}
+	hash, err := snapshotHash(metadata.ChunkHashes)
+	if err != nil || !bytes.Equal(hash, snapshot.Hash) || metadata.Height != snapshot.Height ||
+		uint32(len(metadata.ChunkHashes)) != snapshot.Chunks || snapshot.Chunks == 0 {
+		logger.Warn(fmt.Sprintf("Snapshot de altura %d rechazado: metadata no coincide con el snapshot", snapshot.Height))
+		return abcitypes.OFFER_SNAPSHOT_RESULT_REJECT
+	}
+	// El AppHash de confianza viene del light client de CometBFT
+	if !bytes.Equal(common.FromHex(metadata.AppHash), trustedAppHash) {
+		logger.Warn(fmt.Sprintf("Snapshot de altura %d rechazado: AppHash %s no coincide con el de confianza %X", snapshot.Height, metadata.AppHash, trustedAppHash))
+		return abcitypes.OFFER_SNAPSHOT_RESULT_REJECT
+	}
+
+	dir := filepath.Join(m.dir, "restore")
+	if err := os.RemoveAll(dir); err == nil {
+		err = os.MkdirAll(dir, 0755)
+	}
+	if err != nil {
+		logger.Error("Error preparando restauración de snapshot: " + err.Error())
+		return abcitypes.OFFER_SNAPSHOT_RESULT_ABORT
+	}
+
+	m.mu.Lock()
+	m.restore = &snapshotRestore{
+		snapshot: snapshot,
+		metadata: metadata,
+		dir:      dir,
+		received: make([]bool, snapshot.Chunks),
+		pending:  int(snapshot.Chunks),
+	}
+	m.mu.Unlock()
+	logger.Info(fmt.Sprintf("Restaurando snapshot de altura %d (%d chunks)", snapshot.Height, snapshot.Chunks))
+	return abcitypes.OFFER_SNAPSHOT_RESULT_ACCEPT
+}
+
+// applySnapshotChunk verifica y guarda un chunk de la restauración en curso. Con el último chunk
+// restaura el estado y verifica el AppHash.
+func (app *ABCIApp) applySnapshotChunk(req *abcitypes.ApplySnapshotChunkRequest) *abcitypes.ApplySnapshotChunkResponse {
+	m := app.snapshots
+	m.mu.Lock()
+	restore := m.restore
+	m.mu.Unlock()
+	if restore == nil || int(req.Index) >= len(restore.received) {
+		return &abcitypes.ApplySnapshotChunkResponse{Result: abcitypes.APPLY_SNAPSHOT_CHUNK_RESULT_ABORT}
+	}
+
+	// Un chunk corrupto se vuelve a pedir a otro peer
+	hash := sha256.Sum256(req.Chunk)
+	if common.Bytes2Hex(hash[:]) != restore.metadata.ChunkHashes[req.Index] {
+		logger.Warn(fmt.Sprintf("Chunk %d del snapshot de altura %d con hash inválido (peer %s)", req.Index, restore.snapshot.Height, req.Sender))
+		resp := &abcitypes.ApplySnapshotChunkResponse{
+			Result:        abcitypes.APPLY_SNAPSHOT_CHUNK_RESULT_RETRY,
+			RefetchChunks: []uint32{req.Index},
+		}
+		if req.Sender != "" {
+			resp.RejectSenders = []string{req.Sender}
+		}
+		return resp
+	}
+
+	if !restore.received[req.Index] {
+		if err := os.WriteFile(filepath.Join(restore.dir, chunkFileName(req.Index)), req.Chunk, 0644); err != nil {
+			logger.Error("Error guardando chunk de snapshot: " + err.Error())
+			return &abcitypes.ApplySnapshotChunkResponse{Result: abcitypes.APPLY_SNAPSHOT_CHUNK_RESULT_ABORT}
+		}
+		restore.received[req.Index] = true
+		restore.pending--
+	}
+	if restore.pending > 0 {
+		return &abcitypes.ApplySnapshotChunkResponse{Result: abcitypes.APPLY_SNAPSHOT_CHUNK_RESULT_ACCEPT}
+	}
+
+	err := app.restoreSnapshot(restore)
+	m.mu.Lock()
+	m.restore = nil
+	m.mu.Unlock()
+	os.RemoveAll(restore.dir)
+	if err != nil {
+		logger.Error(fmt.Sprintf("Error restaurando snapshot de altura %d: %v", restore.snapshot.Height, err))
+		return &abcitypes.ApplySnapshotChunkResponse{Result: abcitypes.APPLY_SNAPSHOT_CHUNK_RESULT_REJECT_SNAPSHOT}
+	}
+	logger.Info(fmt.Sprintf("Snapshot de altura %d restaurado", restore.snapshot.Height))
+	return &abcitypes.ApplySnapshotChunkResponse{Result: abcitypes.APPLY_SNAPSHOT_CHUNK_RESULT_ACCEPT}
+}
+
+// restoreSnapshot importa el estado de los chunks recibidos, recarga la aplicación y verifica que el
+// AppHash restaurado es el del snapshot
+func (app *ABCIApp) restoreSnapshot(restore *snapshotRestore) error {
+	readers := make([]io.Reader, 0, len(restore.received))
+	for i := range restore.received {
+		f, err := os.Open(filepath.Join(restore.dir, chunkFileName(uint32(i))))
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		readers = append(readers, f)
+	}
+	gz, err := gzip.NewReader(io.MultiReader(readers...))
+	if err != nil {
+		return fmt.Errorf("snapshot corrupto: %w", err)
+	}
+	r := bufio.NewReader(gz)
+
+	var entries []storage.SnapshotEntry
+	for {
+		kind, key, value, err := readSnapshotEntry(r)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("snapshot corrupto: %w", err)
+		}
+		switch kind {
+		case execution.StateEntryTrieNode, execution.StateEntryCode:
+			if err := app.executor.ImportStateEntry(kind, key, value); err != nil {
+				return err
+			}
+		case snapshotEntryApp:
+			entries = append(entries, storage.SnapshotEntry{Key: key, Value: value})
+		default:
+			return fmt.Errorf("tipo de entrada de snapshot desconocido: %d", kind)
+		}
+	}
+
+	height := restore.metadata.Height
+	if err := app.storage.RestoreSnapshotEntries(entries); err != nil {
+		return err
+	}
+	if err := app.executor.RestoreState(common.HexToHash(restore.metadata.StateRoot), height); err != nil {
+		return err
+	}
+	return app.reloadRestoredState(height, common.FromHex(restore.metadata.AppHash))
+}
+
+// reloadRestoredState recarga los parámetros, el set de validadores y el AppState desde el estado
+// restaurado y verifica el AppHash
+func (app *ABCIApp) reloadRestoredState(height uint64, expectedAppHash []byte) error {
+	genesisData, err := app.storage.GetGenesisState()
+	if err != nil {
+		return fmt.Errorf("snapshot sin app_state del genesis: %w", err)
+	}
+	genesis, err := ParseGenesisState(genesisData)
+	if err != nil {
+		return err
+	}
+	if err := app.applyGenesisState(genesis); err != nil {
+		return err
+	}
+	if err := app.loadFeeConfig(); err != nil {
+		return err
+	}
+	if limit, err := app.storage.GetBlockGasLimit(); err == nil && limit > 0 {
+		app.setBlockGasLimit(limit)
+	}
+	if limit, err := app.storage.GetBlockMaxBytes(); err == nil && limit > 0 {
+		app.blockMaxBytes = limit
+	}
+	if version, err := app.storage.GetAppVersion(); err == nil && version > 0 {
+		app.appVersion = version
+	}
+	app.upgradesChecked = false
+	if app.validators != nil {
+		if err := app.validators.LoadValidators(); err != nil {
+			return fmt.Errorf("error cargando validadores restaurados: %w", err)
+		}
+	}
+
+	appHash, err := app.computeAppHash()
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(appHash, expectedAppHash) {
+		return fmt.Errorf("AppHash restaurado %X no coincide con el del snapshot %X", appHash, expectedAppHash)
+	}
+
+	if err := app.storage.SaveLatestHeight(height); err != nil {
+		return err
+	}
+	if err := app.saveCommittedState(app.executor.GetStateManager().GetRootHash(), height, appHash); err != nil {
+		return err
+	}
+	app.currentBlockHeight = height
+	app.state.Height = int64(height)
+	app.state.AppHash = appHash
+	if app.validators != nil {
+		app.state.Validators = app.validators.ToCometBFTValidators()
+	}
+	return nil
+}
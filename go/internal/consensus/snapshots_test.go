@@ -0,0 +1,192 @@
+package consensus
+
+import (
+	"bytes"
+	"context"
+	"math/big"
+	"path/filepath"
+	"testing"
+	"time"
+
+	execution "github.com/Q-YZX0/oxy-blockchain/internal/execution"
+	"github.com/Q-YZX0/oxy-blockchain/internal/storage"
+	abcitypes "github.com/cometbft/cometbft/abci/types"
+	"github.com/cometbft/cometbft/crypto/ed25519"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// TestABCIApp_StateSyncSnapshot verifica que un nodo crea snapshots cada intervalo con retención, y que
+// un nodo nuevo restaura uno (trie EVM, storage, bytecode y validadores) verificando chunks y AppHash
+func TestABCIApp_StateSyncSnapshot(t *testing.T) {
+	ctx := context.Background()
+
+	testDir := createTestDir("state_sync_snapshot")
+	defer func() {
+		if err := cleanupTestDir(testDir); err != nil {
+			t.Logf("Advertencia: error limpiando directorio: %v", err)
+		}
+	}()
+
+	oneOXG := big.NewInt(1e18)
+	genesis := []byte(`{"rewards":{"inflation_bps":1000,"blocks_per_year":100,"epoch_blocks":2}}`)
+	validatorAddr := "0xA000000000000000000000000000000000000001"
+	validatorKey := ed25519.GenPrivKeyFromSecret([]byte("snapshot-validator")).PubKey().Bytes()
+
+	type node struct {
+		db         *storage.BlockchainDB
+		evm        *execution.EVMExecutor
+		validators *ValidatorSet
+		app        *ABCIApp
+	}
+	newNode := func(name string) *node {
+		db, err := storage.NewBlockchainDB(filepath.Join(testDir, name))
+		if err != nil {
+			t.Fatalf("Error creando storage: %v", err)
+		}
+		evm := execution.NewEVMExecutor(db)
+		if err := evm.Start(); err != nil {
+			t.Fatalf("Error iniciando EVM: %v", err)
+		}
+		validators := NewValidatorSet(db, evm, oneOXG, 10)
+		app := NewABCIApp(db, evm, validators, "test-chain")
+		manager := NewSnapshotManager(filepath.Join(testDir, name, "snapshots"), 2, 1)
+		manager.chunkSize = 256
+		app.SetSnapshotManager(manager)
+		return &node{db: db, evm: evm, validators: validators, app: app}
+	}
+	closeNode := func(n *node) {
+		n.app.snapshots.Wait()
+		n.evm.Stop()
+		n.db.Close()
+	}
+
+	// Nodo A: validador, cuenta fondeada y contrato con storage (slot 0 = 42)
+	a := newNode("a")
+	defer closeNode(a)
+	if _, err := a.validators.RegisterValidator(validatorAddr, validatorKey, new(big.Int).Mul(oneOXG, big.NewInt(3))); err != nil {
+		t.Fatalf("Error registrando validador: %v", err)
+	}
+	if _, err := a.app.InitChain(ctx, &abcitypes.InitChainRequest{ChainId: "test-chain", AppStateBytes: genesis}); err != nil {
+		t.Fatalf("Error en InitChain: %v", err)
+	}
+	deployer := "0x5000000000000000000000000000000000000005"
+	if err := a.evm.FundAccount(deployer, "1000000000000000000000"); err != nil {
+		t.Fatalf("Error fondeando cuenta: %v", err)
+	}
+	contract, _, err := a.evm.DeployContract(deployer, common.FromHex("0x602a6000556a60005460005260206000f3600052600b6015f3"), nil, 200000, "1000000000")
+	if err != nil {
+		t.Fatalf("Error desplegando contrato: %v", err)
+	}
+
+	var lastAppHash []byte
+	for height := int64(1); height <= 4; height++ {
+		resp, err := a.app.FinalizeBlock(ctx, &abcitypes.FinalizeBlockRequest{Height: height, Time: time.Unix(1700000000+height, 0)})
+		if err != nil {
+			t.Fatalf("Error en FinalizeBlock %d: %v", height, err)
+		}
+		if len(resp.AppHash) == 0 {
+			t.Fatalf("FinalizeBlock %d no retornó AppHash", height)
+		}
+		if _, err := a.app.Commit(ctx, &abcitypes.CommitRequest{}); err != nil {
+			t.Fatalf("Error en Commit %d: %v", height, err)
+		}
+		if !bytes.Equal(resp.AppHash, a.app.state.AppHash) {
+			t.Fatalf("AppHash de FinalizeBlock y Commit no coinciden en altura %d", height)
+		}
+		lastAppHash = resp.AppHash
+		a.app.snapshots.Wait()
+	}
+
+	// Snapshots en alturas 2 y 4: solo se conserva el último
+	list, err := a.app.ListSnapshots(ctx, &abcitypes.ListSnapshotsRequest{})
+	if err != nil {
+		t.Fatalf("Error en ListSnapshots: %v", err)
+	}
+	if len(list.Snapshots) != 1 || list.Snapshots[0].Height != 4 {
+		t.Fatalf("Se esperaba un snapshot en altura 4, hay %d", len(list.Snapshots))
+	}
+	snapshot := list.Snapshots[0]
+	if snapshot.Chunks < 2 {
+		t.Fatalf("Se esperaban varios chunks, hay %d", snapshot.Chunks)
+	}
+
+	// Nodo B: nuevo, con el mismo genesis
+	b := newNode("b")
+	defer closeNode(b)
+	if _, err := b.app.InitChain(ctx, &abcitypes.InitChainRequest{ChainId: "test-chain", AppStateBytes: genesis}); err != nil {
+		t.Fatalf("Error en InitChain: %v", err)
+	}
+
+	// Un AppHash distinto al de confianza se rechaza
+	offer, _ := b.app.OfferSnapshot(ctx, &abcitypes.OfferSnapshotRequest{Snapshot: snapshot, AppHash: make([]byte, 32)})
+	if offer.Result != abcitypes.OFFER_SNAPSHOT_RESULT_REJECT {
+		t.Fatalf("Snapshot con AppHash incorrecto debería rechazarse: %v", offer.Result)
+	}
+	offer, _ = b.app.OfferSnapshot(ctx, &abcitypes.OfferSnapshotRequest{Snapshot: snapshot, AppHash: lastAppHash})
+	if offer.Result != abcitypes.OFFER_SNAPSHOT_RESULT_ACCEPT {
+		t.Fatalf("Snapshot válido debería aceptarse: %v", offer.Result)
+	}
+
+	for index := uint32(0); index < snapshot.Chunks; index++ {
+		chunk, err := a.app.LoadSnapshotChunk(ctx, &abcitypes.LoadSnapshotChunkRequest{Height: snapshot.Height, Format: snapshot.Format, Chunk: index})
+		if err != nil || len(chunk.Chunk) == 0 {
+			t.Fatalf("Error cargando chunk %d: %v", index, err)
+		}
+
+		// Un chunk alterado se vuelve a pedir
+		if index == 0 {
+			corrupt := append([]byte(nil), chunk.Chunk...)
+			corrupt[0] ^= 0xff
+			resp, _ := b.app.ApplySnapshotChunk(ctx, &abcitypes.ApplySnapshotChunkRequest{Index: index, Chunk: corrupt, Sender: "peer-malo"})
+			if resp.Result != abcitypes.APPLY_SNAPSHOT_CHUNK_RESULT_RETRY || len(resp.RejectSenders) != 1 {
+				t.Fatalf("Chunk corrupto debería pedirse de nuevo: %v", resp.Result)
+			}
+		}
+
+		resp, _ := b.app.ApplySnapshotChunk(ctx, &abcitypes.ApplySnapshotChunkRequest{Index: index, Chunk: chunk.Chunk, Sender: "peer"})
+		if resp.Result != abcitypes.APPLY_SNAPSHOT_CHUNK_RESULT_ACCEPT {
+			t.Fatalf("Chunk %d debería aceptarse: %v", index, resp.Result)
+		}
+	}
+
+	// B queda en la altura y AppHash del snapshot, con el mismo estado
+	info, _ := b.app.Info(ctx, &abcitypes.InfoRequest{})
+	if info.LastBlockHeight != 4 || !bytes.Equal(info.LastBlockAppHash, lastAppHash) {
+		t.Fatalf("Info restaurado: altura %d, AppHash %X", info.LastBlockHeight, info.LastBlockAppHash)
+	}
+	balanceA, _ := a.evm.GetBalance(deployer)
+	balanceB, _ := b.evm.GetBalance(deployer)
+	if balanceA.Cmp(balanceB) != 0 {
+		t.Errorf("Balance restaurado %s, esperado %s", balanceB, balanceA)
+	}
+	if slot, _ := b.evm.GetStorageAt(contract, common.Hash{}); slot != common.BigToHash(big.NewInt(42)) {
+		t.Errorf("Storage restaurado incorrecto: %s", slot.Hex())
+	}
+	if code, _ := b.evm.GetCode(contract); len(code) == 0 {
+		t.Error("Bytecode del contrato no restaurado")
+	}
+	if b.evm.TotalSupply().Cmp(a.evm.TotalSupply()) != 0 {
+		t.Errorf("Total supply restaurado %s, esperado %s", b.evm.TotalSupply(), a.evm.TotalSupply())
+	}
+	// El total supply viaja en el snapshot (cubierto por el AppHash), no se recalcula
+	if supply, err := b.db.GetTotalSupply(); err != nil || supply.Cmp(a.evm.TotalSupply()) != 0 {
+		t.Errorf("Total supply no incluido en el snapshot: %v, %v", supply, err)
+	}
+	if validator, err := b.validators.GetValidator(validatorAddr); err != nil || validator.Stake.Cmp(new(big.Int).Mul(oneOXG, big.NewInt(3))) != 0 {
+		t.Fatalf("Validador no restaurado: %v", err)
+	}
+
+	// Ambos nodos continúan la cadena con el mismo AppHash
+	next := &abcitypes.FinalizeBlockRequest{Height: 5, Time: time.Unix(1700000005, 0)}
+	respA, err := a.app.FinalizeBlock(ctx, next)
+	if err != nil {
+		t.Fatalf("Error en FinalizeBlock del nodo A: %v", err)
+	}
+	respB, err := b.app.FinalizeBlock(ctx, next)
+	if err != nil {
+		t.Fatalf("Error en FinalizeBlock del nodo B: %v", err)
+	}
+	if !bytes.Equal(respA.AppHash, respB.AppHash) {
+		t.Fatalf("AppHash divergente tras restaurar: %X != %X", respA.AppHash, respB.AppHash)
+	}
+}
@@ -0,0 +1,498 @@
+package consensus
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"sort"
+	"time"
+
+	cryptosigner "github.com/Q-YZX0/oxy-blockchain/internal/crypto"
+	"github.com/Q-YZX0/oxy-blockchain/internal/logger"
+	abcitypes "github.com/cometbft/cometbft/abci/types"
+	"github.com/cometbft/cometbft/crypto/ed25519"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/syndtr/goleveldb/leveldb"
+)
+
+// StakingModuleAddress recibe las transacciones de staking y custodia el stake bondeado.
+// Una transacción de staking es una transferencia normal (firmada, con gas y nonce) a esta dirección
+// cuyo Data es un StakingMsg en JSON; el Value es el monto a bondear.
+var StakingModuleAddress = common.HexToAddress("0x0000000000000000000000000000000000000800")
+
+// Tipos de operación de staking
+const (
+	StakingCreateValidator = "create_validator"
+	StakingStake           = "stake"
+	StakingUnstake         = "unstake"
+	StakingUnjail          = "unjail"
+	StakingEditValidator   = "edit_validator"
+
+	StakingDelegate        = "delegate"
+	StakingRedelegate      = "redelegate"
+	StakingUndelegate      = "undelegate"
+	StakingWithdrawRewards = "withdraw_rewards"
+)
+
+// StakingMsg es el payload de una transacción de staking. En las operaciones de validador el validador
+// es el remitente; en las de delegación el remitente es el delegador y Validator el validador destino.
+type StakingMsg struct {
+	Type           string        `json:"type"`
+	PubKey         hexutil.Bytes `json:"pubKey,omitempty"`         // Clave ed25519 de consenso (create_validator)
+	KeyProof       hexutil.Bytes `json:"keyProof,omitempty"`       // Firma secp256k1 del operador sobre la clave de consenso (create_validator)
+	ConsensusProof hexutil.Bytes `json:"consensusProof,omitempty"` // Firma ed25519 de la clave de consenso sobre chain y operador (create_validator)
+	Amount         string        `json:"amount,omitempty"`         // Monto a retirar o mover en wei (unstake, undelegate, redelegate)
+	Commission     *uint64       `json:"commission,omitempty"`     // Comisión 0-100 (create_validator, edit_validator)
+	Validator      string        `json:"validator,omitempty"`      // Validador delegado (delegate, undelegate, redelegate, withdraw_rewards)
+	DstValidator   string        `json:"dstValidator,omitempty"`   // Validador al que se redelega (redelegate)
+}
+
+// isDelegationMsg indica si la operación es de un delegador en lugar del operador de un validador
+func isDelegationMsg(msgType string) bool {
+	switch msgType {
+	case StakingDelegate, StakingRedelegate, StakingUndelegate, StakingWithdrawRewards:
+		return true
+	}
+	return false
+}
+
+// IsStakingTransaction indica si la transacción está dirigida al módulo de staking
+func IsStakingTransaction(tx *Transaction) bool {
+	return tx.To != "" && common.IsHexAddress(tx.To) && common.HexToAddress(tx.To) == StakingModuleAddress
+}
+
+// ParseStakingMsg decodifica el payload de una transacción de staking
+func ParseStakingMsg(data []byte) (*StakingMsg, error) {
+	var msg StakingMsg
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return nil, fmt.Errorf("payload de staking inválido: %w", err)
+	}
+	return &msg, nil
+}
+
+// checkStakingTx decodifica y valida una transacción de staking contra el set de validadores actual.
+// Se usa en CheckTx y antes de ejecutarla en FinalizeBlock, de modo que aplicarla después no falle.
+func (app *ABCIApp) checkStakingTx(tx *Transaction) (*StakingMsg, error) {
+	if app.validators == nil {
+		return nil, fmt.Errorf("módulo de staking no disponible")
+	}
+
+	msg, err := ParseStakingMsg(tx.Data)
+	if err != nil {
+		return nil, err
+	}
+
+	value := new(big.Int)
+	if tx.Value != "" {
+		if _, ok := value.SetString(tx.Value, 10); !ok || value.Sign() < 0 {
+			return nil, fmt.Errorf("valor inválido: %s", tx.Value)
+		}
+	}
+	if msg.Commission != nil && *msg.Commission > 100 {
+		return nil, fmt.Errorf("comisión inválida: %d", *msg.Commission)
+	}
+
+	vs := app.validators
+	address := common.HexToAddress(tx.From).Hex()
+
+	// Solo create_validator, stake y delegate bondean tokens
+	if value.Sign() > 0 && msg.Type != StakingCreateValidator && msg.Type != StakingStake && msg.Type != StakingDelegate {
+		return nil, fmt.Errorf("%s no admite valor", msg.Type)
+	}
+
+	// En las operaciones de delegación el validador es el del payload
+	if isDelegationMsg(msg.Type) {
+		if !common.IsHexAddress(msg.Validator) {
+			return nil, fmt.Errorf("dirección de validador inválida: %s", msg.Validator)
+		}
+		address = common.HexToAddress(msg.Validator).Hex()
+	}
+	validator, _ := vs.GetValidator(address)
+	if msg.Type != StakingCreateValidator && msg.Type != StakingWithdrawRewards && validator == nil {
+		return nil, fmt.Errorf("validador no encontrado: %s", address)
+	}
+	delegator := common.HexToAddress(tx.From).Hex()
+
+	switch msg.Type {
+	case StakingCreateValidator:
+		if validator != nil {
+			return nil, fmt.Errorf("validador ya está registrado: %s", address)
+		}
+		if len(msg.PubKey) != ed25519.PubKeySize {
+			return nil, fmt.Errorf("clave pública inválida: se esperan %d bytes, tiene %d", ed25519.PubKeySize, len(msg.PubKey))
+		}
+		if _, err := vs.GetValidatorByConsensusAddress(ed25519.PubKey(msg.PubKey).Address()); err == nil {
+			return nil, fmt.Errorf("clave pública ya usada por otro validador")
+		}
+		// El operador (remitente) firma la clave de consenso y la clave de consenso firma al operador:
+		// así nadie puede registrar la clave de consenso de otro
+		if err := cryptosigner.VerifyValidatorKeyProof(app.chainID, common.HexToAddress(address), msg.PubKey, msg.KeyProof); err != nil {
+			return nil, err
+		}
+		if err := cryptosigner.VerifyConsensusKeyProof(app.chainID, common.HexToAddress(address), msg.PubKey, msg.ConsensusProof); err != nil {
+			return nil, err
+		}
+		if value.Cmp(vs.minStake) < 0 {
+			return nil, fmt.Errorf("stake insuficiente: requiere mínimo %s, tiene %s", vs.minStake.String(), value.String())
+		}
+		if lowest := vs.lowestStakeIfFull(); lowest != nil && value.Cmp(lowest.Stake) <= 0 {
+			return nil, fmt.Errorf("número máximo de validadores alcanzado y stake insuficiente para reemplazar al validador con menor stake")
+		}
+
+	case StakingStake:
+		if value.Sign() == 0 {
+			return nil, fmt.Errorf("stake sin valor")
+		}
+		if validator.Jailed {
+			return nil, fmt.Errorf("validador está en jail: %s", address)
+		}
+
+	case StakingUnstake:
+		amount, ok := new(big.Int).SetString(msg.Amount, 10)
+		if !ok || amount.Sign() <= 0 {
+			return nil, fmt.Errorf("monto de unstake inválido: %s", msg.Amount)
+		}
+		if validator.Jailed {
+			return nil, fmt.Errorf("validador está en jail: %s", address)
+		}
+		remaining := new(big.Int).Sub(vs.SelfStake(address), amount)
+		if remaining.Sign() < 0 || remaining.Sign() > 0 && remaining.Cmp(vs.minStake) < 0 {
+			return nil, fmt.Errorf("no se puede unstake %s: quedaría con %s, requiere mínimo %s o cero", amount.String(), remaining.String(), vs.minStake.String())
+		}
+
+	case StakingUnjail:
+		if !validator.Jailed {
+			return nil, fmt.Errorf("validador no está en jail: %s", address)
+		}
+		if validator.Tombstoned {
+			return nil, fmt.Errorf("validador está tombstoned: %s", address)
+		}
+		if app.blockTime().Before(validator.JailedUntil) {
+			return nil, fmt.Errorf("validador aún está en jail hasta %s", validator.JailedUntil.Format(time.RFC3339))
+		}
+
+	case StakingEditValidator:
+		if msg.Commission == nil {
+			return nil, fmt.Errorf("edit_validator sin cambios")
+		}
+
+	case StakingDelegate:
+		if value.Sign() == 0 {
+			return nil, fmt.Errorf("delegate sin valor")
+		}
+		if validator.Jailed {
+			return nil, fmt.Errorf("validador está en jail: %s", address)
+		}
+
+	case StakingUndelegate, StakingRedelegate:
+		amount, ok := new(big.Int).SetString(msg.Amount, 10)
+		if !ok || amount.Sign() <= 0 {
+			return nil, fmt.Errorf("monto de %s inválido: %s", msg.Type, msg.Amount)
+		}
+		if delegator == address {
+			return nil, fmt.Errorf("el operador retira su self-stake con unstake")
+		}
+		delegation, err := vs.GetDelegation(delegator, address)
+		if err != nil {
+			return nil, err
+		}
+		if delegation.Tokens.Cmp(amount) < 0 {
+			return nil, fmt.Errorf("delegación insuficiente: tiene %s, retira %s", delegation.Tokens.String(), amount.String())
+		}
+		if msg.Type == StakingRedelegate {
+			if !common.IsHexAddress(msg.DstValidator) {
+				return nil, fmt.Errorf("dirección de validador destino inválida: %s", msg.DstValidator)
+			}
+			dst, err := vs.GetValidator(common.HexToAddress(msg.DstValidator).Hex())
+			if err != nil {
+				return nil, err
+			}
+			if dst.Address == address {
+				return nil, fmt.Errorf("validador origen y destino son el mismo")
+			}
+			if dst.Jailed {
+				return nil, fmt.Errorf("validador destino está en jail: %s", dst.Address)
+			}
+		}
+
+	case StakingWithdrawRewards:
+		if vs.PendingRewards(delegator, address).Sign() == 0 {
+			return nil, fmt.Errorf("sin rewards para retirar de %s", address)
+		}
+
+	default:
+		return nil, fmt.Errorf("tipo de staking desconocido: %s", msg.Type)
+	}
+
+	return msg, nil
+}
+
+// applyStakingTx aplica una transacción de staking validada cuya transferencia a StakingModuleAddress
+// ya ejecutó la EVM. Los tokens retirados quedan en el módulo hasta que madura su unbonding. Retorna los eventos ABCI.
+func (app *ABCIApp) applyStakingTx(tx *Transaction, msg *StakingMsg) ([]abcitypes.Event, error) {
+	vs := app.validators
+	address := common.HexToAddress(tx.From).Hex()
+
+	value := new(big.Int)
+	if tx.Value != "" {
+		value.SetString(tx.Value, 10)
+	}
+	amount := value
+	var unbonding *UnbondingEntry
+
+	// En las operaciones de delegación el remitente es el delegador
+	delegator := address
+	if isDelegationMsg(msg.Type) {
+		address = common.HexToAddress(msg.Validator).Hex()
+	}
+
+	switch msg.Type {
+	case StakingCreateValidator:
+		// Si el set está lleno el validador con menor stake es reemplazado y sus delegaciones entran en unbonding
+		if _, err := vs.RegisterValidator(address, msg.PubKey, value); err != nil {
+			return nil, err
+		}
+		if msg.Commission != nil {
+			if err := vs.SetCommission(address, *msg.Commission); err != nil {
+				return nil, err
+			}
+		}
+
+	case StakingStake:
+		if err := vs.Stake(address, value); err != nil {
+			return nil, err
+		}
+
+	case StakingUnstake:
+		amount, _ = new(big.Int).SetString(msg.Amount, 10)
+		if err := vs.Unstake(address, amount); err != nil {
+			return nil, err
+		}
+		// Los tokens quedan en el módulo hasta que madura el unbonding
+		unbonding = vs.BeginUnbonding(address, address, amount, app.currentBlockHeight, app.blockTime())
+
+	case StakingUnjail:
+		if err := vs.Unjail(address); err != nil {
+			return nil, err
+		}
+
+	case StakingEditValidator:
+		if err := vs.SetCommission(address, *msg.Commission); err != nil {
+			return nil, err
+		}
+
+	case StakingDelegate:
+		if err := vs.Delegate(delegator, address, value); err != nil {
+			return nil, err
+		}
+
+	case StakingUndelegate:
+		amount, _ = new(big.Int).SetString(msg.Amount, 10)
+		if err := vs.Undelegate(delegator, address, amount); err != nil {
+			return nil, err
+		}
+		unbonding = vs.BeginUnbonding(delegator, address, amount, app.currentBlockHeight, app.blockTime())
+
+	case StakingRedelegate:
+		amount, _ = new(big.Int).SetString(msg.Amount, 10)
+		if err := vs.Redelegate(delegator, address, common.HexToAddress(msg.DstValidator).Hex(), amount); err != nil {
+			return nil, err
+		}
+
+	case StakingWithdrawRewards:
+		rewards, err := vs.WithdrawRewards(delegator, address)
+		if err != nil {
+			return nil, err
+		}
+		if err := app.executor.Transfer(DistributionModuleAddress, common.HexToAddress(delegator), rewards); err != nil {
+			return nil, fmt.Errorf("error pagando rewards: %w", err)
+		}
+		amount = rewards
+	}
+
+	attributes := []abcitypes.EventAttribute{
+		{Key: "action", Value: msg.Type, Index: true},
+		{Key: "validator", Value: address, Index: true},
+		{Key: "amount", Value: amount.String()},
+	}
+	if isDelegationMsg(msg.Type) {
+		attributes = append(attributes, abcitypes.EventAttribute{Key: "delegator", Value: delegator, Index: true})
+	}
+	if msg.Type == StakingRedelegate {
+		attributes = append(attributes, abcitypes.EventAttribute{Key: "dst_validator", Value: common.HexToAddress(msg.DstValidator).Hex()})
+	}
+	if msg.Commission != nil {
+		attributes = append(attributes, abcitypes.EventAttribute{Key: "commission", Value: fmt.Sprintf("%d", *msg.Commission)})
+	}
+	if unbonding != nil {
+		attributes = append(attributes,
+			abcitypes.EventAttribute{Key: "completion_height", Value: fmt.Sprintf("%d", unbonding.CompletionHeight)},
+			abcitypes.EventAttribute{Key: "completion_time", Value: unbonding.CompletionTime.Format(time.RFC3339)},
+		)
+	}
+
+	return []abcitypes.Event{{Type: "staking", Attributes: attributes}}, nil
+}
+
+// releaseMaturedUnbondings libera desde el módulo de staking los retiros que maduraron en el bloque
+func (app *ABCIApp) releaseMaturedUnbondings() []abcitypes.Event {
+	if app.validators == nil {
+		return nil
+	}
+
+	var events []abcitypes.Event
+	for _, entry := range app.validators.MatureUnbondings(app.currentBlockHeight, app.blockTime()) {
+		if err := app.executor.Transfer(StakingModuleAddress, common.HexToAddress(entry.Address), entry.Amount); err != nil {
+			fmt.Fprintf(os.Stderr, "[ABCI] ERROR liberando unbonding %d: %v\n", entry.ID, err)
+			os.Stderr.Sync()
+			logger.Error(fmt.Sprintf("Error liberando unbonding %d: %v", entry.ID, err))
+			continue
+		}
+		events = append(events, abcitypes.Event{
+			Type: "unbonding_completed",
+			Attributes: []abcitypes.EventAttribute{
+				{Key: "address", Value: entry.Address, Index: true},
+				{Key: "validator", Value: entry.Validator},
+				{Key: "amount", Value: entry.Amount.String()},
+			},
+		})
+	}
+	return events
+}
+
+// blockTime retorna la hora del bloque en ejecución
+func (app *ABCIApp) blockTime() time.Time {
+	return time.Unix(app.currentBlockTime, 0).UTC()
+}
+
+// lowestStakeIfFull retorna el validador con menor stake si el set alcanzó el máximo (nil si hay lugar)
+func (vs *ValidatorSet) lowestStakeIfFull() *Validator {
+	vs.mutex.RLock()
+	defer vs.mutex.RUnlock()
+
+	if len(vs.validators) < vs.maxValidators {
+		return nil
+	}
+	return vs.findLowestStakeValidator()
+}
+
+// diffValidatorUpdates retorna los cambios entre dos sets de validadores en formato CometBFT:
+// validadores nuevos o con power distinto, y power 0 para los que salieron del set
+func diffValidatorUpdates(before, after []abcitypes.ValidatorUpdate) []abcitypes.ValidatorUpdate {
+	previous := make(map[string]int64, len(before))
+	for _, v := range before {
+		previous[string(v.PubKeyBytes)] = v.Power
+	}
+
+	updates := make([]abcitypes.ValidatorUpdate, 0)
+	current := make(map[string]bool, len(after))
+	for _, v := range after {
+		current[string(v.PubKeyBytes)] = true
+		if power, exists := previous[string(v.PubKeyBytes)]; !exists || power != v.Power {
+			updates = append(updates, v)
+		}
+	}
+	for _, v := range before {
+		if !current[string(v.PubKeyBytes)] {
+			updates = append(updates, abcitypes.ValidatorUpdate{PubKeyBytes: v.PubKeyBytes, Power: 0})
+		}
+	}
+
+	sort.Slice(updates, func(i, j int) bool {
+		return bytes.Compare(updates[i].PubKeyBytes, updates[j].PubKeyBytes) < 0
+	})
+	return updates
+}
+
+// consensusValidator es un validador del set de CometBFT: clave pública ed25519 y poder de voto
+type consensusValidator struct {
+	PubKey []byte `json:"pub_key"`
+	Power  int64  `json:"power"`
+}
+
+// saveConsensusValidators guarda el set de CometBFT vigente en height, ordenado por clave pública
+func (app *ABCIApp) saveConsensusValidators(height int64, set []consensusValidator) error {
+	sort.Slice(set, func(i, j int) bool {
+		return bytes.Compare(set[i].PubKey, set[j].PubKey) < 0
+	})
+	setData, err := json.Marshal(set)
+	if err != nil {
+		return err
+	}
+	if err := app.storage.SaveConsensusValidators(uint64(height), setData); err != nil {
+		return fmt.Errorf("error guardando set de validadores de la altura %d: %w", height, err)
+	}
+	return nil
+}
+
+// loadConsensusValidators obtiene el set de CometBFT vigente en height (leveldb.ErrNotFound si no está)
+func (app *ABCIApp) loadConsensusValidators(height int64) ([]consensusValidator, error) {
+	setData, err := app.storage.GetConsensusValidators(uint64(height))
+	if err != nil {
+		return nil, err
+	}
+	var set []consensusValidator
+	if err := json.Unmarshal(setData, &set); err != nil {
+		return nil, fmt.Errorf("set de validadores de la altura %d corrupto: %w", height, err)
+	}
+	return set, nil
+}
+
+// toConsensusValidators convierte validadores en formato CometBFT, descartando los de power 0
+func toConsensusValidators(updates []abcitypes.ValidatorUpdate) []consensusValidator {
+	set := make([]consensusValidator, 0, len(updates))
+	for _, v := range updates {
+		if v.Power > 0 {
+			set = append(set, consensusValidator{PubKey: v.PubKeyBytes, Power: v.Power})
+		}
+	}
+	return set
+}
+
+// trackConsensusValidators registra el set que CometBFT usará en height+2: el de height+1 con las
+// actualizaciones que devuelve el bloque height (power 0 = sale del set). El set de height-1 se borra:
+// el bloque siguiente verifica las extensiones de voto contra el de height.
+// current es el set al inicio del bloque; se usa si el de height+1 no está guardado (cadenas iniciadas
+// antes de este registro).
+func (app *ABCIApp) trackConsensusValidators(height int64, updates, current []abcitypes.ValidatorUpdate) error {
+	next, err := app.loadConsensusValidators(height + 1)
+	if err == leveldb.ErrNotFound {
+		logger.Warn(fmt.Sprintf("Set de validadores de la altura %d no guardado, usando el set actual", height+1))
+		next = toConsensusValidators(current)
+		if _, err := app.storage.GetConsensusValidators(uint64(height)); err == leveldb.ErrNotFound {
+			if err := app.saveConsensusValidators(height, toConsensusValidators(current)); err != nil {
+				return err
+			}
+		}
+		if err := app.saveConsensusValidators(height+1, toConsensusValidators(current)); err != nil {
+			return err
+		}
+	} else if err != nil {
+		return fmt.Errorf("error leyendo set de validadores de la altura %d: %w", height+1, err)
+	}
+
+	power := make(map[string]int64, len(next))
+	for _, v := range next {
+		power[string(v.PubKey)] = v.Power
+	}
+	for _, v := range updates {
+		power[string(v.PubKeyBytes)] = v.Power
+	}
+	set := make([]consensusValidator, 0, len(power))
+	for pubKey, p := range power {
+		if p > 0 {
+			set = append(set, consensusValidator{PubKey: []byte(pubKey), Power: p})
+		}
+	}
+	if err := app.saveConsensusValidators(height+2, set); err != nil {
+		return err
+	}
+
+	if err := app.storage.DeleteConsensusValidators(uint64(height - 1)); err != nil {
+		return fmt.Errorf("error borrando set de validadores de la altura %d: %w", height-1, err)
+	}
+	return nil
+}
@@ -0,0 +1,215 @@
+package consensus
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"testing"
+	"time"
+
+	cryptosigner "github.com/Q-YZX0/oxy-blockchain/internal/crypto"
+	execution "github.com/Q-YZX0/oxy-blockchain/internal/execution"
+	"github.com/Q-YZX0/oxy-blockchain/internal/storage"
+	abcitypes "github.com/cometbft/cometbft/abci/types"
+	"github.com/cometbft/cometbft/crypto/ed25519"
+	"github.com/ethereum/go-ethereum/common"
+	ethcrypto "github.com/ethereum/go-ethereum/crypto"
+)
+
+// TestABCIApp_StakingTransactions verifica que create_validator, stake, edit_validator y unstake se
+// aplican en FinalizeBlock moviendo tokens hacia y desde el módulo de staking (con unbonding) y actualizan el set de CometBFT
+func TestABCIApp_StakingTransactions(t *testing.T) {
+	ctx := context.Background()
+
+	testDir := createTestDir("staking_txs")
+	defer func() {
+		if err := cleanupTestDir(testDir); err != nil {
+			t.Logf("Advertencia: error limpiando directorio: %v", err)
+		}
+	}()
+
+	db, err := storage.NewBlockchainDB(testDir)
+	if err != nil {
+		t.Fatalf("Error creando storage: %v", err)
+	}
+	defer db.Close()
+
+	evm := execution.NewEVMExecutor(db)
+	if err := evm.Start(); err != nil {
+		t.Fatalf("Error iniciando EVM: %v", err)
+	}
+	defer evm.Stop()
+
+	oneOXG := big.NewInt(1e18)
+	validators := NewValidatorSet(db, evm, oneOXG, 10)
+	app := NewABCIApp(db, evm, validators, "test-chain")
+	if _, err := app.InitChain(ctx, &abcitypes.InitChainRequest{
+		ChainId:       "test-chain",
+		AppStateBytes: []byte(`{"staking":{"unbonding_blocks":2}}`),
+	}); err != nil {
+		t.Fatalf("Error en InitChain: %v", err)
+	}
+
+	operatorKey, _ := ethcrypto.GenerateKey()
+	operator := ethcrypto.PubkeyToAddress(operatorKey.PublicKey).Hex()
+	if err := evm.FundAccount(operator, "10000000000000000000"); err != nil {
+		t.Fatalf("Error fondeando cuenta: %v", err)
+	}
+	consensusKey := ed25519.GenPrivKey()
+	pubKey := consensusKey.PubKey().Bytes()
+	keyProof, err := cryptosigner.SignValidatorKeyProof("test-chain", pubKey, operatorKey)
+	if err != nil {
+		t.Fatalf("Error firmando prueba de posesión: %v", err)
+	}
+	consensusProof := cryptosigner.SignConsensusKeyProof("test-chain", common.HexToAddress(operator), consensusKey.Bytes())
+
+	txCount := 0
+	stakingTx := func(nonce uint64, value *big.Int, msg string) []byte {
+		txCount++
+		txData, _ := json.Marshal(Transaction{
+			Hash:     "0x" + fmt.Sprintf("%064x", txCount),
+			From:     operator,
+			To:       StakingModuleAddress.Hex(),
+			Value:    value.String(),
+			Data:     []byte(msg),
+			GasLimit: 100000,
+			GasPrice: "1000000000",
+			Nonce:    nonce,
+		})
+		return txData
+	}
+	height := int64(0)
+	finalize := func(txs ...[]byte) *abcitypes.FinalizeBlockResponse {
+		height++
+		resp, err := app.FinalizeBlock(ctx, &abcitypes.FinalizeBlockRequest{
+			Height: height,
+			Time:   time.Unix(1700000000+height, 0),
+			Txs:    txs,
+		})
+		if err != nil {
+			t.Fatalf("Error en FinalizeBlock: %v", err)
+		}
+		if _, err := app.Commit(ctx, &abcitypes.CommitRequest{}); err != nil {
+			t.Fatalf("Error en Commit: %v", err)
+		}
+		return resp
+	}
+
+	// Sin prueba de posesión, con una firmada por otra cuenta o sin la firma de la propia clave de
+	// consenso, la clave no se vincula
+	stake := new(big.Int).Mul(oneOXG, big.NewInt(2))
+	otherKey, _ := ethcrypto.GenerateKey()
+	otherProof, _ := cryptosigner.SignValidatorKeyProof("test-chain", pubKey, otherKey)
+	otherConsensusProof := cryptosigner.SignConsensusKeyProof("test-chain", common.HexToAddress(operator), ed25519.GenPrivKey().Bytes())
+	for _, proof := range []string{
+		"",
+		fmt.Sprintf(`,"keyProof":"0x%x","consensusProof":"0x%x"`, otherProof, consensusProof),
+		fmt.Sprintf(`,"keyProof":"0x%x"`, keyProof),
+		fmt.Sprintf(`,"keyProof":"0x%x","consensusProof":"0x%x"`, keyProof, otherConsensusProof),
+	} {
+		resp := finalize(stakingTx(0, stake, fmt.Sprintf(`{"type":"create_validator","pubKey":"0x%x"%s}`, pubKey, proof)))
+		if resp.TxResults[0].Code == 0 {
+			t.Fatalf("create_validator sin prueba de posesión válida debería ser rechazado")
+		}
+	}
+
+	// create_validator con 2 OXG y comisión del 5%
+	create := stakingTx(0, stake, fmt.Sprintf(`{"type":"create_validator","pubKey":"0x%x","keyProof":"0x%x","consensusProof":"0x%x","commission":5}`, pubKey, keyProof, consensusProof))
+	resp := finalize(create)
+	if resp.TxResults[0].Code != 0 {
+		t.Fatalf("create_validator falló: %s", resp.TxResults[0].Log)
+	}
+	validator, err := validators.GetValidator(operator)
+	if err != nil {
+		t.Fatalf("Validador no registrado: %v", err)
+	}
+	if validator.Stake.Cmp(stake) != 0 || validator.Commission != 5 {
+		t.Errorf("Validador incorrecto: stake=%s, comisión=%d", validator.Stake, validator.Commission)
+	}
+	if balance, _ := evm.GetBalance(StakingModuleAddress.Hex()); balance.Cmp(stake) != 0 {
+		t.Errorf("Balance del módulo de staking incorrecto: %s", balance)
+	}
+	if len(resp.ValidatorUpdates) != 1 || resp.ValidatorUpdates[0].Power != 2 {
+		t.Errorf("ValidatorUpdates incorrectos: %+v", resp.ValidatorUpdates)
+	}
+	stakingEvent := false
+	for _, event := range resp.TxResults[0].Events {
+		stakingEvent = stakingEvent || event.Type == "staking"
+	}
+	if !stakingEvent {
+		t.Error("Falta el evento de staking")
+	}
+
+	// Un segundo create_validator del mismo operador es rechazado sin ejecutarse
+	if resp := finalize(stakingTx(1, stake, fmt.Sprintf(`{"type":"create_validator","pubKey":"0x%x","keyProof":"0x%x","consensusProof":"0x%x"}`, pubKey, keyProof, consensusProof))); resp.TxResults[0].Code != 5 {
+		t.Errorf("create_validator duplicado debería ser rechazado: code=%d", resp.TxResults[0].Code)
+	}
+
+	// stake suma 1 OXG y edit_validator cambia la comisión
+	resp = finalize(
+		stakingTx(1, oneOXG, `{"type":"stake"}`),
+		stakingTx(2, new(big.Int), `{"type":"edit_validator","commission":20}`),
+	)
+	for i, result := range resp.TxResults {
+		if result.Code != 0 {
+			t.Fatalf("Transacción de staking %d falló: %s", i, result.Log)
+		}
+	}
+	if validator.Power != 3 || validator.Commission != 20 {
+		t.Errorf("Validador tras stake/edit incorrecto: power=%d, comisión=%d", validator.Power, validator.Commission)
+	}
+	if len(resp.ValidatorUpdates) != 1 || resp.ValidatorUpdates[0].Power != 3 {
+		t.Errorf("ValidatorUpdates tras stake incorrectos: %+v", resp.ValidatorUpdates)
+	}
+
+	// unstake no admite valor ni dejar el stake por debajo del mínimo
+	if resp := finalize(stakingTx(3, oneOXG, `{"type":"unstake","amount":"1"}`)); resp.TxResults[0].Code != 5 {
+		t.Errorf("unstake con valor debería ser rechazado: code=%d", resp.TxResults[0].Code)
+	}
+	if resp := finalize(stakingTx(3, new(big.Int), `{"type":"unstake","amount":"2500000000000000000"}`)); resp.TxResults[0].Code != 5 {
+		t.Errorf("unstake bajo el mínimo debería ser rechazado: code=%d", resp.TxResults[0].Code)
+	}
+
+	// Retirar todo el stake saca al validador del set; los tokens quedan en unbonding
+	before, _ := evm.GetBalance(operator)
+	resp = finalize(stakingTx(3, new(big.Int), `{"type":"unstake","amount":"3000000000000000000"}`))
+	if resp.TxResults[0].Code != 0 {
+		t.Fatalf("unstake falló: %s", resp.TxResults[0].Log)
+	}
+	if _, err := validators.GetValidator(operator); err == nil {
+		t.Error("El validador debería salir del set")
+	}
+	if len(resp.ValidatorUpdates) != 1 || resp.ValidatorUpdates[0].Power != 0 {
+		t.Errorf("ValidatorUpdates tras salida incorrectos: %+v", resp.ValidatorUpdates)
+	}
+	unbondings := validators.GetUnbondings(operator)
+	if len(unbondings) != 1 || unbondings[0].CompletionHeight != uint64(height)+2 {
+		t.Fatalf("Unbonding incorrecto: %+v", unbondings)
+	}
+	gasCost := new(big.Int).Mul(big.NewInt(int64(resp.TxResults[0].GasUsed)), big.NewInt(1000000000))
+	if after, _ := evm.GetBalance(operator); after.Cmp(new(big.Int).Sub(before, gasCost)) != 0 {
+		t.Errorf("El unstake no debería liberar tokens antes de madurar: %s", after)
+	}
+
+	// Madura dos bloques después y se libera automáticamente
+	finalize()
+	if len(validators.GetUnbondings(operator)) != 1 {
+		t.Fatal("El unbonding no debería madurar antes de tiempo")
+	}
+	resp = finalize()
+	released := false
+	for _, event := range resp.Events {
+		released = released || event.Type == "unbonding_completed"
+	}
+	if !released || len(validators.GetUnbondings(operator)) != 0 {
+		t.Error("El unbonding debería liberarse al madurar")
+	}
+	expected := new(big.Int).Sub(new(big.Int).Add(before, new(big.Int).Mul(oneOXG, big.NewInt(3))), gasCost)
+	if after, _ := evm.GetBalance(operator); after.Cmp(expected) != 0 {
+		t.Errorf("Balance del operador tras unbonding incorrecto: %s, esperado %s", after, expected)
+	}
+	if balance, _ := evm.GetBalance(StakingModuleAddress.Hex()); balance.Sign() != 0 {
+		t.Errorf("El módulo de staking debería quedar vacío: %s", balance)
+	}
+}
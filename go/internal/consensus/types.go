@@ -3,16 +3,21 @@ package consensus
 import (
 	"encoding/json"
 	"time"
+
+	"github.com/ethereum/go-ethereum/core/types"
 )
 
 // BlockHeader representa el header de un bloque
 type BlockHeader struct {
 	Height     uint64
-	Hash       string
+	Hash       string // Hash del header EVM
 	ParentHash string
 	Timestamp  time.Time
-	Validator  string
+	Validator  string // Dirección EVM del proponente (coinbase)
 	ChainID    string
+	StateRoot  string // Root del estado EVM (AppHash)
+	BaseFee    string // Base fee EIP-1559 del bloque (wei, decimal)
+	GasLimit   uint64 // Gas límite por bloque (parámetro de consenso)
 }
 
 // Block representa un bloque completo en la blockchain
@@ -34,17 +39,31 @@ type Transaction struct {
 	Nonce       uint64
 	Signature   []byte // Firma de la transacción
 	Timestamp   int64
+	RawTx       []byte `json:",omitempty"` // Transacción Ethereum firmada original (eth_sendRawTransaction)
+
+	// Campos EIP-1559 (wei, decimal). Vacíos = transacción legacy que paga GasPrice.
+	MaxFeePerGas         string `json:",omitempty"`
+	MaxPriorityFeePerGas string `json:",omitempty"`
+
+	// Access list EIP-2930 de la transacción firmada (vacía si no declara ninguna)
+	AccessList types.AccessList `json:",omitempty"`
 }
 
 // TransactionReceipt representa el recibo de una transacción
 type TransactionReceipt struct {
-	TransactionHash string
-	BlockHash       string
-	BlockNumber     uint64
-	GasUsed         uint64
-	Status          string // "success" o "failed"
-	Logs            []Log
-	Error           string
+	TransactionHash   string
+	TransactionIndex  uint
+	BlockHash         string
+	BlockNumber       uint64
+	GasUsed           uint64
+	CumulativeGasUsed uint64 // Gas usado en el bloque hasta esta transacción (inclusive)
+	EffectiveGasPrice string // Precio por gas cobrado (wei, decimal)
+	Status            string // "success" o "failed"
+	Logs              []Log
+	LogsBloom         string // Bloom de los logs (hex, 256 bytes)
+	Error             string
+	RevertReason      string // Motivo decodificado de Error(string)/Panic(uint256) si la ejecución revirtió
+	ContractAddress   string // Dirección del contrato creado (solo deployments)
 }
 
 // Log representa un evento emitido por un contrato
@@ -53,7 +72,10 @@ type Log struct {
 	Topics      []string
 	Data        []byte
 	BlockNumber uint64
+	BlockHash   string
 	TxHash      string
+	TxIndex     uint
+	LogIndex    uint // Posición del log dentro del bloque
 }
 
 // MarshalJSON implementa json.Marshaler para Block
@@ -0,0 +1,169 @@
+package consensus
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"strings"
+	"time"
+)
+
+// DefaultUnbondingBlocks es el período de unbonding por defecto (~7 días con bloques de 5 segundos)
+const DefaultUnbondingBlocks uint64 = 120960
+
+// UnbondingEntry es un retiro de stake pendiente. Sigue siendo slasheable hasta que madura y
+// se libera a Address en FinalizeBlock.
+type UnbondingEntry struct {
+	ID               uint64    `json:"id"`
+	Address          string    `json:"address"`   // Dueño de los tokens
+	Validator        string    `json:"validator"` // Validador del que se retiró el stake
+	Amount           *big.Int  `json:"amount"`
+	CreationHeight   uint64    `json:"creationHeight"`
+	CompletionHeight uint64    `json:"completionHeight"`
+	CompletionTime   time.Time `json:"completionTime"`
+}
+
+// unbondingState es el formato persistido de la cola de unbonding
+type unbondingState struct {
+	NextID  uint64            `json:"nextId"`
+	Entries []*UnbondingEntry `json:"entries"`
+}
+
+// SetUnbondingPeriod establece el período de unbonding. Un retiro madura cuando se cumplen
+// las dos condiciones configuradas; sin ninguna se usa DefaultUnbondingBlocks.
+func (vs *ValidatorSet) SetUnbondingPeriod(blocks uint64, duration time.Duration) {
+	vs.mutex.Lock()
+	defer vs.mutex.Unlock()
+
+	if blocks == 0 && duration <= 0 {
+		blocks = DefaultUnbondingBlocks
+	}
+	vs.unbondingBlocks = blocks
+	vs.unbondingTime = duration
+}
+
+// BeginUnbonding encola un retiro de stake que madura tras el período de unbonding
+func (vs *ValidatorSet) BeginUnbonding(address, validator string, amount *big.Int, height uint64, blockTime time.Time) *UnbondingEntry {
+	vs.mutex.Lock()
+	defer vs.mutex.Unlock()
+
+	return vs.beginUnbondingLocked(address, validator, amount, height, blockTime)
+}
+
+// beginUnbondingLocked encola un retiro de stake. Asume que el llamador tiene el mutex.
+func (vs *ValidatorSet) beginUnbondingLocked(address, validator string, amount *big.Int, height uint64, blockTime time.Time) *UnbondingEntry {
+	vs.nextUnbondingID++
+	entry := &UnbondingEntry{
+		ID:               vs.nextUnbondingID,
+		Address:          address,
+		Validator:        validator,
+		Amount:           new(big.Int).Set(amount),
+		CreationHeight:   height,
+		CompletionHeight: height + vs.unbondingBlocks,
+		CompletionTime:   blockTime.Add(vs.unbondingTime).UTC(),
+	}
+	vs.unbondings = append(vs.unbondings, entry)
+
+	log.Printf("⏳ Unbonding de %s para %s: madura en altura %d / %s", amount.String(), address, entry.CompletionHeight, entry.CompletionTime.Format(time.RFC3339))
+
+	if err := vs.saveUnbondingsLocked(); err != nil {
+		log.Printf("Advertencia: error guardando unbondings: %v", err)
+	}
+	return entry
+}
+
+// MatureUnbondings quita de la cola y retorna los retiros maduros en la altura y hora de bloque dadas,
+// en orden de creación
+func (vs *ValidatorSet) MatureUnbondings(height uint64, blockTime time.Time) []*UnbondingEntry {
+	vs.mutex.Lock()
+	defer vs.mutex.Unlock()
+
+	var matured []*UnbondingEntry
+	pending := vs.unbondings[:0]
+	for _, entry := range vs.unbondings {
+		if height >= entry.CompletionHeight && !blockTime.Before(entry.CompletionTime) {
+			matured = append(matured, entry)
+		} else {
+			pending = append(pending, entry)
+		}
+	}
+	if len(matured) == 0 {
+		return nil
+	}
+	vs.unbondings = pending
+
+	if err := vs.saveUnbondingsLocked(); err != nil {
+		log.Printf("Advertencia: error guardando unbondings: %v", err)
+	}
+	return matured
+}
+
+// GetUnbondings retorna los retiros pendientes de una dirección
+func (vs *ValidatorSet) GetUnbondings(address string) []*UnbondingEntry {
+	vs.mutex.RLock()
+	defer vs.mutex.RUnlock()
+
+	entries := make([]*UnbondingEntry, 0)
+	for _, entry := range vs.unbondings {
+		if strings.EqualFold(entry.Address, address) {
+			copied := *entry
+			copied.Amount = new(big.Int).Set(entry.Amount)
+			entries = append(entries, &copied)
+		}
+	}
+	return entries
+}
+
+// slashUnbondingsLocked reduce en slashPercent los retiros pendientes de un validador iniciados desde
+// fromHeight (los anteriores ya no estaban bondeados al cometerse la infracción).
+// Retorna el total descontado. Asume que el llamador tiene el mutex.
+func (vs *ValidatorSet) slashUnbondingsLocked(validator string, slashPercent int, fromHeight uint64) *big.Int {
+	total := new(big.Int)
+	for _, entry := range vs.unbondings {
+		if entry.Validator != validator || entry.CreationHeight < fromHeight {
+			continue
+		}
+		amount := new(big.Int).Mul(entry.Amount, big.NewInt(int64(slashPercent)))
+		amount.Div(amount, big.NewInt(100))
+		entry.Amount.Sub(entry.Amount, amount)
+		total.Add(total, amount)
+	}
+
+	if total.Sign() > 0 {
+		log.Printf("⚠️ Unbondings de %s slasheados: -%s (%%%d)", validator, total.String(), slashPercent)
+		if err := vs.saveUnbondingsLocked(); err != nil {
+			log.Printf("Advertencia: error guardando unbondings: %v", err)
+		}
+	}
+	return total
+}
+
+// loadUnbondingsLocked carga la cola de unbonding desde storage. Asume que el llamador tiene el mutex.
+func (vs *ValidatorSet) loadUnbondingsLocked() error {
+	data, err := vs.storage.GetAccount("validators:unbonding")
+	if err != nil {
+		// Sin cola guardada
+		return nil
+	}
+
+	var state unbondingState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return fmt.Errorf("error parseando unbondings: %w", err)
+	}
+	vs.unbondings = state.Entries
+	vs.nextUnbondingID = state.NextID
+	return nil
+}
+
+// saveUnbondingsLocked guarda la cola de unbonding. Asume que el llamador tiene el mutex.
+func (vs *ValidatorSet) saveUnbondingsLocked() error {
+	data, err := json.Marshal(unbondingState{NextID: vs.nextUnbondingID, Entries: vs.unbondings})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "[Validators] ERROR serializando unbondings: %v\n", err)
+		os.Stderr.Sync()
+		return fmt.Errorf("error serializando unbondings: %w", err)
+	}
+	return vs.storage.SaveAccount("validators:unbonding", data)
+}
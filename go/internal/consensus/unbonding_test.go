@@ -0,0 +1,77 @@
+package consensus
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/Q-YZX0/oxy-blockchain/internal/execution"
+	"github.com/Q-YZX0/oxy-blockchain/internal/storage"
+	"github.com/cometbft/cometbft/crypto/ed25519"
+)
+
+// TestValidatorSet_UnbondingQueue verifica que los retiros maduran por altura y tiempo de bloque,
+// siguen siendo slasheables mientras están pendientes y se persisten con el set
+func TestValidatorSet_UnbondingQueue(t *testing.T) {
+	testDir := createTestDir("unbonding_queue")
+	defer func() {
+		if err := cleanupTestDir(testDir); err != nil {
+			t.Logf("Advertencia: error limpiando directorio: %v", err)
+		}
+	}()
+
+	db, err := storage.NewBlockchainDB(testDir)
+	if err != nil {
+		t.Fatalf("Error creando storage: %v", err)
+	}
+	defer db.Close()
+
+	// Los tests de validators no necesitan el EVM corriendo
+	evm := execution.NewEVMExecutor(db)
+	validatorSet := NewValidatorSet(db, evm, big.NewInt(1000), 10)
+	validatorSet.SetUnbondingPeriod(10, time.Hour)
+
+	address := "0xA000000000000000000000000000000000000001"
+	if _, err := validatorSet.RegisterValidator(address, ed25519.GenPrivKey().PubKey().Bytes(), big.NewInt(5000)); err != nil {
+		t.Fatalf("Error registrando validador: %v", err)
+	}
+	if err := validatorSet.Unstake(address, big.NewInt(2000)); err != nil {
+		t.Fatalf("Error en unstake: %v", err)
+	}
+	start := time.Unix(1700000000, 0)
+	validatorSet.BeginUnbonding(address, address, big.NewInt(2000), 5, start)
+
+	// Un slash del 10% alcanza también al stake en unbonding
+	if err := validatorSet.Slash(address, 10, time.Minute); err != nil {
+		t.Fatalf("Error en slash: %v", err)
+	}
+	entries := validatorSet.GetUnbondings(address)
+	if len(entries) != 1 || entries[0].Amount.Cmp(big.NewInt(1800)) != 0 {
+		t.Fatalf("Unbonding tras slash incorrecto: %+v", entries)
+	}
+
+	// Se requieren ambas condiciones: altura 15 y una hora de tiempo de bloque
+	if matured := validatorSet.MatureUnbondings(15, start.Add(30*time.Minute)); len(matured) != 0 {
+		t.Error("No debería madurar antes del tiempo configurado")
+	}
+	if matured := validatorSet.MatureUnbondings(14, start.Add(2*time.Hour)); len(matured) != 0 {
+		t.Error("No debería madurar antes de la altura configurada")
+	}
+
+	// La cola se restaura desde storage
+	reloaded := NewValidatorSet(db, evm, big.NewInt(1000), 10)
+	if err := reloaded.LoadValidators(); err != nil {
+		t.Fatalf("Error cargando validadores: %v", err)
+	}
+	if entries := reloaded.GetUnbondings(address); len(entries) != 1 || entries[0].Amount.Cmp(big.NewInt(1800)) != 0 {
+		t.Fatalf("Unbonding no restaurado: %+v", entries)
+	}
+
+	matured := reloaded.MatureUnbondings(15, start.Add(time.Hour))
+	if len(matured) != 1 || matured[0].Amount.Cmp(big.NewInt(1800)) != 0 {
+		t.Fatalf("Unbonding maduro incorrecto: %+v", matured)
+	}
+	if entries := reloaded.GetUnbondings(address); len(entries) != 0 {
+		t.Errorf("La cola debería quedar vacía: %+v", entries)
+	}
+}
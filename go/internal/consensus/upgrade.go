@@ -0,0 +1,299 @@
+package consensus
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"sort"
+
+	execution "github.com/Q-YZX0/oxy-blockchain/internal/execution"
+	"github.com/Q-YZX0/oxy-blockchain/internal/logger"
+	"github.com/Q-YZX0/oxy-blockchain/internal/storage"
+	abcitypes "github.com/cometbft/cometbft/abci/types"
+	cmtproto "github.com/cometbft/cometbft/api/cometbft/types/v1"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/syndtr/goleveldb/leveldb"
+)
+
+// Las actualizaciones de software se coordinan en la cadena: los operadores de los validadores aprueban
+// un plan (nombre y altura) con transacciones al módulo de actualizaciones y, cuando los que lo aprueban
+// suman más de 2/3 del poder activo, el plan queda programado. En la altura del plan el binario que no
+// tiene el handler de ese nombre se detiene antes de ejecutar el bloque; el binario nuevo ejecuta las
+// migraciones de estado una sola vez y sube la versión de la aplicación.
+
+// AppSoftwareVersion es la versión de este binario (Info.Version)
+const AppSoftwareVersion = "0.1.0"
+
+// UpgradeModuleAddress recibe las transacciones de aprobación de actualizaciones (transferencias de
+// valor 0 firmadas por el operador de un validador cuyo Data es un UpgradeMsg en JSON)
+var UpgradeModuleAddress = common.HexToAddress("0x0000000000000000000000000000000000000803")
+
+// UpgradeApprove es la operación que aprueba un plan de actualización
+const UpgradeApprove = "approve_upgrade"
+
+// maxUpgradeNameLength limita el nombre de un plan (forma parte de una clave de storage)
+const maxUpgradeNameLength = 64
+
+// ErrUpgradeNeeded indica que el bloque requiere una actualización que este binario no tiene
+var ErrUpgradeNeeded = errors.New("actualización necesaria")
+
+// UpgradePlan es una actualización programada
+type UpgradePlan struct {
+	Name   string `json:"name"`
+	Height uint64 `json:"height"`         // Primer bloque que se ejecuta con el binario nuevo
+	Info   string `json:"info,omitempty"` // Datos para los operadores (ej: URL y checksum del binario)
+}
+
+// UpgradeMsg es el payload de una transacción al módulo de actualizaciones
+type UpgradeMsg struct {
+	Type string      `json:"type"`
+	Plan UpgradePlan `json:"plan"`
+}
+
+// UpgradeContext es lo que recibe un handler para migrar el estado: el layout de claves en storage, los
+// validadores y el estado EVM. Las escrituras entran en el batch del bloque y se confirman con él.
+type UpgradeContext struct {
+	Plan       UpgradePlan
+	Storage    *storage.BlockchainDB
+	Executor   *execution.EVMExecutor
+	Validators *ValidatorSet
+}
+
+// UpgradeHandler ejecuta las migraciones de estado de una actualización
+type UpgradeHandler func(ctx *UpgradeContext) error
+
+// upgradeHandlers son los handlers incluidos en este binario. Cada release que acompaña un plan agrega
+// el suyo con el nombre del plan y conserva el de la última actualización aplicada.
+var upgradeHandlers = map[string]UpgradeHandler{}
+
+// SetUpgradeHandler registra el handler de una actualización
+func (app *ABCIApp) SetUpgradeHandler(name string, handler UpgradeHandler) {
+	app.upgradeHandlers[name] = handler
+	app.upgradesChecked = false
+}
+
+// AppVersion retorna la versión de la aplicación (sube con cada actualización aplicada)
+func (app *ABCIApp) AppVersion() uint64 {
+	return app.appVersion
+}
+
+// IsUpgradeTransaction indica si la transacción está dirigida al módulo de actualizaciones
+func IsUpgradeTransaction(tx *Transaction) bool {
+	return tx.To != "" && common.IsHexAddress(tx.To) && common.HexToAddress(tx.To) == UpgradeModuleAddress
+}
+
+// getUpgradePlan retorna el plan programado (nil si no hay)
+func (app *ABCIApp) getUpgradePlan() (*UpgradePlan, error) {
+	data, err := app.storage.GetUpgradePlan()
+	if err == leveldb.ErrNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var plan UpgradePlan
+	if err := json.Unmarshal(data, &plan); err != nil {
+		return nil, fmt.Errorf("plan de actualización corrupto: %w", err)
+	}
+	return &plan, nil
+}
+
+// getUpgradeApprovals retorna los planes aprobados por cada validador que aún no se programaron
+func (app *ABCIApp) getUpgradeApprovals() (map[string]UpgradePlan, error) {
+	approvals := make(map[string]UpgradePlan)
+	data, err := app.storage.GetUpgradeApprovals()
+	if err == leveldb.ErrNotFound {
+		return approvals, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &approvals); err != nil {
+		return nil, fmt.Errorf("aprobaciones de actualización corruptas: %w", err)
+	}
+	return approvals, nil
+}
+
+// checkUpgradeTx decodifica y valida una transacción de aprobación de actualización. Se usa en CheckTx
+// y antes de ejecutarla en FinalizeBlock.
+func (app *ABCIApp) checkUpgradeTx(tx *Transaction) (*UpgradeMsg, error) {
+	if app.validators == nil {
+		return nil, fmt.Errorf("módulo de actualizaciones no disponible")
+	}
+
+	var msg UpgradeMsg
+	if err := json.Unmarshal(tx.Data, &msg); err != nil {
+		return nil, fmt.Errorf("payload de actualización inválido: %w", err)
+	}
+	if msg.Type != UpgradeApprove {
+		return nil, fmt.Errorf("tipo de operación de actualización desconocido: %s", msg.Type)
+	}
+	if tx.Value != "" {
+		if value, ok := new(big.Int).SetString(tx.Value, 10); !ok || value.Sign() != 0 {
+			return nil, fmt.Errorf("la aprobación de actualización no admite valor")
+		}
+	}
+
+	plan := msg.Plan
+	if plan.Name == "" || len(plan.Name) > maxUpgradeNameLength {
+		return nil, fmt.Errorf("nombre de actualización inválido: %q", plan.Name)
+	}
+	if plan.Height <= app.currentBlockHeight {
+		return nil, fmt.Errorf("altura de actualización %d no es futura (altura actual %d)", plan.Height, app.currentBlockHeight)
+	}
+	done, err := app.storage.GetUpgradesDone()
+	if err != nil {
+		return nil, err
+	}
+	if height, ok := done[plan.Name]; ok {
+		return nil, fmt.Errorf("actualización %s ya aplicada en altura %d", plan.Name, height)
+	}
+
+	// Solo aprueban los operadores de validadores activos
+	validator, err := app.validators.GetValidator(common.HexToAddress(tx.From).Hex())
+	if err != nil || validator.Jailed || validator.Power <= 0 {
+		return nil, fmt.Errorf("%s no es operador de un validador activo", tx.From)
+	}
+	return &msg, nil
+}
+
+// applyUpgradeTx registra la aprobación del remitente y programa el plan si los validadores que lo
+// aprueban suman más de 2/3 del poder activo
+func (app *ABCIApp) applyUpgradeTx(tx *Transaction, msg *UpgradeMsg) ([]abcitypes.Event, error) {
+	approvals, err := app.getUpgradeApprovals()
+	if err != nil {
+		return nil, err
+	}
+	approvals[common.HexToAddress(tx.From).Hex()] = msg.Plan
+
+	// Poder que aprueba exactamente este plan
+	var totalPower, approvedPower int64
+	for _, v := range app.validators.GetActiveValidators() {
+		totalPower += v.Power
+		if plan, ok := approvals[v.Address]; ok && plan == msg.Plan {
+			approvedPower += v.Power
+		}
+	}
+
+	action := "approved"
+	if 3*approvedPower > 2*totalPower {
+		planData, err := json.Marshal(msg.Plan)
+		if err != nil {
+			return nil, err
+		}
+		if err := app.storage.SaveUpgradePlan(planData); err != nil {
+			return nil, fmt.Errorf("error guardando plan de actualización: %w", err)
+		}
+		approvals = nil
+		action = "scheduled"
+		logger.Info(fmt.Sprintf("Actualización %s programada para la altura %d", msg.Plan.Name, msg.Plan.Height))
+	}
+
+	var approvalsData []byte
+	if len(approvals) > 0 {
+		if approvalsData, err = json.Marshal(approvals); err != nil {
+			return nil, err
+		}
+	}
+	if err := app.storage.SaveUpgradeApprovals(approvalsData); err != nil {
+		return nil, fmt.Errorf("error guardando aprobaciones de actualización: %w", err)
+	}
+
+	return []abcitypes.Event{{
+		Type: "upgrade",
+		Attributes: []abcitypes.EventAttribute{
+			{Key: "action", Value: action, Index: true},
+			{Key: "name", Value: msg.Plan.Name, Index: true},
+			{Key: "height", Value: fmt.Sprintf("%d", msg.Plan.Height)},
+			{Key: "validator", Value: common.HexToAddress(tx.From).Hex()},
+			{Key: "approved_power", Value: fmt.Sprintf("%d", approvedPower)},
+			{Key: "total_power", Value: fmt.Sprintf("%d", totalPower)},
+		},
+	}}, nil
+}
+
+// checkUpgradeHalt verifica, antes de ejecutar el bloque height, que este binario puede hacerlo: que
+// tiene el handler de la última actualización aplicada y, si hay un plan para esta altura, el de ese plan.
+// Si no, el nodo se detiene sin escribir nada del bloque y retoma con el binario correcto.
+func (app *ABCIApp) checkUpgradeHalt(height uint64) error {
+	if !app.upgradesChecked {
+		done, err := app.storage.GetUpgradesDone()
+		if err != nil {
+			return fmt.Errorf("error leyendo actualizaciones aplicadas: %w", err)
+		}
+		names := make([]string, 0, len(done))
+		for name := range done {
+			names = append(names, name)
+		}
+		sort.Slice(names, func(i, j int) bool { return done[names[i]] > done[names[j]] })
+		if len(names) > 0 && app.upgradeHandlers[names[0]] == nil {
+			return app.haltForUpgrade(fmt.Sprintf("el binario es anterior a la actualización %s aplicada en altura %d", names[0], done[names[0]]))
+		}
+		app.upgradesChecked = true
+	}
+
+	plan, err := app.getUpgradePlan()
+	if err != nil {
+		return err
+	}
+	if plan != nil && plan.Height <= height && app.upgradeHandlers[plan.Name] == nil {
+		return app.haltForUpgrade(fmt.Sprintf("actualización %s programada para la altura %d (%s); instalar el binario que la incluye", plan.Name, plan.Height, plan.Info))
+	}
+	return nil
+}
+
+// haltForUpgrade informa por qué el nodo se detiene y retorna ErrUpgradeNeeded
+func (app *ABCIApp) haltForUpgrade(reason string) error {
+	fmt.Fprintf(os.Stderr, "[ABCI] ACTUALIZACIÓN NECESARIA: %s\n", reason)
+	os.Stderr.Sync()
+	logger.Error("Actualización necesaria: " + reason)
+	return fmt.Errorf("%w: %s", ErrUpgradeNeeded, reason)
+}
+
+// applyScheduledUpgrade ejecuta las migraciones del plan programado para height (checkUpgradeHalt ya
+// verificó que hay handler) y sube la versión de la aplicación. Retorna la versión nueva para
+// informarla a CometBFT en los parámetros de consenso.
+func (app *ABCIApp) applyScheduledUpgrade(height uint64) ([]abcitypes.Event, *cmtproto.ConsensusParams, error) {
+	plan, err := app.getUpgradePlan()
+	if err != nil || plan == nil || plan.Height > height {
+		return nil, nil, err
+	}
+
+	fmt.Fprintf(os.Stdout, "[ABCI] Aplicando actualización %s en altura %d\n", plan.Name, height)
+	os.Stdout.Sync()
+	handler := app.upgradeHandlers[plan.Name]
+	if err := handler(&UpgradeContext{
+		Plan:       *plan,
+		Storage:    app.storage,
+		Executor:   app.executor,
+		Validators: app.validators,
+	}); err != nil {
+		return nil, nil, fmt.Errorf("error en migración de la actualización %s: %w", plan.Name, err)
+	}
+
+	// Registro, borrado del plan y versión nueva en el mismo batch que las migraciones
+	if err := app.storage.SaveUpgradeDone(plan.Name, height); err != nil {
+		return nil, nil, err
+	}
+	if err := app.storage.DeleteUpgradePlan(); err != nil {
+		return nil, nil, err
+	}
+	if err := app.storage.SaveAppVersion(app.appVersion + 1); err != nil {
+		return nil, nil, err
+	}
+	app.appVersion++
+	logger.Info(fmt.Sprintf("Actualización %s aplicada en altura %d: versión de la aplicación %d", plan.Name, height, app.appVersion))
+
+	events := []abcitypes.Event{{
+		Type: "upgrade",
+		Attributes: []abcitypes.EventAttribute{
+			{Key: "action", Value: "applied", Index: true},
+			{Key: "name", Value: plan.Name, Index: true},
+			{Key: "height", Value: fmt.Sprintf("%d", height)},
+			{Key: "app_version", Value: fmt.Sprintf("%d", app.appVersion)},
+		},
+	}}
+	return events, &cmtproto.ConsensusParams{Version: &cmtproto.VersionParams{App: app.appVersion}}, nil
+}
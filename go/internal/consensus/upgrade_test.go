@@ -0,0 +1,221 @@
+package consensus
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"testing"
+	"time"
+
+	execution "github.com/Q-YZX0/oxy-blockchain/internal/execution"
+	"github.com/Q-YZX0/oxy-blockchain/internal/storage"
+	abcitypes "github.com/cometbft/cometbft/abci/types"
+	"github.com/cometbft/cometbft/crypto/ed25519"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// TestABCIApp_ScheduledUpgrade verifica el módulo de actualizaciones: el plan se programa cuando lo
+// aprueba más de 2/3 del poder, el binario sin handler se detiene en la altura del plan, el binario nuevo
+// migra el estado una sola vez (también si se cae antes de Commit) y sube la versión de la aplicación,
+// y un binario anterior a la actualización aplicada no ejecuta bloques
+func TestABCIApp_ScheduledUpgrade(t *testing.T) {
+	ctx := context.Background()
+
+	testDir := createTestDir("scheduled_upgrade")
+	defer func() {
+		if err := cleanupTestDir(testDir); err != nil {
+			t.Logf("Advertencia: error limpiando directorio: %v", err)
+		}
+	}()
+
+	oneOXG := big.NewInt(1e18)
+	operators := []string{
+		common.HexToAddress("0xA000000000000000000000000000000000000001").Hex(),
+		common.HexToAddress("0xA000000000000000000000000000000000000002").Hex(),
+		common.HexToAddress("0xA000000000000000000000000000000000000003").Hex(),
+	}
+	stakes := []int64{2, 2, 1}
+	plan := UpgradePlan{Name: "v2", Height: 4, Info: "https://example.org/oxy-v2"}
+
+	type node struct {
+		db         *storage.BlockchainDB
+		evm        *execution.EVMExecutor
+		validators *ValidatorSet
+		app        *ABCIApp
+	}
+	migrations := 0
+	open := func(withHandler bool) *node {
+		db, err := storage.NewBlockchainDB(testDir)
+		if err != nil {
+			t.Fatalf("Error abriendo storage: %v", err)
+		}
+		evm := execution.NewEVMExecutor(db)
+		if err := evm.Start(); err != nil {
+			t.Fatalf("Error iniciando EVM: %v", err)
+		}
+		validators := NewValidatorSet(db, evm, oneOXG, 10)
+		if err := validators.LoadValidators(); err != nil {
+			t.Fatalf("Error cargando validadores: %v", err)
+		}
+		app := NewABCIApp(db, evm, validators, "test-chain")
+		if withHandler {
+			// Migración de ejemplo: cambia un campo de los validadores
+			app.SetUpgradeHandler("v2", func(ctx *UpgradeContext) error {
+				migrations++
+				return ctx.Validators.SetCommission(operators[2], 10)
+			})
+		}
+		return &node{db: db, evm: evm, validators: validators, app: app}
+	}
+	shutdown := func(n *node) {
+		n.evm.Stop()
+		n.db.Close()
+	}
+
+	nonces := make(map[string]uint64)
+	approve := func(from string, plan UpgradePlan) []byte {
+		msg, _ := json.Marshal(UpgradeMsg{Type: UpgradeApprove, Plan: plan})
+		txData, _ := json.Marshal(Transaction{
+			Hash:     "0x" + fmt.Sprintf("%062x%02x", nonces[from], from[len(from)-1]),
+			From:     from,
+			To:       UpgradeModuleAddress.Hex(),
+			Value:    "0",
+			Data:     msg,
+			GasLimit: 100000,
+			GasPrice: "1000000000",
+			Nonce:    nonces[from],
+		})
+		nonces[from]++
+		return txData
+	}
+	finalize := func(n *node, height int64, txs ...[]byte) (*abcitypes.FinalizeBlockResponse, error) {
+		return n.app.FinalizeBlock(ctx, &abcitypes.FinalizeBlockRequest{Height: height, Time: time.Unix(1700000000+height, 0), Txs: txs})
+	}
+	commit := func(n *node, height int64, txs ...[]byte) *abcitypes.FinalizeBlockResponse {
+		t.Helper()
+		resp, err := finalize(n, height, txs...)
+		if err != nil {
+			t.Fatalf("Error en FinalizeBlock %d: %v", height, err)
+		}
+		if _, err := n.app.Commit(ctx, &abcitypes.CommitRequest{}); err != nil {
+			t.Fatalf("Error en Commit %d: %v", height, err)
+		}
+		return resp
+	}
+	upgradeAction := func(events []abcitypes.Event) string {
+		for _, event := range events {
+			if event.Type == "upgrade" {
+				return event.Attributes[0].Value
+			}
+		}
+		return ""
+	}
+	appVersion := func(n *node) uint64 {
+		info, err := n.app.Info(ctx, &abcitypes.InfoRequest{})
+		if err != nil {
+			t.Fatalf("Error en Info: %v", err)
+		}
+		return info.AppVersion
+	}
+
+	n := open(false)
+	for i, operator := range operators {
+		pubKey := ed25519.GenPrivKeyFromSecret([]byte(operator)).PubKey().Bytes()
+		if _, err := n.validators.RegisterValidator(operator, pubKey, new(big.Int).Mul(oneOXG, big.NewInt(stakes[i]))); err != nil {
+			t.Fatalf("Error registrando validador: %v", err)
+		}
+		if err := n.evm.FundAccount(operator, "1000000000000000000"); err != nil {
+			t.Fatalf("Error fondeando operador: %v", err)
+		}
+	}
+	if _, err := n.app.InitChain(ctx, &abcitypes.InitChainRequest{ChainId: "test-chain"}); err != nil {
+		t.Fatalf("Error en InitChain: %v", err)
+	}
+	if appVersion(n) != 1 {
+		t.Fatalf("Versión inicial de la aplicación: %d", appVersion(n))
+	}
+
+	// Bloque 1: aprueba un validador con 2 de 5 de poder; un no validador no puede aprobar
+	outsider := "0x5000000000000000000000000000000000000005"
+	n.evm.FundAccount(outsider, "1000000000000000000")
+	resp := commit(n, 1, approve(operators[0], plan), approve(outsider, plan))
+	if resp.TxResults[0].Code != 0 || upgradeAction(resp.TxResults[0].Events) != "approved" {
+		t.Fatalf("Aprobación del validador: código %d (%s)", resp.TxResults[0].Code, resp.TxResults[0].Log)
+	}
+	if resp.TxResults[1].Code != 5 {
+		t.Errorf("Aprobación de un no validador debería rechazarse: código %d", resp.TxResults[1].Code)
+	}
+
+	// Bloque 2: con 4 de 5 de poder el plan queda programado
+	resp = commit(n, 2, approve(operators[1], plan))
+	if upgradeAction(resp.TxResults[0].Events) != "scheduled" {
+		t.Fatalf("El plan debería programarse: %s", resp.TxResults[0].Log)
+	}
+	query, _ := n.app.Query(ctx, &abcitypes.QueryRequest{Path: "upgrade"})
+	var status struct {
+		Plan *UpgradePlan `json:"plan"`
+	}
+	if err := json.Unmarshal(query.Value, &status); err != nil || status.Plan == nil || *status.Plan != plan {
+		t.Fatalf("Query upgrade: %s", query.Value)
+	}
+	commit(n, 3)
+
+	// Bloque 4 sin handler: el nodo se detiene sin aplicar nada del bloque
+	if _, err := finalize(n, 4); !errors.Is(err, ErrUpgradeNeeded) {
+		t.Fatalf("Se esperaba ErrUpgradeNeeded en la altura del plan: %v", err)
+	}
+	shutdown(n)
+
+	// Binario nuevo: ejecuta la migración en el bloque 4, pero se cae antes de Commit
+	n = open(true)
+	if info, _ := n.app.Info(ctx, &abcitypes.InfoRequest{}); info.LastBlockHeight != 3 || info.AppVersion != 1 {
+		t.Fatalf("Info tras la detención: altura %d, versión %d", info.LastBlockHeight, info.AppVersion)
+	}
+	first, err := finalize(n, 4)
+	if err != nil {
+		t.Fatalf("Error en FinalizeBlock 4 con handler: %v", err)
+	}
+	shutdown(n)
+
+	// El handshake reproduce el bloque 4: la migración corre sobre el estado del bloque 3 y llega al mismo AppHash
+	n = open(true)
+	resp = commit(n, 4)
+	if !bytes.Equal(resp.AppHash, first.AppHash) {
+		t.Fatalf("AppHash del bloque 4 reproducido %X, esperado %X", resp.AppHash, first.AppHash)
+	}
+	if upgradeAction(resp.Events) != "applied" || resp.ConsensusParamUpdates == nil || resp.ConsensusParamUpdates.Version.App != 2 {
+		t.Fatalf("FinalizeBlock 4 debería aplicar la actualización e informar la versión 2: %+v", resp.ConsensusParamUpdates)
+	}
+	if validator, _ := n.validators.GetValidator(operators[2]); validator.Commission != 10 {
+		t.Errorf("Migración no aplicada: comisión %d", validator.Commission)
+	}
+	if appVersion(n) != 2 {
+		t.Errorf("Versión de la aplicación tras la actualización: %d", appVersion(n))
+	}
+
+	// La migración no se repite y un plan ya aplicado no se puede volver a aprobar
+	migrations = 0
+	resp = commit(n, 5, approve(operators[0], UpgradePlan{Name: "v2", Height: 10}))
+	if migrations != 0 || resp.ConsensusParamUpdates != nil {
+		t.Errorf("La migración no debería repetirse: %d ejecuciones", migrations)
+	}
+	if resp.TxResults[0].Code != 5 {
+		t.Errorf("Aprobar una actualización ya aplicada debería rechazarse: código %d", resp.TxResults[0].Code)
+	}
+	shutdown(n)
+
+	// Reinicio: la versión se conserva; un binario anterior a la actualización no ejecuta bloques
+	n = open(true)
+	if appVersion(n) != 2 {
+		t.Errorf("Versión de la aplicación tras reinicio: %d", appVersion(n))
+	}
+	shutdown(n)
+	n = open(false)
+	defer shutdown(n)
+	if _, err := finalize(n, 6); !errors.Is(err, ErrUpgradeNeeded) {
+		t.Fatalf("Un binario sin el handler de la actualización aplicada debería detenerse: %v", err)
+	}
+}
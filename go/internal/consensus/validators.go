@@ -1,6 +1,7 @@
 package consensus
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"log"
@@ -18,17 +19,23 @@ import (
 
 // Validator representa un validador en la red
 type Validator struct {
-	Address       string    // Dirección Ethereum del validador
+	Address       string    // Cuenta EVM del operador: bondea el stake y cobra los rewards
 	PubKey        []byte    // Clave pública CometBFT
 	Stake         *big.Int  // Cantidad de OXG staked
 	Power         int64     // Poder de voto (calculado del stake)
-	DelegatedTo   string    // Dirección que tiene delegado el stake (opcional)
 	Jailed        bool      // Si está en jail (slashed)
 	JailedUntil   time.Time // Fecha hasta la que está en jail
+	Tombstoned    bool      // Jail permanente por doble firma (no puede hacer unjail)
 	CreatedAt     time.Time // Fecha de creación
 	LastActiveAt  time.Time // Última actividad
-	MissedBlocks  int       // Bloques perdidos consecutivos
+	MissedBlocks  int       // Bloques perdidos en la ventana de liveness actual
 	TotalMissed   int       // Total de bloques perdidos
+	WindowBlocks  uint64    // Bloques registrados desde que empezó la ventana (unjail o alta)
+	MissedBitmap  []byte    // Bloques perdidos en la ventana (un bit por bloque, circular)
+	Commission    uint64    // Porcentaje (0-100) de los rewards que retiene como comisión
+
+	DelegatorShares       *big.Int // Shares emitidas a los delegadores (incluido el self-stake)
+	AccumulatedCommission *big.Int // Comisión acumulada sin retirar (wei)
 }
 
 // ValidatorSet maneja el conjunto de validadores
@@ -39,6 +46,24 @@ type ValidatorSet struct {
 	mutex         sync.RWMutex
 	minStake      *big.Int // Stake mínimo para ser validador
 	maxValidators int      // Número máximo de validadores
+
+	// Cola de unbonding: retiros de stake pendientes de liberar
+	unbondings      []*UnbondingEntry
+	nextUnbondingID uint64
+	unbondingBlocks uint64        // Bloques hasta que madura un retiro
+	unbondingTime   time.Duration // Tiempo de bloque hasta que madura un retiro
+
+	// Delegaciones por delegador/validador (ver delegation.go)
+	delegations map[string]*Delegation
+
+	// Bloque en ejecución: las mutaciones usan su altura y hora (nunca el reloj local) para que
+	// todos los nodos calculen el mismo set
+	blockHeight uint64
+	blockTime   time.Time
+
+	// Liveness: ventana deslizante de firmas y porcentaje mínimo firmado
+	signedBlocksWindow uint64
+	minSignedPercent   uint64
 }
 
 // NewValidatorSet crea un nuevo conjunto de validadores
@@ -49,19 +74,44 @@ func NewValidatorSet(
 	maxValidators int,
 ) *ValidatorSet {
 	return &ValidatorSet{
-		storage:       storage,
-		executor:      executor,
-		validators:    make(map[string]*Validator),
-		minStake:      minStake,
-		maxValidators: maxValidators,
+		storage:         storage,
+		executor:        executor,
+		validators:      make(map[string]*Validator),
+		delegations:     make(map[string]*Delegation),
+		minStake:        minStake,
+		maxValidators:   maxValidators,
+		unbondingBlocks: DefaultUnbondingBlocks,
+
+		signedBlocksWindow: DefaultSignedBlocksWindow,
+		minSignedPercent:   DefaultMinSignedPercent,
 	}
 }
 
+// SetBlockInfo establece la altura y hora del bloque en ejecución. Todas las mutaciones del set que
+// dependen del tiempo (jail, unjail, altas, unbondings que genera el propio set) las usan en lugar del
+// reloj local, de modo que todos los nodos calculan el mismo resultado.
+func (vs *ValidatorSet) SetBlockInfo(height uint64, blockTime time.Time) {
+	vs.mutex.Lock()
+	defer vs.mutex.Unlock()
+	vs.blockHeight = height
+	vs.blockTime = blockTime
+}
+
 // LoadValidators carga validadores desde storage
 func (vs *ValidatorSet) LoadValidators() error {
 	vs.mutex.Lock()
 	defer vs.mutex.Unlock()
 
+	// Cargar cola de unbonding
+	if err := vs.loadUnbondingsLocked(); err != nil {
+		return err
+	}
+
+	// Cargar delegaciones
+	if err := vs.loadDelegationsLocked(); err != nil {
+		return err
+	}
+
 	// Cargar validadores guardados
 	validatorsData, err := vs.storage.GetAccount("validators:set")
 	if err != nil {
@@ -78,6 +128,8 @@ func (vs *ValidatorSet) LoadValidators() error {
 	vs.validators = make(map[string]*Validator)
 	for _, v := range validatorsList {
 		vs.validators[v.Address] = v
+		// Validadores guardados antes de la delegación: todo su stake es self-stake
+		vs.ensureSelfDelegationLocked(v)
 	}
 
 	log.Printf("Cargados %d validadores desde storage", len(vs.validators))
@@ -86,50 +138,36 @@ func (vs *ValidatorSet) LoadValidators() error {
 
 // SaveValidators guarda validadores en storage
 func (vs *ValidatorSet) SaveValidators() error {
-	fmt.Fprintf(os.Stdout, "[Validators] SaveValidators iniciado\n")
-	os.Stdout.Sync()
-	
-	fmt.Fprintf(os.Stdout, "[Validators] Adquiriendo RLock...\n")
-	os.Stdout.Sync()
 	vs.mutex.RLock()
 	defer vs.mutex.RUnlock()
-	fmt.Fprintf(os.Stdout, "[Validators] RLock adquirido\n")
-	os.Stdout.Sync()
+	return vs.saveValidatorsLocked()
+}
 
-	fmt.Fprintf(os.Stdout, "[Validators] Creando lista de validadores (count=%d)...\n", len(vs.validators))
-	os.Stdout.Sync()
+// saveValidatorsLocked guarda validadores en storage asumiendo que el llamador ya tiene el mutex
+// (RLock o Lock). Los métodos que mutan el set lo usan para no re-adquirir el mutex:
+// sync.RWMutex no es reentrante y un RLock dentro de un Lock bloquea para siempre.
+func (vs *ValidatorSet) saveValidatorsLocked() error {
 	validatorsList := make([]*Validator, 0, len(vs.validators))
 	for _, v := range vs.validators {
 		validatorsList = append(validatorsList, v)
 	}
-	fmt.Fprintf(os.Stdout, "[Validators] Lista de validadores creada (count=%d)\n", len(validatorsList))
-	os.Stdout.Sync()
+	sort.Slice(validatorsList, func(i, j int) bool {
+		return validatorsList[i].Address < validatorsList[j].Address
+	})
 
-	fmt.Fprintf(os.Stdout, "[Validators] Serializando validadores...\n")
-	os.Stdout.Sync()
 	validatorsData, err := json.Marshal(validatorsList)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "[Validators] ERROR serializando validadores: %v\n", err)
 		os.Stderr.Sync()
 		return fmt.Errorf("error serializando validadores: %w", err)
 	}
-	fmt.Fprintf(os.Stdout, "[Validators] Validadores serializados (%d bytes)\n", len(validatorsData))
-	os.Stdout.Sync()
 
-	fmt.Fprintf(os.Stdout, "[Validators] Llamando a storage.SaveAccount('validators:set')...\n")
-	os.Stdout.Sync()
-	err = vs.storage.SaveAccount("validators:set", validatorsData)
-	if err != nil {
+	if err := vs.storage.SaveAccount("validators:set", validatorsData); err != nil {
 		fmt.Fprintf(os.Stderr, "[Validators] ERROR en storage.SaveAccount(): %v\n", err)
 		os.Stderr.Sync()
 		return err
 	}
-	fmt.Fprintf(os.Stdout, "[Validators] storage.SaveAccount() completado exitosamente\n")
-	os.Stdout.Sync()
-	
-	fmt.Fprintf(os.Stdout, "[Validators] SaveValidators completado exitosamente\n")
-	os.Stdout.Sync()
-	return nil
+	return vs.saveDelegationsLocked()
 }
 
 // RegisterValidator registra un nuevo validador
@@ -159,8 +197,8 @@ func (vs *ValidatorSet) RegisterValidator(
 			return nil, fmt.Errorf("número máximo de validadores alcanzado y stake insuficiente para reemplazar al validador con menor stake")
 		}
 
-		// Remover validador con menor stake
-		delete(vs.validators, minStakeVal.Address)
+		// Remover validador con menor stake (sus delegaciones pasan al unbonding)
+		vs.removeValidatorLocked(minStakeVal)
 		log.Printf("Removido validador %s con stake %s para hacer espacio", minStakeVal.Address, minStakeVal.Stake.String())
 	}
 
@@ -170,16 +208,17 @@ func (vs *ValidatorSet) RegisterValidator(
 		PubKey:       pubKey,
 		Stake:        new(big.Int).Set(initialStake),
 		Power:        vs.calculatePower(initialStake),
-		CreatedAt:    time.Now(),
-		LastActiveAt: time.Now(),
+		CreatedAt:    vs.blockTime,
+		LastActiveAt: vs.blockTime,
 	}
 
 	vs.validators[address] = validator
+	vs.ensureSelfDelegationLocked(validator)
 
 	log.Printf("✅ Validador registrado: %s con stake %s", address, initialStake.String())
 
 	// Guardar validadores
-	if err := vs.SaveValidators(); err != nil {
+	if err := vs.saveValidatorsLocked(); err != nil {
 		log.Printf("Advertencia: error guardando validadores: %v", err)
 	}
 
@@ -201,15 +240,14 @@ func (vs *ValidatorSet) Stake(address string, amount *big.Int) error {
 		return fmt.Errorf("validador está en jail: %s", address)
 	}
 
-	// Actualizar stake
-	validator.Stake.Add(validator.Stake, amount)
-	validator.Power = vs.calculatePower(validator.Stake)
-	validator.LastActiveAt = time.Now()
+	// El stake del operador es su self-delegation
+	vs.delegateLocked(address, validator, amount)
+	validator.LastActiveAt = vs.blockTime
 
 	log.Printf("✅ Stake actualizado para %s: %s (nuevo total: %s)", address, amount.String(), validator.Stake.String())
 
 	// Guardar validadores
-	if err := vs.SaveValidators(); err != nil {
+	if err := vs.saveValidatorsLocked(); err != nil {
 		log.Printf("Advertencia: error guardando validadores: %v", err)
 	}
 
@@ -231,27 +269,31 @@ func (vs *ValidatorSet) Unstake(address string, amount *big.Int) error {
 		return fmt.Errorf("validador está en jail: %s", address)
 	}
 
-	// Validar que no baje del mínimo
-	newStake := new(big.Int).Sub(validator.Stake, amount)
-	if newStake.Cmp(vs.minStake) < 0 {
-		return fmt.Errorf("no se puede unstake: quedaría con %s, requiere mínimo %s", newStake.String(), vs.minStake.String())
+	// Validar que el self-stake no baje del mínimo (retirar todo el self-stake es salir del set)
+	newSelfStake := new(big.Int).Sub(vs.selfStakeLocked(validator), amount)
+	if newSelfStake.Sign() < 0 {
+		return fmt.Errorf("no se puede unstake %s: self-stake actual %s", amount.String(), vs.selfStakeLocked(validator).String())
+	}
+	if newSelfStake.Sign() > 0 && newSelfStake.Cmp(vs.minStake) < 0 {
+		return fmt.Errorf("no se puede unstake: quedaría con %s, requiere mínimo %s", newSelfStake.String(), vs.minStake.String())
 	}
 
 	// Actualizar stake
-	validator.Stake.Sub(validator.Stake, amount)
-	validator.Power = vs.calculatePower(validator.Stake)
-	validator.LastActiveAt = time.Now()
+	if err := vs.undelegateLocked(address, validator, amount); err != nil {
+		return err
+	}
+	validator.LastActiveAt = vs.blockTime
 
 	log.Printf("✅ Stake reducido para %s: -%s (nuevo total: %s)", address, amount.String(), validator.Stake.String())
 
-	// Si el stake es muy bajo, puede ser removido del set activo
-	if validator.Stake.Cmp(vs.minStake) < 0 {
-		delete(vs.validators, address)
+	// Sin self-stake el validador sale del set y sus delegaciones pasan al unbonding
+	if vs.selfStakeLocked(validator).Sign() == 0 {
+		vs.removeValidatorLocked(validator)
 		log.Printf("⚠️ Validador %s removido por stake insuficiente", address)
 	}
 
 	// Guardar validadores
-	if err := vs.SaveValidators(); err != nil {
+	if err := vs.saveValidatorsLocked(); err != nil {
 		log.Printf("Advertencia: error guardando validadores: %v", err)
 	}
 
@@ -268,36 +310,71 @@ func (vs *ValidatorSet) Slash(address string, slashPercent int, jailDuration tim
 		return fmt.Errorf("validador no encontrado: %s", address)
 	}
 
+	vs.slashLocked(validator, slashPercent, 0, jailDuration)
+	return nil
+}
+
+// SlashForInfraction penaliza a un validador por una infracción cometida en infractionHeight: solo los
+// unbondings iniciados desde esa altura se slashean. Con tombstone el jail es permanente.
+// Retorna el total slasheado (stake y unbondings).
+func (vs *ValidatorSet) SlashForInfraction(address string, slashPercent int, infractionHeight uint64, jailDuration time.Duration, tombstone bool) (*big.Int, error) {
+	vs.mutex.Lock()
+	defer vs.mutex.Unlock()
+
+	validator, exists := vs.validators[address]
+	if !exists {
+		return nil, fmt.Errorf("validador no encontrado: %s", address)
+	}
+	if validator.Tombstoned {
+		return nil, fmt.Errorf("validador ya está tombstoned: %s", address)
+	}
+
+	if tombstone {
+		validator.Tombstoned = true
+		log.Printf("🪦 Validador %s tombstoned", address)
+	}
+	return vs.slashLocked(validator, slashPercent, infractionHeight, jailDuration), nil
+}
+
+// slashLocked reduce el stake del validador y de sus unbondings iniciados desde infractionHeight,
+// lo envía a jail y lo guarda. Retorna el total slasheado. Asume que el llamador tiene el mutex.
+func (vs *ValidatorSet) slashLocked(validator *Validator, slashPercent int, infractionHeight uint64, jailDuration time.Duration) *big.Int {
+	address := validator.Address
+
 	// Calcular cantidad a slashear
 	slashAmount := new(big.Int)
 	slashAmount.Mul(validator.Stake, big.NewInt(int64(slashPercent)))
 	slashAmount.Div(slashAmount, big.NewInt(100))
 
-	// Reducir stake
+	// Reducir stake (las shares no cambian: cada delegador pierde en proporción)
 	validator.Stake.Sub(validator.Stake, slashAmount)
 
+	// El stake en unbonding sigue siendo slasheable
+	unbondingSlashed := vs.slashUnbondingsLocked(address, slashPercent, infractionHeight)
+
 	// Actualizar power
 	validator.Power = vs.calculatePower(validator.Stake)
 
-	// Enviar a jail
+	// Enviar a jail (la ventana de liveness empieza de nuevo)
+	vs.resetLivenessLocked(validator)
 	validator.Jailed = true
-	validator.JailedUntil = time.Now().Add(jailDuration)
+	validator.JailedUntil = vs.blockTime.Add(jailDuration)
 
 	log.Printf("⚠️ Validador slasheado: %s -%s (%%%d)", address, slashAmount.String(), slashPercent)
 	log.Printf("⛓️ Validador %s enviado a jail hasta %s", address, validator.JailedUntil.Format(time.RFC3339))
 
 	// Si el stake es muy bajo después de slash, remover
 	if validator.Stake.Cmp(vs.minStake) < 0 {
-		delete(vs.validators, address)
+		vs.removeValidatorLocked(validator)
 		log.Printf("⚠️ Validador %s removido por stake insuficiente después de slash", address)
 	}
 
 	// Guardar validadores
-	if err := vs.SaveValidators(); err != nil {
+	if err := vs.saveValidatorsLocked(); err != nil {
 		log.Printf("Advertencia: error guardando validadores: %v", err)
 	}
 
-	return nil
+	return slashAmount.Add(slashAmount, unbondingSlashed)
 }
 
 // Unjail libera a un validador de jail
@@ -314,7 +391,11 @@ func (vs *ValidatorSet) Unjail(address string) error {
 		return fmt.Errorf("validador no está en jail: %s", address)
 	}
 
-	if time.Now().Before(validator.JailedUntil) {
+	if validator.Tombstoned {
+		return fmt.Errorf("validador está tombstoned: %s", address)
+	}
+
+	if vs.blockTime.Before(validator.JailedUntil) {
 		return fmt.Errorf("validador aún está en jail hasta %s", validator.JailedUntil.Format(time.RFC3339))
 	}
 
@@ -326,7 +407,30 @@ func (vs *ValidatorSet) Unjail(address string) error {
 	log.Printf("✅ Validador %s liberado de jail", address)
 
 	// Guardar validadores
-	if err := vs.SaveValidators(); err != nil {
+	if err := vs.saveValidatorsLocked(); err != nil {
+		log.Printf("Advertencia: error guardando validadores: %v", err)
+	}
+
+	return nil
+}
+
+// SetCommission establece la comisión (porcentaje 0-100) que un validador retiene sobre sus rewards
+func (vs *ValidatorSet) SetCommission(address string, percent uint64) error {
+	if percent > 100 {
+		return fmt.Errorf("comisión inválida: %d", percent)
+	}
+
+	vs.mutex.Lock()
+	defer vs.mutex.Unlock()
+
+	validator, exists := vs.validators[address]
+	if !exists {
+		return fmt.Errorf("validador no encontrado: %s", address)
+	}
+	validator.Commission = percent
+
+	// Guardar validadores
+	if err := vs.saveValidatorsLocked(); err != nil {
 		log.Printf("Advertencia: error guardando validadores: %v", err)
 	}
 
@@ -345,6 +449,10 @@ func (vs *ValidatorSet) GetValidators() []*Validator {
 		}
 	}
 
+	// Orden por dirección: el mapa no tiene orden definido
+	sort.Slice(validators, func(i, j int) bool {
+		return validators[i].Address < validators[j].Address
+	})
 	return validators
 }
 
@@ -363,9 +471,7 @@ func (vs *ValidatorSet) GetActiveValidators() []*Validator {
 	}
 
 	// Ordenar por stake (mayor primero)
-	sort.Slice(validators, func(i, j int) bool {
-		return validators[i].Stake.Cmp(validators[j].Stake) > 0
-	})
+	sortValidatorsByStake(validators)
 
 	return validators
 }
@@ -383,6 +489,24 @@ func (vs *ValidatorSet) GetValidator(address string) (*Validator, error) {
 	return validator, nil
 }
 
+// GetValidatorByConsensusAddress retorna el validador cuya clave CometBFT corresponde a la
+// dirección de consenso dada (ej: ProposerAddress de FinalizeBlock)
+func (vs *ValidatorSet) GetValidatorByConsensusAddress(consensusAddress []byte) (*Validator, error) {
+	vs.mutex.RLock()
+	defer vs.mutex.RUnlock()
+
+	for _, v := range vs.validators {
+		if len(v.PubKey) != ed25519.PubKeySize {
+			continue
+		}
+		if bytes.Equal(ed25519.PubKey(v.PubKey).Address(), consensusAddress) {
+			return v, nil
+		}
+	}
+
+	return nil, fmt.Errorf("validador no encontrado para dirección de consenso: %X", consensusAddress)
+}
+
 // ToCometBFTValidators convierte validadores a formato CometBFT
 func (vs *ValidatorSet) ToCometBFTValidators() []abcitypes.ValidatorUpdate {
 	vs.mutex.RLock()
@@ -398,15 +522,13 @@ func (vs *ValidatorSet) ToCometBFTValidators() []abcitypes.ValidatorUpdate {
 	}
 	
 	// Ordenar por stake (mayor primero)
-	sort.Slice(activeValidators, func(i, j int) bool {
-		return activeValidators[i].Stake.Cmp(activeValidators[j].Stake) > 0
-	})
+	sortValidatorsByStake(activeValidators)
 	
 	updates := make([]abcitypes.ValidatorUpdate, 0, len(activeValidators))
 
 	for _, v := range activeValidators {
 		// Convertir clave pública a formato CometBFT
-		var pubKey ed25519.PubKey
+		pubKey := make(ed25519.PubKey, ed25519.PubKeySize)
 		if len(v.PubKey) == ed25519.PubKeySize {
 			copy(pubKey[:], v.PubKey)
 		} else {
@@ -441,52 +563,29 @@ func (vs *ValidatorSet) calculatePower(stake *big.Int) int64 {
 	return power.Int64()
 }
 
-// findLowestStakeValidator encuentra el validador con menor stake
+// sortValidatorsByStake ordena por stake (mayor primero) con empates por dirección, para que todos los
+// nodos obtengan el mismo orden
+func sortValidatorsByStake(validators []*Validator) {
+	sort.Slice(validators, func(i, j int) bool {
+		if cmp := validators[i].Stake.Cmp(validators[j].Stake); cmp != 0 {
+			return cmp > 0
+		}
+		return validators[i].Address < validators[j].Address
+	})
+}
+
+// findLowestStakeValidator encuentra el validador con menor stake (empates por dirección, para que
+// todos los nodos reemplacen al mismo validador)
 func (vs *ValidatorSet) findLowestStakeValidator() *Validator {
 	var lowest *Validator
 	for _, v := range vs.validators {
-		if lowest == nil || v.Stake.Cmp(lowest.Stake) < 0 {
+		if lowest == nil || v.Stake.Cmp(lowest.Stake) < 0 || v.Stake.Cmp(lowest.Stake) == 0 && v.Address < lowest.Address {
 			lowest = v
 		}
 	}
 	return lowest
 }
 
-// UpdateValidatorActivity actualiza la última actividad de un validador
-func (vs *ValidatorSet) UpdateValidatorActivity(address string, missedBlock bool) {
-	vs.mutex.Lock()
-	defer vs.mutex.Unlock()
-
-	validator, exists := vs.validators[address]
-	if !exists {
-		return
-	}
-
-	validator.LastActiveAt = time.Now()
-
-	if missedBlock {
-		validator.MissedBlocks++
-		validator.TotalMissed++
-
-		// Si falla muchos bloques consecutivos, aplicar slash automático
-		if validator.MissedBlocks >= 100 {
-			log.Printf("⚠️ Validador %s ha fallado %d bloques consecutivos, aplicando slash", address, validator.MissedBlocks)
-			// Slash del 5% del stake por cada 100 bloques perdidos
-			slashPercentage := float64(validator.MissedBlocks/100) * 0.05 // 5% por cada 100 bloques
-			if slashPercentage > 0.50 { // Máximo 50% de slash
-				slashPercentage = 0.50
-			}
-			// TODO: Implementar SlashValidator cuando esté disponible
-			// Por ahora, solo resetear el contador después de log
-			log.Printf("⚠️ Slash pendiente para validador %s: %.2f%% por %d bloques perdidos", 
-				address, slashPercentage*100, validator.MissedBlocks)
-			validator.MissedBlocks = 0
-		}
-	} else {
-		validator.MissedBlocks = 0
-	}
-}
-
 // RotateValidators rota los validadores según stake y actividad
 func (vs *ValidatorSet) RotateValidators() ([]abcitypes.ValidatorUpdate, error) {
 	fmt.Fprintf(os.Stdout, "[Validators] RotateValidators iniciado\n")
@@ -525,14 +624,11 @@ func (vs *ValidatorSet) RotateValidators() ([]abcitypes.ValidatorUpdate, error)
 	fmt.Fprintf(os.Stdout, "[Validators] Validadores activos encontrados: %d\n", len(validatorsCopy))
 	os.Stdout.Sync()
 	
-	// Limitar a máximo de validadores
+	// Ordenar por stake (mayor primero, empates por dirección) y limitar a máximo de validadores
+	sortValidatorsByStake(validatorsCopy)
 	if len(validatorsCopy) > vs.maxValidators {
-		// Ordenar por stake (mayor primero)
 		fmt.Fprintf(os.Stdout, "[Validators] Limitando a %d validadores (hay %d)\n", vs.maxValidators, len(validatorsCopy))
 		os.Stdout.Sync()
-		sort.Slice(validatorsCopy, func(i, j int) bool {
-			return validatorsCopy[i].Stake.Cmp(validatorsCopy[j].Stake) > 0
-		})
 		validatorsCopy = validatorsCopy[:vs.maxValidators]
 	}
 	
@@ -546,7 +642,7 @@ func (vs *ValidatorSet) RotateValidators() ([]abcitypes.ValidatorUpdate, error)
 	updates := make([]abcitypes.ValidatorUpdate, 0, len(validatorsCopy))
 	for i, v := range validatorsCopy {
 		// Convertir clave pública a formato CometBFT
-		var pubKey ed25519.PubKey
+		pubKey := make(ed25519.PubKey, ed25519.PubKeySize)
 		if len(v.PubKey) == ed25519.PubKeySize {
 			copy(pubKey[:], v.PubKey)
 		} else {
@@ -608,13 +704,14 @@ func (vs *ValidatorSet) InitializeGenesisValidators(genesisValidators []GenesisV
 		validator := &Validator{
 			Address:      gv.Address,
 			PubKey:       gv.PubKey,
-			Stake:        gv.Stake,
+			Stake:        new(big.Int).Set(gv.Stake),
 			Power:        vs.calculatePower(gv.Stake),
-			CreatedAt:    time.Now(),
-			LastActiveAt: time.Now(),
+			CreatedAt:    vs.blockTime,
+			LastActiveAt: vs.blockTime,
 		}
 
 		vs.validators[gv.Address] = validator
+		vs.ensureSelfDelegationLocked(validator)
 		log.Printf("✅ Validador genesis registrado: %s con stake %s", gv.Address, gv.Stake.String())
 		fmt.Fprintf(os.Stdout, "[Validators] Validador registrado: %s\n", gv.Address)
 		os.Stdout.Sync()
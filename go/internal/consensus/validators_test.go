@@ -522,14 +522,19 @@ func TestValidatorSet_Unjail(t *testing.T) {
 		t.Fatalf("Error registrando validador: %v", err)
 	}
 	
-	// Slash con jail de 1 segundo (corto para test)
+	// Slash con jail de 1 segundo de tiempo de bloque
+	start := time.Unix(1700000000, 0)
+	validatorSet.SetBlockInfo(10, start)
 	err = validatorSet.Slash(address, 10, 1*time.Second)
 	if err != nil {
 		t.Fatalf("Error slasheando validador: %v", err)
 	}
 	
-	// Esperar que termine el jail
-	time.Sleep(2 * time.Second)
+	// El jail se mide en tiempo de bloque, no con el reloj local
+	if err := validatorSet.Unjail(address); err == nil {
+		t.Fatal("No debería poder hacer unjail antes de que termine el jail")
+	}
+	validatorSet.SetBlockInfo(11, start.Add(2*time.Second))
 	
 	// Unjail
 	err = validatorSet.Unjail(address)
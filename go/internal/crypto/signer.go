@@ -2,10 +2,12 @@ package crypto
 
 import (
 	"crypto/ecdsa"
+	"crypto/ed25519"
 	"encoding/json"
 	"fmt"
 	"math/big"
 
+	"github.com/ethereum/go-ethereum/accounts"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
@@ -113,25 +115,13 @@ func VerifyTransactionSignatureFromBytes(txHash []byte, signature []byte, fromAd
 	return nil
 }
 
-// SignTransaction firma una transacción con una clave privada
+// SignTransaction firma una transacción con una clave privada (firma el hash de CalculateTransactionHash)
 func SignTransaction(txData map[string]interface{}, privateKey *ecdsa.PrivateKey) ([]byte, error) {
-	// Crear hash de la transacción (excluyendo signature)
-	txCopy := make(map[string]interface{})
-	for k, v := range txData {
-		if k != "signature" {
-			txCopy[k] = v
-		}
-	}
-
-	// Serializar transacción para hashing
-	txBytes, err := json.Marshal(txCopy)
+	hash, err := CalculateTransactionHash(txData)
 	if err != nil {
-		return nil, fmt.Errorf("error serializando transacción: %w", err)
+		return nil, err
 	}
 
-	// Hashear con Keccak256 (hash Ethereum)
-	hash := crypto.Keccak256Hash(txBytes)
-
 	// Firmar hash
 	signature, err := crypto.Sign(hash.Bytes(), privateKey)
 	if err != nil {
@@ -141,12 +131,13 @@ func SignTransaction(txData map[string]interface{}, privateKey *ecdsa.PrivateKey
 	return signature, nil
 }
 
-// CalculateTransactionHash calcula el hash de una transacción para firma
+// CalculateTransactionHash calcula el hash de una transacción para firma.
+// El hash cubre todos los campos salvo el propio hash y la firma.
 func CalculateTransactionHash(txData map[string]interface{}) (common.Hash, error) {
-	// Crear copia sin signature
+	// Crear copia sin hash ni signature
 	txCopy := make(map[string]interface{})
 	for k, v := range txData {
-		if k != "signature" {
+		if k != "signature" && k != "hash" {
 			txCopy[k] = v
 		}
 	}
@@ -188,3 +179,69 @@ func CreateTransactionHash(
 	return signer.Hash(tx)
 }
 
+
+// ValidatorKeyProofMessage retorna el mensaje que firma el operador de un validador para vincular su
+// cuenta EVM con la clave ed25519 de consenso. Incluye el chain ID para que la prueba no sea reutilizable
+// en otra cadena.
+func ValidatorKeyProofMessage(chainID string, operator common.Address, consensusPubKey []byte) []byte {
+	return []byte(fmt.Sprintf("Oxy validator key binding\nchain: %s\noperator: %s\nconsensus key: 0x%x", chainID, operator.Hex(), consensusPubKey))
+}
+
+// SignValidatorKeyProof firma la prueba de posesión de una clave de consenso con la clave del operador
+// (personal_sign, EIP-191: cualquier wallet puede generarla)
+func SignValidatorKeyProof(chainID string, consensusPubKey []byte, privateKey *ecdsa.PrivateKey) ([]byte, error) {
+	operator := crypto.PubkeyToAddress(privateKey.PublicKey)
+	hash := accounts.TextHash(ValidatorKeyProofMessage(chainID, operator, consensusPubKey))
+	return crypto.Sign(hash, privateKey)
+}
+
+// VerifyValidatorKeyProof verifica que la prueba de posesión fue firmada por el operador. Acepta V en
+// 0-1 o 27-28 (formato de las wallets).
+func VerifyValidatorKeyProof(chainID string, operator common.Address, consensusPubKey []byte, proof []byte) error {
+	if len(proof) != 65 {
+		return fmt.Errorf("prueba de posesión inválida: debe tener 65 bytes, tiene %d", len(proof))
+	}
+
+	signature := make([]byte, 65)
+	copy(signature, proof)
+	if signature[64] >= 27 {
+		signature[64] -= 27
+	}
+
+	hash := accounts.TextHash(ValidatorKeyProofMessage(chainID, operator, consensusPubKey))
+	pubKey, err := crypto.SigToPub(hash, signature)
+	if err != nil {
+		return fmt.Errorf("prueba de posesión inválida: %w", err)
+	}
+	if recovered := crypto.PubkeyToAddress(*pubKey); recovered != operator {
+		return fmt.Errorf("prueba de posesión inválida: firmada por %s, operador %s", recovered.Hex(), operator.Hex())
+	}
+	return nil
+}
+
+// ConsensusKeyProofMessage retorna el mensaje que firma la clave de consenso de un validador para probar
+// que quien la registra para ese operador tiene su clave privada
+func ConsensusKeyProofMessage(chainID string, operator common.Address) []byte {
+	return []byte(fmt.Sprintf("Oxy consensus key possession\nchain: %s\noperator: %s", chainID, operator.Hex()))
+}
+
+// SignConsensusKeyProof firma la prueba de posesión con la clave privada ed25519 de consenso
+func SignConsensusKeyProof(chainID string, operator common.Address, consensusKey ed25519.PrivateKey) []byte {
+	return ed25519.Sign(consensusKey, ConsensusKeyProofMessage(chainID, operator))
+}
+
+// VerifyConsensusKeyProof verifica que la prueba de posesión fue firmada por la clave de consenso.
+// Junto con VerifyValidatorKeyProof prueba que operador y clave de consenso pertenecen a la misma parte:
+// nadie puede registrar como propia la clave de consenso de otro validador.
+func VerifyConsensusKeyProof(chainID string, operator common.Address, consensusPubKey []byte, proof []byte) error {
+	if len(consensusPubKey) != ed25519.PublicKeySize {
+		return fmt.Errorf("clave de consenso inválida: se esperan %d bytes, tiene %d", ed25519.PublicKeySize, len(consensusPubKey))
+	}
+	if len(proof) != ed25519.SignatureSize {
+		return fmt.Errorf("prueba de la clave de consenso inválida: debe tener %d bytes, tiene %d", ed25519.SignatureSize, len(proof))
+	}
+	if !ed25519.Verify(ed25519.PublicKey(consensusPubKey), ConsensusKeyProofMessage(chainID, operator), proof) {
+		return fmt.Errorf("prueba de la clave de consenso inválida: firma incorrecta para el operador %s", operator.Hex())
+	}
+	return nil
+}
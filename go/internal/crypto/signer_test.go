@@ -1,6 +1,7 @@
 package crypto
 
 import (
+	"crypto/ed25519"
 	"math/big"
 	"testing"
 
@@ -99,3 +100,70 @@ func TestCreateTransactionHash(t *testing.T) {
 	}
 }
 
+
+// TestVerifyValidatorKeyProof prueba la prueba de posesión que vincula operador y clave de consenso
+func TestVerifyValidatorKeyProof(t *testing.T) {
+	privateKey, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatalf("Error generando clave: %v", err)
+	}
+	operator := crypto.PubkeyToAddress(privateKey.PublicKey)
+	consensusKey := make([]byte, 32)
+	consensusKey[0] = 1
+
+	proof, err := SignValidatorKeyProof("oxy-test", consensusKey, privateKey)
+	if err != nil {
+		t.Fatalf("Error firmando prueba: %v", err)
+	}
+	if err := VerifyValidatorKeyProof("oxy-test", operator, consensusKey, proof); err != nil {
+		t.Fatalf("Prueba válida rechazada: %v", err)
+	}
+
+	// V en formato de wallet (27-28)
+	walletProof := append([]byte{}, proof...)
+	walletProof[64] += 27
+	if err := VerifyValidatorKeyProof("oxy-test", operator, consensusKey, walletProof); err != nil {
+		t.Errorf("Prueba con V 27-28 rechazada: %v", err)
+	}
+
+	// Otra cadena, otra clave de consenso u otro operador
+	if err := VerifyValidatorKeyProof("oxy-main", operator, consensusKey, proof); err == nil {
+		t.Error("Prueba de otra cadena aceptada")
+	}
+	otherKey := make([]byte, 32)
+	if err := VerifyValidatorKeyProof("oxy-test", operator, otherKey, proof); err == nil {
+		t.Error("Prueba de otra clave de consenso aceptada")
+	}
+	if err := VerifyValidatorKeyProof("oxy-test", common.HexToAddress("0x1"), consensusKey, proof); err == nil {
+		t.Error("Prueba de otro operador aceptada")
+	}
+}
+
+// TestVerifyConsensusKeyProof prueba la firma de la clave de consenso sobre la cadena y el operador
+func TestVerifyConsensusKeyProof(t *testing.T) {
+	consensusPubKey, consensusKey, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatalf("Error generando clave: %v", err)
+	}
+	operator := common.HexToAddress("0x1")
+
+	proof := SignConsensusKeyProof("oxy-test", operator, consensusKey)
+	if err := VerifyConsensusKeyProof("oxy-test", operator, consensusPubKey, proof); err != nil {
+		t.Fatalf("Prueba válida rechazada: %v", err)
+	}
+
+	// Otra cadena, otro operador, otra clave de consenso o prueba vacía
+	if err := VerifyConsensusKeyProof("oxy-main", operator, consensusPubKey, proof); err == nil {
+		t.Error("Prueba de otra cadena aceptada")
+	}
+	if err := VerifyConsensusKeyProof("oxy-test", common.HexToAddress("0x2"), consensusPubKey, proof); err == nil {
+		t.Error("Prueba de otro operador aceptada")
+	}
+	otherPubKey, _, _ := ed25519.GenerateKey(nil)
+	if err := VerifyConsensusKeyProof("oxy-test", operator, otherPubKey, proof); err == nil {
+		t.Error("Prueba de otra clave de consenso aceptada")
+	}
+	if err := VerifyConsensusKeyProof("oxy-test", operator, consensusPubKey, nil); err == nil {
+		t.Error("Prueba vacía aceptada")
+	}
+}
@@ -0,0 +1,266 @@
+package execution
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/holiman/uint256"
+)
+
+// DefaultCallGasCap es el gas máximo de una llamada estática cuando no se especifica uno
+const DefaultCallGasCap uint64 = 50000000
+
+// CallRequest describe una llamada estática (semántica eth_call)
+type CallRequest struct {
+	From     string
+	To       string
+	Value    string // En wei, decimal (vacío = 0)
+	Data     []byte
+	GasLimit uint64 // 0 = DefaultCallGasCap
+	Height   uint64 // 0 = último estado
+}
+
+// CallResult contiene el resultado de una llamada estática
+type CallResult struct {
+	Success      bool
+	ReturnData   []byte
+	GasUsed      uint64
+	Reverted     bool
+	RevertReason string // Decodificado de Error(string) / Panic(uint256)
+	Error        string
+}
+
+// RevertError indica que la ejecución revirtió; Data contiene los datos de revert crudos
+type RevertError struct {
+	Reason string
+	Data   []byte
+}
+
+func (e *RevertError) Error() string {
+	if e.Reason == "" {
+		return vm.ErrExecutionReverted.Error()
+	}
+	return fmt.Sprintf("%s: %s", vm.ErrExecutionReverted.Error(), e.Reason)
+}
+
+// StaticCall ejecuta una llamada contra una copia del estado y descarta todos los cambios.
+// No valida nonce ni balance del remitente. Si Height > 0 se usan el estado y el header comprometidos en esa altura.
+func (e *EVMExecutor) StaticCall(req *CallRequest) (*CallResult, error) {
+	if !e.running {
+		return nil, fmt.Errorf("ejecutor EVM no está corriendo")
+	}
+
+	stateDB, err := e.callState(req.Height)
+	if err != nil {
+		return nil, err
+	}
+	blockContext, err := e.callBlockContext(req.Height)
+	if err != nil {
+		return nil, err
+	}
+
+	msg, err := e.newCallMessage(req)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := e.applyCall(stateDB, msg, blockContext)
+	if err != nil {
+		return &CallResult{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+
+	return newCallResult(result), nil
+}
+
+// callState retorna una copia desechable del estado (último o en una altura)
+func (e *EVMExecutor) callState(height uint64) (*state.StateDB, error) {
+	if height > 0 {
+		return e.stateManager.StateAt(height)
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	stateDB := e.getStateDB()
+	if stateDB == nil {
+		return nil, fmt.Errorf("StateDB no está inicializado")
+	}
+	return stateDB.Copy(), nil
+}
+
+// callBlockContext retorna el contexto de bloque de la llamada: el bloque en ejecución o, si se pide
+// una altura, el header persistido de esa altura (número, timestamp, base fee, gas límite y coinbase)
+func (e *EVMExecutor) callBlockContext(height uint64) (vm.BlockContext, error) {
+	if height == 0 {
+		return e.newBlockContext(), nil
+	}
+
+	header, err := LoadHeader(e.storage, height)
+	if err != nil {
+		return vm.BlockContext{}, err
+	}
+	return e.headerBlockContext(header), nil
+}
+
+// newCallMessage convierte una CallRequest en un core.Message sin chequeos de nonce ni de cuenta
+func (e *EVMExecutor) newCallMessage(req *CallRequest) (*core.Message, error) {
+	value := big.NewInt(0)
+	if req.Value != "" {
+		var ok bool
+		value, ok = new(big.Int).SetString(req.Value, 10)
+		if !ok {
+			return nil, fmt.Errorf("valor inválido: %s", req.Value)
+		}
+	}
+
+	gasLimit := req.GasLimit
+	if gasLimit == 0 || gasLimit > DefaultCallGasCap {
+		gasLimit = DefaultCallGasCap
+	}
+
+	var to *common.Address
+	if req.To != "" {
+		addr := common.HexToAddress(req.To)
+		to = &addr
+	}
+
+	return &core.Message{
+		From:                  common.HexToAddress(req.From),
+		To:                    to,
+		Value:                 value,
+		GasLimit:              gasLimit,
+		GasPrice:              big.NewInt(0),
+		GasFeeCap:             big.NewInt(0),
+		GasTipCap:             big.NewInt(0),
+		Data:                  req.Data,
+		SkipNonceChecks:       true,
+		SkipTransactionChecks: true,
+	}, nil
+}
+
+// applyCall ejecuta un mensaje sobre un StateDB desechable
+func (e *EVMExecutor) applyCall(stateDB *state.StateDB, msg *core.Message, blockContext vm.BlockContext) (*core.ExecutionResult, error) {
+	// Sin chequeo de balance: cubrir el value transferido en la copia del estado
+	if msg.Value.Sign() > 0 {
+		value, _ := uint256.FromBig(msg.Value)
+		if stateDB.GetBalance(msg.From).Cmp(value) < 0 {
+			stateDB.SetBalance(msg.From, value, tracing.BalanceChangeUnspecified)
+		}
+	}
+
+	// NoBaseFee permite gas price 0 (igual que eth_call en geth)
+	evm := vm.NewEVM(blockContext, stateDB, e.chainConfig, vm.Config{NoBaseFee: true})
+	return core.ApplyMessage(evm, msg, new(core.GasPool).AddGas(msg.GasLimit))
+}
+
+// newCallResult convierte el resultado de la EVM decodificando el motivo de revert
+func newCallResult(result *core.ExecutionResult) *CallResult {
+	callResult := &CallResult{
+		Success:    !result.Failed(),
+		ReturnData: result.Return(),
+		GasUsed:    result.UsedGas,
+	}
+
+	if result.Err == nil {
+		return callResult
+	}
+
+	callResult.Error = result.Err.Error()
+	if errors.Is(result.Err, vm.ErrExecutionReverted) {
+		callResult.Reverted = true
+		callResult.ReturnData = result.Revert()
+		if reason, err := abi.UnpackRevert(result.Revert()); err == nil {
+			callResult.RevertReason = reason
+			callResult.Error = fmt.Sprintf("%s: %s", vm.ErrExecutionReverted.Error(), reason)
+		}
+	}
+
+	return callResult
+}
+
+// EstimateGas busca por bisección el gas mínimo con el que la llamada/transacción no falla.
+// Cada intento corre sobre una copia desechable del estado. Retorna *RevertError si la
+// ejecución revierte incluso con el gas máximo.
+func (e *EVMExecutor) EstimateGas(req *CallRequest) (uint64, error) {
+	if !e.running {
+		return 0, fmt.Errorf("ejecutor EVM no está corriendo")
+	}
+
+	baseState, err := e.callState(req.Height)
+	if err != nil {
+		return 0, err
+	}
+	blockContext, err := e.callBlockContext(req.Height)
+	if err != nil {
+		return 0, err
+	}
+
+	msg, err := e.newCallMessage(req)
+	if err != nil {
+		return 0, err
+	}
+	hi := msg.GasLimit
+
+	// run ejecuta con un gas dado; failed=true indica que el gas no alcanzó (o la ejecución falló)
+	run := func(gas uint64) (failed bool, result *core.ExecutionResult, err error) {
+		attempt := *msg
+		attempt.GasLimit = gas
+		result, err = e.applyCall(baseState.Copy(), &attempt, blockContext)
+		if err != nil {
+			// Gas por debajo del intrínseco: no es un error, solo falta gas
+			if errors.Is(err, core.ErrIntrinsicGas) || errors.Is(err, core.ErrFloorDataGas) {
+				return true, nil, nil
+			}
+			return true, nil, err
+		}
+		return result.Failed(), result, nil
+	}
+
+	// Primero con el máximo: si falla ahí, no hay gas que alcance
+	failed, result, err := run(hi)
+	if err != nil {
+		return 0, err
+	}
+	if failed {
+		if result != nil && errors.Is(result.Err, vm.ErrExecutionReverted) {
+			revertErr := &RevertError{Data: result.Revert()}
+			if reason, unpackErr := abi.UnpackRevert(result.Revert()); unpackErr == nil {
+				revertErr.Reason = reason
+			}
+			return 0, revertErr
+		}
+		if result != nil {
+			return 0, fmt.Errorf("ejecución falla con gas %d: %v", hi, result.Err)
+		}
+		return 0, fmt.Errorf("gas insuficiente incluso con %d", hi)
+	}
+
+	// El gas usado es cota inferior (los refunds y el 63/64 de EIP-150 pueden requerir más)
+	lo := result.UsedGas - 1
+
+	// Bisección: lo siempre falla, hi siempre funciona
+	for lo+1 < hi {
+		mid := lo + (hi-lo)/2
+		failed, _, err := run(mid)
+		if err != nil {
+			return 0, err
+		}
+		if failed {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+
+	return hi, nil
+}
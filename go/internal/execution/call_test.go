@@ -0,0 +1,245 @@
+package execution
+
+import (
+	"math/big"
+	"os"
+	"testing"
+
+	"github.com/Q-YZX0/oxy-blockchain/internal/storage"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// crearTestEVM crea un ejecutor EVM iniciado sobre un directorio de test limpio
+func crearTestEVM(t *testing.T, name string) *EVMExecutor {
+	return crearTestEVMConSpec(t, name, &ChainSpec{Config: DefaultChainConfig()})
+}
+
+// crearTestEVMConSpec crea un ejecutor EVM iniciado con una configuración de cadena dada
+func crearTestEVMConSpec(t *testing.T, name string, spec *ChainSpec) *EVMExecutor {
+	testDir := createTestDir(name)
+	if err := cleanupTestDir(testDir); err != nil && !os.IsNotExist(err) {
+		t.Logf("Advertencia: error limpiando antes del test: %v", err)
+	}
+
+	db, err := storage.NewBlockchainDB(testDir)
+	if err != nil {
+		t.Fatalf("Error creando storage: %v", err)
+	}
+
+	evm := NewEVMExecutorWithChainSpec(db, spec)
+	if err := evm.Start(); err != nil {
+		t.Fatalf("Error iniciando EVM: %v", err)
+	}
+	evm.SetCurrentBlockInfo(1, 1699999999)
+
+	t.Cleanup(func() {
+		if err := evm.Stop(); err != nil {
+			t.Logf("Advertencia: error deteniendo EVM: %v", err)
+		}
+		if err := db.Close(); err != nil {
+			t.Logf("Advertencia: error cerrando storage: %v", err)
+		}
+		if err := cleanupTestDir(testDir); err != nil {
+			t.Logf("Advertencia: error limpiando directorio: %v", err)
+		}
+	})
+
+	return evm
+}
+
+// revertingCode retorna bytecode que hace REVERT con Error(reason) (reason <= 32 bytes)
+func revertingCode(reason string) []byte {
+	// Error(string): selector + offset + longitud + datos
+	data := common.FromHex("0x08c379a0")
+	data = append(data, common.LeftPadBytes([]byte{0x20}, 32)...)
+	data = append(data, common.LeftPadBytes(big.NewInt(int64(len(reason))).Bytes(), 32)...)
+	data = append(data, common.RightPadBytes([]byte(reason), 32)...)
+
+	// PUSH1 len PUSH1 0x0c PUSH1 0 CODECOPY PUSH1 len PUSH1 0 REVERT
+	code := []byte{0x60, byte(len(data)), 0x60, 0x0c, 0x60, 0x00, 0x39, 0x60, byte(len(data)), 0x60, 0x00, 0xfd}
+	return append(code, data...)
+}
+
+// TestEVMExecutor_StaticCall_NoSideEffects verifica que una llamada no modifica el estado
+func TestEVMExecutor_StaticCall_NoSideEffects(t *testing.T) {
+	evm := crearTestEVM(t, "static_call")
+
+	// SSTORE(0, 42) y retorna 42
+	contract := common.HexToAddress("0x2222222222222222222222222222222222222222")
+	evm.getStateDB().SetCode(contract, common.FromHex("0x602a600055602a60005260206000f3"), tracing.CodeChangeUnspecified)
+
+	caller := "0x3333333333333333333333333333333333333333"
+	result, err := evm.StaticCall(&CallRequest{
+		From:  caller,
+		To:    contract.Hex(),
+		Value: "1000", // El caller no tiene balance: la llamada no debe validarlo
+	})
+	if err != nil {
+		t.Fatalf("Error en StaticCall: %v", err)
+	}
+	if !result.Success {
+		t.Fatalf("StaticCall falló: %s", result.Error)
+	}
+	if new(big.Int).SetBytes(result.ReturnData).Int64() != 42 {
+		t.Errorf("Valor retornado incorrecto: %x", result.ReturnData)
+	}
+
+	// El estado vivo no debe cambiar
+	value, _ := evm.GetStorageAt(contract.Hex(), common.Hash{})
+	if value != (common.Hash{}) {
+		t.Errorf("StaticCall modificó storage: %s", value.Hex())
+	}
+	nonce, _ := evm.GetNonce(caller)
+	if nonce != 0 {
+		t.Errorf("StaticCall modificó nonce: %d", nonce)
+	}
+	balance, _ := evm.GetBalance(contract.Hex())
+	if balance.Sign() != 0 {
+		t.Errorf("StaticCall transfirió value: %s", balance)
+	}
+}
+
+// TestEVMExecutor_StaticCall_RevertReason verifica la decodificación de Error(string)
+func TestEVMExecutor_StaticCall_RevertReason(t *testing.T) {
+	evm := crearTestEVM(t, "static_call_revert")
+
+	contract := common.HexToAddress("0x4444444444444444444444444444444444444444")
+	evm.getStateDB().SetCode(contract, revertingCode("saldo insuficiente"), tracing.CodeChangeUnspecified)
+
+	result, err := evm.StaticCall(&CallRequest{
+		From: "0x3333333333333333333333333333333333333333",
+		To:   contract.Hex(),
+	})
+	if err != nil {
+		t.Fatalf("Error en StaticCall: %v", err)
+	}
+	if result.Success || !result.Reverted {
+		t.Fatalf("Se esperaba revert, obtenido: %+v", result)
+	}
+	if result.RevertReason != "saldo insuficiente" {
+		t.Errorf("Revert reason incorrecto: %q", result.RevertReason)
+	}
+
+	// CallContract debe propagar el motivo en el error
+	if _, err := evm.CallContract("0x3333333333333333333333333333333333333333", contract.Hex(), nil, 0); err == nil {
+		t.Error("CallContract debería fallar con revert")
+	}
+}
+
+// TestEVMExecutor_StaticCall_Height verifica llamadas contra el estado de una altura pasada
+func TestEVMExecutor_StaticCall_Height(t *testing.T) {
+	evm := crearTestEVM(t, "static_call_height")
+
+	// Altura 1: contrato que retorna 42 y contrato que retorna block.timestamp
+	contract := common.HexToAddress("0x5555555555555555555555555555555555555555")
+	clock := common.HexToAddress("0x6666666666666666666666666666666666666666")
+	evm.getStateDB().SetCode(contract, common.FromHex("0x602a60005260206000f3"), tracing.CodeChangeUnspecified)
+	evm.getStateDB().SetCode(clock, common.FromHex("0x4260005260206000f3"), tracing.CodeChangeUnspecified)
+	if err := evm.SaveState(); err != nil {
+		t.Fatalf("Error guardando estado: %v", err)
+	}
+	if _, err := evm.CommitHeader(common.Hash{}, 0, types.Bloom{}); err != nil {
+		t.Fatalf("Error guardando header: %v", err)
+	}
+
+	// Altura 2: el contrato retorna 7
+	evm.SetCurrentBlockInfo(2, 1700000000)
+	evm.getStateDB().SetCode(contract, common.FromHex("0x600760005260206000f3"), tracing.CodeChangeUnspecified)
+	if err := evm.SaveState(); err != nil {
+		t.Fatalf("Error guardando estado: %v", err)
+	}
+	if _, err := evm.CommitHeader(common.Hash{}, 0, types.Bloom{}); err != nil {
+		t.Fatalf("Error guardando header: %v", err)
+	}
+
+	result, err := evm.StaticCall(&CallRequest{To: contract.Hex(), Height: 1})
+	if err != nil {
+		t.Fatalf("Error en StaticCall histórico: %v", err)
+	}
+	if new(big.Int).SetBytes(result.ReturnData).Int64() != 42 {
+		t.Errorf("Valor en altura 1 incorrecto: %x", result.ReturnData)
+	}
+
+	result, err = evm.StaticCall(&CallRequest{To: contract.Hex()})
+	if err != nil {
+		t.Fatalf("Error en StaticCall: %v", err)
+	}
+	if new(big.Int).SetBytes(result.ReturnData).Int64() != 7 {
+		t.Errorf("Valor en último estado incorrecto: %x", result.ReturnData)
+	}
+
+	// El contexto de bloque histórico sale del header persistido, no del bloque en ejecución
+	result, err = evm.StaticCall(&CallRequest{To: clock.Hex(), Height: 1})
+	if err != nil {
+		t.Fatalf("Error en StaticCall histórico: %v", err)
+	}
+	if new(big.Int).SetBytes(result.ReturnData).Int64() != 1699999999 {
+		t.Errorf("block.timestamp en altura 1 incorrecto: %x", result.ReturnData)
+	}
+
+	if _, err := evm.StaticCall(&CallRequest{To: contract.Hex(), Height: 99}); err == nil {
+		t.Error("Se esperaba error para altura sin estado")
+	}
+}
+
+// TestEVMExecutor_EstimateGas verifica la búsqueda binaria del gas mínimo
+func TestEVMExecutor_EstimateGas(t *testing.T) {
+	evm := crearTestEVM(t, "estimate_gas")
+
+	caller := "0x3333333333333333333333333333333333333333"
+
+	// Transferencia simple: gas intrínseco
+	gas, err := evm.EstimateGas(&CallRequest{
+		From:  caller,
+		To:    "0x6666666666666666666666666666666666666666",
+		Value: "1",
+	})
+	if err != nil {
+		t.Fatalf("Error estimando transferencia: %v", err)
+	}
+	if gas != 21000 {
+		t.Errorf("Gas de transferencia incorrecto: esperado 21000, obtenido %d", gas)
+	}
+
+	// Contrato con SSTORE: el estimado debe ser exacto (gas-1 falla)
+	contract := common.HexToAddress("0x2222222222222222222222222222222222222222")
+	evm.getStateDB().SetCode(contract, common.FromHex("0x602a600055602a60005260206000f3"), tracing.CodeChangeUnspecified)
+
+	gas, err = evm.EstimateGas(&CallRequest{From: caller, To: contract.Hex()})
+	if err != nil {
+		t.Fatalf("Error estimando llamada: %v", err)
+	}
+	if result, _ := evm.StaticCall(&CallRequest{From: caller, To: contract.Hex(), GasLimit: gas}); !result.Success {
+		t.Errorf("La llamada falla con el gas estimado %d: %s", gas, result.Error)
+	}
+	if result, _ := evm.StaticCall(&CallRequest{From: caller, To: contract.Hex(), GasLimit: gas - 1}); result.Success {
+		t.Errorf("La llamada no debería alcanzar con %d", gas-1)
+	}
+
+	// El estado vivo no cambia
+	if value, _ := evm.GetStorageAt(contract.Hex(), common.Hash{}); value != (common.Hash{}) {
+		t.Errorf("EstimateGas modificó storage: %s", value.Hex())
+	}
+}
+
+// TestEVMExecutor_EstimateGas_Revert verifica que un revert se reporta con su motivo
+func TestEVMExecutor_EstimateGas_Revert(t *testing.T) {
+	evm := crearTestEVM(t, "estimate_gas_revert")
+
+	contract := common.HexToAddress("0x4444444444444444444444444444444444444444")
+	evm.getStateDB().SetCode(contract, revertingCode("no autorizado"), tracing.CodeChangeUnspecified)
+
+	_, err := evm.EstimateGas(&CallRequest{
+		From: "0x3333333333333333333333333333333333333333",
+		To:   contract.Hex(),
+	})
+	revertErr, ok := err.(*RevertError)
+	if !ok {
+		t.Fatalf("Se esperaba *RevertError, obtenido %v", err)
+	}
+	if revertErr.Reason != "no autorizado" {
+		t.Errorf("Revert reason incorrecto: %q", revertErr.Reason)
+	}
+}
@@ -0,0 +1,87 @@
+package execution
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// DefaultEVMChainID es el chain ID EVM usado cuando no hay archivo de configuración de cadena
+const DefaultEVMChainID int64 = 999
+
+// ChainSpec es el archivo de configuración de la cadena (perfil testnet/mainnet).
+// Config usa el formato "config" del genesis de geth: chainId, forks por bloque
+// (homesteadBlock ... londonBlock) y forks por timestamp (shanghaiTime, cancunTime, pragueTime)
+// junto con su blobSchedule.
+type ChainSpec struct {
+	// CometChainID es el chain_id del genesis de CometBFT con el que debe coincidir el nodo
+	CometChainID string              `json:"cometChainId"`
+	Config       *params.ChainConfig `json:"config"`
+}
+
+// DefaultChainConfig retorna la configuración EVM por defecto (chain ID 999, forks hasta London)
+func DefaultChainConfig() *params.ChainConfig {
+	return &params.ChainConfig{
+		ChainID:             big.NewInt(DefaultEVMChainID),
+		HomesteadBlock:      big.NewInt(0),
+		EIP150Block:         big.NewInt(0),
+		EIP155Block:         big.NewInt(0),
+		EIP158Block:         big.NewInt(0),
+		ByzantiumBlock:      big.NewInt(0),
+		ConstantinopleBlock: big.NewInt(0),
+		PetersburgBlock:     big.NewInt(0),
+		IstanbulBlock:       big.NewInt(0),
+		BerlinBlock:         big.NewInt(0),
+		LondonBlock:         big.NewInt(0),
+	}
+}
+
+// LoadChainSpec lee y valida un archivo de configuración de cadena
+func LoadChainSpec(path string) (*ChainSpec, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error leyendo configuración de cadena %s: %w", path, err)
+	}
+
+	var spec ChainSpec
+	if err := json.Unmarshal(data, &spec); err != nil {
+		return nil, fmt.Errorf("error parseando configuración de cadena %s: %w", path, err)
+	}
+
+	if err := spec.Validate(); err != nil {
+		return nil, fmt.Errorf("configuración de cadena %s inválida: %w", path, err)
+	}
+	return &spec, nil
+}
+
+// Validate verifica chain ID, orden de forks y blob schedule de los forks con blobs
+func (s *ChainSpec) Validate() error {
+	if s.Config == nil {
+		return fmt.Errorf("falta la sección config")
+	}
+	if s.Config.ChainID == nil || s.Config.ChainID.Sign() <= 0 {
+		return fmt.Errorf("chainId EVM inválido")
+	}
+	// El mercado de fees y los headers asumen EIP-1559 (London) activo
+	if s.Config.LondonBlock == nil {
+		return fmt.Errorf("londonBlock es obligatorio")
+	}
+	if err := s.Config.CheckConfigForkOrder(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// ValidateCometChainID verifica que el chain_id del genesis de CometBFT coincida con el de la configuración
+func (s *ChainSpec) ValidateCometChainID(cometChainID string) error {
+	if s.CometChainID == "" {
+		return nil
+	}
+	if s.CometChainID != cometChainID {
+		return fmt.Errorf("chain_id de CometBFT %q no coincide con la configuración de cadena (%q)", cometChainID, s.CometChainID)
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package execution
+
+import (
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// cancunSpecJSON activa Shanghai, Cancun y Prague desde el génesis
+const cancunSpecJSON = `{
+  "cometChainId": "oxy-test-chain",
+  "config": {
+    "chainId": 4242,
+    "homesteadBlock": 0, "eip150Block": 0, "eip155Block": 0, "eip158Block": 0,
+    "byzantiumBlock": 0, "constantinopleBlock": 0, "petersburgBlock": 0,
+    "istanbulBlock": 0, "berlinBlock": 0, "londonBlock": 0,
+    "shanghaiTime": 0, "cancunTime": 0, "pragueTime": 0,
+    "blobSchedule": {
+      "cancun": {"target": 3, "max": 6, "baseFeeUpdateFraction": 3338477},
+      "prague": {"target": 6, "max": 9, "baseFeeUpdateFraction": 5007716}
+    }
+  }
+}`
+
+// escribirSpec escribe una configuración de cadena en un archivo temporal
+func escribirSpec(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "chain.json")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Error escribiendo configuración: %v", err)
+	}
+	return path
+}
+
+// TestLoadChainSpec verifica la carga y validación del archivo de configuración de cadena
+func TestLoadChainSpec(t *testing.T) {
+	spec, err := LoadChainSpec(escribirSpec(t, cancunSpecJSON))
+	if err != nil {
+		t.Fatalf("Error cargando configuración: %v", err)
+	}
+	if spec.Config.ChainID.Cmp(big.NewInt(4242)) != 0 {
+		t.Errorf("ChainID incorrecto: %s", spec.Config.ChainID)
+	}
+	if !spec.Config.IsCancun(big.NewInt(1), 0) || !spec.Config.IsPrague(big.NewInt(1), 0) {
+		t.Error("Cancun y Prague deberían estar activos")
+	}
+
+	if err := spec.ValidateCometChainID("oxy-test-chain"); err != nil {
+		t.Errorf("chain_id correcto rechazado: %v", err)
+	}
+	if err := spec.ValidateCometChainID("otra-cadena"); err == nil {
+		t.Error("chain_id distinto debería rechazarse")
+	}
+
+	// Cancun sin Shanghai: orden de forks inválido
+	invalid := `{"config": {"chainId": 1, "homesteadBlock": 0, "eip150Block": 0, "eip155Block": 0,
+		"eip158Block": 0, "byzantiumBlock": 0, "constantinopleBlock": 0, "petersburgBlock": 0,
+		"istanbulBlock": 0, "berlinBlock": 0, "londonBlock": 0, "cancunTime": 0,
+		"blobSchedule": {"cancun": {"target": 3, "max": 6, "baseFeeUpdateFraction": 3338477}}}}`
+	if _, err := LoadChainSpec(escribirSpec(t, invalid)); err == nil {
+		t.Error("Orden de forks inválido debería rechazarse")
+	}
+
+	if _, err := LoadChainSpec(escribirSpec(t, `{"cometChainId": "x"}`)); err == nil {
+		t.Error("Configuración sin sección config debería rechazarse")
+	}
+}
+
+// TestEVMExecutor_CancunOpcodes verifica que los opcodes de Shanghai/Cancun solo se habilitan con su fork
+func TestEVMExecutor_CancunOpcodes(t *testing.T) {
+	// PUSH1 42 PUSH0 TSTORE PUSH0 TLOAD PUSH0 MSTORE PUSH1 32 PUSH0 RETURN
+	code := common.FromHex("0x602a5f5d5f5c5f5260205ff3")
+	contract := common.HexToAddress("0x4444444444444444444444444444444444444444")
+
+	spec, err := LoadChainSpec(escribirSpec(t, cancunSpecJSON))
+	if err != nil {
+		t.Fatalf("Error cargando configuración: %v", err)
+	}
+	evm := crearTestEVMConSpec(t, "cancun_opcodes", spec)
+	if evm.ChainID().Cmp(big.NewInt(4242)) != 0 {
+		t.Errorf("ChainID del ejecutor incorrecto: %s", evm.ChainID())
+	}
+	evm.getStateDB().SetCode(contract, code, tracing.CodeChangeUnspecified)
+
+	result, err := evm.StaticCall(&CallRequest{To: contract.Hex()})
+	if err != nil {
+		t.Fatalf("Error en StaticCall: %v", err)
+	}
+	if !result.Success || new(big.Int).SetBytes(result.ReturnData).Int64() != 42 {
+		t.Fatalf("TSTORE/TLOAD con Cancun activo falló: %s (%x)", result.Error, result.ReturnData)
+	}
+
+	// El header lleva los campos de los forks activos y sobrevive el round-trip RLP
+	if _, err := evm.CommitHeader(common.Hash{}, 0, types.Bloom{}); err != nil {
+		t.Fatalf("Error guardando header: %v", err)
+	}
+	header, err := LoadHeader(evm.storage, 1)
+	if err != nil {
+		t.Fatalf("Error cargando header: %v", err)
+	}
+	if header.WithdrawalsHash == nil || header.ExcessBlobGas == nil || header.RequestsHash == nil {
+		t.Error("Header sin campos de Shanghai/Cancun/Prague")
+	}
+
+	// Con la configuración por defecto (London) los mismos opcodes son inválidos
+	london := crearTestEVM(t, "london_opcodes")
+	london.getStateDB().SetCode(contract, code, tracing.CodeChangeUnspecified)
+	result, err = london.StaticCall(&CallRequest{To: contract.Hex()})
+	if err != nil {
+		t.Fatalf("Error en StaticCall: %v", err)
+	}
+	if result.Success {
+		t.Error("PUSH0/TSTORE no deberían existir antes de Shanghai/Cancun")
+	}
+}
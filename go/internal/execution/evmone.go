@@ -1,11 +1,14 @@
 package execution
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"math/big"
+	"sync"
 
 	"github.com/Q-YZX0/oxy-blockchain/internal/storage"
+	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/consensus"
 	"github.com/ethereum/go-ethereum/core"
@@ -24,38 +27,41 @@ type EVMExecutor struct {
 	storage          *storage.BlockchainDB
 	stateManager     *StateManager
 	stateDB          *state.StateDB
+	chainSpec        *ChainSpec
 	chainConfig      *params.ChainConfig
 	currentHeight    uint64
 	currentTimestamp int64
+	coinbase         common.Address  // Dirección EVM del proponente del bloque actual
+	feeConfig        FeeConfig       // Parámetros del mercado de fees EIP-1559
+	feeDistribution  FeeDistribution // Reparto de propinas entre proponente y GreenPool
+	blockGasLimit    uint64          // Gas límite por bloque (header y gas pool)
+	gasPool          *core.GasPool   // Gas restante del bloque en ejecución (nil = pool por transacción)
+	totalSupply      *big.Int        // Total supply del token nativo (emitido - quemado)
 	running          bool
+	// mu serializa el acceso al StateDB vivo (consenso escribe, REST/JSON-RPC/mesh leen)
+	mu sync.Mutex
 }
 
-// NewEVMExecutor crea una nueva instancia del ejecutor EVM
+// NewEVMExecutor crea una nueva instancia del ejecutor EVM con la configuración de cadena por defecto
 func NewEVMExecutor(storage *storage.BlockchainDB) *EVMExecutor {
-	// Configurar chain config para Oxy•gen
-	chainConfig := &params.ChainConfig{
-		ChainID:             big.NewInt(999), // Chain ID de Oxy•gen (temporal)
-		HomesteadBlock:      big.NewInt(0),
-		EIP150Block:         big.NewInt(0),
-		EIP155Block:         big.NewInt(0),
-		EIP158Block:         big.NewInt(0),
-		ByzantiumBlock:      big.NewInt(0),
-		ConstantinopleBlock: big.NewInt(0),
-		PetersburgBlock:     big.NewInt(0),
-		IstanbulBlock:       big.NewInt(0),
-		BerlinBlock:         big.NewInt(0),
-		LondonBlock:         big.NewInt(0),
-	}
+	return NewEVMExecutorWithChainSpec(storage, &ChainSpec{Config: DefaultChainConfig()})
+}
 
+// NewEVMExecutorWithChainSpec crea el ejecutor EVM con una configuración de cadena (chain ID y forks)
+func NewEVMExecutorWithChainSpec(storage *storage.BlockchainDB, spec *ChainSpec) *EVMExecutor {
 	// Usar el mismo directorio de datos que el storage para evitar conflictos en tests
 	dataDir := storage.GetDataDir()
 	stateManager := NewStateManager(storage, dataDir)
 
 	return &EVMExecutor{
-		storage:      storage,
-		stateManager: stateManager,
-		chainConfig:  chainConfig,
-		running:      false,
+		storage:       storage,
+		stateManager:  stateManager,
+		chainSpec:     spec,
+		chainConfig:   spec.Config,
+		feeConfig:     DefaultFeeConfig(),
+		blockGasLimit: DefaultBlockGasLimit,
+		totalSupply:   new(big.Int),
+		running:       false,
 	}
 }
 
@@ -68,6 +74,9 @@ func (e *EVMExecutor) Start() error {
 	}
 
 	e.stateDB = stateDB
+	if err := e.loadTotalSupply(); err != nil {
+		return err
+	}
 	e.running = true
 	log.Println("Ejecutor EVM iniciado")
 	return nil
@@ -82,6 +91,8 @@ func (e *EVMExecutor) Stop() error {
 	// Guardar estado antes de detener
 	if err := e.stateManager.SaveState(); err != nil {
 		log.Printf("Advertencia: error guardando estado: %v", err)
+	} else if err := e.storage.SaveTotalSupply(e.totalSupply); err != nil {
+		log.Printf("Advertencia: error guardando total supply: %v", err)
 	}
 
 	// Cerrar gestor de estado
@@ -106,9 +117,17 @@ func (e *EVMExecutor) ExecuteTransaction(tx *Transaction) (*ExecutionResult, err
 		return nil, fmt.Errorf("ejecutor EVM no está corriendo")
 	}
 
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
 	// Convertir transacción a formato go-ethereum
+	// To vacío significa creación de contrato (CREATE)
 	from := common.HexToAddress(tx.From)
-	to := common.HexToAddress(tx.To)
+	var to *common.Address
+	if tx.To != "" {
+		toAddr := common.HexToAddress(tx.To)
+		to = &toAddr
+	}
 	value, ok := new(big.Int).SetString(tx.Value, 10)
 	if !ok {
 		return nil, fmt.Errorf("valor inválido: %s", tx.Value)
@@ -127,113 +146,165 @@ func (e *EVMExecutor) ExecuteTransaction(tx *Transaction) (*ExecutionResult, err
 		}
 	}
 
-	// Preparar header del bloque con valores reales
-	// Coinbase es la dirección del validador (usar zero address si no hay validador específico)
-	coinbase := common.Address{}
-	
-	// BaseFee: usar big.NewInt(0) para chains sin EIP-1559
-	// NewEVMBlockContext requiere que BaseFee no sea nil
-	baseFee := big.NewInt(0) // 0 significa que no se usa EIP-1559
-	
-	// Crear header completo con todos los campos necesarios
-	header := &types.Header{
-		ParentHash: common.Hash{}, // Hash del bloque padre (zero hash para simplificar)
-		UncleHash:  types.EmptyUncleHash,
-		Coinbase:   coinbase, // Dirección del validador
-		Root:       common.Hash{}, // Root del estado (zero hash para simplificar)
-		TxHash:     types.EmptyRootHash,
-		ReceiptHash: types.EmptyRootHash,
-		Bloom:      types.Bloom{},
-		Difficulty: big.NewInt(0), // Difficulty 0 para PoS
-		Number:     big.NewInt(int64(e.currentHeight)),
-		GasLimit:   tx.GasLimit,
-		GasUsed:    0,
-		Time:       uint64(e.currentTimestamp),
-		Extra:      []byte{},
-		MixDigest:  common.Hash{},
-		Nonce:      types.BlockNonce{},
-		BaseFee:    baseFee, // 0 para chains sin EIP-1559
-	}
+	// Preparar contexto de bloque (header, coinbase, base fee)
+	blockContext := e.newBlockContext()
 
-	// Preparar contexto de ejecución
-	// NewEVMBlockContext requiere: header, ChainContext (para obtener headers previos), y author (dirección del validador)
-	// author puede ser zero address si no hay validador específico
-	author := &coinbase // Usar dirección del validador (zero address si no hay)
-	
-	// Crear adaptador de ChainContext con configuración de chain y engine nil (no necesario para ejecución básica)
-	chainAdapter := &chainContextAdapter{
-		chainConfig: e.chainConfig,
-		engine:      nil, // No necesitamos engine para ejecución básica
+	// Transacciones legacy: gasPrice actúa como fee cap y como propina máxima.
+	// Transacciones EIP-1559: maxFeePerGas / maxPriorityFeePerGas.
+	feeCap, tipCap := gasPrice, gasPrice
+	if tx.MaxFeePerGas != "" {
+		if feeCap, ok = new(big.Int).SetString(tx.MaxFeePerGas, 10); !ok {
+			return nil, fmt.Errorf("max fee per gas inválido: %s", tx.MaxFeePerGas)
+		}
+		tipCap = big.NewInt(0)
+		if tx.MaxPriorityFeePerGas != "" {
+			if tipCap, ok = new(big.Int).SetString(tx.MaxPriorityFeePerGas, 10); !ok {
+				return nil, fmt.Errorf("max priority fee per gas inválido: %s", tx.MaxPriorityFeePerGas)
+			}
+		}
 	}
-	
-	blockContext := core.NewEVMBlockContext(header, chainAdapter, author)
 
-	// Crear message para ejecutar
-	// Para chains sin EIP-1559, usamos GasPrice tradicional
-	// GasFeeCap y GasTipCap se usan solo para EIP-1559
+	// Crear message para ejecutar. ApplyMessage rechaza fee caps por debajo del base fee;
+	// el proponente (coinbase) recibe solo la propina efectiva.
 	msg := core.Message{
 		From:       from,
-		To:         &to,
+		To:         to,
 		Nonce:      tx.Nonce,
 		Value:      value,
 		GasLimit:   tx.GasLimit,
-		GasPrice:   gasPrice,
-		GasFeeCap:  gasPrice, // Usar gasPrice como GasFeeCap si no se especifica
-		GasTipCap:  gasPrice, // Usar gasPrice como GasTipCap si no se especifica
+		GasPrice:   effectiveGasPrice(blockContext.BaseFee, feeCap, tipCap),
+		GasFeeCap:  feeCap,
+		GasTipCap:  tipCap,
 		Data:       tx.Data,
-		AccessList: nil,
+		AccessList: tx.AccessList,
 	}
 
+	// Para CREATE la dirección depende del nonce del remitente antes de ejecutar
+	var contractAddress string
+	if to == nil {
+		contractAddress = crypto.CreateAddress(from, e.getStateDB().GetNonce(from)).Hex()
+	}
+
+	// Asociar los logs que emita la ejecución a esta transacción (hash e índice en el bloque)
+	txHash := common.HexToHash(tx.Hash)
+	e.getStateDB().SetTxContext(txHash, tx.Index)
+
 	// Crear EVM (v1.16+: TxContext se pasa directamente en ApplyMessage)
 	evm := vm.NewEVM(blockContext, e.getStateDB(), e.chainConfig, vm.Config{})
 
-	// Ejecutar transacción
-	result, err := core.ApplyMessage(evm, &msg, new(core.GasPool).AddGas(tx.GasLimit))
+	// Gas del bloque: la transacción no entra si su gas límite excede el gas restante del bloque
+	gasPool := e.gasPool
+	if gasPool == nil {
+		gasPool = new(core.GasPool).AddGas(tx.GasLimit)
+	} else if gasPool.Gas() < tx.GasLimit {
+		return nil, fmt.Errorf("%w: gas restante del bloque %d, gas de la transacción %d", core.ErrGasLimitReached, gasPool.Gas(), tx.GasLimit)
+	}
 
+	// Ejecutar transacción. ApplyMessage puede fallar después de cobrar el gas por adelantado
+	// (gas intrínseco insuficiente, fondos insuficientes para el valor): se deshace el cobro y el
+	// consumo del gas del bloque, y la transacción queda sin ejecutar.
+	snapshot := e.getStateDB().Snapshot()
+	gasAvailable := gasPool.Gas()
+	result, err := core.ApplyMessage(evm, &msg, gasPool)
 	if err != nil {
-		// Si hay error, result puede ser nil, usar 0 para GasUsed
-		gasUsed := uint64(0)
-		if result != nil {
-			gasUsed = result.UsedGas
-		}
+		e.getStateDB().RevertToSnapshot(snapshot)
+		gasPool.SetGas(gasAvailable)
 		return &ExecutionResult{
-			Success: false,
-			GasUsed: gasUsed,
-			Error:   err.Error(),
+			Success:  false,
+			Rejected: true,
+			Error:    err.Error(),
 		}, nil
 	}
 
-	// Si la ejecución fue exitosa, guardar estado intermedio
-	if err == nil && !result.Failed() {
-		// Finalizar el StateDB para aplicar cambios
-		e.stateDB.Finalise(true)
+	// El base fee se quema (no se acredita a nadie) salvo que haya un destinatario configurado
+	baseFeeAmount := new(big.Int)
+	if blockContext.BaseFee != nil {
+		baseFeeAmount.Mul(blockContext.BaseFee, new(big.Int).SetUint64(result.UsedGas))
+	}
+	if baseFeeAmount.Sign() > 0 {
+		if e.feeConfig.BaseFeeRecipient != (common.Address{}) {
+			amount, _ := uint256.FromBig(baseFeeAmount)
+			e.stateDB.AddBalance(e.feeConfig.BaseFeeRecipient, amount, tracing.BalanceIncreaseRewardTransactionFee)
+		} else {
+			e.totalSupply.Sub(e.totalSupply, baseFeeAmount)
+		}
 	}
 
-	// Obtener logs del StateDB
-	var logs []Log
-	if err == nil && !result.Failed() {
-		stateDBLogs := e.stateDB.Logs()
-		logs = make([]Log, len(stateDBLogs))
-		for i, log := range stateDBLogs {
-			topics := make([]string, len(log.Topics))
-			for j, topic := range log.Topics {
-				topics[j] = topic.Hex()
-			}
-			logs[i] = Log{
-				Address: log.Address.Hex(),
-				Topics:  topics,
-				Data:    log.Data,
+	// La EVM acreditó la propina (precio efectivo - base fee) al coinbase: repartirla con el GreenPool
+	priorityFee := new(big.Int).Set(msg.GasPrice)
+	if blockContext.BaseFee != nil {
+		priorityFee.Sub(priorityFee, blockContext.BaseFee)
+	}
+	priorityFee.Mul(priorityFee, new(big.Int).SetUint64(result.UsedGas))
+	proposerFee, greenPoolFee := e.distributePriorityFee(blockContext.Coinbase, priorityFee)
+
+	// Finalizar el StateDB siempre: una ejecución revertida también cobra gas e incrementa el nonce
+	e.stateDB.Finalise(true)
+
+	// Obtener solo los logs de esta transacción (una ejecución revertida no deja logs)
+	stateDBLogs := e.stateDB.GetLogs(txHash, e.currentHeight, common.Hash{}, uint64(e.currentTimestamp))
+	logs := make([]Log, len(stateDBLogs))
+	for i, log := range stateDBLogs {
+		topics := make([]string, len(log.Topics))
+		for j, topic := range log.Topics {
+			topics[j] = topic.Hex()
+		}
+		logs[i] = Log{
+			Address: log.Address.Hex(),
+			Topics:  topics,
+			Data:    log.Data,
+		}
+	}
+
+	executionResult := &ExecutionResult{
+		Success:           err == nil && result.Failed() == false,
+		GasUsed:           result.UsedGas,
+		ReturnData:        result.ReturnData,
+		Logs:              logs,
+		Error:             "",
+		EffectiveGasPrice: msg.GasPrice.String(),
+		BaseFee:           blockContext.BaseFee.String(),
+		BaseFeeAmount:     baseFeeAmount.String(),
+		ProposerFee:       proposerFee.String(),
+		GreenPoolFee:      greenPoolFee.String(),
+		Bloom:             types.CreateBloom(&types.Receipt{Logs: stateDBLogs}),
+	}
+	if result.Failed() {
+		executionResult.Error = result.Err.Error()
+		if errors.Is(result.Err, vm.ErrExecutionReverted) {
+			if reason, unpackErr := abi.UnpackRevert(result.Revert()); unpackErr == nil {
+				executionResult.RevertReason = reason
 			}
 		}
+	} else {
+		executionResult.ContractAddress = contractAddress
 	}
 
-	return &ExecutionResult{
-		Success:    err == nil && result.Failed() == false,
-		GasUsed:    result.UsedGas,
-		ReturnData: result.ReturnData,
-		Logs:       logs,
-		Error:      "",
-	}, nil
+	return executionResult, nil
+}
+
+// CheckIntrinsicGas verifica que el gas límite de la transacción cubra su gas intrínseco y, con Prague
+// activo, el piso de calldata de EIP-7623, según las reglas del bloque height. ApplyMessage rechaza
+// esas transacciones sin ejecutarlas.
+func (e *EVMExecutor) CheckIntrinsicGas(tx *Transaction, height uint64, timestamp int64) error {
+	rules := e.chainConfig.Rules(new(big.Int).SetUint64(height), true, uint64(timestamp))
+	gas, err := core.IntrinsicGas(tx.Data, tx.AccessList, nil, tx.To == "", rules.IsHomestead, rules.IsIstanbul, rules.IsShanghai)
+	if err != nil {
+		return err
+	}
+	if tx.GasLimit < gas {
+		return fmt.Errorf("%w: tiene %d, necesita %d", core.ErrIntrinsicGas, tx.GasLimit, gas)
+	}
+	if rules.IsPrague {
+		floor, err := core.FloorDataGas(tx.Data)
+		if err != nil {
+			return err
+		}
+		if tx.GasLimit < floor {
+			return fmt.Errorf("%w: tiene %d, necesita %d", core.ErrFloorDataGas, tx.GasLimit, floor)
+		}
+	}
+	return nil
 }
 
 // getStateDB obtiene o crea el StateDB
@@ -247,12 +318,33 @@ func (e *EVMExecutor) getStateDB() *state.StateDB {
 	return e.stateDB
 }
 
+// newBlockContext crea el contexto de bloque EVM para el bloque en ejecución
+func (e *EVMExecutor) newBlockContext() vm.BlockContext {
+	return e.headerBlockContext(e.pendingHeader())
+}
+
+// headerBlockContext construye el contexto de bloque de la EVM a partir de un header
+func (e *EVMExecutor) headerBlockContext(header *types.Header) vm.BlockContext {
+	// El ChainContext sirve los headers persistidos (BLOCKHASH y ancestros)
+	chainAdapter := &chainContextAdapter{
+		chainConfig: e.chainConfig,
+		engine:      nil, // No necesitamos engine para ejecución básica
+		storage:     e.storage,
+	}
+
+	// NewEVMBlockContext requiere: header, ChainContext (para obtener headers previos), y author (dirección del validador)
+	return core.NewEVMBlockContext(header, chainAdapter, &header.Coinbase)
+}
+
 // GetState retorna el estado actual de una cuenta
 func (e *EVMExecutor) GetState(address string) (*AccountState, error) {
 	if !e.running {
 		return nil, fmt.Errorf("ejecutor EVM no está corriendo")
 	}
 
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
 	addr := common.HexToAddress(address)
 	stateDB := e.getStateDB()
 
@@ -279,6 +371,65 @@ func (e *EVMExecutor) GetState(address string) (*AccountState, error) {
 	}, nil
 }
 
+// ChainID retorna el chain ID EVM configurado (usado para firmas EIP-155 y eth_chainId)
+func (e *EVMExecutor) ChainID() *big.Int {
+	return new(big.Int).Set(e.chainConfig.ChainID)
+}
+
+// ChainConfig retorna la configuración de cadena EVM (forks activos)
+func (e *EVMExecutor) ChainConfig() *params.ChainConfig {
+	return e.chainConfig
+}
+
+// ValidateCometChainID verifica que el chain_id del genesis de CometBFT coincida con la configuración de cadena
+func (e *EVMExecutor) ValidateCometChainID(cometChainID string) error {
+	return e.chainSpec.ValidateCometChainID(cometChainID)
+}
+
+// GetBalance retorna el balance de una cuenta
+func (e *EVMExecutor) GetBalance(address string) (*big.Int, error) {
+	if !e.running {
+		return nil, fmt.Errorf("ejecutor EVM no está corriendo")
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.getStateDB().GetBalance(common.HexToAddress(address)).ToBig(), nil
+}
+
+// GetNonce retorna el nonce de una cuenta
+func (e *EVMExecutor) GetNonce(address string) (uint64, error) {
+	if !e.running {
+		return 0, fmt.Errorf("ejecutor EVM no está corriendo")
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.getStateDB().GetNonce(common.HexToAddress(address)), nil
+}
+
+// GetCode retorna el bytecode desplegado en una dirección
+func (e *EVMExecutor) GetCode(address string) ([]byte, error) {
+	if !e.running {
+		return nil, fmt.Errorf("ejecutor EVM no está corriendo")
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.getStateDB().GetCode(common.HexToAddress(address)), nil
+}
+
+// GetStorageAt retorna el valor de un slot de storage de un contrato
+func (e *EVMExecutor) GetStorageAt(address string, slot common.Hash) (common.Hash, error) {
+	if !e.running {
+		return common.Hash{}, fmt.Errorf("ejecutor EVM no está corriendo")
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.getStateDB().GetState(common.HexToAddress(address), slot), nil
+}
+
 // FundAccount agrega fondos a una cuenta (útil para testing)
 // Nota: Solo debe usarse en testnet, no en producción
 func (e *EVMExecutor) FundAccount(address string, amount string) error {
@@ -286,6 +437,9 @@ func (e *EVMExecutor) FundAccount(address string, amount string) error {
 		return fmt.Errorf("ejecutor EVM no está corriendo")
 	}
 
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
 	addr := common.HexToAddress(address)
 	stateDB := e.getStateDB()
 
@@ -302,6 +456,7 @@ func (e *EVMExecutor) FundAccount(address string, amount string) error {
 	// Agregar balance a la cuenta (nueva API requiere BalanceChangeReason)
 	// Usar BalanceIncreaseGenesisBalance para fondear cuentas en testnet
 	stateDB.AddBalance(addr, amountU256, tracing.BalanceIncreaseGenesisBalance)
+	e.totalSupply.Add(e.totalSupply, amountBig)
 
 	// Guardar estado (esto guardará los cambios en el StateDB)
 	// Nota: El estado se guardará cuando se haga commit del bloque
@@ -315,6 +470,54 @@ func (e *EVMExecutor) FundAccount(address string, amount string) error {
 	return nil
 }
 
+// Transfer mueve tokens nativos entre cuentas fuera de la EVM (módulos de protocolo como staking)
+func (e *EVMExecutor) Transfer(from, to common.Address, amount *big.Int) error {
+	if !e.running {
+		return fmt.Errorf("ejecutor EVM no está corriendo")
+	}
+	if amount.Sign() < 0 {
+		return fmt.Errorf("cantidad a transferir inválida: %s", amount)
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	value, overflow := uint256.FromBig(amount)
+	if overflow {
+		return fmt.Errorf("cantidad a transferir fuera de rango: %s", amount)
+	}
+
+	stateDB := e.getStateDB()
+	if stateDB.GetBalance(from).Cmp(value) < 0 {
+		return fmt.Errorf("balance insuficiente en %s: necesita %s", from.Hex(), amount)
+	}
+	stateDB.SubBalance(from, value, tracing.BalanceChangeTransfer)
+	stateDB.AddBalance(to, value, tracing.BalanceChangeTransfer)
+	return nil
+}
+
+// SetSystemStorage escribe slots de un contrato de sistema fuera de la EVM (módulos de protocolo como
+// el oráculo). Si la cuenta todavía no tiene bytecode, instala code.
+func (e *EVMExecutor) SetSystemStorage(address common.Address, code []byte, slots map[common.Hash]common.Hash) error {
+	if !e.running {
+		return fmt.Errorf("ejecutor EVM no está corriendo")
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	stateDB := e.getStateDB()
+	if stateDB.GetCodeSize(address) == 0 {
+		stateDB.SetCode(address, code, tracing.CodeChangeUnspecified)
+		// Nonce 1 como cualquier contrato desplegado: la cuenta no se considera vacía
+		stateDB.SetNonce(address, 1, tracing.NonceChangeUnspecified)
+	}
+	for slot, value := range slots {
+		stateDB.SetState(address, slot, value)
+	}
+	return nil
+}
+
 // DeployContract despliega un contrato inteligente
 func (e *EVMExecutor) DeployContract(
 	from string,
@@ -327,16 +530,13 @@ func (e *EVMExecutor) DeployContract(
 		return "", nil, fmt.Errorf("ejecutor EVM no está corriendo")
 	}
 
-	fromAddr := common.HexToAddress(from)
-
 	// Combinar bytecode con constructor args
 	contractData := append(code, constructorArgs...)
 
 	// Obtener nonce actual
-	stateDB := e.getStateDB()
-	nonce := uint64(0)
-	if stateDB != nil {
-		nonce = stateDB.GetNonce(common.HexToAddress(from))
+	nonce, err := e.GetNonce(from)
+	if err != nil {
+		return "", nil, err
 	}
 
 	// Crear transacción de deployment (To es nil)
@@ -360,44 +560,22 @@ func (e *EVMExecutor) DeployContract(
 		return "", result, fmt.Errorf("deployment falló: %s", result.Error)
 	}
 
-	// Calcular dirección del contrato
-	// Usar el nonce antes del deployment para calcular la dirección
-	contractAddr := crypto.CreateAddress(fromAddr, nonce).Hex()
-
-	return contractAddr, result, nil
+	return result.ContractAddress, result, nil
 }
 
-// CallContract ejecuta una llamada a un contrato (sin modificar estado)
+// CallContract ejecuta una llamada a un contrato sin modificar estado (ver StaticCall)
 func (e *EVMExecutor) CallContract(
 	from string,
 	contractAddr string,
 	data []byte,
 	gasLimit uint64,
 ) ([]byte, error) {
-	if !e.running {
-		return nil, fmt.Errorf("ejecutor EVM no está corriendo")
-	}
-
-	// Obtener nonce actual
-	stateDB := e.getStateDB()
-	nonce := uint64(0)
-	if stateDB != nil {
-		nonce = stateDB.GetNonce(common.HexToAddress(from))
-	}
-
-	// Crear transacción de llamada
-	tx := &Transaction{
+	result, err := e.StaticCall(&CallRequest{
 		From:     from,
 		To:       contractAddr,
-		Value:    "0",
 		Data:     data,
 		GasLimit: gasLimit,
-		GasPrice: "0", // Sin costo para calls
-		Nonce:    nonce,
-	}
-
-	// Ejecutar transacción
-	result, err := e.ExecuteTransaction(tx)
+	})
 	if err != nil {
 		return nil, fmt.Errorf("error ejecutando call: %w", err)
 	}
@@ -415,11 +593,24 @@ func (e *EVMExecutor) GetStateManager() *StateManager {
 }
 
 // SaveState guarda el estado actual del EVM
+// y registra el root resultante para la altura actual (consultas históricas)
 func (e *EVMExecutor) SaveState() error {
 	if e.stateManager == nil {
 		return fmt.Errorf("stateManager no está inicializado")
 	}
-	return e.stateManager.SaveState()
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if err := e.stateManager.SaveState(); err != nil {
+		return err
+	}
+	// El commit recarga el StateDB desde el nuevo root: los bloques siguientes deben escribir en ese
+	e.stateDB = e.stateManager.GetStateDB()
+	if err := e.storage.SaveTotalSupply(e.totalSupply); err != nil {
+		return fmt.Errorf("error guardando total supply: %w", err)
+	}
+	return e.stateManager.RecordRootAtHeight(e.currentHeight)
 }
 
 // SaveStateAtHeight guarda el estado en una altura específica
@@ -427,6 +618,9 @@ func (e *EVMExecutor) SaveStateAtHeight(height uint64) error {
 	if e.stateManager == nil {
 		return fmt.Errorf("stateManager no está inicializado")
 	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
 	return e.stateManager.SaveStateAtHeight(height)
 }
 
@@ -440,15 +634,38 @@ type Transaction struct {
 	GasLimit uint64
 	GasPrice string
 	Nonce    uint64
+	Index    int // Posición de la transacción en el bloque (atribución de logs)
+	// Campos EIP-1559 (wei, decimal). Si MaxFeePerGas está vacío la transacción es legacy (GasPrice).
+	MaxFeePerGas         string
+	MaxPriorityFeePerGas string
+	// Access list EIP-2930: direcciones y slots que se precalientan antes de ejecutar
+	AccessList types.AccessList
 }
 
 // ExecutionResult contiene el resultado de ejecutar una transacción
 type ExecutionResult struct {
-	Success    bool
+	Success bool
+	// Rejected indica que la EVM rechazó la transacción antes de ejecutarla (nonce, fondos, gas
+	// intrínseco): el estado queda igual que antes y la transacción no se incluye en el bloque
+	Rejected   bool
 	GasUsed    uint64
 	ReturnData []byte
 	Logs       []Log
 	Error      string
+	// RevertReason es el motivo decodificado cuando la ejecución revirtió con Error(string)/Panic(uint256)
+	RevertReason string
+	// ContractAddress es la dirección creada cuando la transacción es un deployment exitoso
+	ContractAddress string
+	// EffectiveGasPrice es el precio por gas efectivamente cobrado (wei, decimal)
+	EffectiveGasPrice string
+	// Bloom es el filtro bloom de los logs de la transacción
+	Bloom types.Bloom
+	// BaseFee es el base fee del bloque y BaseFeeAmount el total cobrado por él (quemado o enviado a BaseFeeRecipient)
+	BaseFee       string
+	BaseFeeAmount string
+	// ProposerFee es la propina acreditada al proponente y GreenPoolFee la parte enviada al GreenPool (wei, decimal)
+	ProposerFee  string
+	GreenPoolFee string
 }
 
 // AccountState representa el estado de una cuenta
@@ -467,11 +684,12 @@ type Log struct {
 	Data    []byte
 }
 
-// chainContextAdapter es un adaptador simple que implementa core.ChainContext
-// para usar en NewEVMBlockContext
+// chainContextAdapter es un adaptador que implementa core.ChainContext
+// sirviendo los headers EVM persistidos por CommitHeader
 type chainContextAdapter struct {
 	chainConfig *params.ChainConfig
 	engine      consensus.Engine
+	storage     *storage.BlockchainDB
 }
 
 // Config retorna la configuración de la chain (ChainHeaderReader)
@@ -479,24 +697,40 @@ func (c *chainContextAdapter) Config() *params.ChainConfig {
 	return c.chainConfig
 }
 
-// CurrentHeader retorna el header actual (ChainHeaderReader)
+// CurrentHeader retorna el header del último bloque comprometido (ChainHeaderReader)
 func (c *chainContextAdapter) CurrentHeader() *types.Header {
-	return nil // Simplificado para ejecución básica
+	height, err := c.storage.GetLatestHeight()
+	if err != nil {
+		return nil
+	}
+	return c.GetHeaderByNumber(height)
 }
 
 // GetHeader retorna un header por hash y número (ChainHeaderReader)
 func (c *chainContextAdapter) GetHeader(hash common.Hash, number uint64) *types.Header {
-	return nil // No necesitamos headers previos para ejecución básica
+	header := c.GetHeaderByNumber(number)
+	if header == nil || header.Hash() != hash {
+		return nil
+	}
+	return header
 }
 
 // GetHeaderByNumber retorna un header por número (ChainHeaderReader)
 func (c *chainContextAdapter) GetHeaderByNumber(number uint64) *types.Header {
-	return nil // No necesitamos headers previos para ejecución básica
+	header, err := LoadHeader(c.storage, number)
+	if err != nil {
+		return nil
+	}
+	return header
 }
 
 // GetHeaderByHash retorna un header por hash (ChainHeaderReader)
 func (c *chainContextAdapter) GetHeaderByHash(hash common.Hash) *types.Header {
-	return nil // No necesitamos headers previos para ejecución básica
+	height, err := c.storage.GetBlockHeightByHash(hash.Hex())
+	if err != nil {
+		return nil
+	}
+	return c.GetHeader(hash, height)
 }
 
 // Engine retorna el engine de consenso (ChainContext)
@@ -1,6 +1,7 @@
 package execution
 
 import (
+	"errors"
 	"fmt"
 	"math/big"
 	"os"
@@ -8,7 +9,10 @@ import (
 	"testing"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/Q-YZX0/oxy-blockchain/internal/storage"
 )
 
@@ -680,3 +684,139 @@ func TestEVMExecutor_ExecuteTransaction_InvalidNonce(t *testing.T) {
 	}
 }
 
+
+// TestEVMExecutor_ExecuteTransaction_ContractCreation verifica que To vacío ejecuta CREATE
+func TestEVMExecutor_ExecuteTransaction_ContractCreation(t *testing.T) {
+	evm := crearTestEVM(t, "contract_creation")
+
+	privateKey, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatalf("Error generando clave: %v", err)
+	}
+	fromAddr := crypto.PubkeyToAddress(privateKey.PublicKey)
+	if err := evm.FundAccount(fromAddr.Hex(), "1000000000000000000"); err != nil {
+		t.Fatalf("Error fondeando cuenta: %v", err)
+	}
+
+	// Init code que despliega el runtime "retorna 42" (602a60005260206000f3)
+	initCode := common.FromHex("0x69602a60005260206000f3600052600a6016f3")
+
+	contractAddr, result, err := evm.DeployContract(fromAddr.Hex(), initCode, nil, 200000, "1000000000")
+	if err != nil {
+		t.Fatalf("Error desplegando contrato: %v", err)
+	}
+
+	expected := crypto.CreateAddress(fromAddr, 0).Hex()
+	if contractAddr != expected || result.ContractAddress != expected {
+		t.Errorf("Dirección de contrato incorrecta: esperada %s, obtenida %s / %s", expected, contractAddr, result.ContractAddress)
+	}
+
+	code, err := evm.GetCode(contractAddr)
+	if err != nil {
+		t.Fatalf("Error obteniendo código: %v", err)
+	}
+	if common.Bytes2Hex(code) != "602a60005260206000f3" {
+		t.Errorf("Código desplegado incorrecto: %x", code)
+	}
+
+	// El contrato desplegado responde a llamadas
+	ret, err := evm.CallContract(fromAddr.Hex(), contractAddr, nil, 0)
+	if err != nil {
+		t.Fatalf("Error llamando contrato: %v", err)
+	}
+	if new(big.Int).SetBytes(ret).Int64() != 42 {
+		t.Errorf("Valor retornado incorrecto: %x", ret)
+	}
+
+	// Una llamada (To no vacío) no reporta dirección de contrato
+	callResult, err := evm.ExecuteTransaction(&Transaction{
+		From:     fromAddr.Hex(),
+		To:       contractAddr,
+		Value:    "0",
+		GasLimit: 100000,
+		GasPrice: "1000000000",
+		Nonce:    1,
+	})
+	if err != nil {
+		t.Fatalf("Error ejecutando llamada: %v", err)
+	}
+	if callResult.ContractAddress != "" {
+		t.Errorf("Llamada no debería reportar dirección de contrato: %s", callResult.ContractAddress)
+	}
+}
+
+// TestEVMExecutor_ExecuteTransaction_LogsPerTx verifica que cada resultado solo contiene sus propios logs
+func TestEVMExecutor_ExecuteTransaction_LogsPerTx(t *testing.T) {
+	evm := crearTestEVM(t, "logs_per_tx")
+
+	from := "0x3333333333333333333333333333333333333333"
+	if err := evm.FundAccount(from, "1000000000000000000"); err != nil {
+		t.Fatalf("Error fondeando cuenta: %v", err)
+	}
+
+	// LOG1(offset=0, size=32, topic=1) y STOP
+	contract := common.HexToAddress("0x7777777777777777777777777777777777777777")
+	evm.getStateDB().SetCode(contract, common.FromHex("0x6001602060006000a100"), tracing.CodeChangeUnspecified)
+
+	for i := 0; i < 2; i++ {
+		hash := common.BigToHash(big.NewInt(int64(i + 1))).Hex()
+		result, err := evm.ExecuteTransaction(&Transaction{
+			Hash:     hash,
+			From:     from,
+			To:       contract.Hex(),
+			Value:    "0",
+			GasLimit: 100000,
+			GasPrice: "1000000000",
+			Nonce:    uint64(i),
+			Index:    i,
+		})
+		if err != nil {
+			t.Fatalf("Error ejecutando transacción %d: %v", i, err)
+		}
+		if !result.Success {
+			t.Fatalf("Transacción %d falló: %s", i, result.Error)
+		}
+		if len(result.Logs) != 1 {
+			t.Fatalf("Transacción %d: esperado 1 log, obtenidos %d", i, len(result.Logs))
+		}
+		if result.EffectiveGasPrice != "1000000000" {
+			t.Errorf("Precio efectivo incorrecto: %s", result.EffectiveGasPrice)
+		}
+		if !types.BloomLookup(result.Bloom, contract) {
+			t.Errorf("El bloom de la transacción %d no contiene la dirección del contrato", i)
+		}
+	}
+}
+
+// TestEVMExecutor_CheckIntrinsicGas verifica el gas intrínseco y el piso de calldata de EIP-7623,
+// que solo se exige con Prague activo
+func TestEVMExecutor_CheckIntrinsicGas(t *testing.T) {
+	config := DefaultChainConfig()
+	pragueTime := uint64(1700000000)
+	config.ShanghaiTime, config.CancunTime, config.PragueTime = new(uint64), new(uint64), &pragueTime
+	evm := crearTestEVMConSpec(t, "intrinsic_gas", &ChainSpec{Config: config})
+
+	to := "0x4000000000000000000000000000000000000004"
+	if err := evm.CheckIntrinsicGas(&Transaction{To: to, GasLimit: 21000}, 1, 1700000000); err != nil {
+		t.Errorf("Transferencia con 21000 de gas rechazada: %v", err)
+	}
+	if err := evm.CheckIntrinsicGas(&Transaction{To: to, GasLimit: 20000}, 1, 1700000000); !errors.Is(err, core.ErrIntrinsicGas) {
+		t.Errorf("Se esperaba ErrIntrinsicGas: %v", err)
+	}
+	if err := evm.CheckIntrinsicGas(&Transaction{GasLimit: 21000}, 1, 1700000000); !errors.Is(err, core.ErrIntrinsicGas) {
+		t.Errorf("Un CREATE con 21000 de gas debería rechazarse: %v", err)
+	}
+
+	// 1000 bytes no nulos: intrínseco 37000, piso 61000 desde Prague
+	data := make([]byte, 1000)
+	for i := range data {
+		data[i] = 1
+	}
+	tx := &Transaction{To: to, Data: data, GasLimit: 40000}
+	if err := evm.CheckIntrinsicGas(tx, 1, 1699999999); err != nil {
+		t.Errorf("Antes de Prague no se exige el piso de calldata: %v", err)
+	}
+	if err := evm.CheckIntrinsicGas(tx, 1, 1700000000); !errors.Is(err, core.ErrFloorDataGas) {
+		t.Errorf("Se esperaba ErrFloorDataGas con Prague activo: %v", err)
+	}
+}
@@ -0,0 +1,141 @@
+package execution
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/misc/eip1559"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/holiman/uint256"
+)
+
+// FeeConfig contiene los parámetros del mercado de fees EIP-1559
+type FeeConfig struct {
+	// InitialBaseFee es el base fee del primer bloque (sin header padre)
+	InitialBaseFee *big.Int
+	// MinBaseFee es el piso del base fee: los bloques vacíos no lo bajan de este valor
+	MinBaseFee *big.Int
+	// BaseFeeRecipient recibe base fee * gas usado de cada transacción. Zero = el base fee se quema.
+	BaseFeeRecipient common.Address
+}
+
+// DefaultFeeConfig retorna la configuración por defecto: base fee inicial de 1 gwei, sin piso, quemado
+func DefaultFeeConfig() FeeConfig {
+	return FeeConfig{
+		InitialBaseFee: new(big.Int).SetUint64(params.InitialBaseFee),
+		MinBaseFee:     big.NewInt(0),
+	}
+}
+
+// SetFeeConfig reemplaza la configuración del mercado de fees
+func (e *EVMExecutor) SetFeeConfig(cfg FeeConfig) error {
+	if cfg.InitialBaseFee == nil || cfg.InitialBaseFee.Sign() < 0 {
+		return fmt.Errorf("base fee inicial inválido")
+	}
+	if cfg.MinBaseFee == nil || cfg.MinBaseFee.Sign() < 0 {
+		return fmt.Errorf("base fee mínimo inválido")
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.feeConfig = cfg
+	return nil
+}
+
+// GetFeeConfig retorna la configuración del mercado de fees
+func (e *EVMExecutor) GetFeeConfig() FeeConfig {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.feeConfig
+}
+
+// FeeDistribution define el reparto de las propinas (priority fees) de cada transacción.
+// El proponente del bloque (coinbase) recibe la propina y GreenPoolPercent% se transfiere al GreenPool.
+type FeeDistribution struct {
+	GreenPool        common.Address // Zero = sin GreenPool, todo al proponente
+	GreenPoolPercent uint64         // Porcentaje (0-100) de la propina destinado al GreenPool
+}
+
+// SetFeeDistribution establece el reparto de propinas (definido en el genesis)
+func (e *EVMExecutor) SetFeeDistribution(dist FeeDistribution) error {
+	if dist.GreenPoolPercent > 100 {
+		return fmt.Errorf("porcentaje del GreenPool inválido: %d", dist.GreenPoolPercent)
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.feeDistribution = dist
+	return nil
+}
+
+// GetFeeDistribution retorna el reparto de propinas
+func (e *EVMExecutor) GetFeeDistribution() FeeDistribution {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.feeDistribution
+}
+
+// distributePriorityFee reparte la propina que la EVM ya acreditó al coinbase: transfiere la parte del
+// GreenPool desde el coinbase. Sin proponente (coinbase zero) toda la propina va al GreenPool.
+// Retorna lo que queda al proponente y lo enviado al GreenPool.
+func (e *EVMExecutor) distributePriorityFee(coinbase common.Address, tip *big.Int) (*big.Int, *big.Int) {
+	greenPoolFee := new(big.Int)
+	if tip.Sign() <= 0 || e.feeDistribution.GreenPool == (common.Address{}) {
+		return new(big.Int).Set(tip), greenPoolFee
+	}
+
+	if coinbase == (common.Address{}) {
+		greenPoolFee.Set(tip)
+	} else {
+		greenPoolFee.Mul(tip, new(big.Int).SetUint64(e.feeDistribution.GreenPoolPercent))
+		greenPoolFee.Div(greenPoolFee, big.NewInt(100))
+	}
+
+	if greenPoolFee.Sign() > 0 {
+		amount, _ := uint256.FromBig(greenPoolFee)
+		e.stateDB.SubBalance(coinbase, amount, tracing.BalanceChangeTransfer)
+		e.stateDB.AddBalance(e.feeDistribution.GreenPool, amount, tracing.BalanceIncreaseRewardTransactionFee)
+	}
+	return new(big.Int).Sub(tip, greenPoolFee), greenPoolFee
+}
+
+// calcBaseFee calcula el base fee de un bloque a partir de su padre (nil = primer bloque).
+// Sube hasta 12.5% si el padre usó más gas que el objetivo (GasLimit/2) y baja si usó menos.
+func (e *EVMExecutor) calcBaseFee(parent *types.Header) *big.Int {
+	if parent == nil || parent.BaseFee == nil {
+		return new(big.Int).Set(e.feeConfig.InitialBaseFee)
+	}
+
+	baseFee := eip1559.CalcBaseFee(e.chainConfig, parent)
+	if baseFee.Cmp(e.feeConfig.MinBaseFee) < 0 {
+		baseFee = new(big.Int).Set(e.feeConfig.MinBaseFee)
+	}
+	return baseFee
+}
+
+// NextBaseFee retorna el base fee del bloque siguiente a height según el header persistido
+func (e *EVMExecutor) NextBaseFee(height uint64) *big.Int {
+	parent, err := LoadHeader(e.storage, height)
+	if err != nil {
+		parent = nil
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.calcBaseFee(parent)
+}
+
+// effectiveGasPrice retorna el precio por gas cobrado: min(feeCap, baseFee + tip)
+func effectiveGasPrice(baseFee, feeCap, tipCap *big.Int) *big.Int {
+	if baseFee == nil {
+		return new(big.Int).Set(feeCap)
+	}
+	price := new(big.Int).Add(baseFee, tipCap)
+	if price.Cmp(feeCap) > 0 {
+		price.Set(feeCap)
+	}
+	return price
+}
@@ -0,0 +1,105 @@
+package execution
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// TestEVMExecutor_BaseFeeAdjustment verifica que el base fee sube con bloques llenos, baja con
+// bloques vacíos y respeta el piso configurado
+func TestEVMExecutor_BaseFeeAdjustment(t *testing.T) {
+	evm := crearTestEVM(t, "base_fee_adjustment")
+
+	cfg := DefaultFeeConfig()
+	cfg.MinBaseFee = big.NewInt(900000000)
+	if err := evm.SetFeeConfig(cfg); err != nil {
+		t.Fatalf("Error configurando fees: %v", err)
+	}
+
+	// Altura 1 sin padre: base fee inicial
+	if baseFee := evm.pendingHeader().BaseFee; baseFee.Cmp(cfg.InitialBaseFee) != 0 {
+		t.Fatalf("Base fee inicial incorrecto: %s", baseFee)
+	}
+
+	// Bloque lleno (el doble del objetivo): +12.5%
+	if _, err := evm.CommitHeader(common.Hash{}, DefaultBlockGasLimit, types.Bloom{}); err != nil {
+		t.Fatalf("Error guardando header: %v", err)
+	}
+	if next := evm.NextBaseFee(1); next.Cmp(big.NewInt(1125000000)) != 0 {
+		t.Errorf("Base fee tras bloque lleno incorrecto: %s", next)
+	}
+
+	// Bloque vacío: -12.5% (1.125 gwei -> 984375000)
+	evm.SetCurrentBlockInfo(2, 1700000000)
+	if _, err := evm.CommitHeader(common.Hash{}, 0, types.Bloom{}); err != nil {
+		t.Fatalf("Error guardando header: %v", err)
+	}
+	if next := evm.NextBaseFee(2); next.Cmp(big.NewInt(984375000)) != 0 {
+		t.Errorf("Base fee tras bloque vacío incorrecto: %s", next)
+	}
+
+	// Otro bloque vacío bajaría a 861328125: se aplica el piso
+	evm.SetCurrentBlockInfo(3, 1700000001)
+	if _, err := evm.CommitHeader(common.Hash{}, 0, types.Bloom{}); err != nil {
+		t.Fatalf("Error guardando header: %v", err)
+	}
+	if next := evm.NextBaseFee(3); next.Cmp(cfg.MinBaseFee) != 0 {
+		t.Errorf("Base fee debería respetar el piso: %s", next)
+	}
+}
+
+// TestEVMExecutor_DynamicFeeTransaction verifica el cobro EIP-1559 y el envío del base fee
+func TestEVMExecutor_DynamicFeeTransaction(t *testing.T) {
+	evm := crearTestEVM(t, "dynamic_fee_tx")
+
+	recipient := common.HexToAddress("0x00000000000000000000000000000000000fee00")
+	cfg := DefaultFeeConfig()
+	cfg.BaseFeeRecipient = recipient
+	if err := evm.SetFeeConfig(cfg); err != nil {
+		t.Fatalf("Error configurando fees: %v", err)
+	}
+	evm.SetCoinbase("0x00000000000000000000000000000000000c0ffe")
+
+	from := "0x3333333333333333333333333333333333333333"
+	if err := evm.FundAccount(from, "1000000000000000000"); err != nil {
+		t.Fatalf("Error fondeando cuenta: %v", err)
+	}
+
+	// Fee cap por debajo del base fee (1 gwei): la transacción se rechaza sin cambiar estado
+	result, err := evm.ExecuteTransaction(&Transaction{
+		Hash: common.HexToHash("0x01").Hex(), From: from, To: recipient.Hex(), Value: "0",
+		GasLimit: 21000, GasPrice: "0", MaxFeePerGas: "500000000", Nonce: 0,
+	})
+	if err != nil {
+		t.Fatalf("Error ejecutando transacción: %v", err)
+	}
+	if result.Success {
+		t.Fatal("Una transacción con fee cap menor al base fee debería fallar")
+	}
+
+	// Fee cap 3 gwei, propina 2 gwei: precio efectivo = min(3, 1+2) = 3 gwei
+	result, err = evm.ExecuteTransaction(&Transaction{
+		Hash: common.HexToHash("0x02").Hex(), From: from, To: "0x4444444444444444444444444444444444444444", Value: "0",
+		GasLimit: 21000, GasPrice: "0", MaxFeePerGas: "3000000000", MaxPriorityFeePerGas: "2000000000", Nonce: 0,
+	})
+	if err != nil || !result.Success {
+		t.Fatalf("Transacción EIP-1559 falló: %v %+v", err, result)
+	}
+	if result.EffectiveGasPrice != "3000000000" {
+		t.Errorf("Precio efectivo incorrecto: %s", result.EffectiveGasPrice)
+	}
+	if result.BaseFeeAmount != "21000000000000" {
+		t.Errorf("Base fee cobrado incorrecto: %s", result.BaseFeeAmount)
+	}
+
+	// El destinatario del base fee recibe base fee * gas y el coinbase solo la propina
+	if balance, _ := evm.GetBalance(recipient.Hex()); balance.String() != "21000000000000" {
+		t.Errorf("Balance del destinatario del base fee incorrecto: %s", balance)
+	}
+	if balance, _ := evm.GetBalance("0x00000000000000000000000000000000000c0ffe"); balance.String() != "42000000000000" {
+		t.Errorf("Balance del coinbase incorrecto: %s", balance)
+	}
+}
@@ -0,0 +1,126 @@
+package execution
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/Q-YZX0/oxy-blockchain/internal/storage"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// DefaultBlockGasLimit es el gas límite por bloque cuando los parámetros de consenso no fijan uno
+const DefaultBlockGasLimit uint64 = 30000000
+
+// LoadHeader carga el header EVM persistido de una altura
+func LoadHeader(db *storage.BlockchainDB, height uint64) (*types.Header, error) {
+	headerData, err := db.GetHeader(height)
+	if err != nil {
+		return nil, fmt.Errorf("header no encontrado en altura %d: %w", height, err)
+	}
+
+	var header types.Header
+	if err := rlp.DecodeBytes(headerData, &header); err != nil {
+		return nil, fmt.Errorf("error decodificando header en altura %d: %w", height, err)
+	}
+	return &header, nil
+}
+
+// SetCoinbase establece la dirección EVM del proponente del bloque actual (block.coinbase)
+func (e *EVMExecutor) SetCoinbase(address string) {
+	if address == "" {
+		e.coinbase = common.Address{}
+		return
+	}
+	e.coinbase = common.HexToAddress(address)
+}
+
+// SetBlockGasLimit establece el gas límite por bloque (parámetro de consenso block.max_gas)
+func (e *EVMExecutor) SetBlockGasLimit(limit uint64) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.blockGasLimit = limit
+}
+
+// BlockGasLimit retorna el gas límite por bloque
+func (e *EVMExecutor) BlockGasLimit() uint64 {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.blockGasLimit
+}
+
+// ResetGasPool inicia el gas pool compartido por todas las transacciones del bloque en ejecución.
+// Mientras está activo, ExecuteTransaction descuenta el gas de cada transacción de este pool.
+func (e *EVMExecutor) ResetGasPool() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.gasPool = new(core.GasPool).AddGas(e.blockGasLimit)
+}
+
+// pendingHeader construye el header del bloque en ejecución a partir del header padre persistido.
+// Root y GasUsed se completan en CommitHeader; el base fee se deriva del padre (EIP-1559).
+func (e *EVMExecutor) pendingHeader() *types.Header {
+	parentHash := common.Hash{}
+	var parent *types.Header
+	if e.currentHeight > 0 {
+		if header, err := LoadHeader(e.storage, e.currentHeight-1); err == nil {
+			parent = header
+			parentHash = parent.Hash()
+		}
+	}
+
+	header := &types.Header{
+		ParentHash:  parentHash,
+		UncleHash:   types.EmptyUncleHash,
+		Coinbase:    e.coinbase,
+		Root:        common.Hash{},
+		TxHash:      types.EmptyRootHash,
+		ReceiptHash: types.EmptyRootHash,
+		Bloom:       types.Bloom{},
+		Difficulty:  big.NewInt(0), // Difficulty 0 para PoS
+		Number:      new(big.Int).SetUint64(e.currentHeight),
+		GasLimit:    e.blockGasLimit,
+		GasUsed:     0,
+		Time:        uint64(e.currentTimestamp),
+		Extra:       []byte{},
+		MixDigest:   common.Hash{},
+		Nonce:       types.BlockNonce{},
+		BaseFee:     e.calcBaseFee(parent), // EIP-1559: ajustado según el gas usado por el padre
+	}
+
+	// Campos obligatorios según los forks activos por timestamp (sin withdrawals, blobs ni requests)
+	if e.chainConfig.IsShanghai(header.Number, header.Time) {
+		header.WithdrawalsHash = &types.EmptyWithdrawalsHash
+	}
+	if e.chainConfig.IsCancun(header.Number, header.Time) {
+		header.ExcessBlobGas = new(uint64)
+		header.BlobGasUsed = new(uint64)
+		header.ParentBeaconRoot = &common.Hash{}
+	}
+	if e.chainConfig.IsPrague(header.Number, header.Time) {
+		header.RequestsHash = &types.EmptyRequestsHash
+	}
+	return header
+}
+
+// CommitHeader completa y persiste el header EVM del bloque actual.
+// Debe llamarse en Commit, después de guardar el estado (root final).
+func (e *EVMExecutor) CommitHeader(root common.Hash, gasUsed uint64, bloom types.Bloom) (*types.Header, error) {
+	header := e.pendingHeader()
+	header.Root = root
+	header.GasUsed = gasUsed
+	header.Bloom = bloom
+
+	headerData, err := rlp.EncodeToBytes(header)
+	if err != nil {
+		return nil, fmt.Errorf("error serializando header: %w", err)
+	}
+
+	if err := e.storage.SaveHeader(e.currentHeight, headerData); err != nil {
+		return nil, fmt.Errorf("error guardando header en altura %d: %w", e.currentHeight, err)
+	}
+
+	return header, nil
+}
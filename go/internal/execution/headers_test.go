@@ -0,0 +1,72 @@
+package execution
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// TestEVMExecutor_HeaderChain verifica parent hash, BLOCKHASH y COINBASE desde headers persistidos
+func TestEVMExecutor_HeaderChain(t *testing.T) {
+	evm := crearTestEVM(t, "header_chain")
+
+	proposer := "0x7777777777777777777777777777777777777777"
+
+	// Comprometer headers de las alturas 1 y 2
+	evm.SetCurrentBlockInfo(1, 1700000000)
+	evm.SetCoinbase(proposer)
+	header1, err := evm.CommitHeader(common.HexToHash("0x01"), 0, types.Bloom{})
+	if err != nil {
+		t.Fatalf("Error comprometiendo header 1: %v", err)
+	}
+
+	evm.SetCurrentBlockInfo(2, 1700000005)
+	header2, err := evm.CommitHeader(common.HexToHash("0x02"), 21000, types.Bloom{})
+	if err != nil {
+		t.Fatalf("Error comprometiendo header 2: %v", err)
+	}
+
+	if header2.ParentHash != header1.Hash() {
+		t.Errorf("ParentHash incorrecto: esperado %s, obtenido %s", header1.Hash().Hex(), header2.ParentHash.Hex())
+	}
+	if header1.Coinbase != common.HexToAddress(proposer) {
+		t.Errorf("Coinbase incorrecto: %s", header1.Coinbase.Hex())
+	}
+
+	loaded, err := LoadHeader(evm.storage, 2)
+	if err != nil {
+		t.Fatalf("Error cargando header: %v", err)
+	}
+	if loaded.Hash() != header2.Hash() || loaded.GasUsed != 21000 {
+		t.Errorf("Header cargado no coincide con el comprometido")
+	}
+
+	// Altura 3 en ejecución: BLOCKHASH(1) y COINBASE deben venir de la cadena real
+	evm.SetCurrentBlockInfo(3, 1700000010)
+
+	blockhashContract := common.HexToAddress("0x8888888888888888888888888888888888888888")
+	// PUSH1 1 BLOCKHASH PUSH1 0 MSTORE PUSH1 32 PUSH1 0 RETURN
+	evm.getStateDB().SetCode(blockhashContract, common.FromHex("0x60014060005260206000f3"), tracing.CodeChangeUnspecified)
+
+	result, err := evm.StaticCall(&CallRequest{To: blockhashContract.Hex()})
+	if err != nil || !result.Success {
+		t.Fatalf("Error llamando BLOCKHASH: %v %+v", err, result)
+	}
+	if common.BytesToHash(result.ReturnData) != header1.Hash() {
+		t.Errorf("BLOCKHASH(1) incorrecto: esperado %s, obtenido %x", header1.Hash().Hex(), result.ReturnData)
+	}
+
+	coinbaseContract := common.HexToAddress("0x9999999999999999999999999999999999999999")
+	// COINBASE PUSH1 0 MSTORE PUSH1 32 PUSH1 0 RETURN
+	evm.getStateDB().SetCode(coinbaseContract, common.FromHex("0x4160005260206000f3"), tracing.CodeChangeUnspecified)
+
+	result, err = evm.StaticCall(&CallRequest{To: coinbaseContract.Hex()})
+	if err != nil || !result.Success {
+		t.Fatalf("Error llamando COINBASE: %v %+v", err, result)
+	}
+	if common.BytesToAddress(result.ReturnData) != common.HexToAddress(proposer) {
+		t.Errorf("COINBASE incorrecto: %x", result.ReturnData)
+	}
+}
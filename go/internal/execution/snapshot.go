@@ -0,0 +1,189 @@
+package execution
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
+	"github.com/ethereum/go-ethereum/triedb"
+)
+
+// Tipos de entrada del estado EVM exportado en un snapshot. Las entradas se direccionan por contenido:
+// la clave es el hash keccak256 del valor, así que cada una se verifica al importarla.
+const (
+	StateEntryTrieNode byte = 1 // Nodo del trie de cuentas o de un trie de storage
+	StateEntryCode     byte = 2 // Bytecode de un contrato
+)
+
+// ExportState recorre el estado del root dado (trie de cuentas, tries de storage y bytecode) y entrega
+// cada nodo a emit. No bloquea la ejecución: los nodos comprometidos no se borran, así que puede correr
+// en segundo plano mientras se procesan bloques nuevos.
+func (e *EVMExecutor) ExportState(root common.Hash, emit func(kind byte, key, value []byte) error) error {
+	e.mu.Lock()
+	sm := e.stateManager
+	database := sm.database
+	e.mu.Unlock()
+
+	if database == nil {
+		return fmt.Errorf("database no está inicializado")
+	}
+	return walkState(database.TrieDB(), root, emit)
+}
+
+// ImportStateEntry escribe en disco un nodo o un bytecode de un snapshot, verificando que su hash
+// coincide con la clave
+func (e *EVMExecutor) ImportStateEntry(kind byte, key, value []byte) error {
+	hash := common.BytesToHash(key)
+	if len(key) != common.HashLength || crypto.Keccak256Hash(value) != hash {
+		return fmt.Errorf("entrada de estado corrupta: hash %x", key)
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.stateManager.database == nil {
+		return fmt.Errorf("database no está inicializado")
+	}
+	disk := e.stateManager.database.TrieDB().Disk()
+	switch kind {
+	case StateEntryTrieNode:
+		rawdb.WriteLegacyTrieNode(disk, hash, value)
+	case StateEntryCode:
+		rawdb.WriteCode(disk, hash, value)
+	default:
+		return fmt.Errorf("tipo de entrada de estado desconocido: %d", kind)
+	}
+	return nil
+}
+
+// RestoreState verifica que el estado importado del root está completo y lo carga como estado actual
+// en la altura dada (restauración de un snapshot)
+func (e *EVMExecutor) RestoreState(root common.Hash, height uint64) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	sm := e.stateManager
+	if sm.database == nil {
+		return fmt.Errorf("database no está inicializado")
+	}
+
+	// Recorrer todo el estado falla si falta algún nodo o bytecode
+	if err := walkState(sm.database.TrieDB(), root, nil); err != nil {
+		return fmt.Errorf("estado restaurado incompleto: %w", err)
+	}
+
+	stateDB, err := sm.reloadStateFromRoot(root)
+	if err != nil {
+		return err
+	}
+	if got := stateDB.IntermediateRoot(true); got != root {
+		return fmt.Errorf("root restaurado %s no coincide con %s", got.Hex(), root.Hex())
+	}
+	sm.stateDB = stateDB
+	sm.stateRoot = root
+	e.stateDB = stateDB
+	e.currentHeight = height
+
+	stateData, err := json.Marshal(map[string]interface{}{
+		"root":   root.Hex(),
+		"height": height,
+	})
+	if err != nil {
+		return fmt.Errorf("error serializando estado: %w", err)
+	}
+	if err := sm.storage.SaveState(stateData); err != nil {
+		return fmt.Errorf("error guardando estado: %w", err)
+	}
+	if err := sm.RecordRootAtHeight(height); err != nil {
+		return err
+	}
+	return e.loadTotalSupply()
+}
+
+// walkState recorre los nodos del trie de cuentas, los tries de storage y el bytecode de un root.
+// Con emit nil solo verifica que todo el estado está disponible.
+func walkState(tdb *triedb.Database, root common.Hash, emit func(kind byte, key, value []byte) error) error {
+	if root == types.EmptyRootHash || root == (common.Hash{}) {
+		return nil
+	}
+
+	accounts, err := trie.New(trie.StateTrieID(root), tdb)
+	if err != nil {
+		return fmt.Errorf("error abriendo trie de estado: %w", err)
+	}
+	codes := make(map[common.Hash]bool)
+	storageRoots := make(map[common.Hash]bool)
+
+	it, err := accounts.NodeIterator(nil)
+	if err != nil {
+		return fmt.Errorf("error iterando trie de estado: %w", err)
+	}
+	for it.Next(true) {
+		if err := emitNode(it, emit); err != nil {
+			return err
+		}
+		if !it.Leaf() {
+			continue
+		}
+
+		var account types.StateAccount
+		if err := rlp.DecodeBytes(it.LeafBlob(), &account); err != nil {
+			return fmt.Errorf("error decodificando cuenta: %w", err)
+		}
+
+		// Los tries de storage y el bytecode compartidos por varias cuentas se exportan una vez
+		if account.Root != types.EmptyRootHash && !storageRoots[account.Root] {
+			storageRoots[account.Root] = true
+			owner := common.BytesToHash(it.LeafKey())
+			storage, err := trie.New(trie.StorageTrieID(root, owner, account.Root), tdb)
+			if err != nil {
+				return fmt.Errorf("error abriendo storage de %x: %w", owner, err)
+			}
+			storageIt, err := storage.NodeIterator(nil)
+			if err != nil {
+				return fmt.Errorf("error iterando storage de %x: %w", owner, err)
+			}
+			for storageIt.Next(true) {
+				if err := emitNode(storageIt, emit); err != nil {
+					return err
+				}
+			}
+			if err := storageIt.Error(); err != nil {
+				return fmt.Errorf("error iterando storage de %x: %w", owner, err)
+			}
+		}
+
+		codeHash := common.BytesToHash(account.CodeHash)
+		if codeHash != types.EmptyCodeHash && !codes[codeHash] {
+			codes[codeHash] = true
+			code := rawdb.ReadCode(tdb.Disk(), codeHash)
+			if len(code) == 0 {
+				return fmt.Errorf("bytecode no encontrado: %s", codeHash.Hex())
+			}
+			if emit != nil {
+				if err := emit(StateEntryCode, codeHash.Bytes(), code); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	if err := it.Error(); err != nil {
+		return fmt.Errorf("error iterando trie de estado: %w", err)
+	}
+	return nil
+}
+
+// emitNode entrega el nodo actual del iterador si está almacenado por hash (los nodos embebidos en su
+// padre no tienen entrada propia)
+func emitNode(it trie.NodeIterator, emit func(kind byte, key, value []byte) error) error {
+	hash := it.Hash()
+	if emit == nil || hash == (common.Hash{}) {
+		return nil
+	}
+	return emit(StateEntryTrieNode, hash.Bytes(), it.NodeBlob())
+}
@@ -136,6 +136,19 @@ func (sm *StateManager) SaveState() error {
 	if err != nil {
 		return fmt.Errorf("error haciendo commit del StateDB: %w", err)
 	}
+
+	// Escribir en disco los nodos del trie: el commit del StateDB solo los deja en memoria y se
+	// perderían si el nodo se cae antes de cerrarse
+	if err := sm.database.TrieDB().Commit(root, false); err != nil {
+		return fmt.Errorf("error escribiendo trie de estado: %w", err)
+	}
+	// Pebble escribe sin sync: forzarlo antes de que el bloque se confirme en LevelDB (con sync), para
+	// que el root confirmado nunca apunte a nodos que una caída se llevó
+	if sm.pebbleDB != nil {
+		if err := sm.pebbleDB.SyncKeyValue(); err != nil {
+			return fmt.Errorf("error sincronizando trie de estado: %w", err)
+		}
+	}
 	
 	// IMPORTANTE: Después del commit, recargar StateDB desde el nuevo root
 	// Esto evita el error "trie is already committed" cuando se modifica después
@@ -151,12 +164,15 @@ func (sm *StateManager) SaveState() error {
 		sm.stateDB = newStateDB
 	}
 	
-	// Guardar root hash en metadata storage
-	stateData, err := json.Marshal(map[string]interface{}{
-		"root":      root.Hex(),
-		"height":    sm.getCurrentHeight(),
-		"timestamp": sm.getCurrentTimestamp(),
-	})
+	// Guardar root hash en metadata storage, conservando los campos que agrega consenso (app_hash)
+	stateInfo := map[string]interface{}{}
+	if previous, err := sm.storage.GetState(); err == nil {
+		json.Unmarshal(previous, &stateInfo)
+	}
+	stateInfo["root"] = root.Hex()
+	stateInfo["height"] = sm.getCurrentHeight()
+	stateInfo["timestamp"] = sm.getCurrentTimestamp()
+	stateData, err := json.Marshal(stateInfo)
 	if err != nil {
 		return fmt.Errorf("error serializando estado: %w", err)
 	}
@@ -284,6 +300,56 @@ func (sm *StateManager) LoadStateAtHeight(height uint64) (*state.StateDB, error)
 	return stateDB, nil
 }
 
+// RecordRootAtHeight registra el root comprometido para una altura (mismo formato que SaveStateAtHeight)
+// sin volver a hacer commit del StateDB
+func (sm *StateManager) RecordRootAtHeight(height uint64) error {
+	stateData, err := json.Marshal(map[string]interface{}{
+		"root":   sm.stateRoot.Hex(),
+		"height": height,
+	})
+	if err != nil {
+		return fmt.Errorf("error serializando estado: %w", err)
+	}
+
+	key := fmt.Sprintf("state:%d", height)
+	if err := sm.storage.SaveAccount(key, stateData); err != nil {
+		return fmt.Errorf("error guardando root en altura %d: %w", height, err)
+	}
+
+	return nil
+}
+
+// StateAt abre un StateDB de solo lectura sobre el root registrado en una altura,
+// reutilizando la base de datos abierta (no abre una nueva instancia de Pebble)
+func (sm *StateManager) StateAt(height uint64) (*state.StateDB, error) {
+	if sm.database == nil {
+		return nil, fmt.Errorf("database no está inicializado")
+	}
+
+	key := fmt.Sprintf("state:%d", height)
+	stateData, err := sm.storage.GetAccount(key)
+	if err != nil {
+		return nil, fmt.Errorf("estado no encontrado en altura %d: %w", height, err)
+	}
+
+	var stateInfo map[string]interface{}
+	if err := json.Unmarshal(stateData, &stateInfo); err != nil {
+		return nil, fmt.Errorf("error parseando estado: %w", err)
+	}
+
+	rootStr, ok := stateInfo["root"].(string)
+	if !ok {
+		return nil, fmt.Errorf("root hash no encontrado en estado")
+	}
+
+	stateDB, err := state.New(common.HexToHash(rootStr), sm.database)
+	if err != nil {
+		return nil, fmt.Errorf("estado en altura %d no disponible: %w", height, err)
+	}
+
+	return stateDB, nil
+}
+
 // GetStateDB retorna el StateDB actual
 func (sm *StateManager) GetStateDB() *state.StateDB {
 	return sm.stateDB
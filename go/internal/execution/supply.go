@@ -0,0 +1,145 @@
+package execution
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
+	"github.com/holiman/uint256"
+)
+
+// El total supply del token nativo se lleva como contador: sube con cada emisión (Mint, FundAccount)
+// y baja con el base fee quemado. El invariante es que coincide con la suma de todos los balances.
+
+// ErrSupplyInvariant indica que el total supply no coincide con la suma de los balances
+var ErrSupplyInvariant = errors.New("invariante de supply roto")
+
+// Mint emite nuevos tokens nativos y los acredita a una cuenta (rewards de protocolo)
+func (e *EVMExecutor) Mint(address common.Address, amount *big.Int) error {
+	if !e.running {
+		return fmt.Errorf("ejecutor EVM no está corriendo")
+	}
+	if amount.Sign() < 0 {
+		return fmt.Errorf("cantidad a emitir inválida: %s", amount)
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	value, overflow := uint256.FromBig(amount)
+	if overflow {
+		return fmt.Errorf("cantidad a emitir fuera de rango: %s", amount)
+	}
+	e.getStateDB().AddBalance(address, value, tracing.BalanceIncreaseRewardMineBlock)
+	e.totalSupply.Add(e.totalSupply, amount)
+	return nil
+}
+
+// Burn quema tokens nativos de una cuenta (stake slasheado) y los descuenta del total supply
+func (e *EVMExecutor) Burn(address common.Address, amount *big.Int) error {
+	if !e.running {
+		return fmt.Errorf("ejecutor EVM no está corriendo")
+	}
+	if amount.Sign() < 0 {
+		return fmt.Errorf("cantidad a quemar inválida: %s", amount)
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	value, overflow := uint256.FromBig(amount)
+	if overflow {
+		return fmt.Errorf("cantidad a quemar fuera de rango: %s", amount)
+	}
+
+	stateDB := e.getStateDB()
+	if stateDB.GetBalance(address).Cmp(value) < 0 {
+		return fmt.Errorf("balance insuficiente en %s: necesita %s", address.Hex(), amount)
+	}
+	stateDB.SubBalance(address, value, tracing.BalanceDecreaseSelfdestructBurn)
+	e.totalSupply.Sub(e.totalSupply, amount)
+	return nil
+}
+
+// TotalSupply retorna el total supply actual del token nativo (incluye cambios aún no confirmados)
+func (e *EVMExecutor) TotalSupply() *big.Int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return new(big.Int).Set(e.totalSupply)
+}
+
+// SaveTotalSupply persiste el total supply actual en el bloque en curso (antes de calcular el AppHash)
+func (e *EVMExecutor) SaveTotalSupply() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if err := e.storage.SaveTotalSupply(e.totalSupply); err != nil {
+		return fmt.Errorf("error guardando total supply: %w", err)
+	}
+	return nil
+}
+
+// CheckSupplyInvariant verifica que el total supply coincida con la suma de los balances del
+// último estado confirmado. Debe llamarse justo después de SaveState.
+func (e *EVMExecutor) CheckSupplyInvariant() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	sum, err := e.stateManager.SumBalances()
+	if err != nil {
+		return err
+	}
+	if sum.Cmp(e.totalSupply) != 0 {
+		return fmt.Errorf("%w: suma de balances %s, total supply %s", ErrSupplyInvariant, sum, e.totalSupply)
+	}
+	return nil
+}
+
+// loadTotalSupply carga el total supply guardado; sin valor guardado lo calcula desde los balances
+func (e *EVMExecutor) loadTotalSupply() error {
+	if supply, err := e.storage.GetTotalSupply(); err == nil {
+		e.totalSupply = supply
+		return nil
+	}
+
+	sum, err := e.stateManager.SumBalances()
+	if err != nil {
+		return fmt.Errorf("error calculando total supply: %w", err)
+	}
+	e.totalSupply = sum
+	return nil
+}
+
+// SumBalances suma los balances de todas las cuentas del último estado confirmado
+func (sm *StateManager) SumBalances() (*big.Int, error) {
+	if sm.database == nil {
+		return nil, fmt.Errorf("database no está inicializado")
+	}
+
+	tr, err := sm.database.OpenTrie(sm.stateRoot)
+	if err != nil {
+		return nil, fmt.Errorf("error abriendo trie de estado: %w", err)
+	}
+	nodeIt, err := tr.NodeIterator(nil)
+	if err != nil {
+		return nil, fmt.Errorf("error iterando trie de estado: %w", err)
+	}
+
+	sum := new(big.Int)
+	it := trie.NewIterator(nodeIt)
+	for it.Next() {
+		var account types.StateAccount
+		if err := rlp.DecodeBytes(it.Value, &account); err != nil {
+			return nil, fmt.Errorf("error decodificando cuenta: %w", err)
+		}
+		sum.Add(sum, account.Balance.ToBig())
+	}
+	if it.Err != nil {
+		return nil, fmt.Errorf("error iterando trie de estado: %w", it.Err)
+	}
+	return sum, nil
+}
@@ -0,0 +1,57 @@
+package execution
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// TestEVMExecutor_TotalSupply verifica que el total supply sigue las emisiones y el base fee quemado,
+// que coincide con la suma de balances y que se persiste con el estado
+func TestEVMExecutor_TotalSupply(t *testing.T) {
+	evm := crearTestEVM(t, "total_supply")
+
+	sender := "0x5000000000000000000000000000000000000005"
+	if err := evm.FundAccount(sender, "1000000000000000000"); err != nil {
+		t.Fatalf("Error fondeando cuenta: %v", err)
+	}
+	validator := common.HexToAddress("0x6000000000000000000000000000000000000006")
+	if err := evm.Mint(validator, big.NewInt(5000)); err != nil {
+		t.Fatalf("Error emitiendo tokens: %v", err)
+	}
+	if err := evm.Mint(validator, big.NewInt(-1)); err == nil {
+		t.Error("Mint debería rechazar cantidades negativas")
+	}
+
+	// Base fee inicial 1 gwei: se queman 21000 gwei (sin BaseFeeRecipient)
+	result, err := evm.ExecuteTransaction(&Transaction{
+		Hash:     "0x01",
+		From:     sender,
+		To:       "0x7000000000000000000000000000000000000007",
+		Value:    "1",
+		GasLimit: 21000,
+		GasPrice: "1000000000",
+	})
+	if err != nil || !result.Success {
+		t.Fatalf("Error ejecutando transacción: %v %+v", err, result)
+	}
+
+	expected, _ := new(big.Int).SetString("1000000000000005000", 10)
+	expected.Sub(expected, big.NewInt(21000*1000000000))
+	if supply := evm.TotalSupply(); supply.Cmp(expected) != 0 {
+		t.Fatalf("Total supply incorrecto: %s, esperado %s", supply, expected)
+	}
+
+	if err := evm.SaveState(); err != nil {
+		t.Fatalf("Error guardando estado: %v", err)
+	}
+	if err := evm.CheckSupplyInvariant(); err != nil {
+		t.Errorf("Invariante de supply: %v", err)
+	}
+
+	// SaveState persiste el total supply para el próximo arranque
+	if saved, err := evm.storage.GetTotalSupply(); err != nil || saved.Cmp(expected) != 0 {
+		t.Errorf("Total supply guardado incorrecto: %v (error: %v)", saved, err)
+	}
+}
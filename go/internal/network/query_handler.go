@@ -5,11 +5,15 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/Q-YZX0/oxy-blockchain/internal/consensus"
+	"github.com/Q-YZX0/oxy-blockchain/internal/execution"
 	"github.com/Q-YZX0/oxy-blockchain/internal/storage"
+	"github.com/ethereum/go-ethereum/common"
 )
 
 // QueryHandler maneja queries P2P por mesh network
@@ -208,6 +212,26 @@ func (qh *QueryHandler) HandleQuery(request QueryRequest) error {
 			}
 		}
 	
+	case len(request.Path) > 5 && request.Path[:5] == "call/":
+		// call/{to}/{data hex}[/{height}]
+		result, err := qh.staticCall(request.Path[5:])
+		if err != nil {
+			response = QueryResponse{
+				Type:      "response",
+				RequestID: request.RequestID,
+				Path:      request.Path,
+				Error:     fmt.Sprintf("error ejecutando call: %v", err),
+			}
+		} else {
+			data, _ := json.Marshal(result)
+			response = QueryResponse{
+				Type:      "response",
+				RequestID: request.RequestID,
+				Path:      request.Path,
+				Data:      data,
+			}
+		}
+	
 	default:
 		response = QueryResponse{
 			Type:      "response",
@@ -244,6 +268,10 @@ func (qh *QueryHandler) sendQuery(request QueryRequest) error {
 		return fmt.Errorf("error serializando query: %w", err)
 	}
 	
+	if qh.meshBridge == nil {
+		return fmt.Errorf("mesh bridge no disponible")
+	}
+
 	// Usar mesh_bridge para enviar mensaje
 	return qh.meshBridge.sendQueryMessage(requestData)
 }
@@ -255,6 +283,10 @@ func (qh *QueryHandler) sendResponse(response QueryResponse) error {
 		return fmt.Errorf("error serializando respuesta: %w", err)
 	}
 	
+	if qh.meshBridge == nil {
+		return fmt.Errorf("mesh bridge no disponible")
+	}
+
 	// Usar mesh_bridge para enviar mensaje
 	return qh.meshBridge.sendResponseMessage(responseData)
 }
@@ -304,3 +336,32 @@ func (qh *QueryHandler) getAccountState(address string) (map[string]interface{},
 	}, nil
 }
 
+// staticCall ejecuta una llamada sin modificar estado a partir de "{to}/{data hex}[/{height}]"
+func (qh *QueryHandler) staticCall(args string) (*execution.CallResult, error) {
+	if qh.consensus == nil || qh.consensus.GetExecutor() == nil {
+		return nil, fmt.Errorf("ejecutor EVM no disponible")
+	}
+
+	parts := strings.Split(args, "/")
+	if len(parts) < 2 || len(parts) > 3 {
+		return nil, fmt.Errorf("formato esperado: call/{to}/{data}[/{height}]")
+	}
+	if !common.IsHexAddress(parts[0]) {
+		return nil, fmt.Errorf("dirección inválida: %s", parts[0])
+	}
+
+	var height uint64
+	if len(parts) == 3 {
+		parsed, err := strconv.ParseUint(parts[2], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("altura inválida: %s", parts[2])
+		}
+		height = parsed
+	}
+
+	return qh.consensus.GetExecutor().StaticCall(&execution.CallRequest{
+		To:     parts[0],
+		Data:   common.FromHex(parts[1]),
+		Height: height,
+	})
+}
@@ -0,0 +1,116 @@
+package oracle
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+)
+
+// Los valores del oráculo son enteros de punto fijo con 18 decimales (1 OXG/USD = 1e18), igual que los
+// montos en wei, para que los contratos los usen sin conversiones.
+const Decimals = 18
+
+// Límites de una extensión de voto: acotan lo que un validador puede agregar a cada voto
+const (
+	MaxFeeds          = 32   // Feeds por extensión
+	MaxFeedNameLength = 64   // Bytes del nombre de un feed
+	MaxExtensionBytes = 4096 // Bytes de la extensión codificada
+)
+
+// Extension es lo que un validador adjunta a su precommit: sus observaciones de cada feed
+type Extension struct {
+	Values map[string]string `json:"values"` // Feed -> valor de punto fijo (decimal)
+}
+
+// EncodeExtension codifica observaciones como extensión de voto (claves en orden, JSON determinista)
+func EncodeExtension(values map[string]*big.Int) ([]byte, error) {
+	ext := Extension{Values: make(map[string]string, len(values))}
+	for feed, value := range values {
+		ext.Values[feed] = value.String()
+	}
+	data, err := json.Marshal(ext)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := DecodeExtension(data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// DecodeExtension decodifica y valida una extensión de voto
+func DecodeExtension(data []byte) (map[string]*big.Int, error) {
+	if len(data) > MaxExtensionBytes {
+		return nil, fmt.Errorf("extensión de %d bytes excede el máximo %d", len(data), MaxExtensionBytes)
+	}
+	var ext Extension
+	if err := json.Unmarshal(data, &ext); err != nil {
+		return nil, fmt.Errorf("error decodificando extensión: %w", err)
+	}
+	if len(ext.Values) > MaxFeeds {
+		return nil, fmt.Errorf("extensión con %d feeds excede el máximo %d", len(ext.Values), MaxFeeds)
+	}
+
+	values := make(map[string]*big.Int, len(ext.Values))
+	for feed, raw := range ext.Values {
+		if feed == "" || len(feed) > MaxFeedNameLength {
+			return nil, fmt.Errorf("nombre de feed inválido: %q", feed)
+		}
+		value, ok := new(big.Int).SetString(raw, 10)
+		if !ok || value.Sign() <= 0 || value.BitLen() > 256 {
+			return nil, fmt.Errorf("valor inválido para %s: %q", feed, raw)
+		}
+		values[feed] = value
+	}
+	return values, nil
+}
+
+// ParseValue convierte un valor decimal ("0.25", "1200") a punto fijo con Decimals decimales
+func ParseValue(s string) (*big.Int, error) {
+	s = strings.TrimSpace(s)
+	whole, frac, _ := strings.Cut(s, ".")
+	if len(frac) > Decimals {
+		return nil, fmt.Errorf("valor %q con más de %d decimales", s, Decimals)
+	}
+	value, ok := new(big.Int).SetString(whole+frac+strings.Repeat("0", Decimals-len(frac)), 10)
+	if !ok || whole == "" || value.Sign() < 0 {
+		return nil, fmt.Errorf("valor inválido: %q", s)
+	}
+	return value, nil
+}
+
+// Report es el valor de un feed informado por un validador, con su poder de voto
+type Report struct {
+	Value *big.Int
+	Power int64
+}
+
+// WeightedMedian retorna la mediana ponderada por poder: el menor valor tal que los reportes con valor
+// menor o igual suman al menos la mitad del poder. Nil si no hay reportes con poder.
+func WeightedMedian(reports []Report) *big.Int {
+	sorted := make([]Report, 0, len(reports))
+	var total int64
+	for _, r := range reports {
+		if r.Power > 0 {
+			sorted = append(sorted, r)
+			total += r.Power
+		}
+	}
+	if total == 0 {
+		return nil
+	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Value.Cmp(sorted[j].Value) < 0
+	})
+
+	var cumulative int64
+	for _, r := range sorted {
+		cumulative += r.Power
+		if 2*cumulative >= total {
+			return new(big.Int).Set(r.Value)
+		}
+	}
+	return new(big.Int).Set(sorted[len(sorted)-1].Value)
+}
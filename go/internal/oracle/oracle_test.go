@@ -0,0 +1,100 @@
+package oracle
+
+import (
+	"context"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWeightedMedian(t *testing.T) {
+	v := func(n int64) *big.Int { return big.NewInt(n) }
+
+	tests := []struct {
+		name    string
+		reports []Report
+		want    *big.Int
+	}{
+		{"sin reportes", nil, nil},
+		{"un reporte", []Report{{v(5), 10}}, v(5)},
+		{"mismo poder", []Report{{v(3), 1}, {v(1), 1}, {v(2), 1}}, v(2)},
+		{"poder mayoritario", []Report{{v(1), 1}, {v(2), 1}, {v(100), 5}}, v(100)},
+		{"empate toma el menor", []Report{{v(10), 1}, {v(20), 1}}, v(10)},
+		{"ignora poder cero", []Report{{v(1), 0}, {v(7), 2}}, v(7)},
+	}
+	for _, tt := range tests {
+		got := WeightedMedian(tt.reports)
+		if (got == nil) != (tt.want == nil) || (got != nil && got.Cmp(tt.want) != 0) {
+			t.Errorf("%s: mediana %v, esperado %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestParseValue(t *testing.T) {
+	valid := map[string]string{
+		"1":      "1000000000000000000",
+		"0.25":   "250000000000000000",
+		"1200.5": "1200500000000000000000",
+	}
+	for input, want := range valid {
+		got, err := ParseValue(input)
+		if err != nil || got.String() != want {
+			t.Errorf("ParseValue(%q) = %v, %v; esperado %s", input, got, err, want)
+		}
+	}
+	for _, input := range []string{"", "abc", "-1", ".5", "0.1234567890123456789"} {
+		if _, err := ParseValue(input); err == nil {
+			t.Errorf("ParseValue(%q) debería fallar", input)
+		}
+	}
+}
+
+func TestExtensionRoundTrip(t *testing.T) {
+	data, err := EncodeExtension(map[string]*big.Int{"OXG/USD": big.NewInt(25), "GREENPOOL/KWH": big.NewInt(1200)})
+	if err != nil {
+		t.Fatalf("Error codificando extensión: %v", err)
+	}
+	values, err := DecodeExtension(data)
+	if err != nil || len(values) != 2 || values["OXG/USD"].Int64() != 25 {
+		t.Fatalf("Extensión decodificada incorrecta: %v, %v", values, err)
+	}
+
+	for _, invalid := range []string{`{"values":{"OXG/USD":"0"}}`, `{"values":{"OXG/USD":"-3"}}`, `{"values":{"":"1"}}`, `no-json`} {
+		if _, err := DecodeExtension([]byte(invalid)); err == nil {
+			t.Errorf("Extensión %s debería rechazarse", invalid)
+		}
+	}
+}
+
+func TestSources(t *testing.T) {
+	ctx := context.Background()
+
+	path := filepath.Join(t.TempDir(), "feeds.json")
+	if err := os.WriteFile(path, []byte(`{"OXG/USD": 0.25, "GREENPOOL/KWH": "1200"}`), 0644); err != nil {
+		t.Fatalf("Error escribiendo archivo: %v", err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"OXG/USD": "0.3"}`))
+	}))
+	defer server.Close()
+
+	values, err := Observe(ctx, []Source{&FileSource{Path: path}, &HTTPSource{URL: server.URL}})
+	if err != nil {
+		t.Fatalf("Error observando fuentes: %v", err)
+	}
+	if values["OXG/USD"].String() != "300000000000000000" {
+		t.Errorf("OXG/USD = %s, esperado el valor de la última fuente", values["OXG/USD"])
+	}
+	if values["GREENPOOL/KWH"].String() != "1200000000000000000000" {
+		t.Errorf("GREENPOOL/KWH = %s", values["GREENPOOL/KWH"])
+	}
+
+	// Una fuente caída no impide usar las demás
+	values, err = Observe(ctx, []Source{&FileSource{Path: filepath.Join(t.TempDir(), "no-existe.json")}, &FileSource{Path: path}})
+	if err == nil || len(values) != 2 {
+		t.Errorf("Se esperaban los feeds de la fuente disponible y un error: %v, %v", values, err)
+	}
+}
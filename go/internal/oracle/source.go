@@ -0,0 +1,100 @@
+package oracle
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"time"
+)
+
+// Source es una fuente de datos del oráculo. Cada validador configura las suyas; las observaciones
+// no necesitan coincidir entre validadores porque la cadena agrega la mediana ponderada.
+type Source interface {
+	Observe(ctx context.Context) (map[string]*big.Int, error)
+}
+
+// parseFeeds decodifica el formato común de las fuentes: {"OXG/USD": "0.25", "GREENPOOL/KWH": "1200"}
+func parseFeeds(data []byte) (map[string]*big.Int, error) {
+	var raw map[string]json.Number
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("error decodificando feeds: %w", err)
+	}
+	values := make(map[string]*big.Int, len(raw))
+	for feed, number := range raw {
+		value, err := ParseValue(number.String())
+		if err != nil {
+			return nil, fmt.Errorf("feed %s: %w", feed, err)
+		}
+		values[feed] = value
+	}
+	return values, nil
+}
+
+// FileSource lee los feeds de un archivo JSON local (lo actualiza un proceso externo)
+type FileSource struct {
+	Path string
+}
+
+// Observe implementa Source
+func (s *FileSource) Observe(ctx context.Context) (map[string]*big.Int, error) {
+	data, err := os.ReadFile(s.Path)
+	if err != nil {
+		return nil, fmt.Errorf("error leyendo %s: %w", s.Path, err)
+	}
+	return parseFeeds(data)
+}
+
+// HTTPSource obtiene los feeds con un GET a un endpoint que responde el mismo JSON que FileSource
+type HTTPSource struct {
+	URL    string
+	Client *http.Client // nil = cliente con timeout de 2 segundos
+}
+
+// Observe implementa Source
+func (s *HTTPSource) Observe(ctx context.Context) (map[string]*big.Int, error) {
+	client := s.Client
+	if client == nil {
+		client = &http.Client{Timeout: 2 * time.Second}
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.URL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creando request a %s: %w", s.URL, err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error consultando %s: %w", s.URL, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s respondió %s", s.URL, resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, MaxExtensionBytes))
+	if err != nil {
+		return nil, fmt.Errorf("error leyendo respuesta de %s: %w", s.URL, err)
+	}
+	return parseFeeds(data)
+}
+
+// Observe consulta todas las fuentes y combina sus feeds (ante un feed repetido gana la última fuente).
+// Una fuente que falla no impide usar las demás; el error se retorna junto a lo observado.
+func Observe(ctx context.Context, sources []Source) (map[string]*big.Int, error) {
+	values := make(map[string]*big.Int)
+	var firstErr error
+	for _, source := range sources {
+		observed, err := source.Observe(ctx)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		for feed, value := range observed {
+			values[feed] = value
+		}
+	}
+	return values, firstErr
+}
@@ -0,0 +1,152 @@
+package storage
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/opt"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+// blockWrites acumula las escrituras de un bloque en ejecución. Las lecturas las ven de inmediato, pero
+// solo llegan a disco juntas en CommitBlock: si el nodo se cae antes, la base queda en el bloque anterior
+// y CometBFT vuelve a ejecutar el bloque en el handshake.
+type blockWrites struct {
+	batch  *leveldb.Batch
+	values map[string][]byte // nil = clave borrada
+}
+
+// Put implementa leveldb.BatchReplay
+func (w *blockWrites) Put(key, value []byte) {
+	w.batch.Put(key, value)
+	w.values[string(key)] = append([]byte{}, value...)
+}
+
+// Delete implementa leveldb.BatchReplay
+func (w *blockWrites) Delete(key []byte) {
+	w.batch.Delete(key)
+	w.values[string(key)] = nil
+}
+
+// BeginBlock empieza a acumular las escrituras del bloque en ejecución (no hace nada si ya se acumulan)
+func (b *BlockchainDB) BeginBlock() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.pending == nil {
+		b.pending = &blockWrites{batch: new(leveldb.Batch), values: make(map[string][]byte)}
+	}
+}
+
+// CommitBlock escribe en disco, en un solo batch sincronizado, todas las escrituras del bloque
+func (b *BlockchainDB) CommitBlock() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.pending == nil {
+		return nil
+	}
+	if err := b.db.Write(b.pending.batch, &opt.WriteOptions{Sync: true}); err != nil {
+		return fmt.Errorf("error confirmando bloque: %w", err)
+	}
+	b.pending = nil
+	return nil
+}
+
+// put escribe una clave (en el bloque en ejecución si lo hay)
+func (b *BlockchainDB) put(key, value []byte) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.pending != nil {
+		b.pending.Put(key, value)
+		return nil
+	}
+	return b.db.Put(key, value, nil)
+}
+
+// write aplica un batch (en el bloque en ejecución si lo hay)
+func (b *BlockchainDB) write(batch *leveldb.Batch) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.pending != nil {
+		return batch.Replay(b.pending)
+	}
+	return b.db.Write(batch, nil)
+}
+
+// get lee una clave viendo las escrituras del bloque en ejecución
+func (b *BlockchainDB) get(key []byte) ([]byte, error) {
+	b.mu.RLock()
+	if b.pending != nil {
+		if value, ok := b.pending.values[string(key)]; ok {
+			b.mu.RUnlock()
+			if value == nil {
+				return nil, leveldb.ErrNotFound
+			}
+			return append([]byte{}, value...), nil
+		}
+	}
+	b.mu.RUnlock()
+	return b.db.Get(key, nil)
+}
+
+// has indica si existe una clave viendo las escrituras del bloque en ejecución
+func (b *BlockchainDB) has(key []byte) (bool, error) {
+	_, err := b.get(key)
+	if err == leveldb.ErrNotFound {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// iteratePrefix recorre en orden de clave las claves con un prefijo, viendo las escrituras del bloque
+// en ejecución
+func (b *BlockchainDB) iteratePrefix(prefix []byte, visit func(key, value []byte)) error {
+	b.mu.RLock()
+	var overlay map[string][]byte
+	if b.pending != nil {
+		for key, value := range b.pending.values {
+			if bytes.HasPrefix([]byte(key), prefix) {
+				if overlay == nil {
+					overlay = make(map[string][]byte)
+				}
+				overlay[key] = value
+			}
+		}
+	}
+	b.mu.RUnlock()
+
+	iter := b.db.NewIterator(util.BytesPrefix(prefix), nil)
+	defer iter.Release()
+	if overlay == nil {
+		for iter.Next() {
+			visit(iter.Key(), iter.Value())
+		}
+		return iter.Error()
+	}
+
+	// Con escrituras pendientes se combinan ambas vistas (el estado de la aplicación es chico)
+	merged := make(map[string][]byte)
+	for iter.Next() {
+		merged[string(iter.Key())] = append([]byte{}, iter.Value()...)
+	}
+	if err := iter.Error(); err != nil {
+		return err
+	}
+	for key, value := range overlay {
+		if value == nil {
+			delete(merged, key)
+		} else {
+			merged[key] = value
+		}
+	}
+	keys := make([]string, 0, len(merged))
+	for key := range merged {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		visit([]byte(key), merged[key])
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package storage
+
+import (
+	"bytes"
+	"os"
+	"testing"
+)
+
+// TestBlockchainDB_BlockWrites verifica que las escrituras de un bloque se leen de inmediato (también al
+// recorrer el estado de la aplicación), llegan juntas a disco en CommitBlock y se pierden si la base se
+// cierra antes
+func TestBlockchainDB_BlockWrites(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "oxy_block_writes")
+	if err != nil {
+		t.Fatalf("Error creando directorio: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	db, err := NewBlockchainDB(tmpDir)
+	if err != nil {
+		t.Fatalf("Error creando base de datos: %v", err)
+	}
+	if err := db.SaveAccount("validators:set", []byte("v1")); err != nil {
+		t.Fatalf("Error guardando validadores: %v", err)
+	}
+	hashBefore, _ := db.AppStateHash()
+
+	// Bloque 1: se confirma
+	db.BeginBlock()
+	db.SaveLatestHeight(1)
+	db.SaveAccount("validators:set", []byte("v2"))
+	db.SaveAccount("validators:delegations", []byte("d2"))
+	if data, _ := db.GetAccount("validators:set"); string(data) != "v2" {
+		t.Fatalf("La escritura pendiente debería leerse: %s", data)
+	}
+	entries, _ := db.SnapshotEntries(0)
+	if len(entries) != 2 || string(entries[0].Key) != "account:validators:delegations" || string(entries[1].Value) != "v2" {
+		t.Fatalf("El estado de la aplicación debería incluir las escrituras pendientes en orden: %v", entries)
+	}
+	hashPending, _ := db.AppStateHash()
+	if err := db.CommitBlock(); err != nil {
+		t.Fatalf("Error confirmando bloque: %v", err)
+	}
+	if hashCommitted, _ := db.AppStateHash(); bytes.Equal(hashBefore, hashCommitted) || !bytes.Equal(hashPending, hashCommitted) {
+		t.Fatalf("AppStateHash confirmado no coincide con el pendiente")
+	}
+
+	// Bloque 2: la base se cierra antes de confirmarlo
+	db.BeginBlock()
+	db.SaveLatestHeight(2)
+	db.SaveAccount("validators:set", []byte("v3"))
+	db.Close()
+
+	db, err = NewBlockchainDB(tmpDir)
+	if err != nil {
+		t.Fatalf("Error reabriendo base de datos: %v", err)
+	}
+	defer db.Close()
+	if height, _ := db.GetLatestHeight(); height != 1 {
+		t.Errorf("Altura tras reinicio: %d, esperado 1", height)
+	}
+	if data, _ := db.GetAccount("validators:set"); string(data) != "v2" {
+		t.Errorf("Validadores tras reinicio: %s, esperado v2", data)
+	}
+}
@@ -2,9 +2,12 @@ package storage
 
 import (
 	"fmt"
+	"math/big"
 	"os"
 	"path/filepath"
 	"runtime"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/syndtr/goleveldb/leveldb"
@@ -14,6 +17,9 @@ import (
 type BlockchainDB struct {
 	db      *leveldb.DB
 	dataDir string
+
+	mu      sync.RWMutex
+	pending *blockWrites // Escrituras del bloque en ejecución (nil = se escriben directo)
 }
 
 // NewBlockchainDB crea una nueva instancia de la base de datos
@@ -72,58 +78,111 @@ func (b *BlockchainDB) GetDataDir() string {
 // SaveBlock guarda un bloque en la base de datos
 func (b *BlockchainDB) SaveBlock(height uint64, blockData []byte) error {
 	key := []byte(fmt.Sprintf("block:%d", height))
-	return b.db.Put(key, blockData, nil)
+	return b.put(key, blockData)
 }
 
 // GetBlock obtiene un bloque por altura
 func (b *BlockchainDB) GetBlock(height uint64) ([]byte, error) {
 	key := []byte(fmt.Sprintf("block:%d", height))
-	return b.db.Get(key, nil)
+	return b.get(key)
+}
+
+// SaveBlockHashIndex guarda el índice hash de bloque -> altura
+func (b *BlockchainDB) SaveBlockHashIndex(blockHash string, height uint64) error {
+	key := []byte(fmt.Sprintf("blockhash:%s", strings.ToLower(blockHash)))
+	return b.put(key, []byte(fmt.Sprintf("%d", height)))
+}
+
+// GetBlockHeightByHash obtiene la altura de un bloque por su hash
+func (b *BlockchainDB) GetBlockHeightByHash(blockHash string) (uint64, error) {
+	key := []byte(fmt.Sprintf("blockhash:%s", strings.ToLower(blockHash)))
+	heightBytes, err := b.get(key)
+	if err != nil {
+		return 0, err
+	}
+
+	var height uint64
+	fmt.Sscanf(string(heightBytes), "%d", &height)
+	return height, nil
+}
+
+// SaveHeader guarda el header EVM (RLP) de una altura
+func (b *BlockchainDB) SaveHeader(height uint64, headerData []byte) error {
+	key := []byte(fmt.Sprintf("header:%d", height))
+	return b.put(key, headerData)
+}
+
+// GetHeader obtiene el header EVM (RLP) de una altura
+func (b *BlockchainDB) GetHeader(height uint64) ([]byte, error) {
+	key := []byte(fmt.Sprintf("header:%d", height))
+	return b.get(key)
 }
 
 // SaveState guarda el estado de la blockchain
 func (b *BlockchainDB) SaveState(stateData []byte) error {
-	return b.db.Put([]byte("state:latest"), stateData, nil)
+	return b.put([]byte("state:latest"), stateData)
 }
 
 // GetState obtiene el estado actual
 func (b *BlockchainDB) GetState() ([]byte, error) {
-	return b.db.Get([]byte("state:latest"), nil)
+	return b.get([]byte("state:latest"))
 }
 
 // SaveTransaction guarda una transacción
 func (b *BlockchainDB) SaveTransaction(txHash string, txData []byte) error {
 	key := []byte(fmt.Sprintf("tx:%s", txHash))
-	return b.db.Put(key, txData, nil)
+	return b.put(key, txData)
 }
 
 // GetTransaction obtiene una transacción por hash
 func (b *BlockchainDB) GetTransaction(txHash string) ([]byte, error) {
 	key := []byte(fmt.Sprintf("tx:%s", txHash))
-	return b.db.Get(key, nil)
+	return b.get(key)
+}
+
+// SaveTxLookup guarda la ubicación (altura e índice) de una transacción incluida en un bloque
+func (b *BlockchainDB) SaveTxLookup(txHash string, height uint64, index int) error {
+	key := []byte(fmt.Sprintf("txlookup:%s", strings.ToLower(txHash)))
+	return b.put(key, []byte(fmt.Sprintf("%d:%d", height, index)))
+}
+
+// GetTxLookup obtiene la altura del bloque y el índice de una transacción
+func (b *BlockchainDB) GetTxLookup(txHash string) (uint64, int, error) {
+	key := []byte(fmt.Sprintf("txlookup:%s", strings.ToLower(txHash)))
+	data, err := b.get(key)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var height uint64
+	var index int
+	if _, err := fmt.Sscanf(string(data), "%d:%d", &height, &index); err != nil {
+		return 0, 0, fmt.Errorf("lookup de transacción corrupto: %w", err)
+	}
+	return height, index, nil
 }
 
 // SaveAccount guarda el estado de una cuenta
 func (b *BlockchainDB) SaveAccount(address string, accountData []byte) error {
 	key := []byte(fmt.Sprintf("account:%s", address))
-	return b.db.Put(key, accountData, nil)
+	return b.put(key, accountData)
 }
 
 // GetAccount obtiene el estado de una cuenta
 func (b *BlockchainDB) GetAccount(address string) ([]byte, error) {
 	key := []byte(fmt.Sprintf("account:%s", address))
-	return b.db.Get(key, nil)
+	return b.get(key)
 }
 
 // SaveLatestHeight guarda la altura del último bloque
 func (b *BlockchainDB) SaveLatestHeight(height uint64) error {
 	heightBytes := []byte(fmt.Sprintf("%d", height))
-	return b.db.Put([]byte("height:latest"), heightBytes, nil)
+	return b.put([]byte("height:latest"), heightBytes)
 }
 
 // GetLatestHeight obtiene la altura del último bloque
 func (b *BlockchainDB) GetLatestHeight() (uint64, error) {
-	heightBytes, err := b.db.Get([]byte("height:latest"), nil)
+	heightBytes, err := b.get([]byte("height:latest"))
 	if err != nil {
 		return 0, err
 	}
@@ -133,3 +192,81 @@ func (b *BlockchainDB) GetLatestHeight() (uint64, error) {
 	return height, nil
 }
 
+
+// SaveBlockGasLimit guarda el gas límite por bloque de los parámetros de consenso
+func (b *BlockchainDB) SaveBlockGasLimit(limit uint64) error {
+	return b.put([]byte("params:blockgaslimit"), []byte(fmt.Sprintf("%d", limit)))
+}
+
+// GetBlockGasLimit obtiene el gas límite por bloque guardado
+func (b *BlockchainDB) GetBlockGasLimit() (uint64, error) {
+	data, err := b.get([]byte("params:blockgaslimit"))
+	if err != nil {
+		return 0, err
+	}
+
+	var limit uint64
+	if _, err := fmt.Sscanf(string(data), "%d", &limit); err != nil {
+		return 0, fmt.Errorf("gas límite por bloque corrupto: %w", err)
+	}
+	return limit, nil
+}
+
+// SaveBlockMaxBytes guarda el tamaño máximo de bloque de los parámetros de consenso
+func (b *BlockchainDB) SaveBlockMaxBytes(limit int64) error {
+	return b.put([]byte("params:blockmaxbytes"), []byte(fmt.Sprintf("%d", limit)))
+}
+
+// GetBlockMaxBytes obtiene el tamaño máximo de bloque guardado
+func (b *BlockchainDB) GetBlockMaxBytes() (int64, error) {
+	data, err := b.get([]byte("params:blockmaxbytes"))
+	if err != nil {
+		return 0, err
+	}
+
+	var limit int64
+	if _, err := fmt.Sscanf(string(data), "%d", &limit); err != nil {
+		return 0, fmt.Errorf("tamaño máximo de bloque corrupto: %w", err)
+	}
+	return limit, nil
+}
+
+// SaveFeeMarketParams guarda la configuración del mercado de fees EIP-1559 (JSON)
+func (b *BlockchainDB) SaveFeeMarketParams(paramsData []byte) error {
+	return b.put([]byte("params:feemarket"), paramsData)
+}
+
+// GetFeeMarketParams obtiene la configuración del mercado de fees guardada
+func (b *BlockchainDB) GetFeeMarketParams() ([]byte, error) {
+	return b.get([]byte("params:feemarket"))
+}
+
+// SaveGenesisState guarda el app_state del genesis (necesario para reanudar tras un reinicio)
+func (b *BlockchainDB) SaveGenesisState(stateData []byte) error {
+	return b.put([]byte("genesis:appstate"), stateData)
+}
+
+// GetGenesisState obtiene el app_state del genesis guardado
+func (b *BlockchainDB) GetGenesisState() ([]byte, error) {
+	return b.get([]byte("genesis:appstate"))
+}
+
+// SaveTotalSupply guarda el total supply del token nativo (wei). Va bajo params: porque la inflación
+// depende de él: forma parte del AppHash y de los snapshots.
+func (b *BlockchainDB) SaveTotalSupply(supply *big.Int) error {
+	return b.put([]byte("params:supply:total"), []byte(supply.String()))
+}
+
+// GetTotalSupply obtiene el total supply guardado
+func (b *BlockchainDB) GetTotalSupply() (*big.Int, error) {
+	data, err := b.get([]byte("params:supply:total"))
+	if err != nil {
+		return nil, err
+	}
+
+	supply, ok := new(big.Int).SetString(string(data), 10)
+	if !ok {
+		return nil, fmt.Errorf("total supply corrupto: %s", data)
+	}
+	return supply, nil
+}
@@ -0,0 +1,219 @@
+package storage
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+// LogBloomSectionSize es la cantidad de bloques que cubre cada bloom de sección
+const LogBloomSectionSize uint64 = 1024
+
+// IndexedLog es un log a indexar; Data es el log serializado que retornan las consultas
+type IndexedLog struct {
+	Index   uint // Posición del log dentro del bloque
+	Address string
+	Topics  []string
+	Data    []byte
+}
+
+// LogPosition identifica un log por altura e índice dentro del bloque
+type LogPosition struct {
+	Height uint64
+	Index  uint
+}
+
+// LogFilter describe una consulta de logs por rango de bloques, dirección y topics
+type LogFilter struct {
+	FromBlock uint64
+	ToBlock   uint64
+	Address   string       // Vacío = cualquier dirección
+	Topics    []string     // Por posición; "" = cualquier topic en esa posición
+	After     *LogPosition // Cursor de paginación: retornar logs posteriores a esta posición
+	Limit     int          // Máximo de logs a retornar (> 0)
+}
+
+// Claves del índice (altura e índice con padding para que el orden lexicográfico sea el numérico):
+//
+//	log:<altura>:<índice>                        -> log serializado
+//	logaddr:<dirección>:<altura>:<índice>        -> vacío
+//	logtopic:<pos>:<topic>:<altura>:<índice>     -> vacío
+//	logbloom:<sección>                           -> bloom combinado de los bloques de la sección
+func logPositionSuffix(height uint64, index uint) string {
+	return fmt.Sprintf("%020d:%06d", height, index)
+}
+
+func logKey(height uint64, index uint) []byte {
+	return []byte("log:" + logPositionSuffix(height, index))
+}
+
+func logAddressPrefix(address string) string {
+	return fmt.Sprintf("logaddr:%s:", strings.ToLower(address))
+}
+
+func logTopicPrefix(position int, topic string) string {
+	return fmt.Sprintf("logtopic:%d:%s:", position, strings.ToLower(topic))
+}
+
+func logBloomKey(section uint64) []byte {
+	return []byte(fmt.Sprintf("logbloom:%d", section))
+}
+
+// SaveLogs indexa los logs de un bloque y actualiza el bloom de su sección en un solo batch
+func (b *BlockchainDB) SaveLogs(height uint64, logs []IndexedLog, blockBloom []byte) error {
+	if len(logs) == 0 {
+		return nil
+	}
+
+	batch := new(leveldb.Batch)
+
+	for _, log := range logs {
+		suffix := logPositionSuffix(height, log.Index)
+		batch.Put(logKey(height, log.Index), log.Data)
+		batch.Put([]byte(logAddressPrefix(log.Address)+suffix), nil)
+		for i, topic := range log.Topics {
+			batch.Put([]byte(logTopicPrefix(i, topic)+suffix), nil)
+		}
+	}
+
+	if len(blockBloom) == types.BloomByteLength {
+		section := height / LogBloomSectionSize
+		sectionBloom, err := b.get(logBloomKey(section))
+		if err != nil || len(sectionBloom) != types.BloomByteLength {
+			sectionBloom = make([]byte, types.BloomByteLength)
+		}
+		for i := range sectionBloom {
+			sectionBloom[i] |= blockBloom[i]
+		}
+		batch.Put(logBloomKey(section), sectionBloom)
+	}
+
+	return b.write(batch)
+}
+
+// FilterLogs retorna los logs serializados que cumplen el filtro, en orden de bloque e índice.
+// Si se alcanzó el límite retorna también la posición del último log como cursor para continuar.
+func (b *BlockchainDB) FilterLogs(filter *LogFilter) ([][]byte, *LogPosition, error) {
+	if filter.ToBlock < filter.FromBlock {
+		return nil, nil, fmt.Errorf("rango de bloques inválido: %d > %d", filter.FromBlock, filter.ToBlock)
+	}
+	if filter.Limit <= 0 {
+		return nil, nil, fmt.Errorf("límite inválido: %d", filter.Limit)
+	}
+
+	// Índice principal a recorrer: dirección, primer topic especificado o todos los logs
+	primary := "log:"
+	primaryTopic := -1
+	if filter.Address != "" {
+		primary = logAddressPrefix(filter.Address)
+	} else {
+		for i, topic := range filter.Topics {
+			if topic != "" {
+				primary = logTopicPrefix(i, topic)
+				primaryTopic = i
+				break
+			}
+		}
+	}
+
+	start := logPositionSuffix(filter.FromBlock, 0)
+	if filter.After != nil && filter.After.Height >= filter.FromBlock {
+		start = logPositionSuffix(filter.After.Height, filter.After.Index+1)
+	}
+
+	var results [][]byte
+	for section := filter.FromBlock / LogBloomSectionSize; section <= filter.ToBlock/LogBloomSectionSize; section++ {
+		// Descartar secciones enteras cuyo bloom no contiene la dirección/topics buscados
+		if !b.sectionMayMatch(section, filter) {
+			continue
+		}
+
+		sectionStart := logPositionSuffix(section*LogBloomSectionSize, 0)
+		if sectionStart < start {
+			sectionStart = start
+		}
+		lastHeight := (section+1)*LogBloomSectionSize - 1
+		if lastHeight > filter.ToBlock {
+			lastHeight = filter.ToBlock
+		}
+		sectionEnd := logPositionSuffix(lastHeight+1, 0)
+		if sectionStart >= sectionEnd {
+			continue
+		}
+
+		iter := b.db.NewIterator(&util.Range{
+			Start: []byte(primary + sectionStart),
+			Limit: []byte(primary + sectionEnd),
+		}, nil)
+		for iter.Next() {
+			suffix := strings.TrimPrefix(string(iter.Key()), primary)
+			if !b.logMatches(suffix, filter, primaryTopic) {
+				continue
+			}
+
+			data := iter.Value()
+			if primary != "log:" {
+				var err error
+				data, err = b.get([]byte("log:" + suffix))
+				if err != nil {
+					iter.Release()
+					return nil, nil, fmt.Errorf("error leyendo log %s: %w", suffix, err)
+				}
+			} else {
+				data = append([]byte(nil), data...)
+			}
+			results = append(results, data)
+
+			if len(results) >= filter.Limit {
+				iter.Release()
+				var next LogPosition
+				if _, err := fmt.Sscanf(suffix, "%d:%d", &next.Height, &next.Index); err != nil {
+					return nil, nil, fmt.Errorf("clave de log corrupta %s: %w", suffix, err)
+				}
+				return results, &next, nil
+			}
+		}
+		iter.Release()
+		if err := iter.Error(); err != nil {
+			return nil, nil, fmt.Errorf("error recorriendo índice de logs: %w", err)
+		}
+	}
+
+	return results, nil, nil
+}
+
+// sectionMayMatch consulta el bloom de la sección; una sección sin bloom no tiene logs
+func (b *BlockchainDB) sectionMayMatch(section uint64, filter *LogFilter) bool {
+	data, err := b.get(logBloomKey(section))
+	if err != nil || len(data) != types.BloomByteLength {
+		return false
+	}
+
+	bloom := types.BytesToBloom(data)
+	if filter.Address != "" && !types.BloomLookup(bloom, common.HexToAddress(filter.Address)) {
+		return false
+	}
+	for _, topic := range filter.Topics {
+		if topic != "" && !types.BloomLookup(bloom, common.HexToHash(topic)) {
+			return false
+		}
+	}
+	return true
+}
+
+// logMatches verifica los criterios que no cubre el índice principal
+func (b *BlockchainDB) logMatches(suffix string, filter *LogFilter, primaryTopic int) bool {
+	for i, topic := range filter.Topics {
+		if topic == "" || i == primaryTopic {
+			continue
+		}
+		if ok, err := b.has([]byte(logTopicPrefix(i, topic) + suffix)); err != nil || !ok {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,91 @@
+package storage
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// TestBlockchainDB_FilterLogs verifica el índice de logs por dirección, topics, rango y paginación
+func TestBlockchainDB_FilterLogs(t *testing.T) {
+	tmpDir := "./test_data_logs"
+	defer os.RemoveAll(tmpDir)
+
+	db, err := NewBlockchainDB(tmpDir)
+	if err != nil {
+		t.Fatalf("Error creando base de datos: %v", err)
+	}
+	defer db.Close()
+
+	tokenA := "0x1111111111111111111111111111111111111111"
+	tokenB := "0x2222222222222222222222222222222222222222"
+	transfer := common.HexToHash("0x01").Hex()
+	approval := common.HexToHash("0x02").Hex()
+
+	// Un log por contrato en cada bloque; la sección 1 (bloque 1500) solo tiene logs de tokenB
+	saveBlock := func(height uint64, logs []IndexedLog) {
+		var bloom types.Bloom
+		for i := range logs {
+			logs[i].Index = uint(i)
+			logs[i].Data = []byte(fmt.Sprintf("%d:%d", height, i))
+			bloom.Add(common.HexToAddress(logs[i].Address).Bytes())
+			for _, topic := range logs[i].Topics {
+				bloom.Add(common.HexToHash(topic).Bytes())
+			}
+		}
+		if err := db.SaveLogs(height, logs, bloom.Bytes()); err != nil {
+			t.Fatalf("Error guardando logs del bloque %d: %v", height, err)
+		}
+	}
+	for height := uint64(1); height <= 3; height++ {
+		saveBlock(height, []IndexedLog{
+			{Address: tokenA, Topics: []string{transfer}},
+			{Address: tokenB, Topics: []string{approval}},
+		})
+	}
+	saveBlock(1500, []IndexedLog{{Address: tokenB, Topics: []string{transfer}}})
+
+	query := func(filter LogFilter) ([]string, *LogPosition) {
+		results, next, err := db.FilterLogs(&filter)
+		if err != nil {
+			t.Fatalf("Error filtrando logs: %v", err)
+		}
+		ids := make([]string, len(results))
+		for i, data := range results {
+			ids[i] = string(data)
+		}
+		return ids, next
+	}
+
+	ids, _ := query(LogFilter{FromBlock: 0, ToBlock: 2000, Address: tokenA, Limit: 100})
+	if fmt.Sprint(ids) != "[1:0 2:0 3:0]" {
+		t.Errorf("Filtro por dirección incorrecto: %v", ids)
+	}
+
+	ids, _ = query(LogFilter{FromBlock: 2, ToBlock: 2000, Topics: []string{transfer}, Limit: 100})
+	if fmt.Sprint(ids) != "[2:0 3:0 1500:0]" {
+		t.Errorf("Filtro por topic0 incorrecto: %v", ids)
+	}
+
+	ids, _ = query(LogFilter{FromBlock: 0, ToBlock: 2000, Address: tokenB, Topics: []string{transfer}, Limit: 100})
+	if fmt.Sprint(ids) != "[1500:0]" {
+		t.Errorf("Filtro por dirección y topic incorrecto: %v", ids)
+	}
+
+	// Paginación sobre todos los logs
+	ids, next := query(LogFilter{FromBlock: 1, ToBlock: 3, Limit: 4})
+	if fmt.Sprint(ids) != "[1:0 1:1 2:0 2:1]" || next == nil || next.Height != 2 || next.Index != 1 {
+		t.Fatalf("Primera página incorrecta: %v (cursor %+v)", ids, next)
+	}
+	ids, next = query(LogFilter{FromBlock: 1, ToBlock: 3, Limit: 4, After: next})
+	if fmt.Sprint(ids) != "[3:0 3:1]" || next != nil {
+		t.Errorf("Segunda página incorrecta: %v (cursor %+v)", ids, next)
+	}
+
+	if _, _, err := db.FilterLogs(&LogFilter{FromBlock: 5, ToBlock: 1, Limit: 1}); err == nil {
+		t.Error("Se esperaba error para rango inválido")
+	}
+}
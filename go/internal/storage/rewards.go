@@ -0,0 +1,86 @@
+package storage
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+// ValidatorReward es el reward de un validador en un bloque; Data es la entrada serializada que retornan las consultas
+type ValidatorReward struct {
+	Validator string
+	Amount    *big.Int // Total emitido al validador (incluye comisión)
+	Data      []byte
+}
+
+// Claves (altura con padding para que el orden lexicográfico sea el numérico):
+//
+//	reward:<validador>:<altura>   -> entrada serializada
+//	rewardtotal:<validador>       -> total acumulado (wei, decimal)
+func rewardPrefix(validator string) string {
+	return fmt.Sprintf("reward:%s:", strings.ToLower(validator))
+}
+
+func rewardTotalKey(validator string) []byte {
+	return []byte("rewardtotal:" + strings.ToLower(validator))
+}
+
+// SaveRewards guarda los rewards de un bloque y actualiza los totales acumulados en un solo batch
+func (b *BlockchainDB) SaveRewards(height uint64, rewards []ValidatorReward) error {
+	if len(rewards) == 0 {
+		return nil
+	}
+
+	batch := new(leveldb.Batch)
+	for _, reward := range rewards {
+		batch.Put([]byte(rewardPrefix(reward.Validator)+fmt.Sprintf("%020d", height)), reward.Data)
+
+		total, err := b.GetRewardTotal(reward.Validator)
+		if err != nil {
+			return err
+		}
+		total.Add(total, reward.Amount)
+		batch.Put(rewardTotalKey(reward.Validator), []byte(total.String()))
+	}
+
+	return b.write(batch)
+}
+
+// GetRewards retorna las entradas de reward de un validador, de la más reciente a la más antigua
+func (b *BlockchainDB) GetRewards(validator string, limit int) ([][]byte, error) {
+	if limit <= 0 {
+		return nil, fmt.Errorf("límite inválido: %d", limit)
+	}
+
+	iter := b.db.NewIterator(util.BytesPrefix([]byte(rewardPrefix(validator))), nil)
+	defer iter.Release()
+
+	var results [][]byte
+	for ok := iter.Last(); ok && len(results) < limit; ok = iter.Prev() {
+		results = append(results, append([]byte(nil), iter.Value()...))
+	}
+	if err := iter.Error(); err != nil {
+		return nil, fmt.Errorf("error recorriendo rewards: %w", err)
+	}
+	return results, nil
+}
+
+// GetRewardTotal retorna el total de rewards acumulado por un validador (cero si no tiene)
+func (b *BlockchainDB) GetRewardTotal(validator string) (*big.Int, error) {
+	data, err := b.get(rewardTotalKey(validator))
+	if err == leveldb.ErrNotFound {
+		return new(big.Int), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	total, ok := new(big.Int).SetString(string(data), 10)
+	if !ok {
+		return nil, fmt.Errorf("total de rewards corrupto: %s", data)
+	}
+	return total, nil
+}
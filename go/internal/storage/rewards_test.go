@@ -0,0 +1,51 @@
+package storage
+
+import (
+	"math/big"
+	"os"
+	"testing"
+)
+
+// TestBlockchainDB_Rewards verifica el historial de rewards por validador y su total acumulado
+func TestBlockchainDB_Rewards(t *testing.T) {
+	tmpDir := "./test_data_rewards"
+	defer os.RemoveAll(tmpDir)
+
+	db, err := NewBlockchainDB(tmpDir)
+	if err != nil {
+		t.Fatalf("Error creando base de datos: %v", err)
+	}
+	defer db.Close()
+
+	validatorA := "0xAAAA000000000000000000000000000000000001"
+	validatorB := "0xBBBB000000000000000000000000000000000002"
+
+	for height := uint64(1); height <= 3; height++ {
+		if err := db.SaveRewards(height, []ValidatorReward{
+			{Validator: validatorA, Amount: big.NewInt(10), Data: []byte{byte('0' + height)}},
+			{Validator: validatorB, Amount: big.NewInt(1), Data: []byte("b")},
+		}); err != nil {
+			t.Fatalf("Error guardando rewards: %v", err)
+		}
+	}
+
+	// Más recientes primero; la dirección no distingue mayúsculas
+	rewards, err := db.GetRewards("0xaaaa000000000000000000000000000000000001", 2)
+	if err != nil {
+		t.Fatalf("Error obteniendo rewards: %v", err)
+	}
+	if len(rewards) != 2 || string(rewards[0]) != "3" || string(rewards[1]) != "2" {
+		t.Errorf("Rewards incorrectos: %q", rewards)
+	}
+
+	if total, _ := db.GetRewardTotal(validatorA); total.Cmp(big.NewInt(30)) != 0 {
+		t.Errorf("Total de rewards incorrecto: %s", total)
+	}
+	if total, _ := db.GetRewardTotal("0x0000000000000000000000000000000000000009"); total.Sign() != 0 {
+		t.Errorf("Un validador sin rewards debería tener total cero: %s", total)
+	}
+
+	if _, err := db.GetRewards(validatorA, 0); err == nil {
+		t.Error("Un límite inválido debería fallar")
+	}
+}
@@ -0,0 +1,65 @@
+package storage
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+// SlashingRecord es un slash aplicado a un validador; Data es la entrada serializada que retornan las consultas
+type SlashingRecord struct {
+	Validator string
+	Data      []byte
+}
+
+// Claves (altura e índice con padding para que el orden lexicográfico sea el numérico):
+//
+//	slash:<altura>:<índice>               -> entrada serializada (historial completo)
+//	slashval:<validador>:<altura>:<índice> -> entrada serializada (historial por validador)
+const slashPrefix = "slash:"
+
+func validatorSlashPrefix(validator string) string {
+	return fmt.Sprintf("slashval:%s:", strings.ToLower(validator))
+}
+
+// SaveSlashingRecords guarda los slashes de un bloque en un solo batch
+func (b *BlockchainDB) SaveSlashingRecords(height uint64, records []SlashingRecord) error {
+	if len(records) == 0 {
+		return nil
+	}
+
+	batch := new(leveldb.Batch)
+	for i, record := range records {
+		suffix := fmt.Sprintf("%020d:%04d", height, i)
+		batch.Put([]byte(slashPrefix+suffix), record.Data)
+		batch.Put([]byte(validatorSlashPrefix(record.Validator)+suffix), record.Data)
+	}
+
+	return b.write(batch)
+}
+
+// GetSlashingRecords retorna los últimos slashes de la cadena (validator vacío) o de un validador,
+// del más reciente al más antiguo
+func (b *BlockchainDB) GetSlashingRecords(validator string, limit int) ([][]byte, error) {
+	if limit <= 0 {
+		return nil, fmt.Errorf("límite inválido: %d", limit)
+	}
+
+	prefix := slashPrefix
+	if validator != "" {
+		prefix = validatorSlashPrefix(validator)
+	}
+	iter := b.db.NewIterator(util.BytesPrefix([]byte(prefix)), nil)
+	defer iter.Release()
+
+	var results [][]byte
+	for ok := iter.Last(); ok && len(results) < limit; ok = iter.Prev() {
+		results = append(results, append([]byte(nil), iter.Value()...))
+	}
+	if err := iter.Error(); err != nil {
+		return nil, fmt.Errorf("error recorriendo slashes: %w", err)
+	}
+	return results, nil
+}
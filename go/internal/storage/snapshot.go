@@ -0,0 +1,119 @@
+package storage
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"strings"
+
+	"github.com/syndtr/goleveldb/leveldb"
+)
+
+// SnapshotEntry es una clave de la base de datos incluida en un snapshot de state sync
+type SnapshotEntry struct {
+	Key   []byte
+	Value []byte
+}
+
+// appStatePrefixes son las claves del estado de la aplicación fuera del EVM: set de validadores,
+// delegaciones y unbondings, parámetros del genesis, gas límite por bloque, total supply y sets de
+// CometBFT de las alturas recientes. Forman parte del AppHash (AppStateHash) y de los snapshots.
+// El historial (bloques, transacciones, logs, rewards, slashes) no es estado.
+var appStatePrefixes = []string{
+	"account:validators:",
+	"genesis:",
+	"params:",
+}
+
+// snapshotHeaders es cuántos headers EVM recientes incluye un snapshot (ventana de BLOCKHASH)
+const snapshotHeaders = 256
+
+// AppStateHash retorna el hash sha256 de las claves del estado de la aplicación fuera del EVM, en
+// orden de prefijo y clave
+func (b *BlockchainDB) AppStateHash() ([]byte, error) {
+	hasher := sha256.New()
+	err := b.iterateAppState(func(key, value []byte) {
+		var length [8]byte
+		for _, field := range [][]byte{key, value} {
+			binary.BigEndian.PutUint64(length[:], uint64(len(field)))
+			hasher.Write(length[:])
+			hasher.Write(field)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return hasher.Sum(nil), nil
+}
+
+// SnapshotEntries retorna las entradas del estado de la aplicación y los headers EVM de los últimos
+// bloques hasta height
+func (b *BlockchainDB) SnapshotEntries(height uint64) ([]SnapshotEntry, error) {
+	var entries []SnapshotEntry
+	err := b.iterateAppState(func(key, value []byte) {
+		entries = append(entries, SnapshotEntry{
+			Key:   append([]byte(nil), key...),
+			Value: append([]byte(nil), value...),
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	from := uint64(1)
+	if height > snapshotHeaders {
+		from = height - snapshotHeaders + 1
+	}
+	for h := from; h <= height; h++ {
+		header, err := b.GetHeader(h)
+		if err == leveldb.ErrNotFound {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error leyendo header %d: %w", h, err)
+		}
+		entries = append(entries, SnapshotEntry{Key: []byte(fmt.Sprintf("header:%d", h)), Value: header})
+	}
+	return entries, nil
+}
+
+// RestoreSnapshotEntries reemplaza el estado de la aplicación por las entradas de un snapshot en un
+// solo batch. Solo acepta claves del estado de la aplicación y headers.
+func (b *BlockchainDB) RestoreSnapshotEntries(entries []SnapshotEntry) error {
+	batch := new(leveldb.Batch)
+	if err := b.iterateAppState(func(key, _ []byte) {
+		batch.Delete(append([]byte(nil), key...))
+	}); err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if !isSnapshotKey(string(entry.Key)) {
+			return fmt.Errorf("clave no permitida en un snapshot: %q", entry.Key)
+		}
+		batch.Put(entry.Key, entry.Value)
+	}
+	return b.db.Write(batch, nil)
+}
+
+// iterateAppState recorre las claves del estado de la aplicación en orden de prefijo y clave
+func (b *BlockchainDB) iterateAppState(visit func(key, value []byte)) error {
+	for _, prefix := range appStatePrefixes {
+		if err := b.iteratePrefix([]byte(prefix), visit); err != nil {
+			return fmt.Errorf("error leyendo %s: %w", prefix, err)
+		}
+	}
+	return nil
+}
+
+// isSnapshotKey indica si una clave puede venir en un snapshot
+func isSnapshotKey(key string) bool {
+	if strings.HasPrefix(key, "header:") {
+		return true
+	}
+	for _, prefix := range appStatePrefixes {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,95 @@
+package storage
+
+import (
+	"fmt"
+
+	"github.com/syndtr/goleveldb/leveldb"
+)
+
+// Claves del módulo de actualizaciones. Van bajo params: para que formen parte del AppHash y de los
+// snapshots:
+//
+//	params:upgrade:plan          -> plan programado (JSON)
+//	params:upgrade:approvals     -> aprobaciones pendientes por validador (JSON)
+//	params:upgrade:done:<nombre> -> altura en que se aplicó la actualización
+//	params:appversion            -> versión de la aplicación (ausente = 1)
+const (
+	upgradePlanKey      = "params:upgrade:plan"
+	upgradeApprovalsKey = "params:upgrade:approvals"
+	upgradeDonePrefix   = "params:upgrade:done:"
+	appVersionKey       = "params:appversion"
+)
+
+// SaveUpgradePlan guarda el plan de actualización programado
+func (b *BlockchainDB) SaveUpgradePlan(planData []byte) error {
+	return b.put([]byte(upgradePlanKey), planData)
+}
+
+// GetUpgradePlan obtiene el plan de actualización programado (leveldb.ErrNotFound si no hay)
+func (b *BlockchainDB) GetUpgradePlan() ([]byte, error) {
+	return b.get([]byte(upgradePlanKey))
+}
+
+// DeleteUpgradePlan borra el plan programado (aplicado o reemplazado)
+func (b *BlockchainDB) DeleteUpgradePlan() error {
+	batch := new(leveldb.Batch)
+	batch.Delete([]byte(upgradePlanKey))
+	return b.write(batch)
+}
+
+// SaveUpgradeApprovals guarda las aprobaciones pendientes (vacío = borrarlas)
+func (b *BlockchainDB) SaveUpgradeApprovals(approvalsData []byte) error {
+	if len(approvalsData) == 0 {
+		batch := new(leveldb.Batch)
+		batch.Delete([]byte(upgradeApprovalsKey))
+		return b.write(batch)
+	}
+	return b.put([]byte(upgradeApprovalsKey), approvalsData)
+}
+
+// GetUpgradeApprovals obtiene las aprobaciones pendientes (leveldb.ErrNotFound si no hay)
+func (b *BlockchainDB) GetUpgradeApprovals() ([]byte, error) {
+	return b.get([]byte(upgradeApprovalsKey))
+}
+
+// SaveUpgradeDone registra que una actualización se aplicó en una altura
+func (b *BlockchainDB) SaveUpgradeDone(name string, height uint64) error {
+	return b.put([]byte(upgradeDonePrefix+name), []byte(fmt.Sprintf("%d", height)))
+}
+
+// GetUpgradesDone retorna las actualizaciones aplicadas con la altura en que se aplicaron
+func (b *BlockchainDB) GetUpgradesDone() (map[string]uint64, error) {
+	done := make(map[string]uint64)
+	var parseErr error
+	err := b.iteratePrefix([]byte(upgradeDonePrefix), func(key, value []byte) {
+		var height uint64
+		if _, err := fmt.Sscanf(string(value), "%d", &height); err != nil {
+			parseErr = fmt.Errorf("altura de actualización corrupta: %w", err)
+			return
+		}
+		done[string(key[len(upgradeDonePrefix):])] = height
+	})
+	if err != nil {
+		return nil, err
+	}
+	return done, parseErr
+}
+
+// SaveAppVersion guarda la versión de la aplicación
+func (b *BlockchainDB) SaveAppVersion(version uint64) error {
+	return b.put([]byte(appVersionKey), []byte(fmt.Sprintf("%d", version)))
+}
+
+// GetAppVersion obtiene la versión de la aplicación guardada
+func (b *BlockchainDB) GetAppVersion() (uint64, error) {
+	data, err := b.get([]byte(appVersionKey))
+	if err != nil {
+		return 0, err
+	}
+
+	var version uint64
+	if _, err := fmt.Sscanf(string(data), "%d", &version); err != nil {
+		return 0, fmt.Errorf("versión de la aplicación corrupta: %w", err)
+	}
+	return version, nil
+}
@@ -0,0 +1,35 @@
+package storage
+
+import (
+	"fmt"
+
+	"github.com/syndtr/goleveldb/leveldb"
+)
+
+// consensusValidatorsPrefix guarda el set de validadores de CometBFT vigente en cada altura reciente
+// (JSON). Va bajo params: para que forme parte del AppHash y de los snapshots:
+//
+//	params:valset:<altura> -> set de validadores de CometBFT en esa altura
+const consensusValidatorsPrefix = "params:valset:"
+
+// consensusValidatorsKey arma la clave con la altura de ancho fijo para que se ordenen por altura
+func consensusValidatorsKey(height uint64) []byte {
+	return []byte(fmt.Sprintf("%s%020d", consensusValidatorsPrefix, height))
+}
+
+// SaveConsensusValidators guarda el set de validadores de CometBFT vigente en una altura
+func (b *BlockchainDB) SaveConsensusValidators(height uint64, setData []byte) error {
+	return b.put(consensusValidatorsKey(height), setData)
+}
+
+// GetConsensusValidators obtiene el set de validadores de CometBFT de una altura (leveldb.ErrNotFound si no hay)
+func (b *BlockchainDB) GetConsensusValidators(height uint64) ([]byte, error) {
+	return b.get(consensusValidatorsKey(height))
+}
+
+// DeleteConsensusValidators borra el set de una altura que ya no se necesita
+func (b *BlockchainDB) DeleteConsensusValidators(height uint64) error {
+	batch := new(leveldb.Batch)
+	batch.Delete(consensusValidatorsKey(height))
+	return b.write(batch)
+}
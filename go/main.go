@@ -5,13 +5,17 @@ import (
 	"fmt"
 	"log"
 	"math/big"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
 
+	"github.com/Q-YZX0/oxy-blockchain/internal/api"
 	"github.com/Q-YZX0/oxy-blockchain/internal/config"
 	"github.com/Q-YZX0/oxy-blockchain/internal/consensus"
 	"github.com/Q-YZX0/oxy-blockchain/internal/execution"
+	"github.com/Q-YZX0/oxy-blockchain/internal/health"
+	"github.com/Q-YZX0/oxy-blockchain/internal/metrics"
 	"github.com/Q-YZX0/oxy-blockchain/internal/storage"
 	"github.com/Q-YZX0/oxy-blockchain/internal/network"
 )
@@ -30,8 +34,20 @@ func main() {
 	}
 	defer db.Close()
 
-	// Inicializar motor de ejecución (EVM)
-	evm := execution.NewEVMExecutor(db)
+	// Inicializar motor de ejecución (EVM) con la configuración de cadena (chain ID y hardforks)
+	var evm *execution.EVMExecutor
+	if cfg.ChainConfigFile != "" {
+		chainSpec, err := execution.LoadChainSpec(cfg.ChainConfigFile)
+		if err != nil {
+			log.Fatalf("Error cargando configuración de cadena: %v", err)
+		}
+		if err := chainSpec.ValidateCometChainID(cfg.ChainID); err != nil {
+			log.Fatalf("Configuración de cadena incompatible con OXY_CHAIN_ID: %v", err)
+		}
+		evm = execution.NewEVMExecutorWithChainSpec(db, chainSpec)
+	} else {
+		evm = execution.NewEVMExecutor(db)
+	}
 	
 	// Iniciar ejecutor EVM
 	if err := evm.Start(); err != nil {
@@ -51,14 +67,7 @@ func main() {
 	}
 
 	// Inicializar consenso (CometBFT)
-	consensusConfig := &consensus.Config{
-		DataDir:       cfg.DataDir,
-		ChainID:       cfg.ChainID,
-		ValidatorAddr: cfg.ValidatorAddr,
-		ValidatorKey:  cfg.ValidatorKey,
-	}
-	
-	consensusEngine, err := consensus.NewCometBFT(ctx, consensusConfig, db, evm, validators)
+	consensusEngine, err := consensus.NewCometBFT(ctx, newConsensusConfig(cfg), db, evm, validators)
 	if err != nil {
 		log.Fatalf("Error inicializando consenso: %v", err)
 	}
@@ -85,6 +94,36 @@ func main() {
 	}
 	defer p2pNetwork.Stop()
 
+	// Iniciar servidor REST si está habilitado
+	if cfg.APIEnabled {
+		healthChecker := health.NewHealthChecker()
+		healthChecker.SetStorageHealth(true)
+		healthChecker.SetEVMHealth(true)
+		healthChecker.SetConsensusHealth(true)
+		healthChecker.SetMeshHealth(true)
+		metricsInstance := metrics.NewMetrics()
+		consensusEngine.SetMetrics(metricsInstance)
+
+		restServer := api.NewRestServer(cfg.APIHost, cfg.APIPort, db, consensusEngine, healthChecker, metricsInstance, evm)
+		go func() {
+			if err := restServer.Start(); err != nil && err != http.ErrServerClosed {
+				log.Printf("Error iniciando servidor REST: %v", err)
+			}
+		}()
+		defer restServer.Stop()
+	}
+
+	// Iniciar servidor JSON-RPC compatible con Ethereum si está habilitado
+	if cfg.RPCEnabled {
+		rpcServer := api.NewJSONRPCServer(cfg.RPCHost, cfg.RPCPort, db, consensusEngine, evm)
+		go func() {
+			if err := rpcServer.Start(); err != nil && err != http.ErrServerClosed {
+				log.Printf("Error iniciando servidor JSON-RPC: %v", err)
+			}
+		}()
+		defer rpcServer.Stop()
+	}
+
 	fmt.Println("✅ Oxy•gen Blockchain iniciada correctamente")
 
 	// Manejar señales de terminación
@@ -95,5 +134,16 @@ func main() {
 	fmt.Println("\n⏹️  Deteniendo Oxy•gen Blockchain...")
 }
 
-
-
+// newConsensusConfig arma la configuración de consenso del nodo a partir de la configuración cargada
+func newConsensusConfig(cfg *config.Config) *consensus.Config {
+	return &consensus.Config{
+		DataDir:            cfg.DataDir,
+		ChainID:            cfg.ChainID,
+		ValidatorAddr:      cfg.ValidatorAddr,
+		ValidatorKey:       cfg.ValidatorKey,
+		SnapshotInterval:   cfg.SnapshotInterval,
+		SnapshotKeepRecent: cfg.SnapshotKeepRecent,
+		OracleFile:         cfg.OracleFile,
+		OracleURL:          cfg.OracleURL,
+	}
+}
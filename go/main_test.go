@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Q-YZX0/oxy-blockchain/internal/config"
+	"github.com/Q-YZX0/oxy-blockchain/internal/consensus"
+	"github.com/Q-YZX0/oxy-blockchain/internal/execution"
+	"github.com/Q-YZX0/oxy-blockchain/internal/oracle"
+	"github.com/Q-YZX0/oxy-blockchain/internal/storage"
+	abcitypes "github.com/cometbft/cometbft/abci/types"
+)
+
+// TestConsensusConfig_OracleExtension verifica que la fuente del oráculo configurada por entorno llega
+// hasta las extensiones de voto del nodo
+func TestConsensusConfig_OracleExtension(t *testing.T) {
+	dir := t.TempDir()
+	oracleFile := filepath.Join(dir, "oracle.json")
+	if err := os.WriteFile(oracleFile, []byte(`{"OXG/USD": "0.25"}`), 0644); err != nil {
+		t.Fatalf("Error escribiendo archivo del oráculo: %v", err)
+	}
+	t.Setenv("OXY_DATA_DIR", filepath.Join(dir, "data"))
+	t.Setenv("OXY_ORACLE_FILE", oracleFile)
+
+	cfg := config.LoadConfig()
+	consensusConfig := newConsensusConfig(cfg)
+	if consensusConfig.OracleFile != oracleFile {
+		t.Fatalf("OracleFile no llega a la configuración de consenso: %q", consensusConfig.OracleFile)
+	}
+
+	db, err := storage.NewBlockchainDB(cfg.DataDir)
+	if err != nil {
+		t.Fatalf("Error creando storage: %v", err)
+	}
+	defer db.Close()
+	evm := execution.NewEVMExecutor(db)
+	if err := evm.Start(); err != nil {
+		t.Fatalf("Error iniciando EVM: %v", err)
+	}
+	defer evm.Stop()
+
+	app := consensus.NewABCIApp(db, evm, nil, cfg.ChainID)
+	app.SetOracleSources(consensusConfig.OracleSources()...)
+	resp, err := app.ExtendVote(context.Background(), &abcitypes.ExtendVoteRequest{Height: 1})
+	if err != nil {
+		t.Fatalf("Error en ExtendVote: %v", err)
+	}
+	values, err := oracle.DecodeExtension(resp.VoteExtension)
+	if err != nil || len(values) != 1 || values["OXG/USD"] == nil {
+		t.Fatalf("Extensión de voto sin el feed configurado: %x (%v)", resp.VoteExtension, err)
+	}
+}